@@ -0,0 +1,195 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// lockFile is the file of ReadWriteOncePod volumes that the pods using
+	// them lock with the hold-lock command, when their class sets
+	// rwopLockFile.
+	lockFile = ".rwop.lock"
+	// annLockFile records lockFile on the PVs that have one.
+	annLockFile = "nfs.io/lock-file"
+)
+
+// volumeAccessModes returns the access modes of the PV of claim: those the
+// claim asks for, once each. NFS directories support them all, but
+// ReadWriteOncePod cannot be combined with another mode.
+func volumeAccessModes(claim *v1.PersistentVolumeClaim) ([]v1.PersistentVolumeAccessMode, error) {
+	var modes []v1.PersistentVolumeAccessMode
+	seen := map[v1.PersistentVolumeAccessMode]bool{}
+	for _, mode := range claim.Spec.AccessModes {
+		switch mode {
+		case v1.ReadWriteOnce, v1.ReadOnlyMany, v1.ReadWriteMany, v1.ReadWriteOncePod:
+		default:
+			return nil, fmt.Errorf("unsupported access mode %q", mode)
+		}
+		if !seen[mode] {
+			seen[mode] = true
+			modes = append(modes, mode)
+		}
+	}
+	switch {
+	case len(modes) == 0:
+		return nil, fmt.Errorf("claim asks for no access mode")
+	case seen[v1.ReadWriteOncePod] && len(modes) > 1:
+		return nil, fmt.Errorf("access mode ReadWriteOncePod cannot be combined with other access modes")
+	}
+	return modes, nil
+}
+
+// accessModeAbbreviations are the short names of access modes, as kubectl
+// shows them.
+var accessModeAbbreviations = map[string]v1.PersistentVolumeAccessMode{
+	"RWO":  v1.ReadWriteOnce,
+	"ROX":  v1.ReadOnlyMany,
+	"RWX":  v1.ReadWriteMany,
+	"RWOP": v1.ReadWriteOncePod,
+}
+
+// allowedAccessModes returns the access modes the allowedAccessModes
+// parameter of class lets its claims ask for, nil if it sets none.
+func allowedAccessModes(class *storage.StorageClass) (map[v1.PersistentVolumeAccessMode]bool, error) {
+	value := class.Parameters["allowedAccessModes"]
+	if value == "" {
+		return nil, nil
+	}
+	allowed := map[v1.PersistentVolumeAccessMode]bool{}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		mode, ok := accessModeAbbreviations[name]
+		if !ok {
+			mode = v1.PersistentVolumeAccessMode(name)
+		}
+		switch mode {
+		case v1.ReadWriteOnce, v1.ReadOnlyMany, v1.ReadWriteMany, v1.ReadWriteOncePod:
+			allowed[mode] = true
+		default:
+			return nil, fmt.Errorf("invalid allowedAccessModes %q: unknown access mode %q", value, name)
+		}
+	}
+	return allowed, nil
+}
+
+// checkAllowedAccessModes refuses claims asking for an access mode their
+// class does not grant.
+func checkAllowedAccessModes(class *storage.StorageClass, claim *v1.PersistentVolumeClaim) error {
+	allowed, err := allowedAccessModes(class)
+	if err != nil || allowed == nil {
+		return err
+	}
+	for _, mode := range claim.Spec.AccessModes {
+		if !allowed[mode] {
+			return fmt.Errorf("StorageClass %s does not grant access mode %s, only %s", class.Name, mode, class.Parameters["allowedAccessModes"])
+		}
+	}
+	return nil
+}
+
+// wantsLockFile reports whether the volume of claim, of class, gets a
+// lockFile.
+func wantsLockFile(class *storage.StorageClass, claim *v1.PersistentVolumeClaim) (bool, error) {
+	value := class.Parameters["rwopLockFile"]
+	if value == "" {
+		return false, nil
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid rwopLockFile %q, must be true or false", value)
+	}
+	modes, err := volumeAccessModes(claim)
+	if err != nil {
+		return false, err
+	}
+	return enabled && modes[0] == v1.ReadWriteOncePod, nil
+}
+
+// createLockFile creates the lockFile of the volume directory dir, which
+// any user of the volume may lock.
+func createLockFile(dir string) error {
+	path := filepath.Join(dir, lockFile)
+	// A reused directory may hold a link or a special file in its place.
+	if info, err := os.Lstat(path); err == nil && !info.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", path)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|syscall.O_NOFOLLOW, 0666)
+	if err != nil {
+		return err
+	}
+	// Regardless of the umask.
+	err = f.Chmod(0666)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// runHoldLock implements the hold-lock command, which locks the lockFile of
+// a ReadWriteOncePod volume for as long as the pod runs, and fails if
+// another pod holds it already.
+func runHoldLock(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	file := fs.String("file", "", "Lock file of a ReadWriteOncePod volume, on its NFS mount, e.g. /data/"+lockFile+".")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("--file is required")
+	}
+	f, err := os.OpenFile(*file, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := lockExclusive(f); err != nil {
+		if errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.EACCES) {
+			holder, _ := io.ReadAll(io.LimitReader(f, 4096))
+			return fmt.Errorf("%s is locked by %s, the volume is used by another pod despite ReadWriteOncePod", *file, strings.TrimSpace(string(holder)))
+		}
+		return fmt.Errorf("unable to lock %s: %v", *file, err)
+	}
+	// Tell the next pod who holds the volume.
+	holder := fmt.Sprintf("pod %s/%s on node %s since %s\n", os.Getenv("POD_NAMESPACE"), os.Getenv("POD_NAME"), os.Getenv("NODE_NAME"), time.Now().UTC().Format(time.RFC3339))
+	if err := f.Truncate(0); err == nil {
+		_, _ = f.WriteAt([]byte(holder), 0)
+	}
+	logger := klog.FromContext(ctx)
+	logger.Info("locked volume, releasing it on SIGTERM", "file", *file)
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	<-ctx.Done()
+	// Closing the file releases the lock.
+	logger.Info("released volume", "file", *file)
+	return nil
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCreateLockFile(t *testing.T) {
+	tests := []struct {
+		name string
+		// existing puts something at path, the lock file, beforehand;
+		// outside is a file out of the volume.
+		existing func(path, outside string) error
+		wantErr  bool
+	}{
+		{name: "new", existing: func(string, string) error { return nil }},
+		{name: "reused", existing: func(path, _ string) error { return os.WriteFile(path, nil, 0o600) }},
+		{name: "link out of the volume", existing: func(path, outside string) error { return os.Symlink(outside, path) }, wantErr: true},
+		{name: "directory", existing: func(path, _ string) error { return os.Mkdir(path, 0o755) }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmp := t.TempDir()
+			dir, outside := filepath.Join(tmp, "volume"), filepath.Join(tmp, "outside")
+			if err := os.Mkdir(dir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(outside, nil, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join(dir, lockFile)
+			if err := tt.existing(path, outside); err != nil {
+				t.Fatal(err)
+			}
+
+			err := createLockFile(dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("createLockFile() error = %v, want error %v", err, tt.wantErr)
+			}
+			if info, err := os.Stat(outside); err != nil || info.Mode().Perm() != 0o600 {
+				t.Errorf("mode of the file out of the volume = %v, %v, want 0600", info.Mode().Perm(), err)
+			}
+			if tt.wantErr {
+				return
+			}
+			if info, err := os.Lstat(path); err != nil || info.Mode() != 0o666 {
+				t.Errorf("lock file = %v, %v, want a regular file of mode 0666", info.Mode(), err)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	storage "k8s.io/api/storage/v1"
+)
+
+// The extended attributes holding the POSIX ACLs of a file, and the format
+// of their value, as in <linux/posix_acl_xattr.h>.
+const (
+	aclAccessXattr  = "system.posix_acl_access"
+	aclDefaultXattr = "system.posix_acl_default"
+	aclXattrVersion = 2
+	aclUndefinedID  = 0xffffffff
+)
+
+// The tags of ACL entries, in the order they are stored in.
+const (
+	aclUserObj  uint16 = 0x01
+	aclUser     uint16 = 0x02
+	aclGroupObj uint16 = 0x04
+	aclGroup    uint16 = 0x08
+	aclMask     uint16 = 0x10
+	aclOther    uint16 = 0x20
+)
+
+var aclTags = map[string]uint16{
+	"u": aclUser, "user": aclUser,
+	"g": aclGroup, "group": aclGroup,
+	"m": aclMask, "mask": aclMask,
+	"o": aclOther, "other": aclOther,
+}
+
+// aclEntry is an entry of a POSIX ACL: the permissions, as rwx bits, of the
+// owner, owning group, other users, or of a user or group given by ID.
+type aclEntry struct {
+	tag  uint16
+	id   uint32
+	perm uint16
+}
+
+// parseACL parses an ACL in the short form of setfacl -m, a comma separated
+// list of entries such as u::rwx, g:1000:r-x, m::rx or o::-. Users and
+// groups are given by numeric ID, as names mean nothing to the NFS server.
+func parseACL(spec string) ([]aclEntry, error) {
+	var entries []aclEntry
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		parts := strings.Split(field, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid ACL entry %q, must be type:[id]:perms", field)
+		}
+		tag, ok := aclTags[parts[0]]
+		if !ok {
+			return nil, fmt.Errorf("invalid ACL entry %q, type must be u, g, m or o", field)
+		}
+		e := aclEntry{tag: tag, id: aclUndefinedID}
+		switch {
+		case parts[1] == "" && tag == aclUser:
+			e.tag = aclUserObj
+		case parts[1] == "" && tag == aclGroup:
+			e.tag = aclGroupObj
+		case parts[1] != "" && (tag == aclMask || tag == aclOther):
+			return nil, fmt.Errorf("invalid ACL entry %q, %s entries take no ID", field, parts[0])
+		case parts[1] != "":
+			id, err := strconv.ParseUint(parts[1], 10, 32)
+			if err != nil || id == aclUndefinedID {
+				return nil, fmt.Errorf("invalid ACL entry %q, users and groups must be numeric IDs", field)
+			}
+			e.id = uint32(id)
+		}
+		perm, err := parseACLPerm(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid ACL entry %q: %v", field, err)
+		}
+		e.perm = perm
+		entries = append(entries, e)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("empty ACL %q", spec)
+	}
+	return entries, nil
+}
+
+// parseACLPerm parses permissions given as letters, e.g. r-x or rx, or as
+// an octal digit.
+func parseACLPerm(s string) (uint16, error) {
+	if len(s) == 1 && s[0] >= '0' && s[0] <= '7' {
+		return uint16(s[0] - '0'), nil
+	}
+	var perm uint16
+	for _, c := range s {
+		switch c {
+		case 'r':
+			perm |= 4
+		case 'w':
+			perm |= 2
+		case 'x':
+			perm |= 1
+		case '-':
+		default:
+			return 0, fmt.Errorf("invalid permissions %q", s)
+		}
+	}
+	return perm, nil
+}
+
+// completeACL returns entries merged over base, an ACL with at least the
+// owner, group and other entries, sorted as the kernel expects them. As
+// with setfacl, an ACL with user or group entries gets a mask, the union of
+// the permissions of the group classes, unless entries set one.
+func completeACL(base, entries []aclEntry) []aclEntry {
+	merged := map[[2]uint32]aclEntry{}
+	for _, list := range [][]aclEntry{base, entries} {
+		for _, e := range list {
+			merged[[2]uint32{uint32(e.tag), e.id}] = e
+		}
+	}
+	var acl []aclEntry
+	var named, hasMask bool
+	var mask uint16
+	for _, e := range merged {
+		switch e.tag {
+		case aclUser, aclGroup:
+			named = true
+			mask |= e.perm
+		case aclGroupObj:
+			mask |= e.perm
+		case aclMask:
+			hasMask = true
+		}
+		acl = append(acl, e)
+	}
+	if named && !hasMask {
+		acl = append(acl, aclEntry{tag: aclMask, id: aclUndefinedID, perm: mask})
+	}
+	sort.Slice(acl, func(i, j int) bool {
+		return acl[i].tag < acl[j].tag || acl[i].tag == acl[j].tag && acl[i].id < acl[j].id
+	})
+	return acl
+}
+
+// modeACL returns the minimal ACL equivalent to mode.
+func modeACL(mode os.FileMode) []aclEntry {
+	return []aclEntry{
+		{tag: aclUserObj, id: aclUndefinedID, perm: uint16(mode>>6) & 7},
+		{tag: aclGroupObj, id: aclUndefinedID, perm: uint16(mode>>3) & 7},
+		{tag: aclOther, id: aclUndefinedID, perm: uint16(mode) & 7},
+	}
+}
+
+// encodeACL returns acl as the value of an ACL extended attribute.
+func encodeACL(acl []aclEntry) []byte {
+	data := binary.LittleEndian.AppendUint32(nil, aclXattrVersion)
+	for _, e := range acl {
+		data = binary.LittleEndian.AppendUint16(data, e.tag)
+		data = binary.LittleEndian.AppendUint16(data, e.perm)
+		data = binary.LittleEndian.AppendUint32(data, e.id)
+	}
+	return data
+}
+
+// applyACLs sets the acl and defaultAcl parameters of class, if any, on the
+// new directory at path, created with mode. Entries of acl are added to the
+// permissions of mode; the owner, group and other entries of defaultAcl
+// default to those of the resulting access ACL, as with setfacl.
+func applyACLs(class *storage.StorageClass, path string, mode os.FileMode) error {
+	access := modeACL(mode)
+	if spec := class.Parameters["acl"]; spec != "" {
+		entries, err := parseACL(spec)
+		if err != nil {
+			return fmt.Errorf("invalid acl: %v", err)
+		}
+		access = completeACL(access, entries)
+		if err := setXattr(path, aclAccessXattr, encodeACL(access)); err != nil {
+			return fmt.Errorf("unable to set the ACL of %s: %w", path, err)
+		}
+	}
+	if spec := class.Parameters["defaultAcl"]; spec != "" {
+		entries, err := parseACL(spec)
+		if err != nil {
+			return fmt.Errorf("invalid defaultAcl: %v", err)
+		}
+		var base []aclEntry
+		for _, e := range access {
+			if e.tag == aclUserObj || e.tag == aclGroupObj || e.tag == aclOther {
+				base = append(base, e)
+			}
+		}
+		if err := setXattr(path, aclDefaultXattr, encodeACL(completeACL(base, entries))); err != nil {
+			return fmt.Errorf("unable to set the default ACL of %s: %w", path, err)
+		}
+	}
+	return nil
+}
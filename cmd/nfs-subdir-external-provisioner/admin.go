@@ -0,0 +1,267 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	annProvisionedBy = "pv.kubernetes.io/provisioned-by"
+
+	backendProbeTimeout = 5 * time.Second
+)
+
+// managedVolume is the state of a PV owned by this provisioner.
+type managedVolume struct {
+	Name         string                           `json:"name"`
+	Claim        string                           `json:"claim,omitempty"`
+	StorageClass string                           `json:"storageClass,omitempty"`
+	Phase        v1.PersistentVolumePhase         `json:"phase"`
+	Reclaim      v1.PersistentVolumeReclaimPolicy `json:"reclaimPolicy"`
+	Path         string                           `json:"path"`
+	Deleting     bool                             `json:"deleting,omitempty"`
+}
+
+// backendHealth is the result of probing the mounted export.
+type backendHealth struct {
+	Server    string `json:"server"`
+	Path      string `json:"path"`
+	MountPath string `json:"mountPath"`
+	Healthy   bool   `json:"healthy"`
+	Error     string `json:"error,omitempty"`
+}
+
+// stateSnapshot is what /debug/state returns.
+type stateSnapshot struct {
+	Time              time.Time          `json:"time"`
+	Provisioner       string             `json:"provisioner"`
+	Backend           backendHealth      `json:"backend"`
+	PendingOperations []operation        `json:"pendingOperations"`
+	FailingOperations []operationFailure `json:"failingOperations"`
+	ManagedVolumes    []managedVolume    `json:"managedVolumes"`
+	Error             string             `json:"error,omitempty"`
+}
+
+// serveAdmin serves the admin endpoints on address until the process exits,
+// over TLS when tlsConfig is not nil. The worker limits can only be changed
+// by clients authenticated by tlsConfig, or by anyone with allowChanges.
+func (p *nfsProvisioner) serveAdmin(ctx context.Context, address string, tlsConfig *tls.Config, allowChanges bool) {
+	changes := tlsConfig != nil || allowChanges
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/state", p.serveState)
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/queue", p.serveQueue)
+	mux.HandleFunc("/workers", func(w http.ResponseWriter, r *http.Request) {
+		p.serveWorkers(w, r, changes)
+	})
+	mux.HandleFunc("/readyz", p.serveReady)
+
+	logger := klog.FromContext(ctx)
+	logger.Info("starting admin server", "address", address, "tls", tlsConfig != nil)
+	go func() {
+		listener, err := net.Listen("tcp", address)
+		if err == nil {
+			if tlsConfig != nil {
+				listener = tls.NewListener(listener, tlsConfig)
+			}
+			err = http.Serve(listener, mux)
+		}
+		logger.Error(err, "admin server failed", "address", address)
+	}()
+}
+
+func (p *nfsProvisioner) serveState(w http.ResponseWriter, r *http.Request) {
+	snapshot := stateSnapshot{
+		Time:        p.clock.Now().UTC(),
+		Provisioner: p.name,
+		Backend:     p.probeBackend(),
+	}
+	snapshot.PendingOperations, snapshot.FailingOperations = p.operations.snapshot()
+
+	volumes, err := p.managedVolumes(r.Context())
+	if err != nil {
+		snapshot.Error = err.Error()
+	}
+	snapshot.ManagedVolumes = volumes
+
+	writeJSON(w, r, snapshot)
+}
+
+// queueLength is what /queue returns, for autoscalers that read JSON.
+type queueLength struct {
+	Provision int `json:"provision"`
+	Delete    int `json:"delete"`
+	Total     int `json:"total"`
+}
+
+func (p *nfsProvisioner) serveQueue(w http.ResponseWriter, r *http.Request) {
+	provision, del := p.pendingOperations()
+	writeJSON(w, r, queueLength{Provision: provision, Delete: del, Total: provision + del})
+}
+
+// workerLimits is what /workers returns.
+type workerLimits struct {
+	Max       int `json:"max"`
+	Provision int `json:"provision"`
+	Delete    int `json:"delete"`
+	Archive   int `json:"archive"`
+}
+
+// serveWorkers reports the worker limits. A POST with provision, delete
+// and/or archive query parameters changes them, when changes are allowed.
+// Either all of them are valid and applied, or none is.
+func (p *nfsProvisioner) serveWorkers(w http.ResponseWriter, r *http.Request, changes bool) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodPost:
+		if !changes {
+			http.Error(w, "worker limits can only be changed over mutual TLS or with --admin-allow-changes", http.StatusForbidden)
+			return
+		}
+		limits, err := p.parseWorkerLimits(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, limit := range limits {
+			limit.limiter.setLimit(limit.n)
+			klog.FromContext(r.Context()).Info("changed worker limit", "operation", limit.name, "limit", limit.n)
+		}
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limits := workerLimits{Max: p.maxWorkers}
+	limits.Provision, _ = p.provisionWorkers.usage()
+	limits.Delete, _ = p.deleteWorkers.usage()
+	limits.Archive, _ = p.archiveWorkers.usage()
+	writeJSON(w, r, limits)
+}
+
+// workerLimit is a limit requested for the workers of an operation.
+type workerLimit struct {
+	name    string
+	limiter *workerLimiter
+	n       int
+}
+
+// parseWorkerLimits returns the worker limits of the provision, delete and
+// archive parameters of query, refusing them all if one is invalid or
+// another parameter is given.
+func (p *nfsProvisioner) parseWorkerLimits(query url.Values) ([]workerLimit, error) {
+	limiters := []workerLimit{{name: "provision", limiter: p.provisionWorkers}, {name: "delete", limiter: p.deleteWorkers}, {name: "archive", limiter: p.archiveWorkers}}
+	for name := range query {
+		if name != "provision" && name != "delete" && name != "archive" {
+			return nil, fmt.Errorf("unknown parameter %q, must be provision, delete or archive", name)
+		}
+	}
+	var limits []workerLimit
+	for _, limit := range limiters {
+		values, ok := query[limit.name]
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(values[0])
+		if err != nil || len(values) > 1 || n < 1 || n > p.maxWorkers {
+			return nil, fmt.Errorf("%s must be between 1 and %d", limit.name, p.maxWorkers)
+		}
+		limit.n = n
+		limits = append(limits, limit)
+	}
+	if len(limits) == 0 {
+		return nil, fmt.Errorf("no limit given, set provision, delete and/or archive")
+	}
+	return limits, nil
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		klog.FromContext(r.Context()).Error(err, "failed to write response")
+	}
+}
+
+// probeBackend stats the mount path. A hung NFS mount blocks stat forever, so
+// the probe gives up after backendProbeTimeout.
+func (p *nfsProvisioner) probeBackend() backendHealth {
+	health := backendHealth{Server: p.server, Path: p.path, MountPath: p.mountPath}
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := os.Stat(p.mountPath)
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		if err != nil {
+			health.Error = err.Error()
+		} else {
+			health.Healthy = true
+		}
+	case <-time.After(backendProbeTimeout):
+		health.Error = "stat timed out after " + backendProbeTimeout.String()
+	}
+	return health
+}
+
+// managedVolumes lists the PVs provisioned by this provisioner.
+func (p *nfsProvisioner) managedVolumes(ctx context.Context) ([]managedVolume, error) {
+	pvs, err := p.client.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	volumes := []managedVolume{}
+	for _, pv := range pvs.Items {
+		if !p.owns(pv.Annotations[annProvisionedBy]) || pv.Spec.NFS == nil {
+			continue
+		}
+		volume := managedVolume{
+			Name:         pv.Name,
+			StorageClass: pv.Spec.StorageClassName,
+			Phase:        pv.Status.Phase,
+			Reclaim:      pv.Spec.PersistentVolumeReclaimPolicy,
+			Path:         pv.Spec.NFS.Path,
+			Deleting:     pv.DeletionTimestamp != nil,
+		}
+		if claim := pv.Spec.ClaimRef; claim != nil {
+			volume.Claim = claim.Namespace + "/" + claim.Name
+		}
+		volumes = append(volumes, volume)
+	}
+	return volumes, nil
+}
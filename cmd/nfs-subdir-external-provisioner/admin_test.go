@@ -0,0 +1,70 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestServeWorkers(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		query      string
+		changes    bool
+		wantStatus int
+		// want are the provision, delete and archive limits afterwards.
+		want [3]int
+	}{
+		{name: "get", method: http.MethodGet, wantStatus: http.StatusOK, want: [3]int{4, 4, 2}},
+		{name: "get ignores parameters", method: http.MethodGet, query: "provision=8", wantStatus: http.StatusOK, want: [3]int{4, 4, 2}},
+		{name: "post", method: http.MethodPost, query: "provision=8&archive=1", changes: true, wantStatus: http.StatusOK, want: [3]int{8, 4, 1}},
+		{name: "post without changes allowed", method: http.MethodPost, query: "provision=8", wantStatus: http.StatusForbidden, want: [3]int{4, 4, 2}},
+		{name: "one invalid limit applies none", method: http.MethodPost, query: "provision=8&delete=0", changes: true, wantStatus: http.StatusBadRequest, want: [3]int{4, 4, 2}},
+		{name: "limit above the maximum", method: http.MethodPost, query: "archive=17", changes: true, wantStatus: http.StatusBadRequest, want: [3]int{4, 4, 2}},
+		{name: "not a number", method: http.MethodPost, query: "delete=two", changes: true, wantStatus: http.StatusBadRequest, want: [3]int{4, 4, 2}},
+		{name: "repeated parameter", method: http.MethodPost, query: "delete=2&delete=3", changes: true, wantStatus: http.StatusBadRequest, want: [3]int{4, 4, 2}},
+		{name: "unknown parameter", method: http.MethodPost, query: "provision=8&deletes=2", changes: true, wantStatus: http.StatusBadRequest, want: [3]int{4, 4, 2}},
+		{name: "no parameter", method: http.MethodPost, changes: true, wantStatus: http.StatusBadRequest, want: [3]int{4, 4, 2}},
+		{name: "put", method: http.MethodPut, query: "provision=8", changes: true, wantStatus: http.StatusMethodNotAllowed, want: [3]int{4, 4, 2}},
+		{name: "delete", method: http.MethodDelete, changes: true, wantStatus: http.StatusMethodNotAllowed, want: [3]int{4, 4, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &nfsProvisioner{
+				maxWorkers:       16,
+				provisionWorkers: newWorkerLimiter(4),
+				deleteWorkers:    newWorkerLimiter(4),
+				archiveWorkers:   newWorkerLimiter(2),
+			}
+			w := httptest.NewRecorder()
+			p.serveWorkers(w, httptest.NewRequest(tt.method, "/workers?"+tt.query, nil), tt.changes)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			var got [3]int
+			got[0], _ = p.provisionWorkers.usage()
+			got[1], _ = p.deleteWorkers.usage()
+			got[2], _ = p.archiveWorkers.usage()
+			if got != tt.want {
+				t.Errorf("limits = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// tlsReloader serves the certificate and client CA of a server from files,
+// typically a mounted Secret, and picks up rotated files on the next
+// handshake. Without a client CA, clients are not authenticated.
+type tlsReloader struct {
+	logger   klog.Logger
+	server   string
+	certFile string
+	keyFile  string
+	caFile   string
+
+	mu       sync.Mutex
+	modTimes []time.Time
+	config   *tls.Config
+}
+
+// newAdminTLSConfig returns the TLS config of the admin server: clients must
+// present a certificate signed by the CA in caFile.
+func newAdminTLSConfig(ctx context.Context, certFile, keyFile, caFile string) (*tls.Config, error) {
+	return newTLSReloader(ctx, "admin server", certFile, keyFile, caFile)
+}
+
+// newTLSReloader returns the TLS config of server, loaded from the files.
+func newTLSReloader(ctx context.Context, server, certFile, keyFile, caFile string) (*tls.Config, error) {
+	r := &tlsReloader{logger: klog.FromContext(ctx), server: server, certFile: certFile, keyFile: keyFile, caFile: caFile}
+	if _, err := r.getConfigForClient(nil); err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		GetConfigForClient: r.getConfigForClient,
+	}, nil
+}
+
+func (r *tlsReloader) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	files := []string{r.certFile, r.keyFile}
+	if r.caFile != "" {
+		files = append(files, r.caFile)
+	}
+	var modTimes []time.Time
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return r.keep(err)
+		}
+		modTimes = append(modTimes, info.ModTime())
+	}
+	if r.config != nil && equalTimes(modTimes, r.modTimes) {
+		return r.config, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return r.keep(err)
+	}
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+	if r.caFile != "" {
+		ca, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return r.keep(err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return r.keep(fmt.Errorf("no certificate found in %s", r.caFile))
+		}
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+		config.ClientCAs = pool
+	}
+
+	if r.config != nil {
+		r.logger.Info("reloaded certificates", "server", r.server)
+	}
+	r.modTimes = modTimes
+	r.config = config
+	return r.config, nil
+}
+
+// keep returns the config loaded last, if any, when the files cannot be
+// loaded: they may be caught in the middle of a rotation.
+func (r *tlsReloader) keep(err error) (*tls.Config, error) {
+	if r.config == nil {
+		return nil, err
+	}
+	r.logger.Error(err, "failed to reload certificates, keeping the previous ones", "server", r.server)
+	return r.config, nil
+}
+
+func equalTimes(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+)
+
+const (
+	// defaultArchivePattern names the archives kept on the export,
+	// defaultS3ArchivePattern those offloaded to S3, which are never
+	// renamed on collision and so carry the time.
+	defaultArchivePattern   = archivePrefix + "${.Directory}"
+	defaultS3ArchivePattern = archivePrefix + "${.Directory}-${.Time}"
+	// defaultArchiveTimeFormat is the Go layout of ${.Time}, e.g.
+	// 20260315T093000Z in UTC.
+	defaultArchiveTimeFormat = "20060102T150405Z0700"
+)
+
+var archiveField = regexp.MustCompile(`\${\s*\.([\w.]+)((?:\s*\|\s*\w+)*)\s*}`)
+
+// archiveFields are the fields an archivePattern can use.
+var archiveFields = map[string]bool{
+	"Directory":     true,
+	"Path":          true,
+	"Time":          true,
+	"PV.name":       true,
+	"PVC.name":      true,
+	"PVC.namespace": true,
+	"PVC.uid":       true,
+}
+
+// checkArchivePattern checks the syntax of an archivePattern: the fields and
+// template functions it uses, and that it names a directory starting with
+// archivePrefix at the root of the export.
+func checkArchivePattern(archivePattern string) error {
+	rest := archivePattern
+	for _, r := range archiveField.FindAllStringSubmatch(archivePattern, -1) {
+		if !archiveFields[r[1]] {
+			return fmt.Errorf("unknown field %q in %q, must be Directory, Path, Time, PV.name, PVC.name, PVC.namespace or PVC.uid", r[1], r[0])
+		}
+		for _, name := range strings.Split(r[2], "|")[1:] {
+			if _, ok := templateFuncs[strings.TrimSpace(name)]; !ok {
+				return fmt.Errorf("unknown template function %q in %q", strings.TrimSpace(name), r[0])
+			}
+		}
+		rest = strings.Replace(rest, r[0], "x", 1)
+	}
+	if strings.Contains(rest, "${") {
+		return fmt.Errorf("unterminated or malformed ${...} in %q", archivePattern)
+	}
+	if !strings.HasPrefix(archivePattern, archivePrefix) || strings.Contains(rest, "..") {
+		return fmt.Errorf("%q must start with %s and stay inside the export", archivePattern, archivePrefix)
+	}
+	return nil
+}
+
+// archiveTime returns now in the archiveTimeZone of class, UTC by default,
+// formatted with its archiveTimeFormat.
+func archiveTime(class *storage.StorageClass, now time.Time) (string, error) {
+	location := time.UTC
+	if zone := class.Parameters["archiveTimeZone"]; zone != "" {
+		var err error
+		if location, err = time.LoadLocation(zone); err != nil {
+			return "", fmt.Errorf("invalid archiveTimeZone %q: %v", zone, err)
+		}
+	}
+	layout := class.Parameters["archiveTimeFormat"]
+	if layout == "" {
+		layout = defaultArchiveTimeFormat
+	}
+	return now.In(location).Format(layout), nil
+}
+
+// archiveName returns where the directory of volume, at dir relative to the
+// export, is archived, relative to the root of the export or of the S3
+// prefix: the archivePattern of class expanded, or fallback.
+func archiveName(volume *v1.PersistentVolume, class *storage.StorageClass, dir string, now time.Time, fallback string) (string, error) {
+	archivePattern := class.Parameters["archivePattern"]
+	if archivePattern == "" {
+		archivePattern = fallback
+	}
+	if err := checkArchivePattern(archivePattern); err != nil {
+		return "", fmt.Errorf("invalid archivePattern: %v", err)
+	}
+	stamp, err := archiveTime(class, now)
+	if err != nil {
+		return "", err
+	}
+	values := map[string]string{
+		"Directory": filepath.Base(dir),
+		"Path":      filepath.ToSlash(dir),
+		"Time":      stamp,
+		"PV.name":   volume.Name,
+	}
+	if ref := volume.Spec.ClaimRef; ref != nil {
+		values["PVC.name"] = ref.Name
+		values["PVC.namespace"] = ref.Namespace
+		values["PVC.uid"] = string(ref.UID)
+	}
+	name := archivePattern
+	for _, r := range archiveField.FindAllStringSubmatch(archivePattern, -1) {
+		value := values[r[1]]
+		for _, fn := range strings.Split(r[2], "|")[1:] {
+			value = templateFuncs[strings.TrimSpace(fn)](value)
+		}
+		name = strings.Replace(name, r[0], value, 1)
+	}
+	name = filepath.Clean(name)
+	for _, part := range strings.Split(name, string(filepath.Separator)) {
+		if part == ".." || part == "" {
+			return "", fmt.Errorf("archivePattern %q gives the invalid name %q", archivePattern, name)
+		}
+	}
+	return name, nil
+}
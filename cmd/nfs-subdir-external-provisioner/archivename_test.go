@@ -0,0 +1,140 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckArchivePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		wantErr bool
+	}{
+		{pattern: defaultArchivePattern},
+		{pattern: defaultS3ArchivePattern},
+		{pattern: "archived-${.PVC.namespace}/${.PVC.name}-${.Time}"},
+		{pattern: "archived-${ .PVC.name | shortHash }-${.PV.name}"},
+		{pattern: "archived-${.Path | hash | shortHash}"},
+		{pattern: "archived-static"},
+		{pattern: "${.Directory}", wantErr: true},
+		{pattern: "old/archived-${.Directory}", wantErr: true},
+		{pattern: "archived-${.Directory}/../escape", wantErr: true},
+		{pattern: "archived-${.Volume}", wantErr: true},
+		{pattern: "archived-${.Directory | upper}", wantErr: true},
+		{pattern: "archived-${.Directory", wantErr: true},
+		{pattern: "archived-${Directory}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			if err := checkArchivePattern(tt.pattern); (err != nil) != tt.wantErr {
+				t.Errorf("checkArchivePattern() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestArchiveName(t *testing.T) {
+	now := time.Date(2026, 3, 15, 9, 30, 0, 0, time.UTC)
+	claimed := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc-1"},
+		Spec: v1.PersistentVolumeSpec{
+			ClaimRef: &v1.ObjectReference{Namespace: "team-a", Name: "data", UID: "uid-1"},
+		},
+	}
+	unclaimed := &v1.PersistentVolume{ObjectMeta: metav1.ObjectMeta{Name: "pvc-2"}}
+	tests := []struct {
+		name       string
+		volume     *v1.PersistentVolume
+		parameters map[string]string
+		dir        string
+		fallback   string
+		want       string
+		wantErr    bool
+	}{
+		{name: "default", volume: claimed, dir: "team-a-data-pvc-1", fallback: defaultArchivePattern, want: "archived-team-a-data-pvc-1"},
+		{name: "S3 default", volume: claimed, dir: "team-a-data-pvc-1", fallback: defaultS3ArchivePattern, want: "archived-team-a-data-pvc-1-20260315T093000Z"},
+		{name: "directory of a nested path", volume: claimed, dir: "team-a/data", fallback: defaultArchivePattern, want: "archived-data"},
+		{
+			name:       "claim fields",
+			volume:     claimed,
+			parameters: map[string]string{"archivePattern": "archived-${.PVC.namespace}/${.PVC.name}-${.PVC.uid}-${.PV.name}"},
+			dir:        "x",
+			want:       "archived-team-a/data-uid-1-pvc-1",
+		},
+		{
+			name:       "template function",
+			volume:     claimed,
+			parameters: map[string]string{"archivePattern": "archived-${.PVC.name | shortHash}"},
+			dir:        "x",
+			want:       "archived-3a6eb079",
+		},
+		{
+			name:       "time zone and format",
+			volume:     claimed,
+			parameters: map[string]string{"archivePattern": "archived-${.Time}", "archiveTimeZone": "Europe/Paris", "archiveTimeFormat": "2006-01-02T15h04"},
+			dir:        "x",
+			want:       "archived-2026-03-15T10h30",
+		},
+		{
+			name:       "no claim",
+			volume:     unclaimed,
+			parameters: map[string]string{"archivePattern": "archived-${.PV.name}${.PVC.name}"},
+			dir:        "x",
+			want:       "archived-pvc-2",
+		},
+		{
+			name:       "path escaping the export",
+			volume:     claimed,
+			parameters: map[string]string{"archivePattern": "archived-${.Path}"},
+			dir:        "a/../../etc",
+			wantErr:    true,
+		},
+		{
+			name:       "invalid time zone",
+			volume:     claimed,
+			parameters: map[string]string{"archiveTimeZone": "Mars/Olympus"},
+			dir:        "x",
+			fallback:   defaultArchivePattern,
+			wantErr:    true,
+		},
+		{
+			name:       "invalid pattern",
+			volume:     claimed,
+			parameters: map[string]string{"archivePattern": "backup-${.Directory}"},
+			dir:        "x",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			class := &storage.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "nfs"}, Parameters: tt.parameters}
+			got, err := archiveName(tt.volume, class, tt.dir, now, tt.fallback)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("archiveName() error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("archiveName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
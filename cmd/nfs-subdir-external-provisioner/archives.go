@@ -0,0 +1,304 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+	v1 "k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// archiveManifestSuffix is appended to the name of an archive to name its
+// manifest.
+const archiveManifestSuffix = ".manifest.json"
+
+// archiveManifest describes an archive. It is stored next to the archive,
+// on the export or in S3, to find and restore it later.
+type archiveManifest struct {
+	// Archive is the URL of an archive in S3, or the path of an archived
+	// directory relative to the export.
+	Archive string `json:"archive"`
+	// Format is "directory" for archived directories.
+	Format       string    `json:"format"`
+	SHA256       string    `json:"sha256,omitempty"`
+	Size         int64     `json:"size,omitempty"`
+	Files        int64     `json:"files"`
+	Bytes        int64     `json:"bytes"`
+	Archived     time.Time `json:"archived"`
+	PV           string    `json:"pv"`
+	PVC          string    `json:"pvc,omitempty"`
+	Namespace    string    `json:"namespace,omitempty"`
+	UID          string    `json:"uid,omitempty"`
+	StorageClass string    `json:"storageClass"`
+	Server       string    `json:"server"`
+	Path         string    `json:"path"`
+	// Volume is the PV as it was when it was deleted.
+	Volume *v1.PersistentVolume `json:"volume,omitempty"`
+}
+
+// newArchiveManifest returns the manifest of the archive of volume, of
+// class, at location, archived at now.
+func newArchiveManifest(volume *v1.PersistentVolume, class *storage.StorageClass, location, format string, now time.Time) archiveManifest {
+	manifest := archiveManifest{
+		Archive:      location,
+		Format:       format,
+		Archived:     now.UTC(),
+		PV:           volume.Name,
+		StorageClass: class.Name,
+		Server:       volume.Spec.NFS.Server,
+		Path:         volume.Spec.NFS.Path,
+		Volume:       volume,
+	}
+	if ref := volume.Spec.ClaimRef; ref != nil {
+		manifest.PVC, manifest.Namespace, manifest.UID = ref.Name, ref.Namespace, string(ref.UID)
+	}
+	return manifest
+}
+
+// writeArchiveManifest measures the directory archived at name, relative to
+// the export mounted at mountPath, and writes its manifest next to it.
+func writeArchiveManifest(ctx context.Context, manifest archiveManifest, mountPath, name string) error {
+	dir := filepath.Join(mountPath, name)
+	var err error
+	manifest.Bytes, manifest.Files, err = diskUsage(ctx, dir, nil)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dir+archiveManifestSuffix, append(data, '\n'), 0644)
+}
+
+// listedArchive is an archive found on an export by list-archives.
+type listedArchive struct {
+	archiveManifest
+	// Manifest tells whether the archive has a manifest. Archives made
+	// before manifests were written only have a name, and what the metadata
+	// file of the volume says.
+	Manifest bool `json:"manifest"`
+}
+
+func runListArchives(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	export := fs.String("export", "", "Mounted export to list the archives of.")
+	namespace := fs.String("namespace", "", "Only list the archives of claims of this namespace.")
+	pvc := fs.String("pvc", "", "Only list the archives of claims of this name.")
+	pv := fs.String("pv", "", "Only list the archive of this PV.")
+	metadataFile := fs.String("metadata-file", ".nfs-provisioner.json", "Name of the metadata files of the volumes, read for archives without a manifest.")
+	format := fs.String("format", "table", "Output format: table, yaml or json.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *export == "" {
+		return fmt.Errorf("--export is required")
+	}
+	switch *format {
+	case "table", "yaml", "json":
+	default:
+		return fmt.Errorf("invalid --format %q, must be table, yaml or json", *format)
+	}
+
+	archives, err := listArchives(ctx, *export, *metadataFile)
+	if err != nil {
+		return err
+	}
+	matching := archives[:0]
+	for _, a := range archives {
+		if (*namespace == "" || a.Namespace == *namespace) && (*pvc == "" || a.PVC == *pvc) && (*pv == "" || a.PV == *pv) {
+			matching = append(matching, a)
+		}
+	}
+
+	switch *format {
+	case "json":
+		data, err := json.MarshalIndent(matching, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", data)
+	case "yaml":
+		data, err := yaml.Marshal(matching)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(data))
+	default:
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "ARCHIVE\tNAMESPACE\tPVC\tPV\tARCHIVED\tSIZE\tFILES")
+		for _, a := range matching {
+			archived, size, files := "-", "-", "-"
+			if a.Manifest {
+				archived = a.Archived.Format(time.RFC3339)
+				size = resource.NewQuantity(a.Bytes, resource.BinarySI).String()
+				files = fmt.Sprint(a.Files)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", a.Archive, dash(a.Namespace), dash(a.PVC), dash(a.PV), archived, size, files)
+		}
+		return w.Flush()
+	}
+	return nil
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// listArchives returns the archives of the export mounted at export, by
+// name: the directories starting with archivePrefix at its root or, for
+// archivePatterns making directories, under them, those with a manifest
+// next to them.
+func listArchives(ctx context.Context, export, metadataFile string) ([]listedArchive, error) {
+	logger := klog.FromContext(ctx)
+	var archives []listedArchive
+	err := filepath.WalkDir(export, func(dir string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if dir == export {
+				return err
+			}
+			logger.Error(err, "failed to walk the export", "path", dir)
+			return nil
+		}
+		if dir == export || !entry.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(export, dir)
+		if err != nil {
+			return err
+		}
+		topLevel := !strings.Contains(rel, string(filepath.Separator))
+		if topLevel && !strings.HasPrefix(rel, archivePrefix) || strings.HasPrefix(entry.Name(), ".") {
+			return filepath.SkipDir
+		}
+		a := listedArchive{archiveManifest: archiveManifest{Archive: filepath.ToSlash(rel), Format: "directory"}}
+		data, err := os.ReadFile(dir + archiveManifestSuffix)
+		switch {
+		case err == nil:
+			if err := json.Unmarshal(data, &a.archiveManifest); err != nil {
+				logger.Error(err, "invalid archive manifest", "path", dir+archiveManifestSuffix)
+				break
+			}
+			a.Archive, a.Manifest, a.Volume = filepath.ToSlash(rel), true, nil
+		case !errors.Is(err, os.ErrNotExist):
+			logger.Error(err, "failed to read archive manifest", "path", dir+archiveManifestSuffix)
+		case !topLevel:
+			// A directory an archivePattern made to keep archives in.
+			return nil
+		default:
+			if metadataFile != "" {
+				if metadata, err := readMetadataFile(filepath.Join(dir, metadataFile)); err == nil {
+					a.PV, a.PVC, a.Namespace, a.UID = metadata.PV, metadata.PVC, metadata.Namespace, metadata.UID
+					a.StorageClass, a.Server, a.Path = metadata.StorageClass, metadata.Server, metadata.Path
+					break
+				}
+			}
+			if hasArchiveManifests(dir) {
+				// A directory an archivePattern made to keep archives in.
+				return nil
+			}
+		}
+		archives = append(archives, a)
+		// The content of an archive is not ours to walk.
+		return filepath.SkipDir
+	})
+	sort.Slice(archives, func(i, j int) bool { return archives[i].Archive < archives[j].Archive })
+	return archives, err
+}
+
+// hasArchiveManifests tells whether dir holds archive manifests, i.e. is a
+// directory an archivePattern made to keep archives in.
+func hasArchiveManifests(dir string) bool {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*"+archiveManifestSuffix))
+	return len(matches) > 0
+}
+
+var (
+	archivesDesc = prometheus.NewDesc(metricsNamespace+"_archives",
+		"Number of archived directories on an export, by StorageClass of their volume, as of the last scan.",
+		[]string{"export", "server", "path", "storageclass"}, nil)
+	archiveBytesDesc = prometheus.NewDesc(metricsNamespace+"_archive_bytes",
+		"Apparent size of the archived directories on an export, by StorageClass of their volume, as of the last scan.",
+		[]string{"export", "server", "path", "storageclass"}, nil)
+)
+
+// archiveGroup is the archives of an export of one StorageClass, "" for
+// archives that do not tell theirs.
+type archiveGroup struct {
+	export, server, path, storageClass string
+}
+
+// archiveTotal is the number and size of the archives of an archiveGroup.
+type archiveTotal struct {
+	count, bytes int64
+}
+
+// measureArchives counts and measures the archives of the mounted exports,
+// by StorageClass. The size of an archive is read from its manifest, and
+// only measured, reading at most as fast as limiter allows, when it has
+// none. Exports that fail to be listed keep their last measure in previous.
+func (p *nfsProvisioner) measureArchives(ctx context.Context, limiter *rate.Limiter, previous map[archiveGroup]archiveTotal) map[archiveGroup]archiveTotal {
+	logger := klog.FromContext(ctx)
+	metadataFile := p.settings().MetadataFile
+	totals := map[archiveGroup]archiveTotal{}
+	for name, b := range p.mountedBackends() {
+		archives, err := listArchives(ctx, b.mountPath, metadataFile)
+		if err != nil {
+			logger.Error(err, "failed to list the archives of export", "export", mountSource(b.server, b.path))
+			for group, total := range previous {
+				if group.export == name {
+					totals[group] = total
+				}
+			}
+			continue
+		}
+		for _, a := range archives {
+			bytes := a.Bytes
+			if !a.Manifest {
+				if bytes, _, err = diskUsage(ctx, filepath.Join(b.mountPath, a.Archive), limiter); err != nil {
+					logger.Error(err, "failed to measure archive", "path", filepath.Join(b.mountPath, a.Archive))
+					continue
+				}
+			}
+			group := archiveGroup{export: name, server: b.server, path: b.path, storageClass: a.StorageClass}
+			total := totals[group]
+			total.count++
+			total.bytes += bytes
+			totals[group] = total
+		}
+	}
+	return totals
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+)
+
+type auditAction string
+
+const (
+	auditProvision auditAction = "provision"
+	auditDelete    auditAction = "delete"
+	auditArchive   auditAction = "archive"
+	auditDefer     auditAction = "defer-delete"
+	auditRetain    auditAction = "retain"
+	auditSkip      auditAction = "skip"
+)
+
+// auditRecord is a single line of the audit log.
+type auditRecord struct {
+	Timestamp    time.Time   `json:"timestamp"`
+	Action       auditAction `json:"action"`
+	PVC          string      `json:"pvc,omitempty"`
+	Namespace    string      `json:"namespace,omitempty"`
+	PV           string      `json:"pv"`
+	StorageClass string      `json:"storageClass,omitempty"`
+	Server       string      `json:"server,omitempty"`
+	Path         string      `json:"path,omitempty"`
+	Result       string      `json:"result"`
+	Error        string      `json:"error,omitempty"`
+}
+
+// auditLogger writes volume lifecycle operations as JSON lines, independent
+// of klog, so they can be shipped to a SIEM as-is. A nil auditLogger
+// discards everything.
+type auditLogger struct {
+	mu    sync.Mutex
+	enc   *json.Encoder
+	clock clock.PassiveClock
+}
+
+// newAuditLogger opens dest for appending. "-" and "stdout" write to the
+// standard output of the process. Records are timestamped with clock.
+func newAuditLogger(dest string, clock clock.PassiveClock) (*auditLogger, error) {
+	var w io.Writer
+	switch dest {
+	case "-", "stdout":
+		w = os.Stdout
+	default:
+		f, err := os.OpenFile(dest, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o640)
+		if err != nil {
+			return nil, err
+		}
+		w = f
+	}
+	return &auditLogger{enc: json.NewEncoder(w), clock: clock}, nil
+}
+
+func (a *auditLogger) log(record auditRecord, err error) {
+	if a == nil {
+		return
+	}
+
+	record.Timestamp = a.clock.Now().UTC()
+	record.Result = "success"
+	if err != nil {
+		record.Result = "failure"
+		record.Error = err.Error()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.enc.Encode(record); err != nil {
+		klog.Background().Error(err, "failed to write audit record")
+	}
+}
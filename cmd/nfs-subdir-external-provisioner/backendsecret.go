@@ -0,0 +1,74 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// annBackendSecret records on a PV the Secret, as namespace/name, that
+	// describes the export holding its directory.
+	annBackendSecret = "nfs.io/backend-secret"
+
+	secretServerKey       = "server"
+	secretPathKey         = "path"
+	secretMountOptionsKey = "mountOptions"
+)
+
+// backendForSecret returns the export described by the Secret called name in
+// namespace, mounted by the provisioner. The Secret holds the server and path
+// of the export, optionally its mount options, and the Kerberos credentials
+// to mount it with.
+func (p *nfsProvisioner) backendForSecret(ctx context.Context, namespace, name string) (*backend, error) {
+	if p.exports == nil {
+		return nil, fmt.Errorf("backend Secret %s/%s needs --enable-exports", namespace, name)
+	}
+	secret, err := p.client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get backend Secret %s/%s: %v", namespace, name, err)
+	}
+	spec := nfsExportSpec{
+		Server:       string(secret.Data[secretServerKey]),
+		Path:         string(secret.Data[secretPathKey]),
+		MountOptions: splitMountOptions([]string{string(secret.Data[secretMountOptionsKey])}),
+	}
+	if spec.Server == "" || !filepath.IsAbs(spec.Path) {
+		return nil, fmt.Errorf("backend Secret %s/%s needs a %s and an absolute %s", namespace, name, secretServerKey, secretPathKey)
+	}
+	if spec.Server, err = canonicalServer(spec.Server); err != nil {
+		return nil, fmt.Errorf("backend Secret %s/%s: %v", namespace, name, err)
+	}
+	if keytab, ok := secret.Data[secretKeytabKey]; ok {
+		if p.kerberos == nil {
+			return nil, fmt.Errorf("backend Secret %s/%s has a keytab, but --kerberos-keytab is not set", namespace, name)
+		}
+		if err := p.kerberos.install(namespace+"/"+name, keytab, secret.Data[secretKrb5ConfKey]); err != nil {
+			return nil, err
+		}
+	}
+	// NFSExport names cannot start with a dot, nor namespaces hold one.
+	b, _, err := p.exports.mount(ctx, ".secret."+namespace+"."+name, spec)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
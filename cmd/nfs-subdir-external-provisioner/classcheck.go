@@ -0,0 +1,387 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// classParameters are the StorageClass parameters the provisioner knows.
+var classParameters = map[string]bool{
+	"acl":                      true,
+	"allowedAccessModes":       true,
+	"allowedGIDs":              true,
+	"allowedMountOptions":      true,
+	"allowedUIDs":              true,
+	"allowInspection":          true,
+	"archiveOnDelete":          true,
+	"archivePattern":           true,
+	"archiveS3Endpoint":        true,
+	"archiveS3Region":          true,
+	"archiveS3SecretName":      true,
+	"archiveS3SecretNamespace": true,
+	"archiveS3URL":             true,
+	"archiveTimeFormat":        true,
+	"archiveTimeZone":          true,
+	"backendSecretName":        true,
+	"backendSecretNamespace":   true,
+	"defaultAcl":               true,
+	"deleteDelay":              true,
+	"directoryLayout":          true,
+	"export":                   true,
+	"exportClients":            true,
+	"exportOptions":            true,
+	"exportPerVolume":          true,
+	"ephemeralOnDelete":        true,
+	"gid":                      true,
+	"gidMax":                   true,
+	"gidMin":                   true,
+	"inspectAnyNamespace":      true,
+	"kerberosSecretName":       true,
+	"kerberosSecretNamespace":  true,
+	"maxSize":                  true,
+	"minSize":                  true,
+	"minSizePolicy":            true,
+	"maxFiles":                 true,
+	"mountSecurityProfile":     true,
+	"nodeAffinity":             true,
+	"onDelete":                 true,
+	"onExisting":               true,
+	"pathPattern":              true,
+	"pvAnnotations":            true,
+	"pvLabels":                 true,
+	"pvNameTemplate":           true,
+	"pvTemplate":               true,
+	"roundSizeTo":              true,
+	"rwopLockFile":             true,
+	"serverResolution":         true,
+	"setgid":                   true,
+	"sharedPath":               true,
+	"sizeEnforced":             true,
+	"seLinuxLabel":             true,
+	"seLinuxLabelMode":         true,
+	"servers":                  true,
+	"skeletonPath":             true,
+}
+
+// checkTemplate checks the PVC fields and template functions that str, a
+// pathPattern or PV metadata value, uses. It returns str with each field
+// replaced by x.
+func checkTemplate(str string) (string, error) {
+	rest := str
+	for _, r := range pattern.FindAllStringSubmatch(str, -1) {
+		switch {
+		case r[2] != "" && r[3] == "":
+			return "", fmt.Errorf("%q names no %s key", r[0], r[2])
+		case r[2] == "" && r[1] != "name" && r[1] != "namespace" && r[1] != "uid":
+			return "", fmt.Errorf("unknown PVC field %q in %q, must be name, namespace, uid, labels.<key> or annotations.<key>", r[1], r[0])
+		}
+		for _, name := range strings.Split(r[4], "|")[1:] {
+			if _, ok := templateFuncs[strings.TrimSpace(name)]; !ok {
+				return "", fmt.Errorf("unknown template function %q in %q", strings.TrimSpace(name), r[0])
+			}
+		}
+		rest = strings.Replace(rest, r[0], "x", 1)
+	}
+	if strings.Contains(rest, "${") {
+		return "", fmt.Errorf("unterminated or malformed ${...} in %q", str)
+	}
+	return rest, nil
+}
+
+// checkPathPattern checks the syntax of a pathPattern: the PVC fields and
+// template functions it uses, and that it stays inside the export.
+func checkPathPattern(pathPattern string) error {
+	rest, err := checkTemplate(pathPattern)
+	if err != nil {
+		return err
+	}
+	if filepath.IsAbs(rest) || strings.HasPrefix(filepath.Clean(rest), "..") {
+		return fmt.Errorf("%q is not relative to the export", pathPattern)
+	}
+	return nil
+}
+
+// checkClass returns the problems of the parameters of class that would make
+// its claims fail to provision, or its volumes fail to be deleted, in the
+// order of the parameter names.
+func checkClass(class *storage.StorageClass) []string {
+	var problems []string
+	names := make([]string, 0, len(class.Parameters))
+	for name := range class.Parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !classParameters[name] {
+			problems = append(problems, fmt.Sprintf("unknown parameter %q", name))
+			continue
+		}
+		if err := checkParameter(class, name, class.Parameters[name]); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	onDelete, archive := class.Parameters["onDelete"], class.Parameters["archiveOnDelete"]
+	if archived, err := strconv.ParseBool(archive); err == nil {
+		switch {
+		case onDelete == "retain",
+			onDelete == "delete" && archived,
+			onDelete == "archive" && !archived:
+			problems = append(problems, fmt.Sprintf("onDelete %q conflicts with archiveOnDelete %q", onDelete, archive))
+		}
+	}
+	for _, pair := range [][2]string{{"backendSecretName", "backendSecretNamespace"}, {"kerberosSecretName", "kerberosSecretNamespace"}} {
+		if (class.Parameters[pair[0]] == "") != (class.Parameters[pair[1]] == "") {
+			problems = append(problems, fmt.Sprintf("%s and %s must be set together", pair[0], pair[1]))
+		}
+	}
+	if class.Parameters["sharedPath"] != "" {
+		for _, name := range []string{"pathPattern", "skeletonPath", "onDelete", "archiveOnDelete", "deleteDelay", "sizeEnforced"} {
+			if class.Parameters[name] != "" {
+				problems = append(problems, fmt.Sprintf("sharedPath conflicts with %s", name))
+			}
+		}
+	}
+	for _, conflict := range mountOptionConflicts(class.MountOptions) {
+		problems = append(problems, "mountOptions: "+conflict)
+	}
+	if enforced, _ := sizeEnforced(class); enforced && class.Parameters["skeletonPath"] != "" {
+		problems = append(problems, "sizeEnforced conflicts with skeletonPath")
+	}
+	if allowed, _ := allowedAccessModes(class); allowed != nil {
+		if class.Parameters["sharedPath"] != "" && !allowed[v1.ReadOnlyMany] {
+			problems = append(problems, "sharedPath needs ReadOnlyMany among the allowedAccessModes")
+		}
+		if enforced, _ := sizeEnforced(class); enforced && !allowed[v1.ReadWriteOnce] && !allowed[v1.ReadWriteOncePod] {
+			problems = append(problems, "sizeEnforced needs ReadWriteOnce or ReadWriteOncePod among the allowedAccessModes")
+		}
+	}
+	if _, _, ok, err := gidRange(class); err != nil {
+		problems = append(problems, err.Error())
+	} else if ok && class.Parameters["gid"] != "" {
+		problems = append(problems, "gid conflicts with gidMin and gidMax")
+	}
+	if class.Parameters["seLinuxLabelMode"] != "" && class.Parameters["seLinuxLabel"] == "" {
+		problems = append(problems, "seLinuxLabelMode needs seLinuxLabel")
+	}
+	for _, name := range []string{"archiveS3Endpoint", "archiveS3Region", "archiveS3SecretName", "archiveS3SecretNamespace"} {
+		if class.Parameters[name] != "" && class.Parameters["archiveS3URL"] == "" {
+			problems = append(problems, fmt.Sprintf("%s needs archiveS3URL", name))
+		}
+	}
+	if class.Parameters["deleteDelay"] != "" {
+		if archived, _ := strconv.ParseBool(archive); onDelete == "retain" || onDelete == "archive" || archived {
+			problems = append(problems, "deleteDelay only applies to directories that are deleted, not retained or archived")
+		}
+		if class.Parameters["backendSecretName"] != "" {
+			problems = append(problems, "deleteDelay is not supported with backendSecretName")
+		}
+	}
+	if class.Parameters["backendSecretName"] != "" && class.Parameters["export"] != "" {
+		problems = append(problems, "backendSecretName and export are mutually exclusive")
+	}
+	return problems
+}
+
+// checkParameter checks value, the parameter called name of class.
+func checkParameter(class *storage.StorageClass, name, value string) error {
+	switch name {
+	case "allowInspection", "archiveOnDelete", "exportPerVolume", "inspectAnyNamespace", "rwopLockFile", "setgid", "sizeEnforced":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("invalid %s %q, must be true or false", name, value)
+		}
+	case "onDelete", "ephemeralOnDelete":
+		switch value {
+		case "", "delete", "retain", "archive":
+		default:
+			return fmt.Errorf("invalid %s %q, must be delete, retain or archive", name, value)
+		}
+	case "deleteDelay":
+		if _, err := deleteDelay(class); err != nil {
+			return err
+		}
+	case "directoryLayout":
+		if _, err := layoutDirectory(class, "dir"); err != nil {
+			return err
+		}
+	case "onExisting":
+		switch value {
+		case "", "fail", "reuse", "unique":
+		default:
+			return fmt.Errorf("invalid onExisting %q, must be fail, reuse or unique", value)
+		}
+	case "pathPattern":
+		if err := checkPathPattern(value); err != nil {
+			return fmt.Errorf("invalid pathPattern: %v", err)
+		}
+	case "skeletonPath":
+		if err := checkSkeletonPath(value); err != nil {
+			return err
+		}
+	case "seLinuxLabel":
+		if err := checkSELinuxLabel(value); err != nil {
+			return err
+		}
+	case "seLinuxLabelMode":
+		if _, _, err := seLinuxLabelMode(class); err != nil {
+			return err
+		}
+	case "gid":
+		if _, err := parseID(name, value); err != nil {
+			return err
+		}
+	case "allowedUIDs", "allowedGIDs":
+		if _, err := parseIDRanges(name, value); err != nil {
+			return err
+		}
+	case "allowedMountOptions":
+		if _, err := allowedMountOptions(class); err != nil {
+			return err
+		}
+	case "allowedAccessModes":
+		if _, err := allowedAccessModes(class); err != nil {
+			return err
+		}
+	case "maxFiles":
+		if _, err := maxFiles(class); err != nil {
+			return err
+		}
+	case "sharedPath":
+		if err := checkSharedPath(value); err != nil {
+			return err
+		}
+	case "acl", "defaultAcl":
+		if _, err := parseACL(value); err != nil {
+			return fmt.Errorf("invalid %s: %v", name, err)
+		}
+	case "archivePattern":
+		if err := checkArchivePattern(value); err != nil {
+			return fmt.Errorf("invalid archivePattern: %v", err)
+		}
+	case "archiveTimeFormat":
+		// A layout without any element formats to itself.
+		if other := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC); other.Format(value) == value {
+			return fmt.Errorf("invalid archiveTimeFormat %q, must be a Go time layout such as 20060102T150405Z0700", value)
+		}
+	case "archiveTimeZone":
+		if _, err := archiveTime(class, time.Time{}); err != nil {
+			return err
+		}
+	case "archiveS3URL":
+		if _, _, err := parseS3Prefix(value); err != nil {
+			return err
+		}
+	case "archiveS3Endpoint":
+		if u, err := url.Parse(value); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("invalid archiveS3Endpoint %q, must be an http(s) URL", value)
+		}
+	case "pvNameTemplate":
+		if err := checkPVNameTemplate(value); err != nil {
+			return err
+		}
+	case "pvLabels", "pvAnnotations":
+		if _, err := pvMetadataTemplates(class, name); err != nil {
+			return err
+		}
+	case "pvTemplate":
+		pv := &v1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv"},
+			Spec: v1.PersistentVolumeSpec{
+				PersistentVolumeSource: v1.PersistentVolumeSource{NFS: &v1.NFSVolumeSource{Server: "server", Path: "/path"}},
+			},
+		}
+		if _, err := applyPVTemplate(pv, value); err != nil {
+			return err
+		}
+	case "minSize", "maxSize", "roundSizeTo":
+		if _, err := sizeParameter(class, name); err != nil {
+			return err
+		}
+	case "minSizePolicy":
+		if value != "" && value != "raise" && value != "reject" {
+			return fmt.Errorf("invalid minSizePolicy %q, must be raise or reject", value)
+		}
+	case "mountSecurityProfile":
+		if _, err := enforceMountSecurityProfile(value, nil); err != nil {
+			return err
+		}
+	case "nodeAffinity":
+		if _, err := selectorRequirements(value); err != nil {
+			return fmt.Errorf("invalid nodeAffinity %q: %v", value, err)
+		}
+	case "serverResolution":
+		switch value {
+		case "", "none", "verify", "pin":
+		default:
+			return fmt.Errorf("invalid serverResolution %q, must be none, verify or pin", value)
+		}
+	case "servers":
+		if _, err := serverList(value); err != nil {
+			return err
+		}
+	case "exportClients":
+		for _, client := range strings.Fields(value) {
+			if !exportToken.MatchString(client) {
+				return fmt.Errorf("invalid export client %q", client)
+			}
+		}
+	case "exportOptions":
+		if value != "" && !exportToken.MatchString(value) {
+			return fmt.Errorf("invalid exportOptions %q", value)
+		}
+	}
+	return nil
+}
+
+// startClassChecks checks the StorageClasses of this provisioner as the
+// informer lists them, at startup and whenever one is created, and records a
+// warning on those with problems, so that they are found before their first
+// claim fails.
+func (p *nfsProvisioner) startClassChecks(ctx context.Context, classes cache.SharedIndexInformer) {
+	logger := klog.FromContext(ctx)
+	_, err := classes.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			class, ok := obj.(*storage.StorageClass)
+			if !ok || !p.owns(class.Provisioner) {
+				return
+			}
+			problems := checkClass(class)
+			if len(problems) == 0 {
+				return
+			}
+			logger.Info("StorageClass is misconfigured", "storageClass", class.Name, "problems", problems)
+			p.recorder.Eventf(class, v1.EventTypeWarning, "InvalidParameters", "StorageClass %s is misconfigured: %s", class.Name, strings.Join(problems, "; "))
+		},
+	})
+	if err != nil {
+		logger.Error(err, "failed to watch StorageClasses to check them")
+	}
+}
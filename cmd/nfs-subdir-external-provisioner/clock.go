@@ -0,0 +1,82 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// deterministicEpoch is the first time returned by a deterministic clock.
+var deterministicEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// deterministicClock starts at deterministicEpoch and moves one second
+// forward every time it is read, so that runs performing the same operations
+// in the same order observe the same times.
+type deterministicClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+var _ clock.PassiveClock = &deterministicClock{}
+
+func (c *deterministicClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.now.IsZero() {
+		c.now = deterministicEpoch
+	}
+	c.now = c.now.Add(time.Second)
+	return c.now
+}
+
+func (c *deterministicClock) Since(t time.Time) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now.Sub(t)
+}
+
+// randomSource hands out the random parts of generated names. It is safe for
+// concurrent use.
+type randomSource struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func newRandomSource(seed int64) *randomSource {
+	return &randomSource{rand: rand.New(rand.NewSource(seed))}
+}
+
+// suffix returns 8 random hex digits.
+func (r *randomSource) suffix() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return fmt.Sprintf("%08x", r.rand.Uint32())
+}
+
+// newTimeSources returns the clock and random source of the provisioner. In
+// deterministic mode both produce the same sequence on every run.
+func newTimeSources(deterministic bool) (clock.PassiveClock, *randomSource) {
+	if deterministic {
+		return &deterministicClock{}, newRandomSource(1)
+	}
+	return clock.RealClock{}, newRandomSource(time.Now().UnixNano())
+}
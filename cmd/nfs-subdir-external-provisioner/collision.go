@@ -0,0 +1,99 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	storage "k8s.io/api/storage/v1"
+)
+
+// maxUniqueAttempts bounds the suffixes tried by onExisting: unique.
+const maxUniqueAttempts = 10
+
+// occupied reports whether the directory at path exists and holds files
+// that ignore does not skip. An empty directory is left by an earlier
+// attempt to provision the same volume, or holds nothing to expose.
+func occupied(path string, ignore func(name string) bool) (bool, error) {
+	dir, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer dir.Close()
+	for {
+		names, err := dir.Readdirnames(1)
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if !ignore(names[0]) {
+			return true, nil
+		}
+	}
+}
+
+// avoidCollision applies the onExisting parameter of class to the directory
+// of the new volume pvName, at path on the export and fullPath in the
+// provisioner, when it already holds files, typically because pathPattern
+// gives several claims the same directory: "reuse" keeps it, as when the
+// parameter is not set, "fail" refuses the claim, and "unique" appends a
+// random suffix. It returns the path and full path to use.
+func (p *nfsProvisioner) avoidCollision(class *storage.StorageClass, pvName, path, fullPath string) (string, string, error) {
+	mode := class.Parameters["onExisting"]
+	switch mode {
+	case "", "reuse":
+		return path, fullPath, nil
+	case "fail", "unique":
+	default:
+		return "", "", fmt.Errorf("invalid onExisting %q, must be fail, reuse or unique", mode)
+	}
+	// An earlier attempt at the same volume may have left its metadata file.
+	metadataFile := p.settings().MetadataFile
+	taken, err := occupied(fullPath, func(name string) bool {
+		if metadataFile == "" || name != metadataFile {
+			return false
+		}
+		metadata, err := readMetadataFile(filepath.Join(fullPath, name))
+		return err == nil && metadata.PV == pvName
+	})
+	if err != nil || !taken {
+		return path, fullPath, err
+	}
+	if mode == "fail" {
+		return "", "", fmt.Errorf("directory %s already exists and is not empty, and StorageClass %s sets onExisting to fail", path, class.Name)
+	}
+	for i := 0; i < maxUniqueAttempts; i++ {
+		suffix := "-" + p.random.suffix()
+		taken, err := occupied(fullPath+suffix, func(string) bool { return false })
+		if err != nil {
+			return "", "", err
+		}
+		if !taken {
+			return path + suffix, fullPath + suffix, nil
+		}
+	}
+	return "", "", fmt.Errorf("unable to find a free directory next to %s", path)
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	storage "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAvoidCollision(t *testing.T) {
+	// The suffixes the random source of the test hands out, in order.
+	seq := newRandomSource(1)
+	first, second := "-"+seq.suffix(), "-"+seq.suffix()
+	tests := []struct {
+		name     string
+		mode     string
+		existing map[string]string
+		want     string
+		wantErr  bool
+	}{
+		{name: "free, reused", want: "data"},
+		{name: "occupied, reused", existing: map[string]string{"data/file": ""}, want: "data"},
+		{name: "occupied, reused explicitly", mode: "reuse", existing: map[string]string{"data/file": ""}, want: "data"},
+		{name: "free, fail", mode: "fail", want: "data"},
+		{name: "empty, fail", mode: "fail", existing: map[string]string{"data/": ""}, want: "data"},
+		{name: "occupied, fail", mode: "fail", existing: map[string]string{"data/file": ""}, wantErr: true},
+		{name: "own metadata file, fail", mode: "fail", existing: map[string]string{"data/.nfs-provisioner.json": `{"pv": "pvc-1"}`}, want: "data"},
+		{name: "metadata file of another volume, fail", mode: "fail", existing: map[string]string{"data/.nfs-provisioner.json": `{"pv": "pvc-2"}`}, wantErr: true},
+		{name: "free, unique", mode: "unique", want: "data"},
+		{name: "occupied, unique", mode: "unique", existing: map[string]string{"data/file": ""}, want: "data" + first},
+		{name: "suffix occupied too, unique", mode: "unique", existing: map[string]string{"data/file": "", "data" + first + "/file": ""}, want: "data" + second},
+		{name: "empty suffix, unique", mode: "unique", existing: map[string]string{"data/file": "", "data" + first + "/": ""}, want: "data" + first},
+		{name: "invalid mode", mode: "rename", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			// Names ending with a slash are empty directories.
+			for name, content := range tt.existing {
+				path := filepath.Join(root, name)
+				if strings.HasSuffix(name, "/") {
+					if err := os.MkdirAll(path, 0o755); err != nil {
+						t.Fatal(err)
+					}
+					continue
+				}
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			p := &nfsProvisioner{cfg: &config{MetadataFile: ".nfs-provisioner.json"}, random: newRandomSource(1)}
+			class := &storage.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "nfs"}, Parameters: map[string]string{"onExisting": tt.mode}}
+
+			path, fullPath, err := p.avoidCollision(class, "pvc-1", "data", filepath.Join(root, "data"))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("avoidCollision() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if path != tt.want || fullPath != filepath.Join(root, tt.want) {
+				t.Errorf("avoidCollision() = %q, %q, want %q", path, fullPath, tt.want)
+			}
+		})
+	}
+}
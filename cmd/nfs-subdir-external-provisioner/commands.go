@@ -0,0 +1,108 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// command is an administrative subcommand. Without a subcommand the binary
+// runs the provisioner.
+type command struct {
+	usage string
+	run   func(ctx context.Context, fs *flag.FlagSet, args []string) error
+}
+
+var commands = map[string]command{
+	"hold-lock": {
+		usage: "Lock the lock file of a ReadWriteOncePod volume while the pod runs, from a sidecar.",
+		run:   runHoldLock,
+	},
+	"list-archives": {
+		usage: "List the archived volumes of an export, with their manifests.",
+		run:   runListArchives,
+	},
+	"mount-image": {
+		usage: "Mount the file system image of a sizeEnforced volume, from a privileged container.",
+		run:   runMountImage,
+	},
+	"plan-consolidation": {
+		usage: "Compute a plan for consolidating several exports into one.",
+		run:   runPlanConsolidation,
+	},
+	"purge-archives": {
+		usage: "Remove the archived volumes of an export by age, size and number per claim.",
+		run:   runPurgeArchives,
+	},
+	"recover": {
+		usage: "Recreate the PVs of an export from the metadata files of its volumes.",
+		run:   runRecover,
+	},
+	"undelete": {
+		usage: "Restore a volume deleted less than the deleteDelay of its class ago, with its PV.",
+		run:   runUndelete,
+	},
+	"verify": {
+		usage: "Check the PVs of an export against its directories, failing on inconsistencies.",
+		run:   runVerify,
+	},
+}
+
+// runCommand runs the subcommand named by args[0], if there is one, and
+// reports whether it did.
+func runCommand(ctx context.Context, args []string) (bool, error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+	if args[0] == "help" {
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(os.Stderr, "Usage: %s [command] [flags]\n\nCommands:\n", os.Args[0])
+		for _, name := range names {
+			fmt.Fprintf(os.Stderr, "  %-20s %s\n", name, commands[name].usage)
+		}
+		return true, nil
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return false, nil
+	}
+	fs := flag.NewFlagSet(args[0], flag.ExitOnError)
+	return true, cmd.run(ctx, fs, args[1:])
+}
+
+// commandClient returns a client of the cluster of kubeconfig, or of
+// $KUBECONFIG, ~/.kube/config, then the in-cluster config when it is empty.
+func commandClient(kubeconfig string) (kubernetes.Interface, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfig
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(config)
+}
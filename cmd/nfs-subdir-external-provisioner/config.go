@@ -0,0 +1,872 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/sig-storage-lib-external-provisioner/v10/controller"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	defaultMountPath = "/persistentvolumes"
+)
+
+// config holds the provisioner-wide settings. Every setting can come from a
+// flag, from the YAML config file or from an environment variable, in that
+// order of precedence.
+type config struct {
+	NFSServer             string  `json:"nfsServer"`
+	NFSPath               string  `json:"nfsPath"`
+	FailoverServers       string  `json:"nfsFailoverServers"`
+	DecommissionedServers string  `json:"decommissionedServers"`
+	ProvisionerName       string  `json:"provisionerName"`
+	MountPath             string  `json:"mountPath"`
+	Kubeconfig            string  `json:"kubeconfig"`
+	Clusters              string  `json:"clusters"`
+	KubeAPIQPS            float64 `json:"kubeAPIQPS"`
+	KubeAPIBurst          int     `json:"kubeAPIBurst"`
+	LeaderElection        bool    `json:"leaderElection"`
+	VerifyMount           bool    `json:"verifyMount"`
+	MountExport           bool    `json:"mountExport"`
+	ExportMountOptions    string  `json:"exportMountOptions"`
+	ProbeNFSVersions      bool    `json:"probeNFSVersions"`
+	ActiveActive          bool    `json:"activeActive"`
+	PolicyURL             string  `json:"policyURL"`
+	PreProvisionHook      string  `json:"preProvisionHook"`
+	PostProvisionHook     string  `json:"postProvisionHook"`
+	PreDeleteHook         string  `json:"preDeleteHook"`
+	PostDeleteHook        string  `json:"postDeleteHook"`
+	HookTimeout           string  `json:"hookTimeout"`
+	AuditLog              string  `json:"auditLog"`
+	ExportAuditLog        string  `json:"exportAuditLog"`
+	Notifiers             string  `json:"notifiers"`
+	NotifyEvents          string  `json:"notifyEvents"`
+	AdminAddress          string  `json:"adminAddress"`
+	AdminTLSCert          string  `json:"adminTLSCert"`
+	AdminTLSKey           string  `json:"adminTLSKey"`
+	AdminTLSClientCA      string  `json:"adminTLSClientCA"`
+	AdminAllowChanges     bool    `json:"adminAllowChanges"`
+	WebhookAddress        string  `json:"webhookAddress"`
+	WebhookTLSCert        string  `json:"webhookTLSCert"`
+	WebhookTLSKey         string  `json:"webhookTLSKey"`
+	EnablePprof           bool    `json:"enablePprof"`
+	PprofAddress          string  `json:"pprofAddress"`
+	PprofAllowRemote      bool    `json:"pprofAllowRemote"`
+	WorkerThreads         int     `json:"workerThreads"`
+	MaxWorkerThreads      int     `json:"maxWorkerThreads"`
+	ArchiveWorkerThreads  int     `json:"archiveWorkerThreads"`
+	ResyncPeriod          string  `json:"resyncPeriod"`
+	RetryBaseDelay        string  `json:"retryBaseDelay"`
+	RetryMaxDelay         string  `json:"retryMaxDelay"`
+	FailedProvisionLimit  int     `json:"failedProvisionThreshold"`
+	FailedDeleteLimit     int     `json:"failedDeleteThreshold"`
+	QuarantineThreshold   int     `json:"quarantineThreshold"`
+	ProgressInterval      string  `json:"progressInterval"`
+	DeleteOpsRate         int     `json:"deleteOpsPerSecond"`
+	DeleteBandwidth       string  `json:"deleteBandwidth"`
+	PVFinalizer           bool    `json:"pvFinalizer"`
+	LogLevel              int     `json:"logLevel"`
+	DefaultOnDelete       string  `json:"defaultOnDelete"`
+	DirectoryMode         string  `json:"directoryMode"`
+	OwnershipStrategy     string  `json:"ownershipStrategy"`
+	DefaultMountOptions   string  `json:"defaultMountOptions"`
+	FSUID                 int     `json:"fsUID"`
+	FSGID                 int     `json:"fsGID"`
+	MkfsCommand           string  `json:"mkfsCommand"`
+	MetadataFile          string  `json:"metadataFile"`
+	ConfigObject          string  `json:"configObject"`
+	Deterministic         bool    `json:"deterministic"`
+	EnableExports         bool    `json:"enableExports"`
+	EnableQuotas          bool    `json:"enableQuotas"`
+	EnableVolumeImports   bool    `json:"enableVolumeImports"`
+	ImportAllowedHosts    string  `json:"importAllowedHosts"`
+	EnableSnapshots       bool    `json:"enableSnapshotSchedules"`
+	EnableRquota          bool    `json:"enableRquota"`
+	EnableAttributes      bool    `json:"enableAttributesClasses"`
+	ExportsMountPath      string  `json:"exportsMountPath"`
+	Exports               string  `json:"exports"`
+	ExportHealthInterval  string  `json:"exportHealthInterval"`
+	ExportServerType      string  `json:"exportServerType"`
+	KerberosKeytab        string  `json:"kerberosKeytab"`
+	KerberosConfig        string  `json:"kerberosConfig"`
+	ExportSSHAddress      string  `json:"exportSSHAddress"`
+	ExportSSHUser         string  `json:"exportSSHUser"`
+	ExportSSHKey          string  `json:"exportSSHKey"`
+	ExportSSHKnownHosts   string  `json:"exportSSHKnownHosts"`
+	StateBundleInterval   string  `json:"stateBundleInterval"`
+	UsageScanInterval     string  `json:"usageScanInterval"`
+	UsageAnnotateInterval string  `json:"usageAnnotationInterval"`
+	UsageReportInterval   string  `json:"usageReportInterval"`
+	UsageReportConfigMap  string  `json:"usageReportConfigMap"`
+	UsageReportTop        int     `json:"usageReportTop"`
+	FreeSpaceInterval     string  `json:"freeSpaceInterval"`
+	DirCheckInterval      string  `json:"directoryCheckInterval"`
+	StatusObject          string  `json:"statusObject"`
+	StatusInterval        string  `json:"statusInterval"`
+	OrphanGracePeriod     string  `json:"orphanGracePeriod"`
+	OrphanExclude         string  `json:"orphanExclude"`
+	OrphanAction          string  `json:"orphanAction"`
+	FreeSpaceThreshold    int     `json:"freeSpaceThreshold"`
+	MarkFullClasses       bool    `json:"markFullClasses"`
+	UsageScanRate         int     `json:"usageScanFilesPerSecond"`
+	MountWatchdogInterval string  `json:"mountWatchdogInterval"`
+	MountWatchdogFailures int     `json:"mountWatchdogFailures"`
+	HealthAddress         string  `json:"healthAddress"`
+	ShutdownTimeout       string  `json:"shutdownTimeout"`
+	AdoptProvisioners     string  `json:"adoptProvisionerNames"`
+	QuotaAlertThresholds  string  `json:"quotaAlertThresholds"`
+	AllowedNamespaces     string  `json:"allowedNamespaces"`
+	DeniedNamespaces      string  `json:"deniedNamespaces"`
+	ShardName             string  `json:"shardName"`
+	ShardSelector         string  `json:"shardNamespaceSelector"`
+	ShardCount            int     `json:"shardCount"`
+	ShardIndex            int     `json:"shardIndex"`
+	NamespaceSelector     string  `json:"allowedNamespaceSelector"`
+
+	// file, spec and overrides are what reload needs to rebuild the
+	// configuration: the config file, the spec of the NfsProvisionerConfig
+	// object as JSON, and the flags given on the command line.
+	file      string
+	spec      []byte
+	overrides map[string]string
+}
+
+func defaultConfig() *config {
+	return &config{
+		MountPath:             defaultMountPath,
+		KubeAPIQPS:            float64(rest.DefaultQPS),
+		KubeAPIBurst:          rest.DefaultBurst,
+		LeaderElection:        true,
+		VerifyMount:           true,
+		ProbeNFSVersions:      true,
+		PprofAddress:          "localhost:6060",
+		WorkerThreads:         controller.DefaultThreadiness,
+		MaxWorkerThreads:      16,
+		ResyncPeriod:          controller.DefaultResyncPeriod.String(),
+		RetryBaseDelay:        "15s",
+		RetryMaxDelay:         "16m40s",
+		FailedProvisionLimit:  controller.DefaultFailedProvisionThreshold,
+		FailedDeleteLimit:     controller.DefaultFailedDeleteThreshold,
+		PVFinalizer:           true,
+		DirectoryMode:         "0777",
+		OwnershipStrategy:     ownershipEnforce,
+		MkfsCommand:           "mkfs.ext4 -q -F",
+		ExportsMountPath:      "/exports",
+		ExportHealthInterval:  "1m",
+		ExportServerType:      "exportfs",
+		ExportSSHUser:         "root",
+		MountWatchdogFailures: 3,
+		ShutdownTimeout:       "25s",
+		HookTimeout:           "30s",
+		UsageScanRate:         1000,
+		UsageReportConfigMap:  "nfs-usage-report",
+		UsageReportTop:        10,
+		FreeSpaceThreshold:    10,
+		StatusInterval:        "5m",
+		ProgressInterval:      "5m",
+		OrphanAction:          orphanActionArchive,
+	}
+}
+
+// configOption binds a config field to its flag and environment variable.
+// value points to the field: a *string, *bool or *int. Options marked
+// reloadable take effect when the config file changes at runtime; the others
+// need a restart. Options marked fileOnly, which run commands or reach other
+// hosts, are reloadable from the config file but refused in an
+// NfsProvisionerConfig, which is not as closely guarded.
+type configOption struct {
+	flag       string
+	env        string
+	usage      string
+	value      interface{}
+	reloadable bool
+	fileOnly   bool
+}
+
+func (o configOption) set(value string) error {
+	var err error
+	switch v := o.value.(type) {
+	case *string:
+		*v = value
+	case *bool:
+		*v, err = strconv.ParseBool(value)
+	case *int:
+		*v, err = strconv.Atoi(value)
+	case *float64:
+		*v, err = strconv.ParseFloat(value, 64)
+	}
+	return err
+}
+
+func (o configOption) String() string {
+	switch v := o.value.(type) {
+	case *string:
+		return *v
+	case *bool:
+		return strconv.FormatBool(*v)
+	case *int:
+		return strconv.Itoa(*v)
+	case *float64:
+		return strconv.FormatFloat(*v, 'g', -1, 64)
+	}
+	return ""
+}
+
+func (c *config) options() []configOption {
+	return []configOption{
+		{flag: "nfs-server", env: "NFS_SERVER", value: &c.NFSServer, usage: "Hostname or IP of the NFS server. IPv6 addresses may be given with or without brackets."},
+		{flag: "nfs-path", env: "NFS_PATH", value: &c.NFSPath, usage: "Exported path on the NFS server that is mounted at --mount-path."},
+		{flag: "nfs-failover-servers", env: "NFS_FAILOVER_SERVERS", value: &c.FailoverServers, reloadable: true, usage: "Comma separated servers that also serve --nfs-path, taking over from --nfs-server when it is decommissioned."},
+		{flag: "decommissioned-servers", env: "DECOMMISSIONED_SERVERS", value: &c.DecommissionedServers, reloadable: true, usage: "Comma separated servers that are no longer used: new PVs name the next server of their export, and existing PVs are repointed to it."},
+		{flag: "provisioner-name", env: "PROVISIONER_NAME", value: &c.ProvisionerName, usage: "Name of the provisioner, referenced by StorageClasses."},
+		{flag: "mount-path", env: "MOUNT_PATH", value: &c.MountPath, usage: "Where the export is mounted inside the container."},
+		{flag: "mount-export", env: "MOUNT_EXPORT", value: &c.MountExport, usage: "Mount --nfs-path from --nfs-server, or one of --nfs-failover-servers, at --mount-path at startup, rather than expect the deployment to mount it there. Needs CAP_SYS_ADMIN."},
+		{flag: "export-mount-options", env: "EXPORT_MOUNT_OPTIONS", value: &c.ExportMountOptions, usage: "Comma separated options --mount-export mounts the export with, e.g. nfsvers=4.1,hard."},
+		{flag: "kubeconfig", env: "KUBECONFIG", value: &c.Kubeconfig, usage: "Path to a kubeconfig, for running outside of the cluster."},
+		{flag: "clusters", env: "CLUSTERS", value: &c.Clusters, usage: "Comma separated further clusters sharing the export, as name=kubeconfig or name=kubeconfig#context. The volumes of each are provisioned in the directory of its name."},
+		{flag: "kube-api-qps", env: "KUBE_API_QPS", value: &c.KubeAPIQPS, usage: "Sustained requests per second to the API server. Raise it when many PVs are deleted at once."},
+		{flag: "kube-api-burst", env: "KUBE_API_BURST", value: &c.KubeAPIBurst, usage: "Requests to the API server allowed in a burst above --kube-api-qps."},
+		{flag: "verify-mount", env: "VERIFY_MOUNT", value: &c.VerifyMount, usage: "Refuse to start unless --mount-path is an NFS mount of --nfs-path from --nfs-server or one of --nfs-failover-servers."},
+		{flag: "probe-nfs-versions", env: "PROBE_NFS_VERSIONS", value: &c.ProbeNFSVersions, usage: "Probe the NFS versions the servers support, over TCP port 2049, and warn about claims whose mount options ask for another one."},
+		{flag: "active-active", env: "ACTIVE_ACTIVE", value: &c.ActiveActive, usage: "Let every replica provision and delete volumes, coordinating through lock files on the export, instead of electing a leader."},
+		{flag: "enable-leader-election", env: "ENABLE_LEADER_ELECTION", value: &c.LeaderElection, usage: "Elect a leader among replicas before provisioning."},
+		{flag: "policy-url", env: "POLICY_URL", value: &c.PolicyURL, reloadable: true, fileOnly: true, usage: "OPA-compatible endpoint consulted before a directory is deleted."},
+		{flag: "pre-provision-hook", env: "PRE_PROVISION_HOOK", value: &c.PreProvisionHook, reloadable: true, fileOnly: true, usage: "Executable, by absolute path, or http(s) webhook run before the directory of a volume is created. Its failure fails provisioning."},
+		{flag: "post-provision-hook", env: "POST_PROVISION_HOOK", value: &c.PostProvisionHook, reloadable: true, fileOnly: true, usage: "Executable or webhook run once the directory of a volume is ready. Its failure fails provisioning."},
+		{flag: "pre-delete-hook", env: "PRE_DELETE_HOOK", value: &c.PreDeleteHook, reloadable: true, fileOnly: true, usage: "Executable or webhook run before a volume is reclaimed. Its failure fails the deletion, which is retried."},
+		{flag: "post-delete-hook", env: "POST_DELETE_HOOK", value: &c.PostDeleteHook, reloadable: true, fileOnly: true, usage: "Executable or webhook run after a volume was reclaimed, or failed to be. Its failure is only reported."},
+		{flag: "hook-timeout", env: "HOOK_TIMEOUT", value: &c.HookTimeout, reloadable: true, usage: "How long a hook may run before it is killed and taken to have failed."},
+		{flag: "audit-log", env: "AUDIT_LOG", value: &c.AuditLog, usage: "File, or \"stdout\", receiving a JSON audit record per volume operation."},
+		{flag: "export-audit-log", env: "EXPORT_AUDIT_LOG", value: &c.ExportAuditLog, usage: "Name of a file at the root of each export receiving a JSON record per directory deleted, archived or moved aside, e.g. .nfs-provisioner-audit.log."},
+		{flag: "notifiers", env: "NOTIFIERS", value: &c.Notifiers, reloadable: true, fileOnly: true, usage: "Comma separated URLs notified of failed provisionings, exports short of space, archived directories and quarantined volumes: http(s) webhooks, slack+https Slack incoming webhooks, nats://host:port/subject NATS subjects, and kafka+http(s) topic URLs of a Kafka REST proxy."},
+		{flag: "notify-events", env: "NOTIFY_EVENTS", value: &c.NotifyEvents, reloadable: true, usage: "Comma separated events sent to --notifiers, among provision-failed, low-space, archived and quarantined. All of them when empty."},
+		{flag: "admin-address", env: "ADMIN_ADDRESS", value: &c.AdminAddress, usage: "Listen address of the admin endpoints. Disabled when empty."},
+		{flag: "admin-tls-cert", env: "ADMIN_TLS_CERT", value: &c.AdminTLSCert, usage: "Certificate file of the admin endpoints. They are served over plain HTTP when empty."},
+		{flag: "admin-tls-key", env: "ADMIN_TLS_KEY", value: &c.AdminTLSKey, usage: "Private key file of --admin-tls-cert."},
+		{flag: "admin-tls-client-ca", env: "ADMIN_TLS_CLIENT_CA", value: &c.AdminTLSClientCA, usage: "CA certificates file that clients of the admin endpoints must present a certificate of."},
+		{flag: "admin-allow-changes", env: "ADMIN_ALLOW_CHANGES", value: &c.AdminAllowChanges, usage: "Let clients of the admin endpoints change the worker limits without a client certificate. Without it, only clients authenticated by --admin-tls-client-ca can."},
+		{flag: "enable-pprof", env: "ENABLE_PPROF", value: &c.EnablePprof, usage: "Serve net/http/pprof profiles on --pprof-address."},
+		{flag: "pprof-address", env: "PPROF_ADDRESS", value: &c.PprofAddress, usage: "Listen address of the pprof server. Keep it bound to localhost and use kubectl port-forward to reach it."},
+		{flag: "pprof-allow-remote", env: "PPROF_ALLOW_REMOTE", value: &c.PprofAllowRemote, usage: "Allow a --pprof-address other than a loopback one, which serves the unauthenticated profiles to whoever reaches it."},
+		{flag: "webhook-address", env: "WEBHOOK_ADDRESS", value: &c.WebhookAddress, usage: "Listen address of the validating admission webhook of StorageClasses. Disabled when empty."},
+		{flag: "webhook-tls-cert", env: "WEBHOOK_TLS_CERT", value: &c.WebhookTLSCert, usage: "Certificate file of the webhook."},
+		{flag: "webhook-tls-key", env: "WEBHOOK_TLS_KEY", value: &c.WebhookTLSKey, usage: "Private key file of --webhook-tls-cert."},
+		{flag: "worker-threads", env: "WORKER_THREADS", value: &c.WorkerThreads, reloadable: true, usage: "Number of Provision calls, and of Delete calls not archiving the directory, allowed to run concurrently. Adjustable at runtime through the admin endpoint."},
+		{flag: "archive-worker-threads", env: "ARCHIVE_WORKER_THREADS", value: &c.ArchiveWorkerThreads, reloadable: true, usage: "Number of archivings allowed to run concurrently, apart from the deletions limited by --worker-threads. 0 uses --worker-threads. Adjustable at runtime through the admin endpoint."},
+		{flag: "max-worker-threads", env: "MAX_WORKER_THREADS", value: &c.MaxWorkerThreads, usage: "Number of controller workers, the upper bound for --worker-threads at runtime."},
+		{flag: "resync-period", env: "RESYNC_PERIOD", value: &c.ResyncPeriod, usage: "How often every claim and PV is processed again, even without changes."},
+		{flag: "retry-base-delay", env: "RETRY_BASE_DELAY", value: &c.RetryBaseDelay, usage: "Delay before retrying a failed Provision or Delete call, doubled on every further failure."},
+		{flag: "retry-max-delay", env: "RETRY_MAX_DELAY", value: &c.RetryMaxDelay, usage: "Upper bound of the delay between retries of a failed Provision or Delete call."},
+		{flag: "failed-provision-threshold", env: "FAILED_PROVISION_THRESHOLD", value: &c.FailedProvisionLimit, usage: "Failed Provision calls after which a claim is given up on until it changes. 0 retries forever."},
+		{flag: "failed-delete-threshold", env: "FAILED_DELETE_THRESHOLD", value: &c.FailedDeleteLimit, usage: "Failed Delete calls after which a PV is given up on until it changes. 0 retries forever."},
+		{flag: "delete-quarantine-threshold", env: "DELETE_QUARANTINE_THRESHOLD", value: &c.QuarantineThreshold, reloadable: true, usage: "Deletions failing on the file system in a row after which a PV is quarantined, and not retried until its nfs.io/quarantined annotation is removed. 0 never quarantines."},
+		{flag: "progress-interval", env: "PROGRESS_INTERVAL", value: &c.ProgressInterval, reloadable: true, usage: "How often the progress of a deletion or archiving running longer is logged and reported as an event on its PV. 0 disables it."},
+		{flag: "delete-ops-per-second", env: "DELETE_OPS_PER_SECOND", value: &c.DeleteOpsRate, reloadable: true, usage: "File operations per second at most of all deletions, archivings and reaping together, to spare the NFS server. 0 for no limit."},
+		{flag: "delete-bandwidth", env: "DELETE_BANDWIDTH", value: &c.DeleteBandwidth, reloadable: true, usage: "Bytes per second at most copied or uploaded by all archivings together, as a quantity such as 50Mi. Empty for no limit."},
+		{flag: "pv-finalizer", env: "PV_FINALIZER", value: &c.PVFinalizer, usage: "Put a finalizer on provisioned PVs, removed once their directory is deleted, archived or retained, so that deleting a PV object cannot skip its onDelete. false removes it from existing PVs."},
+		{flag: "log-level", env: "LOG_LEVEL", value: &c.LogLevel, reloadable: true, usage: "Log verbosity, as -v. Unlike -v it can be changed in the config file at runtime."},
+		{flag: "default-on-delete", env: "DEFAULT_ON_DELETE", value: &c.DefaultOnDelete, reloadable: true, usage: "What to do with the directory of a deleted volume whose StorageClass sets neither onDelete nor archiveOnDelete: delete, retain or archive."},
+		{flag: "directory-mode", env: "DIRECTORY_MODE", value: &c.DirectoryMode, reloadable: true, usage: "Permissions of provisioned directories, in octal."},
+		{flag: "ownership-strategy", env: "OWNERSHIP_STRATEGY", value: &c.OwnershipStrategy, reloadable: true, usage: "What to do when the permissions, group, ACLs or SELinux label of a new directory cannot be set: enforce fails provisioning, best-effort provisions the volume with a warning event if not permitted to set them, skip never sets them."},
+		{flag: "default-mount-options", env: "DEFAULT_MOUNT_OPTIONS", value: &c.DefaultMountOptions, reloadable: true, usage: "Comma separated mount options of the PVs whose StorageClass has no mountOptions, e.g. nfsvers=4.2,hard,noatime."},
+		{flag: "fs-uid", env: "FS_UID", value: &c.FSUID, usage: "User ID the provisioner accesses files as, e.g. the anonuid of an export with root_squash. 0 keeps root."},
+		{flag: "fs-gid", env: "FS_GID", value: &c.FSGID, usage: "Group ID the provisioner accesses files as with --fs-uid. 0 uses --fs-uid."},
+		{flag: "mkfs-command", env: "MKFS_COMMAND", value: &c.MkfsCommand, usage: "Command formatting the image files of sizeEnforced StorageClasses, to which the path of the image is appended."},
+		{flag: "metadata-file", env: "METADATA_FILE", value: &c.MetadataFile, reloadable: true, usage: "Name of a JSON file recording the claim and PV of every new volume, written in its directory, e.g. .nfs-provisioner.json. Disabled when empty."},
+		{flag: "config-object", env: "CONFIG_OBJECT", value: &c.ConfigObject, usage: "Name of a cluster-scoped NfsProvisionerConfig object whose spec overrides the config file."},
+		{flag: "mount-watchdog-interval", env: "MOUNT_WATCHDOG_INTERVAL", value: &c.MountWatchdogInterval, usage: "How often a probe file is written to the export to check its mount, e.g. 30s. Disabled when empty."},
+		{flag: "mount-watchdog-failures", env: "MOUNT_WATCHDOG_FAILURES", value: &c.MountWatchdogFailures, usage: "Consecutive failed probes after which provisioning is paused until the mount works again."},
+		{flag: "health-address", env: "HEALTH_ADDRESS", value: &c.HealthAddress, usage: "Listen address of the /healthz and /readyz probes of the pod. Disabled when empty."},
+		{flag: "shutdown-timeout", env: "SHUTDOWN_TIMEOUT", value: &c.ShutdownTimeout, usage: "How long in-flight operations are waited for on SIGTERM, which should be less than the termination grace period of the pod."},
+		{flag: "state-bundle-interval", env: "STATE_BUNDLE_INTERVAL", value: &c.StateBundleInterval, usage: "How often the manifests of the provisioned volumes are rolled up into a state bundle on the export, e.g. 10m. Disabled when empty."},
+		{flag: "usage-scan-interval", env: "USAGE_SCAN_INTERVAL", value: &c.UsageScanInterval, usage: "How often the directories of all the volumes are measured for the volume usage metrics, e.g. 1h. Disabled when empty."},
+		{flag: "usage-scan-files-per-second", env: "USAGE_SCAN_FILES_PER_SECOND", value: &c.UsageScanRate, usage: "Files read per second at most while measuring volumes for the usage metrics, to spare the NFS server."},
+		{flag: "usage-annotation-interval", env: "USAGE_ANNOTATION_INTERVAL", value: &c.UsageAnnotateInterval, usage: "How often the PVs are annotated with the last measures of the usage scan, e.g. 1h. Needs --usage-scan-interval. Disabled when empty."},
+		{flag: "usage-report-interval", env: "USAGE_REPORT_INTERVAL", value: &c.UsageReportInterval, usage: "How often a usage report is published in --usage-report-configmap, e.g. 1h. Needs --usage-scan-interval. Disabled when empty."},
+		{flag: "usage-report-configmap", env: "USAGE_REPORT_CONFIGMAP", value: &c.UsageReportConfigMap, usage: "ConfigMap the usage report is published in, as name in the namespace of the provisioner, or namespace/name."},
+		{flag: "usage-report-top", env: "USAGE_REPORT_TOP", value: &c.UsageReportTop, usage: "Number of namespaces and of claims listed as top consumers in the usage report."},
+		{flag: "free-space-interval", env: "FREE_SPACE_INTERVAL", value: &c.FreeSpaceInterval, usage: "How often the free space of the exports is measured, for the export metrics and low space events, e.g. 1m. Disabled when empty."},
+		{flag: "free-space-threshold", env: "FREE_SPACE_THRESHOLD", value: &c.FreeSpaceThreshold, usage: "Free space, in percent of the size of an export, below which a warning event is recorded on its StorageClasses."},
+		{flag: "mark-full-classes", env: "MARK_FULL_CLASSES", value: &c.MarkFullClasses, usage: "Annotate with nfs.io/full the StorageClasses whose exports are all below --free-space-threshold, holding their new claims until space is freed."},
+		{flag: "directory-check-interval", env: "DIRECTORY_CHECK_INTERVAL", value: &c.DirCheckInterval, usage: "How often the directories of bound volumes are checked to still be on the export, e.g. 10m. Disabled when empty."},
+		{flag: "status-object", env: "STATUS_OBJECT", value: &c.StatusObject, usage: "Name of a cluster-scoped NfsProvisionerStatus object the results of the consistency checks are published in. Disabled when empty."},
+		{flag: "status-interval", env: "STATUS_INTERVAL", value: &c.StatusInterval, usage: "How often the consistency checks of --status-object run."},
+		{flag: "orphan-grace-period", env: "ORPHAN_GRACE_PERIOD", value: &c.OrphanGracePeriod, usage: "Reap the directories of the exports that have belonged to no PV for this long, e.g. 30d. At least 1h. Disabled when empty."},
+		{flag: "orphan-exclude", env: "ORPHAN_EXCLUDE", value: &c.OrphanExclude, usage: "Comma separated glob patterns, relative to the export, of directories never reaped, e.g. manual/*,scratch."},
+		{flag: "orphan-action", env: "ORPHAN_ACTION", value: &c.OrphanAction, usage: "What to do with orphaned directories past --orphan-grace-period: archive or delete."},
+		{flag: "allowed-namespaces", env: "ALLOWED_NAMESPACES", value: &c.AllowedNamespaces, reloadable: true, usage: "Comma separated namespaces, or regular expressions between slashes such as /team-.*/, allowed to provision volumes. All namespaces when neither this nor --allowed-namespace-selector is set."},
+		{flag: "allowed-namespace-selector", env: "ALLOWED_NAMESPACE_SELECTOR", value: &c.NamespaceSelector, reloadable: true, usage: "Label selector of the namespaces allowed to provision volumes, in addition to --allowed-namespaces."},
+		{flag: "denied-namespaces", env: "DENIED_NAMESPACES", value: &c.DeniedNamespaces, reloadable: true, usage: "Comma separated namespaces, or regular expressions between slashes, refused even if they are allowed."},
+		{flag: "shard-name", env: "SHARD_NAME", value: &c.ShardName, usage: "Name of the slice of the claims this instance handles, when several instances share the provisioner name. Required with the other shard options."},
+		{flag: "shard-namespace-selector", env: "SHARD_NAMESPACE_SELECTOR", value: &c.ShardSelector, usage: "Label selector of the namespaces whose claims this shard handles."},
+		{flag: "shard-count", env: "SHARD_COUNT", value: &c.ShardCount, usage: "Number of shards the claims are spread over by a hash of their UID."},
+		{flag: "shard-index", env: "SHARD_INDEX", value: &c.ShardIndex, usage: "Index, from 0, of the shard out of --shard-count handled by this instance."},
+		{flag: "quota-alert-thresholds", env: "QUOTA_ALERT_THRESHOLDS", value: &c.QuotaAlertThresholds, usage: "Comma separated percentages, e.g. 80,90,100, of the storage ResourceQuotas of a namespace at which it is annotated with nfs.io/quota-alert and an event is recorded. Disabled when empty."},
+		{flag: "deterministic", env: "DETERMINISTIC", value: &c.Deterministic, usage: "Use a fixed clock and random seed, so that the same operations produce the same names and timestamps. Meant for tests and disaster recovery drills."},
+		{flag: "adopt-provisioner-names", env: "ADOPT_PROVISIONER_NAMES", value: &c.AdoptProvisioners, usage: "Comma separated names of other provisioners, such as the upstream nfs-subdir-external-provisioner, whose StorageClasses and PVs are taken over."},
+		{flag: "enable-exports", env: "ENABLE_EXPORTS", value: &c.EnableExports, usage: "Provision on the NFSExports referenced by the export StorageClass parameter, mounting them on demand. Needs CAP_SYS_ADMIN."},
+		{flag: "enable-quotas", env: "ENABLE_QUOTAS", value: &c.EnableQuotas, usage: "Enforce the NFSQuotas of namespaces on the volumes of this provisioner."},
+		{flag: "enable-volume-imports", env: "ENABLE_VOLUME_IMPORTS", value: &c.EnableVolumeImports, usage: "Fill the volumes of claims whose dataSourceRef is an NfsVolumeImport with the archive it points to."},
+		{flag: "import-allowed-hosts", env: "IMPORT_ALLOWED_HOSTS", value: &c.ImportAllowedHosts, reloadable: true, fileOnly: true, usage: "Comma separated host names, *.domain wildcards and CIDRs NfsVolumeImports may download from. Empty allows public addresses only."},
+		{flag: "enable-snapshot-schedules", env: "ENABLE_SNAPSHOT_SCHEDULES", value: &c.EnableSnapshots, usage: "Copy the volumes of the claims selected by NfsSnapshotSchedules into the .snapshots directory of their export, on schedule."},
+		{flag: "enable-attributes-classes", env: "ENABLE_ATTRIBUTES_CLASSES", value: &c.EnableAttributes, usage: "Apply the parameters of the VolumeAttributesClasses of claims, whose driverName is the name of the provisioner, when volumes are provisioned and when claims switch to another one."},
+		{flag: "enable-rquota", env: "ENABLE_RQUOTA", value: &c.EnableRquota, usage: "Query the rquotad of NFS servers for the usage of volumes with a GID or XFS project of their own, and for the space left in the quota of the provisioner, instead of walking directories."},
+		{flag: "exports-mount-path", env: "EXPORTS_MOUNT_PATH", value: &c.ExportsMountPath, usage: "Directory under which NFSExports are mounted."},
+		{flag: "exports", env: "EXPORTS", value: &c.Exports, usage: "Comma separated exports referenced by the export StorageClass parameter like NFSExports, as name=server:/path, optionally followed by mount options such as ?nfsvers=4.1&hard. Needs CAP_SYS_ADMIN."},
+		{flag: "export-health-interval", env: "EXPORT_HEALTH_INTERVAL", value: &c.ExportHealthInterval, usage: "How often the mounts of NFSExports and --exports are checked, and remounted when stale. 0 disables it."},
+		{flag: "kerberos-keytab", env: "KERBEROS_KEYTAB", value: &c.KerberosKeytab, usage: "Keytab file the keytabs of the kerberosSecretName Secrets of StorageClasses are written to, for an rpc.gssd sharing it. Disabled when empty."},
+		{flag: "kerberos-config", env: "KERBEROS_CONFIG", value: &c.KerberosConfig, usage: "File the krb5.conf of the kerberosSecretName Secrets is written to. Not written when empty."},
+		{flag: "export-server-type", env: "EXPORT_SERVER_TYPE", value: &c.ExportServerType, usage: "NFS server that dedicated exports are created on: exportfs for the kernel NFS server, or ganesha for NFS-Ganesha."},
+		{flag: "export-ssh-address", env: "EXPORT_SSH_ADDRESS", value: &c.ExportSSHAddress, usage: "SSH address of the NFS server, for StorageClasses with exportPerVolume. Disabled when empty."},
+		{flag: "export-ssh-user", env: "EXPORT_SSH_USER", value: &c.ExportSSHUser, usage: "SSH user allowed to manage the exports of the NFS server."},
+		{flag: "export-ssh-key", env: "EXPORT_SSH_KEY", value: &c.ExportSSHKey, usage: "Private key file of --export-ssh-user."},
+		{flag: "export-ssh-known-hosts", env: "EXPORT_SSH_KNOWN_HOSTS", value: &c.ExportSSHKnownHosts, usage: "known_hosts file holding the host key of the NFS server."},
+	}
+}
+
+// loadConfig builds the configuration from the environment, the config file
+// named by --config, and the flags in args.
+func loadConfig(fs *flag.FlagSet, args []string) (*config, error) {
+	cfg := defaultConfig()
+	options := cfg.options()
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "Path to a YAML config file. Flags take precedence over it, and it takes precedence over environment variables.")
+	for _, o := range options {
+		usage := fmt.Sprintf("%s (env %s)", o.usage, o.env)
+		switch v := o.value.(type) {
+		case *string:
+			fs.StringVar(v, o.flag, *v, usage)
+		case *bool:
+			fs.BoolVar(v, o.flag, *v, usage)
+		case *int:
+			fs.IntVar(v, o.flag, *v, usage)
+		case *float64:
+			fs.Float64Var(v, o.flag, *v, usage)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// Remember the flags given on the command line, load the file over
+	// everything, then put the flags back on top.
+	cfg.file = *configFile
+	cfg.overrides = map[string]string{}
+	fs.Visit(func(f *flag.Flag) { cfg.overrides[f.Name] = f.Value.String() })
+	if err := cfg.loadFile(); err != nil {
+		return nil, err
+	}
+	if err := cfg.applyOverrides(); err != nil {
+		return nil, err
+	}
+
+	return cfg, cfg.validate()
+}
+
+// reload builds a new configuration from the environment, the current
+// content of the config file, the NfsProvisionerConfig spec and the original
+// command line flags.
+func (c *config) reload() (*config, error) {
+	cfg := defaultConfig()
+	cfg.file = c.file
+	cfg.spec = c.spec
+	cfg.overrides = c.overrides
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.loadFile(); err != nil {
+		return nil, err
+	}
+	if err := cfg.loadSpec(); err != nil {
+		return nil, err
+	}
+	if err := cfg.applyOverrides(); err != nil {
+		return nil, err
+	}
+	return cfg, cfg.validate()
+}
+
+func (c *config) loadEnv() error {
+	for _, o := range c.options() {
+		value, ok := os.LookupEnv(o.env)
+		if !ok || value == "" {
+			continue
+		}
+		if err := o.set(value); err != nil {
+			return fmt.Errorf("invalid value %q for %s: %v", value, o.env, err)
+		}
+	}
+	return nil
+}
+
+// loadFile applies the config file, if any.
+func (c *config) loadFile() error {
+	if c.file == "" {
+		return nil
+	}
+	data, err := os.ReadFile(c.file)
+	if err != nil {
+		return err
+	}
+	if err := yaml.UnmarshalStrict(data, c); err != nil {
+		return fmt.Errorf("invalid config file %s: %v", c.file, err)
+	}
+	return nil
+}
+
+// loadSpec applies the NfsProvisionerConfig spec, if any. The spec may only
+// hold reloadable options that are not fileOnly.
+func (c *config) loadSpec() error {
+	if len(c.spec) == 0 {
+		return nil
+	}
+	options := c.options()
+	before := make([]string, len(options))
+	for i, o := range options {
+		before[i] = o.String()
+	}
+	if err := yaml.UnmarshalStrict(c.spec, c); err != nil {
+		return fmt.Errorf("invalid spec: %v", err)
+	}
+	for i, o := range options {
+		if (!o.reloadable || o.fileOnly) && o.String() != before[i] {
+			return fmt.Errorf("%s cannot be set in an NfsProvisionerConfig", o.flag)
+		}
+	}
+	return nil
+}
+
+// applyOverrides puts the flags given on the command line back on top of
+// the other sources.
+func (c *config) applyOverrides() error {
+	for _, o := range c.options() {
+		if value, ok := c.overrides[o.flag]; ok {
+			if err := o.set(value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *config) validate() error {
+	switch {
+	case c.NFSServer == "":
+		return fmt.Errorf("--nfs-server (or NFS_SERVER) is not set")
+	case c.NFSPath == "":
+		return fmt.Errorf("--nfs-path (or NFS_PATH) is not set")
+	case c.ProvisionerName == "":
+		return fmt.Errorf("--provisioner-name (or PROVISIONER_NAME) is not set")
+	case c.MountPath == "":
+		return fmt.Errorf("--mount-path must not be empty")
+	case c.MountExport && !filepath.IsAbs(c.MountPath):
+		return fmt.Errorf("--mount-path must be an absolute path with --mount-export")
+	case (c.EnableExports || c.Exports != "") && !filepath.IsAbs(c.ExportsMountPath):
+		return fmt.Errorf("--exports-mount-path must be an absolute path")
+	case c.ExportSSHAddress != "" && (c.ExportSSHKey == "" || c.ExportSSHKnownHosts == ""):
+		return fmt.Errorf("--export-ssh-address needs --export-ssh-key and --export-ssh-known-hosts")
+	case (c.AdminTLSCert != "" || c.AdminTLSKey != "" || c.AdminTLSClientCA != "") && (c.AdminTLSCert == "" || c.AdminTLSKey == "" || c.AdminTLSClientCA == ""):
+		return fmt.Errorf("--admin-tls-cert, --admin-tls-key and --admin-tls-client-ca must be set together")
+	case c.EnablePprof && !c.PprofAllowRemote && !loopbackAddress(c.PprofAddress):
+		return fmt.Errorf("--pprof-address must be a localhost or loopback address, unless --pprof-allow-remote is set")
+	case c.WebhookAddress != "" && (c.WebhookTLSCert == "" || c.WebhookTLSKey == ""):
+		return fmt.Errorf("--webhook-address needs --webhook-tls-cert and --webhook-tls-key")
+	case c.ExportServerType != "exportfs" && c.ExportServerType != "ganesha":
+		return fmt.Errorf("--export-server-type must be exportfs or ganesha")
+	case c.WorkerThreads < 1 || c.WorkerThreads > c.MaxWorkerThreads:
+		return fmt.Errorf("--worker-threads must be between 1 and --max-worker-threads (%d)", c.MaxWorkerThreads)
+	case c.ArchiveWorkerThreads < 0 || c.ArchiveWorkerThreads > c.MaxWorkerThreads:
+		return fmt.Errorf("--archive-worker-threads must be between 0 and --max-worker-threads (%d)", c.MaxWorkerThreads)
+	case c.KubeAPIQPS <= 0 || c.KubeAPIBurst < 1:
+		return fmt.Errorf("--kube-api-qps and --kube-api-burst must be positive")
+	case c.FailedProvisionLimit < 0 || c.FailedDeleteLimit < 0:
+		return fmt.Errorf("--failed-provision-threshold and --failed-delete-threshold must not be negative")
+	case c.QuarantineThreshold < 0:
+		return fmt.Errorf("--delete-quarantine-threshold must not be negative")
+	case c.ExportAuditLog != "" && (strings.ContainsRune(c.ExportAuditLog, '/') || c.ExportAuditLog == "." || c.ExportAuditLog == ".."):
+		return fmt.Errorf("--export-audit-log must be a file name, not a path")
+	}
+	for _, d := range []struct{ flag, value string }{
+		{"--resync-period", c.ResyncPeriod},
+		{"--retry-base-delay", c.RetryBaseDelay},
+		{"--retry-max-delay", c.RetryMaxDelay},
+	} {
+		if duration, err := time.ParseDuration(d.value); err != nil || duration <= 0 {
+			return fmt.Errorf("%s must be a positive duration such as 15s", d.flag)
+		}
+	}
+	if base, maxDelay := c.retryDelays(); base > maxDelay {
+		return fmt.Errorf("--retry-base-delay must not exceed --retry-max-delay")
+	}
+	switch c.DefaultOnDelete {
+	case "", "delete", "retain", "archive":
+	default:
+		return fmt.Errorf("--default-on-delete must be one of delete, retain or archive")
+	}
+	if strings.ContainsAny(c.DefaultMountOptions, " \t\"'") {
+		return fmt.Errorf("--default-mount-options must be comma separated mount options, without spaces or quotes")
+	}
+	if strings.ContainsAny(c.ExportMountOptions, " \t\"'") {
+		return fmt.Errorf("--export-mount-options must be comma separated mount options, without spaces or quotes")
+	}
+	if c.MountExport {
+		if err := checkXprtsec(c.exportMountOptions()); err != nil {
+			return fmt.Errorf("--export-mount-options: %v", err)
+		}
+	}
+	if c.MountWatchdogInterval != "" {
+		if interval, err := time.ParseDuration(c.MountWatchdogInterval); err != nil || interval <= 0 {
+			return fmt.Errorf("--mount-watchdog-interval must be a positive duration such as 30s")
+		}
+	}
+	if c.MountWatchdogFailures < 1 {
+		return fmt.Errorf("--mount-watchdog-failures must be at least 1")
+	}
+	if timeout, err := time.ParseDuration(c.ShutdownTimeout); err != nil || timeout < 0 {
+		return fmt.Errorf("--shutdown-timeout must be a duration such as 25s")
+	}
+	if timeout, err := time.ParseDuration(c.HookTimeout); err != nil || timeout <= 0 {
+		return fmt.Errorf("--hook-timeout must be a positive duration such as 30s")
+	}
+	for _, name := range []string{hookPreProvision, hookPostProvision, hookPreDelete, hookPostDelete} {
+		if err := checkHook(c.hook(name)); err != nil {
+			return fmt.Errorf("--%s-hook: %v", name, err)
+		}
+	}
+	if _, err := parseNotifiers(c.Notifiers); err != nil {
+		return fmt.Errorf("--notifiers: %v", err)
+	}
+	if err := checkNotifyEvents(c.NotifyEvents); err != nil {
+		return fmt.Errorf("--notify-events: %v", err)
+	}
+	if c.StateBundleInterval != "" {
+		if interval, err := time.ParseDuration(c.StateBundleInterval); err != nil || interval <= 0 {
+			return fmt.Errorf("--state-bundle-interval must be a positive duration such as 10m")
+		}
+	}
+	if c.UsageScanInterval != "" {
+		if interval, err := time.ParseDuration(c.UsageScanInterval); err != nil || interval <= 0 {
+			return fmt.Errorf("--usage-scan-interval must be a positive duration such as 1h")
+		}
+	}
+	if c.UsageScanRate < 1 {
+		return fmt.Errorf("--usage-scan-files-per-second must be at least 1")
+	}
+	if c.UsageAnnotateInterval != "" {
+		if interval, err := time.ParseDuration(c.UsageAnnotateInterval); err != nil || interval <= 0 {
+			return fmt.Errorf("--usage-annotation-interval must be a positive duration such as 1h")
+		}
+		if c.UsageScanInterval == "" {
+			return fmt.Errorf("--usage-annotation-interval needs --usage-scan-interval")
+		}
+	}
+	if c.UsageReportInterval != "" {
+		if interval, err := time.ParseDuration(c.UsageReportInterval); err != nil || interval <= 0 {
+			return fmt.Errorf("--usage-report-interval must be a positive duration such as 1h")
+		}
+		if c.UsageScanInterval == "" {
+			return fmt.Errorf("--usage-report-interval needs --usage-scan-interval")
+		}
+		if namespace, name := c.usageReportConfigMap(); len(validation.IsDNS1123Label(namespace)) > 0 || len(validation.IsDNS1123Subdomain(name)) > 0 {
+			return fmt.Errorf("--usage-report-configmap must be a ConfigMap name, or namespace/name")
+		}
+	}
+	if c.UsageReportTop < 1 {
+		return fmt.Errorf("--usage-report-top must be at least 1")
+	}
+	if c.FreeSpaceInterval != "" {
+		if interval, err := time.ParseDuration(c.FreeSpaceInterval); err != nil || interval <= 0 {
+			return fmt.Errorf("--free-space-interval must be a positive duration such as 1m")
+		}
+	}
+	if c.DirCheckInterval != "" {
+		if interval, err := time.ParseDuration(c.DirCheckInterval); err != nil || interval <= 0 {
+			return fmt.Errorf("--directory-check-interval must be a positive duration such as 10m")
+		}
+	}
+	if c.StatusObject != "" && len(validation.IsDNS1123Subdomain(c.StatusObject)) > 0 {
+		return fmt.Errorf("--status-object must be a valid object name")
+	}
+	if interval, err := time.ParseDuration(c.StatusInterval); err != nil || interval <= 0 {
+		return fmt.Errorf("--status-interval must be a positive duration such as 5m")
+	}
+	if _, err := parseImportAllowedHosts(c.ImportAllowedHosts); err != nil {
+		return fmt.Errorf("--import-allowed-hosts: %v", err)
+	}
+	if _, err := parseStaticExports(c.Exports); err != nil {
+		return fmt.Errorf("--exports: %v", err)
+	}
+	if interval, err := time.ParseDuration(c.ExportHealthInterval); err != nil || interval < 0 {
+		return fmt.Errorf("--export-health-interval must be a duration such as 1m, or 0")
+	}
+	if interval, err := time.ParseDuration(c.ProgressInterval); err != nil || interval < 0 {
+		return fmt.Errorf("--progress-interval must be a duration such as 5m, or 0")
+	}
+	if c.DeleteOpsRate < 0 {
+		return fmt.Errorf("--delete-ops-per-second must not be negative")
+	}
+	if c.DeleteBandwidth != "" {
+		if q, err := resource.ParseQuantity(c.DeleteBandwidth); err != nil || q.Sign() <= 0 {
+			return fmt.Errorf("--delete-bandwidth must be a positive quantity such as 50Mi")
+		}
+	}
+	if c.OrphanGracePeriod != "" {
+		if grace, err := parseAge(c.OrphanGracePeriod); err != nil || grace < time.Hour {
+			return fmt.Errorf("--orphan-grace-period must be a duration of at least 1h, such as 30d")
+		}
+	}
+	for _, pattern := range splitList(c.OrphanExclude) {
+		if _, err := path.Match(pattern, ""); err != nil || path.IsAbs(pattern) {
+			return fmt.Errorf("--orphan-exclude: invalid pattern %q", pattern)
+		}
+	}
+	if c.OrphanAction != orphanActionArchive && c.OrphanAction != orphanActionDelete {
+		return fmt.Errorf("--orphan-action must be archive or delete")
+	}
+	if c.FreeSpaceThreshold < 0 || c.FreeSpaceThreshold > 100 {
+		return fmt.Errorf("--free-space-threshold must be a percentage between 0 and 100")
+	}
+	if _, err := canonicalServer(c.NFSServer); err != nil {
+		return fmt.Errorf("--nfs-server: %v", err)
+	}
+	if _, err := serverList(c.FailoverServers); err != nil {
+		return fmt.Errorf("--nfs-failover-servers: %v", err)
+	}
+	if _, err := serverList(c.DecommissionedServers); err != nil {
+		return fmt.Errorf("--decommissioned-servers: %v", err)
+	}
+	if _, err := parseNamespacePatterns(c.AllowedNamespaces); err != nil {
+		return fmt.Errorf("--allowed-namespaces: %v", err)
+	}
+	if _, err := parseNamespacePatterns(c.DeniedNamespaces); err != nil {
+		return fmt.Errorf("--denied-namespaces: %v", err)
+	}
+	if _, err := labels.Parse(c.NamespaceSelector); err != nil {
+		return fmt.Errorf("--allowed-namespace-selector: %v", err)
+	}
+	if _, err := labels.Parse(c.ShardSelector); err != nil {
+		return fmt.Errorf("--shard-namespace-selector: %v", err)
+	}
+	switch sharded := c.ShardSelector != "" || c.ShardCount > 0; {
+	case sharded && c.ShardName == "":
+		return fmt.Errorf("--shard-namespace-selector and --shard-count need --shard-name")
+	case !sharded && c.ShardName != "":
+		return fmt.Errorf("--shard-name needs --shard-namespace-selector or --shard-count")
+	case c.ShardName != "" && len(validation.IsDNS1123Label(c.ShardName)) > 0:
+		return fmt.Errorf("--shard-name must be a DNS label such as team-a")
+	case c.ShardCount < 0 || c.ShardIndex < 0 || c.ShardCount > 0 && c.ShardIndex >= c.ShardCount:
+		return fmt.Errorf("--shard-index must be between 0 and --shard-count minus 1")
+	}
+	if _, err := parseClusters(c.Clusters); err != nil {
+		return fmt.Errorf("--clusters: %v", err)
+	}
+	if _, err := parseThresholds(c.QuotaAlertThresholds); err != nil {
+		return fmt.Errorf("--quota-alert-thresholds must be comma separated percentages such as 80,90,100: %v", err)
+	}
+	if _, err := strconv.ParseUint(c.DirectoryMode, 8, 32); err != nil || len(c.DirectoryMode) > 4 {
+		return fmt.Errorf("--directory-mode must be an octal mode such as 0777")
+	}
+	switch {
+	case c.FSUID < 0 || c.FSGID < 0:
+		return fmt.Errorf("--fs-uid and --fs-gid must not be negative")
+	case c.FSGID != 0 && c.FSUID == 0:
+		return fmt.Errorf("--fs-gid needs --fs-uid")
+	}
+	switch c.OwnershipStrategy {
+	case ownershipEnforce, ownershipBestEffort, ownershipSkip:
+	default:
+		return fmt.Errorf("--ownership-strategy must be one of enforce, best-effort or skip")
+	}
+	if c.MetadataFile != "" && (c.MetadataFile == "." || c.MetadataFile == ".." || strings.ContainsRune(c.MetadataFile, '/')) {
+		return fmt.Errorf("--metadata-file must be a file name, such as .nfs-provisioner.json")
+	}
+	return nil
+}
+
+// adoptedProvisioners returns the names of the provisioners whose volumes
+// are taken over.
+func (c *config) adoptedProvisioners() []string {
+	var names []string
+	for _, name := range strings.Split(c.AdoptProvisioners, ",") {
+		if name = strings.TrimSpace(name); name != "" && name != c.ProvisionerName {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// stateBundleInterval returns StateBundleInterval as a duration, 0 when the
+// state bundle is disabled.
+func (c *config) stateBundleInterval() time.Duration {
+	interval, _ := time.ParseDuration(c.StateBundleInterval)
+	return interval
+}
+
+// mountWatchdogInterval returns MountWatchdogInterval as a duration, 0 when
+// the watchdog is disabled.
+func (c *config) mountWatchdogInterval() time.Duration {
+	interval, _ := time.ParseDuration(c.MountWatchdogInterval)
+	return interval
+}
+
+// usageScanInterval returns UsageScanInterval as a duration, 0 when the
+// usage scanner is disabled.
+func (c *config) usageScanInterval() time.Duration {
+	interval, _ := time.ParseDuration(c.UsageScanInterval)
+	return interval
+}
+
+// usageAnnotationInterval returns UsageAnnotateInterval as a duration, 0 when
+// PVs are not annotated with their usage.
+func (c *config) usageAnnotationInterval() time.Duration {
+	interval, _ := time.ParseDuration(c.UsageAnnotateInterval)
+	return interval
+}
+
+// usageReportInterval returns UsageReportInterval as a duration, 0 when no
+// usage report is published.
+func (c *config) usageReportInterval() time.Duration {
+	interval, _ := time.ParseDuration(c.UsageReportInterval)
+	return interval
+}
+
+// usageReportConfigMap returns the namespace and name of the ConfigMap of the
+// usage report.
+func (c *config) usageReportConfigMap() (string, string) {
+	if namespace, name, ok := strings.Cut(c.UsageReportConfigMap, "/"); ok {
+		return namespace, name
+	}
+	return podNamespace(), c.UsageReportConfigMap
+}
+
+// freeSpaceInterval returns FreeSpaceInterval as a duration, 0 when the
+// free space of the exports is not measured.
+func (c *config) freeSpaceInterval() time.Duration {
+	interval, _ := time.ParseDuration(c.FreeSpaceInterval)
+	return interval
+}
+
+// directoryCheckInterval returns DirCheckInterval as a duration, 0 when the
+// directories of volumes are not checked.
+func (c *config) directoryCheckInterval() time.Duration {
+	interval, _ := time.ParseDuration(c.DirCheckInterval)
+	return interval
+}
+
+// statusInterval returns StatusInterval as a duration.
+func (c *config) statusInterval() time.Duration {
+	interval, _ := time.ParseDuration(c.StatusInterval)
+	return interval
+}
+
+// exportHealthInterval returns ExportHealthInterval as a duration, 0 when
+// the mounts of the exports are not checked.
+func (c *config) exportHealthInterval() time.Duration {
+	interval, _ := time.ParseDuration(c.ExportHealthInterval)
+	return interval
+}
+
+// progressInterval returns ProgressInterval as a duration, 0 when the
+// progress of deletions is not reported.
+func (c *config) progressInterval() time.Duration {
+	interval, _ := time.ParseDuration(c.ProgressInterval)
+	return interval
+}
+
+// deleteBandwidth returns DeleteBandwidth in bytes, 0 for no limit.
+func (c *config) deleteBandwidth() int64 {
+	if c.DeleteBandwidth == "" {
+		return 0
+	}
+	q, _ := resource.ParseQuantity(c.DeleteBandwidth)
+	return q.Value()
+}
+
+// archiveWorkerThreads returns the number of archivings allowed to run
+// concurrently.
+func (c *config) archiveWorkerThreads() int {
+	if c.ArchiveWorkerThreads == 0 {
+		return c.WorkerThreads
+	}
+	return c.ArchiveWorkerThreads
+}
+
+// orphanGracePeriod returns OrphanGracePeriod as a duration, 0 when orphaned
+// directories are not reaped.
+func (c *config) orphanGracePeriod() time.Duration {
+	grace, _ := parseAge(c.OrphanGracePeriod)
+	return grace
+}
+
+// resyncPeriod returns ResyncPeriod as a duration.
+func (c *config) resyncPeriod() time.Duration {
+	period, _ := time.ParseDuration(c.ResyncPeriod)
+	return period
+}
+
+// retryDelays returns RetryBaseDelay and RetryMaxDelay as durations.
+func (c *config) retryDelays() (time.Duration, time.Duration) {
+	base, _ := time.ParseDuration(c.RetryBaseDelay)
+	maxDelay, _ := time.ParseDuration(c.RetryMaxDelay)
+	return base, maxDelay
+}
+
+// shutdownTimeout returns ShutdownTimeout as a duration.
+func (c *config) shutdownTimeout() time.Duration {
+	timeout, _ := time.ParseDuration(c.ShutdownTimeout)
+	return timeout
+}
+
+// hookTimeout returns HookTimeout as a duration.
+func (c *config) hookTimeout() time.Duration {
+	timeout, _ := time.ParseDuration(c.HookTimeout)
+	return timeout
+}
+
+// fsIdentity returns the user and group IDs files are accessed as, with
+// ok false when they are accessed as root.
+func (c *config) fsIdentity() (uid, gid int, ok bool) {
+	if c.FSUID == 0 {
+		return 0, 0, false
+	}
+	if c.FSGID == 0 {
+		return c.FSUID, c.FSUID, true
+	}
+	return c.FSUID, c.FSGID, true
+}
+
+// dirMode returns DirectoryMode as a file mode.
+func (c *config) dirMode() os.FileMode {
+	mode, _ := strconv.ParseUint(c.DirectoryMode, 8, 32)
+	return os.FileMode(mode)
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		set  func(c *config)
+		// wantErr is a part of the error expected, none when empty.
+		wantErr string
+	}{
+		{name: "defaults", set: func(c *config) {}},
+		{name: "no server", set: func(c *config) { c.NFSServer = "" }, wantErr: "--nfs-server"},
+		{name: "invalid server", set: func(c *config) { c.NFSServer = "[10.0.0.1]" }, wantErr: "--nfs-server"},
+		{name: "no path", set: func(c *config) { c.NFSPath = "" }, wantErr: "--nfs-path"},
+		{name: "no provisioner name", set: func(c *config) { c.ProvisionerName = "" }, wantErr: "--provisioner-name"},
+		{name: "relative mount path, mounted", set: func(c *config) { c.MountExport, c.MountPath = true, "persistentvolumes" }, wantErr: "--mount-path"},
+		{name: "relative mount path", set: func(c *config) { c.MountPath = "persistentvolumes" }},
+		{name: "admin TLS without client CA", set: func(c *config) { c.AdminTLSCert, c.AdminTLSKey = "/tls/tls.crt", "/tls/tls.key" }, wantErr: "--admin-tls-client-ca"},
+		{name: "admin TLS", set: func(c *config) {
+			c.AdminTLSCert, c.AdminTLSKey, c.AdminTLSClientCA = "/tls/tls.crt", "/tls/tls.key", "/tls/ca.crt"
+		}},
+		{name: "pprof on loopback", set: func(c *config) { c.EnablePprof, c.PprofAddress = true, "127.0.0.1:6060" }},
+		{name: "pprof on all addresses", set: func(c *config) { c.EnablePprof, c.PprofAddress = true, ":6060" }, wantErr: "--pprof-allow-remote"},
+		{name: "pprof on all addresses, allowed", set: func(c *config) { c.EnablePprof, c.PprofAddress, c.PprofAllowRemote = true, ":6060", true }},
+		{name: "pprof address unused", set: func(c *config) { c.PprofAddress = ":6060" }},
+		{name: "no worker threads", set: func(c *config) { c.WorkerThreads = 0 }, wantErr: "--worker-threads"},
+		{name: "worker threads above the maximum", set: func(c *config) { c.WorkerThreads = c.MaxWorkerThreads + 1 }, wantErr: "--worker-threads"},
+		{name: "negative archive worker threads", set: func(c *config) { c.ArchiveWorkerThreads = -1 }, wantErr: "--archive-worker-threads"},
+		{name: "export audit log path", set: func(c *config) { c.ExportAuditLog = "logs/audit.jsonl" }, wantErr: "--export-audit-log"},
+		{name: "retry delays reversed", set: func(c *config) { c.RetryBaseDelay, c.RetryMaxDelay = "1m", "30s" }, wantErr: "--retry-base-delay"},
+		{name: "invalid resync period", set: func(c *config) { c.ResyncPeriod = "15" }, wantErr: "--resync-period"},
+		{name: "default on delete", set: func(c *config) { c.DefaultOnDelete = "archive" }},
+		{name: "invalid default on delete", set: func(c *config) { c.DefaultOnDelete = "keep" }, wantErr: "--default-on-delete"},
+		{name: "mount options with spaces", set: func(c *config) { c.DefaultMountOptions = "nfsvers=4.1, hard" }, wantErr: "--default-mount-options"},
+		{name: "usage report without scan", set: func(c *config) { c.UsageReportInterval = "1h" }, wantErr: "--usage-scan-interval"},
+		{name: "import from any host", set: func(c *config) { c.ImportAllowedHosts = "*" }, wantErr: "--import-allowed-hosts"},
+		{name: "import allowed hosts", set: func(c *config) { c.ImportAllowedHosts = "archives.example.com,*.example.org" }},
+		{name: "short orphan grace period", set: func(c *config) { c.OrphanGracePeriod = "30m" }, wantErr: "--orphan-grace-period"},
+		{name: "orphan grace period in days", set: func(c *config) { c.OrphanGracePeriod = "30d" }},
+		{name: "absolute orphan exclude", set: func(c *config) { c.OrphanExclude = "/scratch" }, wantErr: "--orphan-exclude"},
+		{name: "invalid orphan action", set: func(c *config) { c.OrphanAction = "retain" }, wantErr: "--orphan-action"},
+		{name: "free space threshold above 100", set: func(c *config) { c.FreeSpaceThreshold = 101 }, wantErr: "--free-space-threshold"},
+		{name: "shard without name", set: func(c *config) { c.ShardCount = 2 }, wantErr: "--shard-name"},
+		{name: "shard index out of range", set: func(c *config) { c.ShardName, c.ShardCount, c.ShardIndex = "shard-1", 2, 2 }, wantErr: "--shard-index"},
+		{name: "shard", set: func(c *config) { c.ShardName, c.ShardCount, c.ShardIndex = "shard-1", 2, 1 }},
+		{name: "invalid clusters", set: func(c *config) { c.Clusters = "east-1=kubeconfig" }, wantErr: "--clusters"},
+		{name: "invalid directory mode", set: func(c *config) { c.DirectoryMode = "0779" }, wantErr: "--directory-mode"},
+		{name: "group without user", set: func(c *config) { c.FSGID = 1000 }, wantErr: "--fs-gid"},
+		{name: "invalid ownership strategy", set: func(c *config) { c.OwnershipStrategy = "always" }, wantErr: "--ownership-strategy"},
+		{name: "metadata file path", set: func(c *config) { c.MetadataFile = "meta/data.json" }, wantErr: "--metadata-file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := defaultConfig()
+			c.NFSServer, c.NFSPath, c.ProvisionerName = "10.0.0.1", "/srv/nfs", "nfs-subdir"
+			tt.set(c)
+			err := c.validate()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("validate() = %v, want no error", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("validate() = %v, want an error about %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigLoadSpec(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		// wantErr is a part of the error expected, none when empty.
+		wantErr string
+	}{
+		{name: "no spec", spec: ""},
+		{name: "reloadable", spec: `{"logLevel": 4, "workerThreads": 8}`},
+		{name: "not reloadable", spec: `{"nfsServer": "10.0.0.2"}`, wantErr: "nfs-server"},
+		{name: "policy", spec: `{"policyURL": "http://10.0.0.2/"}`, wantErr: "policy-url"},
+		{name: "hook", spec: `{"preProvisionHook": "/bin/sh"}`, wantErr: "pre-provision-hook"},
+		{name: "notifiers", spec: `{"notifiers": "http://10.0.0.2/"}`, wantErr: "notifiers"},
+		{name: "import allowed hosts", spec: `{"importAllowedHosts": "10.0.0.2"}`, wantErr: "import-allowed-hosts"},
+		{name: "policy of the config file", spec: `{"policyURL": "http://opa.example.com/"}`},
+		{name: "unknown", spec: `{"server": "10.0.0.2"}`, wantErr: "invalid spec"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := defaultConfig()
+			c.NFSServer, c.NFSPath, c.ProvisionerName = "10.0.0.1", "/srv/nfs", "nfs-subdir"
+			c.PolicyURL = "http://opa.example.com/"
+			c.spec = []byte(tt.spec)
+			err := c.loadSpec()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("loadSpec() = %v, want no error", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("loadSpec() = %v, want an error about %s", err, tt.wantErr)
+			}
+		})
+	}
+}
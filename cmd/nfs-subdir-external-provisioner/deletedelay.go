@@ -0,0 +1,192 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// deletedDir holds, at the root of an export, the directories of the
+	// volumes of classes with a deleteDelay until they are removed.
+	deletedDir = ".nfs-provisioner-deleted"
+	// purgeDeletedInterval is how often the directories past their
+	// deleteDelay are removed.
+	purgeDeletedInterval = 10 * time.Minute
+)
+
+// pendingDelete is the manifest of a directory waiting in deletedDir.
+type pendingDelete struct {
+	archiveManifest
+	// Directory is where the directory was, relative to the export.
+	Directory string `json:"directory"`
+	// Expires is when the directory is removed.
+	Expires time.Time `json:"expires"`
+}
+
+// deleteDelay returns how long the deleted directories of the volumes of
+// class are kept before they are removed, 0 if they are removed at once.
+func deleteDelay(class *storage.StorageClass) (time.Duration, error) {
+	value := class.Parameters["deleteDelay"]
+	if value == "" {
+		return 0, nil
+	}
+	delay, err := parseAge(value)
+	if err != nil || delay <= 0 {
+		return 0, fmt.Errorf("invalid deleteDelay %q, must be a positive duration such as 24h or 7d", value)
+	}
+	return delay, nil
+}
+
+// deferDelete moves the directory oldPath of volume, on the export mounted
+// at mountPath, to deletedDir, to be removed after delay. It returns where
+// the directory was moved.
+func (p *nfsProvisioner) deferDelete(ctx context.Context, volume *v1.PersistentVolume, class *storage.StorageClass, mountPath, oldPath string, delay time.Duration) (string, error) {
+	dir, err := filepath.Rel(mountPath, oldPath)
+	if err != nil {
+		return oldPath, err
+	}
+	if err := os.MkdirAll(filepath.Join(mountPath, deletedDir), 0700); err != nil {
+		return oldPath, err
+	}
+	target := filepath.Join(mountPath, deletedDir, volume.Name)
+	if _, err := os.Lstat(target); err == nil {
+		// A volume of the same name is waiting already; keep both.
+		target += "-" + p.random.suffix()
+	}
+	now := p.clock.Now()
+	location := filepath.ToSlash(filepath.Join(deletedDir, filepath.Base(target)))
+	pending := pendingDelete{
+		archiveManifest: newArchiveManifest(volume, class, location, "directory", now),
+		Directory:       filepath.ToSlash(dir),
+		Expires:         now.Add(delay).UTC(),
+	}
+	data, err := json.MarshalIndent(pending, "", "  ")
+	if err != nil {
+		return oldPath, err
+	}
+	// The manifest comes first, so that no directory is left without one.
+	manifest := target + archiveManifestSuffix
+	if err := os.WriteFile(manifest, append(data, '\n'), 0600); err != nil {
+		return oldPath, err
+	}
+	klog.FromContext(ctx).Info(fmt.Sprintf("moving path %s to %s until %s", oldPath, target, pending.Expires.Format(time.RFC3339)))
+	err = traced(ctx, "Rename", func() error { return moveDir(ctx, oldPath, target) }, "from", oldPath, "to", target)
+	if err != nil {
+		_ = os.Remove(manifest)
+		return oldPath, err
+	}
+	return target, nil
+}
+
+// listPendingDeletes returns the directories waiting in the deletedDir of the
+// export mounted at export, by name, those with an unreadable manifest or one
+// naming a directory that is not right in deletedDir left out.
+func listPendingDeletes(ctx context.Context, export string) ([]pendingDelete, error) {
+	logger := klog.FromContext(ctx)
+	manifests, err := filepath.Glob(filepath.Join(export, deletedDir, "*"+archiveManifestSuffix))
+	if err != nil {
+		return nil, err
+	}
+	var pending []pendingDelete
+	for _, manifest := range manifests {
+		data, err := os.ReadFile(manifest)
+		if err != nil {
+			logger.Error(err, "failed to read the manifest of a deleted directory", "path", manifest)
+			continue
+		}
+		var p pendingDelete
+		if err := json.Unmarshal(data, &p); err != nil {
+			logger.Error(err, "invalid manifest of a deleted directory", "path", manifest)
+			continue
+		}
+		if path.Clean(p.Archive) != p.Archive || path.Dir(p.Archive) != deletedDir || path.Base(p.Archive) == ".." {
+			logger.Info("warning: ignoring the manifest of a directory outside of "+deletedDir, "path", manifest, "archive", p.Archive)
+			continue
+		}
+		pending = append(pending, p)
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Archive < pending[j].Archive })
+	return pending, nil
+}
+
+// startDeletePurger removes the directories of the mounted exports whose
+// deleteDelay is over every purgeDeletedInterval until ctx is done.
+func (p *nfsProvisioner) startDeletePurger(ctx context.Context) {
+	go wait.UntilWithContext(ctx, p.purgeDeleted, purgeDeletedInterval)
+}
+
+func (p *nfsProvisioner) purgeDeleted(ctx context.Context) {
+	ctx = withThrottle(ctx, p.throttle)
+	logger := klog.FromContext(ctx)
+	if err := p.watchdog.healthy(); err != nil {
+		logger.V(2).Info("not removing deleted directories", "reason", err.Error())
+		return
+	}
+	now := p.clock.Now()
+	for _, b := range p.mountedBackends() {
+		pending, err := listPendingDeletes(ctx, b.mountPath)
+		if err != nil {
+			logger.Error(err, "failed to list the deleted directories of export", "export", mountSource(b.server, b.path))
+			continue
+		}
+		for _, d := range pending {
+			if now.Before(d.Expires) {
+				continue
+			}
+			dir := filepath.Join(b.mountPath, filepath.FromSlash(d.Archive))
+			err := removeTree(ctx, dir)
+			if err == nil {
+				err = os.Remove(dir + archiveManifestSuffix)
+			}
+			p.audit.log(auditRecord{
+				Action:       auditDelete,
+				PV:           d.PV,
+				PVC:          d.PVC,
+				Namespace:    d.Namespace,
+				StorageClass: d.StorageClass,
+				Server:       d.Server,
+				Path:         d.Path,
+			}, err)
+			if err != nil {
+				logger.Error(err, "failed to remove deleted directory", "path", dir, "pv", d.PV)
+				continue
+			}
+			p.shareAudit.log(b, shareAuditRecord{
+				Action:       auditDelete,
+				PV:           d.PV,
+				PVC:          d.PVC,
+				Namespace:    d.Namespace,
+				StorageClass: d.StorageClass,
+				Path:         path.Join(b.path, d.Archive),
+			})
+			logger.Info("removed deleted directory past its deleteDelay", "path", dir, "pv", d.PV, "deleted", d.Archived)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListPendingDeletes(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		want     bool
+	}{
+		{name: "deleted directory", manifest: `{"archive": ".nfs-provisioner-deleted/team-a-data-pvc-1"}`, want: true},
+		{name: "parent of the deleted directories", manifest: `{"archive": ".nfs-provisioner-deleted/.."}`},
+		{name: "deleted directories", manifest: `{"archive": ".nfs-provisioner-deleted/."}`},
+		{name: "trailing slash", manifest: `{"archive": ".nfs-provisioner-deleted/team-a-data-pvc-1/"}`},
+		{name: "out of the deleted directories", manifest: `{"archive": ".nfs-provisioner-deleted/../team-a-data-pvc-1"}`},
+		{name: "volume", manifest: `{"archive": "team-a-data-pvc-1"}`},
+		{name: "nested", manifest: `{"archive": ".nfs-provisioner-deleted/team-a/data-pvc-1"}`},
+		{name: "absolute", manifest: `{"archive": "/.nfs-provisioner-deleted/team-a-data-pvc-1"}`},
+		{name: "invalid manifest", manifest: `{`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			export := t.TempDir()
+			if err := os.Mkdir(filepath.Join(export, deletedDir), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			manifest := filepath.Join(export, deletedDir, "team-a-data-pvc-1"+archiveManifestSuffix)
+			if err := os.WriteFile(manifest, []byte(tt.manifest), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			pending, err := listPendingDeletes(context.Background(), export)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(pending) == 1; got != tt.want {
+				t.Errorf("listPendingDeletes() = %+v, want the directory listed %v", pending, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// annDirectoryMissing marks the bound PVs whose directory is gone from the
+// export, with the time it was found missing.
+const annDirectoryMissing = "nfs.io/directory-missing"
+
+// errExportEmpty is returned by checkExportRoot for an export without
+// entries.
+var errExportEmpty = errors.New("empty")
+
+var missingDirectories = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: metricsNamespace,
+	Name:      "missing_directories",
+	Help:      "Bound volumes whose directory was missing from the export, as of the last check.",
+})
+
+// startDirectoryChecks checks that the directory of every bound volume is
+// still on its export every interval, until ctx is done.
+func (p *nfsProvisioner) startDirectoryChecks(ctx context.Context, volumes cache.SharedIndexInformer, interval time.Duration) {
+	prometheus.MustRegister(missingDirectories)
+	go func() {
+		if !cache.WaitForCacheSync(ctx.Done(), volumes.HasSynced) {
+			return
+		}
+		wait.UntilWithContext(ctx, p.checkDirectories, interval)
+	}()
+}
+
+// checkDirectories looks for the directory of every bound volume once, and
+// marks those that are missing, or back.
+func (p *nfsProvisioner) checkDirectories(ctx context.Context) {
+	logger := klog.FromContext(ctx)
+	// A stale mount would make every directory look missing.
+	if err := p.watchdog.healthy(); err != nil {
+		logger.V(2).Info("not checking the directories of volumes", "reason", err.Error())
+		return
+	}
+	list, err := p.volumes.List(labels.Everything())
+	if err != nil {
+		logger.Error(err, "failed to list volumes to check the directories of")
+		return
+	}
+	// Whether the export mounted at each path looks mounted.
+	exports := map[string]error{}
+	missing := 0
+	for _, volume := range list {
+		if !p.directoryChecked(volume) {
+			continue
+		}
+		b, err := p.backendForVolume(ctx, volume)
+		if err != nil {
+			logger.V(2).Info("no export to check the directory of volume on", "pv", volume.Name, "err", err)
+			continue
+		}
+		unusable, checked := exports[b.mountPath]
+		if !checked {
+			unusable = checkExportRoot(b.mountPath)
+			exports[b.mountPath] = unusable
+			if unusable != nil {
+				logger.Info("warning: not checking the directories of volumes on export", "export", mountSource(b.server, b.path), "reason", unusable.Error())
+			}
+		}
+		if unusable == nil {
+			_, err = lstatTimeout(b.localPath(volume.Spec.NFS.Path))
+		}
+		b.release()
+		if unusable != nil {
+			continue
+		}
+		if err != nil && !os.IsNotExist(err) {
+			logger.Error(err, "failed to check the directory of volume", "pv", volume.Name, "path", volume.Spec.NFS.Path)
+			continue
+		}
+		if err != nil {
+			missing++
+		}
+		p.markDirectoryMissing(ctx, volume, err != nil)
+	}
+	missingDirectories.Set(float64(missing))
+	logger.V(2).Info("checked the directories of volumes", "missing", missing)
+}
+
+// directoryChecked reports whether the directory of volume is checked: a
+// bound volume of this instance, on an export it mounts.
+func (p *nfsProvisioner) directoryChecked(volume *v1.PersistentVolume) bool {
+	if !p.owns(volume.Annotations[annProvisionedBy]) || !p.inShard(volume) || volume.Spec.NFS == nil || volume.Spec.ClaimRef == nil {
+		return false
+	}
+	_, dedicated := volume.Annotations[annDedicatedExport]
+	return volume.Status.Phase == v1.VolumeBound && !dedicated
+}
+
+// checkExportRoot returns why the directories under root, where an export
+// is mounted, cannot be trusted to be missing: it cannot be read, or it is
+// empty, as when the export is not mounted or was restored empty.
+func checkExportRoot(root string) error {
+	done := make(chan error, 1)
+	go func() {
+		f, err := os.Open(root)
+		if err != nil {
+			done <- err
+			return
+		}
+		defer f.Close()
+		if names, _ := f.Readdirnames(1); len(names) == 0 {
+			done <- fmt.Errorf("%s is %w", root, errExportEmpty)
+			return
+		}
+		done <- nil
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(backendProbeTimeout):
+		return fmt.Errorf("listing %s timed out after %s", root, backendProbeTimeout)
+	}
+}
+
+// lstatTimeout is os.Lstat, giving up after backendProbeTimeout on a hung
+// mount.
+func lstatTimeout(path string) (os.FileInfo, error) {
+	type result struct {
+		info os.FileInfo
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		info, err := os.Lstat(path)
+		done <- result{info, err}
+	}()
+	select {
+	case r := <-done:
+		return r.info, r.err
+	case <-time.After(backendProbeTimeout):
+		return nil, fmt.Errorf("lstat of %s timed out after %s", path, backendProbeTimeout)
+	}
+}
+
+// markDirectoryMissing records whether the directory of volume is missing
+// in its annotations, with events on it and its claim when that changes.
+func (p *nfsProvisioner) markDirectoryMissing(ctx context.Context, volume *v1.PersistentVolume, missing bool) {
+	logger := klog.FromContext(ctx)
+	since, marked := volume.Annotations[annDirectoryMissing]
+	if missing == marked {
+		return
+	}
+	var value interface{}
+	if missing {
+		value = p.clock.Now().UTC().Format(time.RFC3339)
+	}
+	patch, err := json.Marshal(map[string]interface{}{"metadata": map[string]interface{}{"annotations": map[string]interface{}{annDirectoryMissing: value}}})
+	if err != nil {
+		return
+	}
+	if _, err := p.client.CoreV1().PersistentVolumes().Patch(ctx, volume.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		logger.Error(err, "failed to mark the directory of volume", "pv", volume.Name, "missing", missing)
+		return
+	}
+	// The claim may be gone already.
+	claim, _ := p.claims.PersistentVolumeClaims(volume.Spec.ClaimRef.Namespace).Get(volume.Spec.ClaimRef.Name)
+	event := func(eventType, reason, messageFmt string, args ...interface{}) {
+		p.recorder.Eventf(volume, eventType, reason, messageFmt, args...)
+		if claim != nil {
+			p.recorder.Eventf(claim, eventType, reason, messageFmt, args...)
+		}
+	}
+	if missing {
+		logger.Info("warning: the directory of volume is missing", "pv", volume.Name, "path", volume.Spec.NFS.Path)
+		event(v1.EventTypeWarning, "DirectoryMissing", "The directory %s of the volume is missing from the export, pods will see an empty or failing volume", volume.Spec.NFS.Path)
+		return
+	}
+	logger.Info("the directory of volume is back", "pv", volume.Name, "path", volume.Spec.NFS.Path, "missingSince", since)
+	event(v1.EventTypeNormal, "DirectoryRestored", "The directory %s of the volume is back on the export, missing since %s", volume.Spec.NFS.Path, since)
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// lockDir holds the lock files of the volumes of an export, at its root.
+	lockDir = ".nfs-provisioner-locks"
+	// lockTTL is how long a lock file outlives its last heartbeat, after
+	// which it is taken to belong to a dead replica and may be taken over.
+	lockTTL = 2 * time.Minute
+)
+
+// errLocked is returned when another replica holds the lock of a volume.
+var errLocked = errors.New("another replica is working on the volume")
+
+// lockRecord is the content of a lock file.
+type lockRecord struct {
+	Holder   string    `json:"holder"`
+	Token    string    `json:"token"`
+	Acquired time.Time `json:"acquired"`
+}
+
+// heldLock is a lock of this replica.
+type heldLock struct {
+	file  string
+	token string
+	stop  chan struct{}
+}
+
+// volumeLocks implements the lock files with which replicas running side by
+// side on the same export keep from working on the same volume. A lock file
+// is created exclusively, which NFS guarantees, and kept alive by touching
+// it. Each acquisition writes a new fencing token in it; before changing the
+// directory of the volume, a replica checks that the token is still its
+// own, so that one that stalled past lockTTL and lost its lock stops there.
+type volumeLocks struct {
+	holder string
+
+	mu   sync.Mutex
+	held map[string]*heldLock
+	// generation makes the tokens of this replica unique.
+	generation uint64
+}
+
+func newVolumeLocks(holder string) *volumeLocks {
+	return &volumeLocks{holder: holder, held: map[string]*heldLock{}}
+}
+
+// lock takes the lock of the volume called name, in the export mounted at
+// root, returning errLocked while another replica holds it, and the function
+// releasing it.
+func (l *volumeLocks) lock(root, name string) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	dir := filepath.Join(root, lockDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("unable to create the lock directory: %v", err)
+	}
+	l.mu.Lock()
+	l.generation++
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 10) + "-" + strconv.FormatUint(l.generation, 10)
+	l.mu.Unlock()
+	token := l.holder + "/" + suffix
+	data, err := json.Marshal(lockRecord{Holder: l.holder, Token: token, Acquired: time.Now().UTC()})
+	if err != nil {
+		return nil, err
+	}
+
+	file := filepath.Join(dir, name+".lock")
+	for attempt := 0; ; attempt++ {
+		f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			_, err = f.Write(data)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				_ = os.Remove(file)
+				return nil, fmt.Errorf("unable to write the lock file %s: %v", file, err)
+			}
+			break
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("unable to create the lock file %s: %v", file, err)
+		}
+		info, err := os.Stat(file)
+		if os.IsNotExist(err) {
+			// Released meanwhile.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if time.Since(info.ModTime()) < lockTTL || attempt > 0 {
+			return nil, errLocked
+		}
+		// Stale: move it out of the way. Of several replicas doing so at
+		// the same time, one renames it and the others find it gone.
+		stale := file + ".stale-" + suffix
+		if err := os.Rename(file, stale); err == nil {
+			_ = os.Remove(stale)
+		}
+	}
+
+	held := &heldLock{file: file, token: token, stop: make(chan struct{})}
+	l.mu.Lock()
+	l.held[name] = held
+	l.mu.Unlock()
+	go func() {
+		ticker := time.NewTicker(lockTTL / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-held.stop:
+				return
+			case <-ticker.C:
+				now := time.Now()
+				_ = os.Chtimes(file, now, now)
+			}
+		}
+	}()
+
+	return func() {
+		l.mu.Lock()
+		delete(l.held, name)
+		l.mu.Unlock()
+		close(held.stop)
+		if held.valid() == nil {
+			_ = os.Remove(file)
+		}
+	}, nil
+}
+
+// valid returns an error unless the lock file still holds the token of h.
+func (h *heldLock) valid() error {
+	data, err := os.ReadFile(h.file)
+	if err != nil {
+		return fmt.Errorf("lost the lock %s: %v", h.file, err)
+	}
+	var record lockRecord
+	if err := json.Unmarshal(data, &record); err != nil || record.Token != h.token {
+		return fmt.Errorf("lost the lock %s to %s", h.file, record.Holder)
+	}
+	return nil
+}
+
+// fence returns an error if this replica locked the volume called name and
+// no longer holds the lock, to be checked before changing its directory.
+func (l *volumeLocks) fence(name string) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	held := l.held[name]
+	l.mu.Unlock()
+	if held == nil {
+		return nil
+	}
+	return held.valid()
+}
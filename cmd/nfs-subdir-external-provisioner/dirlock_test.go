@@ -0,0 +1,151 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestVolumeLocksLock(t *testing.T) {
+	tests := []struct {
+		name string
+		// age is how long ago the lock file of another replica was last
+		// touched, none when zero.
+		age     time.Duration
+		wantErr error
+	}{
+		{name: "free"},
+		{name: "held", age: time.Second, wantErr: errLocked},
+		{name: "held, about to expire", age: lockTTL - 10*time.Second, wantErr: errLocked},
+		{name: "stale, taken over", age: lockTTL + time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			file := filepath.Join(root, lockDir, "pvc-1.lock")
+			if tt.age != 0 {
+				if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+					t.Fatal(err)
+				}
+				data, _ := json.Marshal(lockRecord{Holder: "replica-b", Token: "replica-b/1-1"})
+				if err := os.WriteFile(file, data, 0600); err != nil {
+					t.Fatal(err)
+				}
+				touched := time.Now().Add(-tt.age)
+				if err := os.Chtimes(file, touched, touched); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			l := newVolumeLocks("replica-a")
+			release, err := l.lock(root, "pvc-1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("lock() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if ferr := l.fence("pvc-1"); ferr != nil {
+					t.Errorf("fence() of a volume never locked = %v", ferr)
+				}
+				return
+			}
+			var record lockRecord
+			if data, err := os.ReadFile(file); err != nil || json.Unmarshal(data, &record) != nil || record.Holder != "replica-a" {
+				t.Errorf("lock file holds %+v, %v, want replica-a", record, err)
+			}
+			if err := l.fence("pvc-1"); err != nil {
+				t.Errorf("fence() of a held lock = %v", err)
+			}
+			release()
+			if _, err := os.Stat(file); !os.IsNotExist(err) {
+				t.Errorf("lock file left after release: %v", err)
+			}
+		})
+	}
+}
+
+func TestVolumeLocksFence(t *testing.T) {
+	tests := []struct {
+		name string
+		// lose does to the lock file what makes the replica lose its lock.
+		lose     func(t *testing.T, root, file string)
+		wantLost bool
+		// wantKept tells whether releasing leaves the lock file, being
+		// someone else's.
+		wantKept bool
+	}{
+		{name: "kept", lose: func(*testing.T, string, string) {}},
+		{name: "removed", wantLost: true, lose: func(t *testing.T, _, file string) {
+			if err := os.Remove(file); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{name: "taken over", wantLost: true, wantKept: true, lose: func(t *testing.T, root, file string) {
+			stale := time.Now().Add(-2 * lockTTL)
+			if err := os.Chtimes(file, stale, stale); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := newVolumeLocks("replica-b").lock(root, "pvc-1"); err != nil {
+				t.Fatalf("taking over a stale lock: %v", err)
+			}
+		}},
+		{name: "corrupted", wantLost: true, wantKept: true, lose: func(t *testing.T, _, file string) {
+			if err := os.WriteFile(file, []byte("{"), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			file := filepath.Join(root, lockDir, "pvc-1.lock")
+			l := newVolumeLocks("replica-a")
+			release, err := l.lock(root, "pvc-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.lose(t, root, file)
+
+			if err := l.fence("pvc-1"); (err != nil) != tt.wantLost {
+				t.Errorf("fence() = %v, want error %v", err, tt.wantLost)
+			}
+			if err := l.fence("pvc-2"); err != nil {
+				t.Errorf("fence() of another volume = %v", err)
+			}
+			release()
+			if _, err := os.Stat(file); (err == nil) != tt.wantKept {
+				t.Errorf("lock file kept after release = %v, want %v", err == nil, tt.wantKept)
+			}
+		})
+	}
+}
+
+func TestVolumeLocksDisabled(t *testing.T) {
+	var l *volumeLocks
+	release, err := l.lock(t.TempDir(), "pvc-1")
+	if err != nil {
+		t.Fatalf("lock() without locks = %v", err)
+	}
+	release()
+	if err := l.fence("pvc-1"); err != nil {
+		t.Errorf("fence() without locks = %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// annEphemeral marks the PVs of the claims of generic ephemeral volumes.
+const annEphemeral = "nfs.io/ephemeral"
+
+// The kinds of volumes, as labeled in metrics.
+const (
+	volumeKindEphemeral  = "ephemeral"
+	volumeKindPersistent = "persistent"
+)
+
+// isEphemeral reports whether claim was created for a generic ephemeral
+// volume, in which case the pod it belongs to controls it.
+func isEphemeral(claim *v1.PersistentVolumeClaim) bool {
+	owner := metav1.GetControllerOf(claim)
+	return owner != nil && owner.APIVersion == "v1" && owner.Kind == "Pod"
+}
+
+// volumeKind returns the kind of volume, ephemeral or persistent.
+func volumeKind(volume *v1.PersistentVolume) string {
+	if volume.Annotations[annEphemeral] == "true" {
+		return volumeKindEphemeral
+	}
+	return volumeKindPersistent
+}
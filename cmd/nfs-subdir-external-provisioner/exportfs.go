@@ -0,0 +1,150 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	storage "k8s.io/api/storage/v1"
+)
+
+const (
+	// annDedicatedExport records on a PV the exports file created for it on
+	// the NFS server.
+	annDedicatedExport = "nfs.io/dedicated-export"
+
+	exportsDir           = "/etc/exports.d"
+	defaultExportOptions = "rw,sync,no_subtree_check"
+)
+
+// exportToken matches the export clients and options that are safe to put
+// in an exports file and on a shell command line.
+var exportToken = regexp.MustCompile(`^[A-Za-z0-9_.:/*,=@\[\]-]+$`)
+
+// exportsFileName matches the base names exportsFile gives the exports files
+// of volumes, named after PVs.
+var exportsFileName = regexp.MustCompile(`^nfs-subdir-[a-z0-9]([-a-z0-9.]*[a-z0-9])?\.exports$`)
+
+// exporter creates and removes the dedicated export of a volume. export
+// returns a reference to the export, recorded in annDedicatedExport, that
+// unexport is later called with.
+type exporter interface {
+	export(ctx context.Context, name, path string, clients []string, options string) (string, error)
+	unexport(ctx context.Context, ref string) error
+}
+
+// usageQuerier is implemented by the exporters that can measure a volume
+// on the server itself.
+type usageQuerier interface {
+	usage(ctx context.Context, path string) (int64, int64, error)
+}
+
+// exportfsClient manages dedicated per-volume exports on a kernel NFS server
+// over SSH. Each export is a file in /etc/exports.d, so that it survives
+// server restarts, applied with exportfs -ra.
+type exportfsClient struct {
+	ssh *sshClient
+}
+
+// exportsFile returns the exports file of the volume called name.
+func exportsFile(name string) string {
+	return exportsDir + "/nfs-subdir-" + name + ".exports"
+}
+
+// export exports path to clients with options, in the exports file of the
+// volume called name. It returns that file.
+func (c *exportfsClient) export(ctx context.Context, name, path string, clients []string, options string) (string, error) {
+	if options == "" {
+		options = defaultExportOptions
+	}
+	if !exportToken.MatchString(options) {
+		return "", fmt.Errorf("invalid export options %q", options)
+	}
+	entries := make([]string, 0, len(clients))
+	for _, client := range clients {
+		if !exportToken.MatchString(client) {
+			return "", fmt.Errorf("invalid export client %q", client)
+		}
+		entries = append(entries, client+"("+options+")")
+	}
+	if strings.ContainsAny(path, "'\n") {
+		return "", fmt.Errorf("unable to export %q", path)
+	}
+
+	file := exportsFile(name)
+	line := fmt.Sprintf("%q %s", path, strings.Join(entries, " "))
+	command := fmt.Sprintf("mkdir -p %s && printf '%%s\\n' '%s' > %s && exportfs -ra", exportsDir, line, file)
+	_, err := c.ssh.run(ctx, command)
+	return file, err
+}
+
+// checkExportsFile refuses file unless it is an exports file export could
+// have created. It is read from a PV annotation, which whoever can edit PVs
+// could point at any other file of the server.
+func checkExportsFile(file string) error {
+	if path.Clean(file) != file || path.Dir(file) != exportsDir || !exportsFileName.MatchString(path.Base(file)) {
+		return fmt.Errorf("refusing to remove %q, not an exports file of a volume", file)
+	}
+	return nil
+}
+
+// unexport removes file, an exports file created by export.
+func (c *exportfsClient) unexport(ctx context.Context, file string) error {
+	if err := checkExportsFile(file); err != nil {
+		return err
+	}
+	_, err := c.ssh.run(ctx, fmt.Sprintf("rm -f %s && exportfs -ra", file))
+	return err
+}
+
+// dedicatedExport reports whether volumes of class get an export of their
+// own.
+func (p *nfsProvisioner) dedicatedExport(class *storage.StorageClass) (bool, error) {
+	value, ok := class.Parameters["exportPerVolume"]
+	if !ok {
+		return false, nil
+	}
+	dedicated, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid exportPerVolume %q: %v", value, err)
+	}
+	if dedicated && p.exporter == nil {
+		return false, fmt.Errorf("StorageClass %s sets exportPerVolume, but --export-ssh-address is not set", class.Name)
+	}
+	return dedicated, nil
+}
+
+// exportVolume creates the dedicated export of the volume called name, with
+// the clients and options of class. It returns the reference of the export.
+func (p *nfsProvisioner) exportVolume(ctx context.Context, class *storage.StorageClass, name, path string) (string, error) {
+	clients := strings.Fields(class.Parameters["exportClients"])
+	if len(clients) == 0 {
+		clients = []string{"*"}
+	}
+	options := class.Parameters["exportOptions"]
+	var ref string
+	err := traced(ctx, "Export", func() (err error) {
+		ref, err = p.exporter.export(ctx, name, path, clients, options)
+		return err
+	}, "path", path)
+	return ref, err
+}
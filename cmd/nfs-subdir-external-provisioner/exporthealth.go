@@ -0,0 +1,146 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+var exportRemounts = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metricsNamespace,
+	Name:      "export_remounts_total",
+	Help:      "Mounts of NFSExports and of the exports of --exports found stale and remounted, by export and result: remounted, deferred while operations use the mount, or failed.",
+}, []string{"export", "result"})
+
+// startHealthChecks checks the mounts of the exports every interval until
+// ctx is done, and remounts those found stale.
+func (m *exportManager) startHealthChecks(ctx context.Context, interval time.Duration) {
+	prometheus.MustRegister(exportRemounts)
+	go wait.UntilWithContext(ctx, m.checkHealth, interval)
+}
+
+// checkHealth probes each mounted export, and remounts those whose probe
+// fails. An export failing to remount is forgotten, to be mounted again by
+// the next volume that needs it.
+func (m *exportManager) checkHealth(ctx context.Context) {
+	for name, b := range m.list() {
+		if err := probeMount(b.mountPath); err != nil {
+			m.remount(ctx, name, b, err)
+		}
+	}
+}
+
+// probeMount checks that an NFS file system is still mounted at mountPath,
+// and can be listed. A stale mount fails with ESTALE, and a hung one blocks
+// until checkExportRoot gives up.
+func probeMount(mountPath string) error {
+	if err := checkNFSMount(mountPath); err != nil {
+		return err
+	}
+	if err := checkExportRoot(mountPath); err != nil && !errors.Is(err, errExportEmpty) {
+		return err
+	}
+	return nil
+}
+
+// checkNFSMount checks that an NFS file system is mounted at mountPath,
+// from the mount table alone. Only the parent of mountPath is resolved,
+// which a hung mount cannot block.
+func checkNFSMount(mountPath string) error {
+	parent, err := filepath.EvalSymlinks(filepath.Dir(mountPath))
+	if err != nil {
+		return err
+	}
+	entry, err := mountAt(mountTable, filepath.Join(parent, filepath.Base(mountPath)))
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(entry.fsType, "nfs") {
+		return fmt.Errorf("%s is a %s mount of %s, not an NFS mount", mountPath, entry.fsType, entry.source)
+	}
+	return nil
+}
+
+// checkMounted refuses to use b when the provisioner mounted it and it is no
+// longer mounted, so that no directory is created in, or found missing from,
+// the empty directory left behind.
+func (b *backend) checkMounted() error {
+	if b.held == nil {
+		return nil
+	}
+	if err := checkNFSMount(b.mountPath); err != nil {
+		return fmt.Errorf("the export %s is not mounted: %v", mountSource(b.server, b.path), err)
+	}
+	return nil
+}
+
+// remount mounts the export called name again, b being the mount of it
+// found stale by reason, unless it was remounted or unmounted meanwhile.
+// While operations still use the stale mount, it is retired instead: new
+// operations are refused, and it is unmounted once the last one finishes,
+// to be mounted again by the next volume that needs it. Unmounting it under
+// them would let them create, or find missing, directories in the empty
+// directory left behind.
+func (m *exportManager) remount(ctx context.Context, name string, b *backend, reason error) {
+	logger := klog.FromContext(ctx).WithValues("export", name, "path", b.mountPath)
+	m.mu.Lock()
+	mounted, ok := m.mounted[name]
+	if !ok || mounted.backend != b || mounted.retired {
+		m.mu.Unlock()
+		return
+	}
+	spec, users := mounted.spec, mounted.users
+	m.retire(ctx, name)
+	m.mu.Unlock()
+
+	var result string
+	var condition metav1.Condition
+	if users > 0 {
+		logger.Info("the mount of the export is stale, remounting it once the operations using it finish", "reason", reason.Error(), "operations", users)
+		result, condition = "deferred", metav1.Condition{Type: conditionReady, Status: metav1.ConditionFalse, Reason: "Stale", Message: fmt.Sprintf("the mount at %s is stale, remounted once the %d operations using it finish: %v", b.mountPath, users, reason)}
+	} else {
+		logger.Info("the mount of the export is stale, remounting it", "reason", reason.Error())
+		remounted, _, err := m.mount(ctx, name, spec)
+		remounted.release()
+		result, condition = "remounted", metav1.Condition{Type: conditionReady, Status: metav1.ConditionTrue, Reason: "Remounted", Message: fmt.Sprintf("remounted at %s, the mount was stale: %v", b.mountPath, reason)}
+		if err != nil {
+			logger.Error(err, "failed to remount the export")
+			result, condition = "failed", metav1.Condition{Type: conditionReady, Status: metav1.ConditionFalse, Reason: "RemountFailed", Message: err.Error()}
+		}
+	}
+	exportRemounts.WithLabelValues(name, result).Inc()
+	if _, static := m.static[name]; static || m.lister == nil || strings.HasPrefix(name, ".") {
+		return
+	}
+	u, _, err := m.object(name)
+	if err == nil {
+		err = setStatusConditions(ctx, m.client, u, condition)
+	}
+	if err != nil {
+		logger.Error(err, "failed to update NFSExport status")
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRemountWaitsForUsers(t *testing.T) {
+	ctx := context.Background()
+	m := &exportManager{root: t.TempDir(), mounted: map[string]*mountedExport{}}
+	b := fakeMount(m, "fast", 2)
+
+	m.remount(ctx, "fast", b, errors.New("stale file handle"))
+	mounted, ok := m.mounted["fast"]
+	if !ok {
+		t.Fatalf("a stale mount in use was unmounted")
+	}
+	if !mounted.retired {
+		t.Errorf("a stale mount in use still takes new operations")
+	}
+	if _, _, err := m.mount(ctx, "fast", mounted.spec); err == nil {
+		t.Errorf("a new operation was given the stale mount")
+	}
+
+	// A second probe of the same mount changes nothing.
+	m.remount(ctx, "fast", b, errors.New("stale file handle"))
+	b.release()
+	if _, ok := m.mounted["fast"]; !ok {
+		t.Fatalf("unmounted with an operation still using it")
+	}
+	b.release()
+	if _, ok := m.mounted["fast"]; ok {
+		t.Errorf("still mounted once the operations finished")
+	}
+}
+
+func TestCheckMounted(t *testing.T) {
+	m := &exportManager{root: t.TempDir(), mounted: map[string]*mountedExport{}}
+	tests := []struct {
+		name    string
+		backend *backend
+		wantErr bool
+	}{
+		{name: "export not mounted by the provisioner", backend: &backend{mountPath: t.TempDir()}},
+		{name: "unmounted export", backend: fakeMount(m, "fast", 1), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.backend.checkMounted(); (err != nil) != tt.wantErr {
+				t.Errorf("checkMounted() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseStaticExports(t *testing.T) {
+	tests := []struct {
+		list    string
+		want    map[string]nfsExportSpec
+		wantErr bool
+	}{
+		{list: "", want: map[string]nfsExportSpec{}},
+		{
+			list: "fast=10.0.0.2:/exports/fast?nfsvers=4.1&hard, bulk=[fd00::2]:/bulk",
+			want: map[string]nfsExportSpec{
+				"fast": {Server: "10.0.0.2", Path: "/exports/fast", MountOptions: []string{"nfsvers=4.1", "hard"}},
+				"bulk": {Server: "fd00::2", Path: "/bulk"},
+			},
+		},
+		{list: "a.b=filer:/x?", want: map[string]nfsExportSpec{"a.b": {Server: "filer", Path: "/x"}}},
+		{list: "fast", wantErr: true},
+		{list: "Fast=filer:/x", wantErr: true},
+		{list: ".secret=filer:/x", wantErr: true},
+		{list: "fast=filer", wantErr: true},
+		{list: "fast=filer:relative", wantErr: true},
+		{list: "fast=:/x", wantErr: true},
+		{list: "fast=filer:/x,fast=filer:/y", wantErr: true},
+		{list: "fast=filer:/x?xprtsec=maybe", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.list, func(t *testing.T) {
+			got, err := parseStaticExports(tt.list)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseStaticExports() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseStaticExports() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
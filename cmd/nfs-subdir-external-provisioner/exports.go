@@ -0,0 +1,516 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/sig-storage-lib-external-provisioner/v10/controller"
+)
+
+const (
+	conditionReady = "Ready"
+)
+
+var exportResource = schema.GroupVersionResource{Group: apiGroup, Version: "v1alpha1", Resource: "nfsexports"}
+
+// nfsExportSpec is the spec of an NFSExport object.
+type nfsExportSpec struct {
+	Server       string             `json:"server"`
+	Path         string             `json:"path"`
+	MountOptions []string           `json:"mountOptions,omitempty"`
+	Capacity     *resource.Quantity `json:"capacity,omitempty"`
+	NodeAffinity string             `json:"nodeAffinity,omitempty"`
+}
+
+// backend is an NFS export and the local directory it is mounted at.
+// capacity is the size hint of its NFSExport, if any.
+type backend struct {
+	server    string
+	path      string
+	mountPath string
+	capacity  *resource.Quantity
+	// mountOptions are the options the provisioner mounted the export
+	// with, if it mounted it.
+	mountOptions []string
+	// nodeAffinity selects the nodes able to reach the export, all of them
+	// when empty.
+	nodeAffinity string
+	// held is the mount of b when the provisioner mounted it, which keeps
+	// it mounted until release is called.
+	held *mountedExport
+}
+
+// release ends the use of b by an operation, begun by the backendFor
+// functions, so that the export can be unmounted once it changes or goes
+// away. It does nothing for exports the provisioner did not mount.
+func (b *backend) release() {
+	if b == nil || b.held == nil {
+		return
+	}
+	e := b.held
+	e.manager.mu.Lock()
+	defer e.manager.mu.Unlock()
+	e.users--
+	if e.users == 0 && e.retired {
+		e.manager.unmount(context.Background(), e.name)
+	}
+}
+
+// localPath returns where path, a path on the export, is mounted locally.
+func (b *backend) localPath(path string) string {
+	return strings.Replace(path, b.path, b.mountPath, 1)
+}
+
+// checkCapacity refuses claims that could never fit on the export.
+func (b *backend) checkCapacity(claim *v1.PersistentVolumeClaim) error {
+	if b.capacity == nil {
+		return nil
+	}
+	request := claim.Spec.Resources.Requests[v1.ResourceStorage]
+	if request.Cmp(*b.capacity) > 0 {
+		return fmt.Errorf("claim requests %s, more than the %s capacity of its NFSExport", request.String(), b.capacity.String())
+	}
+	return nil
+}
+
+// mountedExport is an NFSExport the provisioner has mounted.
+type mountedExport struct {
+	manager *exportManager
+	name    string
+	spec    nfsExportSpec
+	backend *backend
+	// users counts the operations using the mount. A retired mount takes
+	// no new users, and is unmounted when the last one releases it.
+	users   int
+	retired bool
+}
+
+// exportManager mounts NFSExports, and the exports of --exports, the first
+// time a volume needs them and unmounts them when they change or go away.
+// It remounts those whose mount goes stale, see startHealthChecks.
+type exportManager struct {
+	client dynamic.ResourceInterface
+	lister cache.GenericLister
+	root   string
+	// static are the exports of --exports, by name. They hide the
+	// NFSExports of the same name.
+	static map[string]nfsExportSpec
+
+	mu      sync.Mutex
+	mounted map[string]*mountedExport
+}
+
+// newExportManager mounts the exports of static, and NFSExports unless
+// client is nil, under root.
+func newExportManager(ctx context.Context, client dynamic.Interface, root string, static map[string]nfsExportSpec) (*exportManager, error) {
+	m := &exportManager{
+		root:    root,
+		static:  static,
+		mounted: map[string]*mountedExport{},
+	}
+	if client == nil {
+		return m, nil
+	}
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(client, controller.DefaultResyncPeriod)
+	informer := factory.ForResource(exportResource)
+	m.client = client.Resource(exportResource)
+	m.lister = informer.Lister()
+
+	_, err := informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(_, obj interface{}) {
+			u, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				return
+			}
+			if _, ok := m.static[u.GetName()]; ok {
+				return
+			}
+			spec, err := parseExportSpec(u)
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			if mounted, ok := m.mounted[u.GetName()]; ok && (err != nil || !equality.Semantic.DeepEqual(mounted.spec, spec)) {
+				// Mounted again with the new spec by the next volume
+				// that needs it.
+				m.retire(ctx, u.GetName())
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			u, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				return
+			}
+			if _, ok := m.static[u.GetName()]; ok {
+				return
+			}
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			m.retire(ctx, u.GetName())
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+	return m, nil
+}
+
+func parseExportSpec(obj *unstructured.Unstructured) (nfsExportSpec, error) {
+	var spec nfsExportSpec
+	raw, found, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil || !found {
+		return spec, fmt.Errorf("NFSExport %s has no spec", obj.GetName())
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &spec); err != nil {
+		return spec, fmt.Errorf("invalid NFSExport %s: %v", obj.GetName(), err)
+	}
+	if spec.Server == "" || !filepath.IsAbs(spec.Path) {
+		return spec, fmt.Errorf("NFSExport %s needs a server and an absolute path", obj.GetName())
+	}
+	if spec.Server, err = canonicalServer(spec.Server); err != nil {
+		return spec, fmt.Errorf("NFSExport %s: %v", obj.GetName(), err)
+	}
+	if _, err := labels.Parse(spec.NodeAffinity); err != nil {
+		return spec, fmt.Errorf("NFSExport %s has an invalid nodeAffinity: %v", obj.GetName(), err)
+	}
+	return spec, nil
+}
+
+// parseStaticExports parses the comma separated name=server:/path entries
+// of --exports, each optionally followed by mount options separated by &,
+// e.g. fast=10.0.0.2:/exports/fast?nfsvers=4.1&hard.
+func parseStaticExports(list string) (map[string]nfsExportSpec, error) {
+	exports := map[string]nfsExportSpec{}
+	for _, item := range splitList(list) {
+		name, source, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not name=server:/path", item)
+		}
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return nil, fmt.Errorf("export name %q is invalid: %s", name, strings.Join(errs, ", "))
+		}
+		if _, ok := exports[name]; ok {
+			return nil, fmt.Errorf("export %s is given twice", name)
+		}
+		source, options, _ := strings.Cut(source, "?")
+		server, exportPath, ok := splitMountSource(source)
+		if !ok || server == "" || !filepath.IsAbs(exportPath) {
+			return nil, fmt.Errorf("export %s needs a server and an absolute path, as server:/path", name)
+		}
+		spec := nfsExportSpec{Path: exportPath}
+		var err error
+		if spec.Server, err = canonicalServer(server); err != nil {
+			return nil, fmt.Errorf("export %s: %v", name, err)
+		}
+		for _, option := range strings.Split(options, "&") {
+			if option != "" {
+				spec.MountOptions = append(spec.MountOptions, option)
+			}
+		}
+		if err := checkXprtsec(spec.MountOptions); err != nil {
+			return nil, fmt.Errorf("export %s: %v", name, err)
+		}
+		exports[name] = spec
+	}
+	return exports, nil
+}
+
+// object returns the NFSExport called name and its spec, or the spec of the
+// export of --exports called name and no object.
+func (m *exportManager) object(name string) (*unstructured.Unstructured, nfsExportSpec, error) {
+	if spec, ok := m.static[name]; ok {
+		return nil, spec, nil
+	}
+	if m.lister == nil {
+		return nil, nfsExportSpec{}, fmt.Errorf("export %s is not in --exports, and NFSExports are disabled", name)
+	}
+	obj, err := m.lister.Get(name)
+	if err != nil {
+		return nil, nfsExportSpec{}, fmt.Errorf("unable to get NFSExport %s: %v", name, err)
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, nfsExportSpec{}, fmt.Errorf("unexpected object for NFSExport %s", name)
+	}
+	spec, err := parseExportSpec(u)
+	return u, spec, err
+}
+
+// get returns the NFSExport called name, mounting it if needed.
+func (m *exportManager) get(ctx context.Context, name string) (*backend, error) {
+	u, spec, err := m.object(name)
+	if err != nil {
+		return nil, err
+	}
+
+	b, mounted, err := m.mount(ctx, name, spec)
+	if !mounted {
+		return b, err
+	}
+	if u == nil {
+		if err != nil {
+			return nil, err
+		}
+		return b, nil
+	}
+	condition := metav1.Condition{Type: conditionReady, Status: metav1.ConditionTrue, Reason: "Mounted", Message: "mounted at " + b.mountPath}
+	if err != nil {
+		condition = metav1.Condition{Type: conditionReady, Status: metav1.ConditionFalse, Reason: "MountFailed", Message: err.Error()}
+	}
+	if err := setStatusConditions(ctx, m.client, u, condition); err != nil {
+		klog.FromContext(ctx).Error(err, "failed to update NFSExport status", "nfsExport", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// mount returns the export described by spec, mounted under the directory
+// called name, held until its release. It reports whether it had to mount
+// it, successfully or not, rather than reuse the mount made for the same
+// spec earlier. An earlier mount of another spec still in use is refused.
+func (m *exportManager) mount(ctx context.Context, name string, spec nfsExportSpec) (*backend, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if mounted, ok := m.mounted[name]; ok {
+		if !mounted.retired && equality.Semantic.DeepEqual(mounted.spec, spec) {
+			mounted.users++
+			return mounted.backend, false, nil
+		}
+		m.retire(ctx, name)
+		if mounted.users > 0 {
+			return nil, false, fmt.Errorf("export %s is being unmounted, %d operations still use it", name, mounted.users)
+		}
+	}
+
+	b := &backend{server: spec.Server, path: spec.Path, mountPath: m.mountPath(name), capacity: spec.Capacity, mountOptions: spec.MountOptions, nodeAffinity: spec.NodeAffinity}
+	err := checkXprtsec(spec.MountOptions)
+	if err == nil {
+		err = os.MkdirAll(b.mountPath, 0o755)
+	}
+	if err == nil {
+		err = mountNFS(ctx, spec.Server, spec.Path, b.mountPath, spec.MountOptions)
+	}
+	if err != nil {
+		return b, true, err
+	}
+	b.held = &mountedExport{manager: m, name: name, spec: spec, backend: b, users: 1}
+	m.mounted[name] = b.held
+	return b, true, nil
+}
+
+// find returns the NFSExport holding path on server. When several exports
+// match, the most specific one wins.
+func (m *exportManager) find(ctx context.Context, server, path string) (*backend, error) {
+	specs, err := m.specs()
+	if err != nil {
+		return nil, err
+	}
+	var best string
+	var bestLen int
+	for name, spec := range specs {
+		if spec.Server != server || !isSubpath(spec.Path, path) {
+			continue
+		}
+		if len(spec.Path) > bestLen || len(spec.Path) == bestLen && name < best {
+			best, bestLen = name, len(spec.Path)
+		}
+	}
+	if best == "" {
+		return nil, fmt.Errorf("no NFSExport holds %s:%s", server, path)
+	}
+	return m.get(ctx, best)
+}
+
+// specs returns the specs of the valid NFSExports and of the exports of
+// --exports, by name.
+func (m *exportManager) specs() (map[string]nfsExportSpec, error) {
+	specs := map[string]nfsExportSpec{}
+	if m.lister != nil {
+		objs, err := m.lister.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objs {
+			u, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+			if spec, err := parseExportSpec(u); err == nil {
+				specs[u.GetName()] = spec
+			}
+		}
+	}
+	for name, spec := range m.static {
+		specs[name] = spec
+	}
+	return specs, nil
+}
+
+// list returns the NFSExports mounted at the moment, by name, leaving out
+// those waiting to be unmounted.
+func (m *exportManager) list() map[string]*backend {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	backends := make(map[string]*backend, len(m.mounted))
+	for name, mounted := range m.mounted {
+		if !mounted.retired {
+			backends[name] = mounted.backend
+		}
+	}
+	return backends
+}
+
+// mountPath returns where the export called name is mounted.
+func (m *exportManager) mountPath(name string) string {
+	return filepath.Join(m.root, name)
+}
+
+// retire refuses new operations on the mount of the NFSExport called name,
+// and unmounts it once the operations using it release it, right away when
+// none does. m.mu must be held.
+func (m *exportManager) retire(ctx context.Context, name string) {
+	mounted, ok := m.mounted[name]
+	if !ok {
+		return
+	}
+	mounted.retired = true
+	if mounted.users == 0 {
+		m.unmount(ctx, name)
+		return
+	}
+	klog.FromContext(ctx).Info("unmounting the export once the operations using it finish", "nfsExport", name, "operations", mounted.users)
+}
+
+// unmount forgets the mount of the NFSExport called name. m.mu must be held.
+func (m *exportManager) unmount(ctx context.Context, name string) {
+	mounted, ok := m.mounted[name]
+	if !ok {
+		return
+	}
+	delete(m.mounted, name)
+	if err := unmountNFS(mounted.backend.mountPath); err != nil {
+		klog.FromContext(ctx).Error(err, "failed to unmount NFSExport", "nfsExport", name)
+	}
+}
+
+// isSubpath reports whether path is root or below it.
+func isSubpath(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+// defaultBackend returns the export given on the command line.
+func (p *nfsProvisioner) defaultBackend() *backend {
+	return &backend{server: p.server, path: p.path, mountPath: p.mountPath, mountOptions: p.mountOpts}
+}
+
+// mountedBackends returns the default export and the NFSExports mounted at
+// the moment, by NFSExport name, the default export being "".
+func (p *nfsProvisioner) mountedBackends() map[string]*backend {
+	backends := map[string]*backend{"": p.defaultBackend()}
+	if p.exports != nil {
+		for name, b := range p.exports.list() {
+			backends[name] = b
+		}
+	}
+	return backends
+}
+
+// backendForClass returns the export the volume of options is provisioned
+// on: the one described by the backend Secret of its class, one of the
+// NFSExports named by its export parameter, or the default export. The
+// caller releases it once done with it.
+func (p *nfsProvisioner) backendForClass(ctx context.Context, options controller.ProvisionOptions) (*backend, error) {
+	class := options.StorageClass
+	if name := class.Parameters["backendSecretName"]; name != "" {
+		namespace := class.Parameters["backendSecretNamespace"]
+		if namespace == "" {
+			return nil, fmt.Errorf("StorageClass %s sets backendSecretName, but not backendSecretNamespace", class.Name)
+		}
+		return p.backendForSecret(ctx, namespace, name)
+	}
+	name := class.Parameters["export"]
+	if name == "" {
+		return p.defaultBackend(), nil
+	}
+	if p.exports == nil {
+		return nil, fmt.Errorf("StorageClass %s uses NFSExport %s, but NFSExports are disabled", class.Name, name)
+	}
+	if names := splitList(name); len(names) > 1 {
+		return p.placeVolume(ctx, names, options)
+	}
+	return p.exports.get(ctx, strings.TrimSpace(name))
+}
+
+// backendForVolume returns the export holding the directory of volume. The
+// caller releases it once done with it.
+func (p *nfsProvisioner) backendForVolume(ctx context.Context, volume *v1.PersistentVolume) (*backend, error) {
+	source := volume.Spec.PersistentVolumeSource.NFS
+	if ref := volume.Annotations[annBackendSecret]; ref != "" {
+		namespace, name, _ := strings.Cut(ref, "/")
+		return p.backendForSecret(ctx, namespace, name)
+	}
+	// PVs made before servers were canonicalized may bracket IPv6 addresses
+	// or spell hostnames differently.
+	server, err := canonicalServer(source.Server)
+	if err != nil {
+		server = source.Server
+	}
+	if declared := volume.Annotations[annExportServer]; declared != "" {
+		server = declared
+	}
+	b := p.defaultBackend()
+	if server == b.server && isSubpath(b.path, source.Path) {
+		return b, nil
+	}
+	if p.exports != nil {
+		if e, err := p.exports.find(ctx, server, source.Path); err == nil {
+			return e, nil
+		}
+	}
+	// Volumes adopted from the upstream provisioner, or provisioned before
+	// a change of --nfs-server, may name the server differently. The path is
+	// what locates the directory.
+	if isSubpath(b.path, source.Path) {
+		return b, nil
+	}
+	return nil, fmt.Errorf("%s:%s is not on an export of this provisioner", source.Server, source.Path)
+}
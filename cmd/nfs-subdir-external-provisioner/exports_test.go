@@ -0,0 +1,116 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+// fakeMount records a mount of the export called name with users users, as
+// mount would after mounting it.
+func fakeMount(m *exportManager, name string, users int) *backend {
+	spec := nfsExportSpec{Server: "10.0.0.2", Path: "/exports/" + name}
+	b := &backend{server: spec.Server, path: spec.Path, mountPath: m.mountPath(name)}
+	b.held = &mountedExport{manager: m, name: name, spec: spec, backend: b, users: users}
+	m.mounted[name] = b.held
+	return b
+}
+
+func TestRetireWaitsForUsers(t *testing.T) {
+	tests := []struct {
+		name  string
+		users int
+		// releases are the releases after retire, before which the mount
+		// is expected to stay.
+		releases int
+	}{
+		{name: "unused", users: 0, releases: 0},
+		{name: "one user", users: 1, releases: 1},
+		{name: "several users", users: 3, releases: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m := &exportManager{root: t.TempDir(), mounted: map[string]*mountedExport{}}
+			b := fakeMount(m, "fast", tt.users)
+
+			m.mu.Lock()
+			m.retire(ctx, "fast")
+			m.mu.Unlock()
+			for i := 0; i < tt.releases; i++ {
+				if _, ok := m.mounted["fast"]; !ok {
+					t.Fatalf("unmounted with %d users left", tt.releases-i)
+				}
+				if _, listed := m.list()["fast"]; listed {
+					t.Fatalf("a retired mount is listed")
+				}
+				b.release()
+			}
+			if _, ok := m.mounted["fast"]; ok {
+				t.Errorf("still mounted once every user released it")
+			}
+		})
+	}
+}
+
+func TestMountRefusesRetiredExport(t *testing.T) {
+	ctx := context.Background()
+	m := &exportManager{root: t.TempDir(), mounted: map[string]*mountedExport{}}
+	b := fakeMount(m, "fast", 1)
+	m.mu.Lock()
+	m.retire(ctx, "fast")
+	m.mu.Unlock()
+
+	for _, spec := range []nfsExportSpec{b.held.spec, {Server: "10.0.0.3", Path: "/exports/fast"}} {
+		got, _, err := m.mount(ctx, "fast", spec)
+		if err == nil {
+			t.Errorf("mount of %v succeeded while the retired mount is in use", spec)
+		}
+		if got != nil {
+			t.Errorf("mount of %v returned a backend on error", spec)
+		}
+	}
+	if users := b.held.users; users != 1 {
+		t.Errorf("users = %d after refused mounts, want 1", users)
+	}
+}
+
+func TestMountReusesHeldExport(t *testing.T) {
+	ctx := context.Background()
+	m := &exportManager{root: t.TempDir(), mounted: map[string]*mountedExport{}}
+	b := fakeMount(m, "fast", 1)
+
+	got, mounted, err := m.mount(ctx, "fast", b.held.spec)
+	if err != nil || mounted || got != b {
+		t.Fatalf("mount() = %v, %v, %v, want the existing backend", got, mounted, err)
+	}
+	if users := b.held.users; users != 2 {
+		t.Errorf("users = %d, want 2", users)
+	}
+	got.release()
+	b.release()
+	if _, ok := m.mounted["fast"]; !ok {
+		t.Errorf("an export that was not retired was unmounted")
+	}
+}
+
+func TestReleaseOfUnmanagedBackend(t *testing.T) {
+	var nilBackend *backend
+	nilBackend.release()
+	(&backend{mountPath: "/persistentvolumes"}).release()
+}
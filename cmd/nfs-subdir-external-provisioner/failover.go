@@ -0,0 +1,233 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	storagehelpers "k8s.io/component-helpers/storage/volume"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// annExportServer records the server an export is declared with on the
+	// PVs that name another of its servers, to find their export.
+	annExportServer = "nfs.io/export-server"
+	// annRepointedFrom records on a repointed PV the server it named before.
+	annRepointedFrom = "nfs.io/repointed-from"
+
+	repointInterval = time.Minute
+)
+
+// splitList splits a comma separated list, dropping empty elements.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// serversFor returns the servers of the export b, for volumes of class, in
+// order of preference: the servers parameter of class, or else the server of
+// b followed, for the default export, by --nfs-failover-servers.
+func (p *nfsProvisioner) serversFor(class *storage.StorageClass, b *backend) ([]string, error) {
+	if class != nil {
+		servers, err := serverList(class.Parameters["servers"])
+		if err != nil || len(servers) > 0 {
+			return servers, err
+		}
+	}
+	servers := []string{b.server}
+	if b.mountPath == p.mountPath {
+		failover, _ := serverList(p.settings().FailoverServers)
+		servers = append(servers, failover...)
+	}
+	return servers, nil
+}
+
+// decommissioned returns the set of --decommissioned-servers.
+func (p *nfsProvisioner) decommissioned() map[string]bool {
+	servers, _ := serverList(p.settings().DecommissionedServers)
+	set := map[string]bool{}
+	for _, server := range servers {
+		set[server] = true
+	}
+	return set
+}
+
+// preferredServer returns the first of servers that is not decommissioned.
+func (p *nfsProvisioner) preferredServer(servers []string) (string, error) {
+	decommissioned := p.decommissioned()
+	for _, server := range servers {
+		if !decommissioned[server] {
+			return server, nil
+		}
+	}
+	return "", fmt.Errorf("every server of the export is decommissioned: %s", strings.Join(servers, ", "))
+}
+
+// startRepointing rewrites, every repointInterval until ctx is done, the PVs
+// naming a decommissioned server to the preferred surviving server of their
+// export.
+func (p *nfsProvisioner) startRepointing(ctx context.Context, volumes cache.SharedIndexInformer) {
+	logger := klog.FromContext(ctx)
+	go func() {
+		if !cache.WaitForCacheSync(ctx.Done(), volumes.HasSynced) {
+			return
+		}
+		wait.UntilWithContext(ctx, func(ctx context.Context) {
+			decommissioned := p.decommissioned()
+			if len(decommissioned) == 0 {
+				return
+			}
+			list, err := p.volumes.List(labels.Everything())
+			if err != nil {
+				logger.Error(err, "failed to list volumes to repoint")
+				return
+			}
+			for _, volume := range list {
+				if volume.DeletionTimestamp != nil || volume.Spec.NFS == nil || !p.owns(volume.Annotations[annProvisionedBy]) || !p.inShard(volume) {
+					continue
+				}
+				server, err := canonicalServer(volume.Spec.NFS.Server)
+				if err != nil {
+					server = volume.Spec.NFS.Server
+				}
+				if !decommissioned[server] && !decommissioned[volume.Annotations[annServerHostname]] {
+					continue
+				}
+				if err := p.repoint(ctx, volume); err != nil {
+					logger.Error(err, "failed to repoint volume", "pv", volume.Name, "server", volume.Spec.NFS.Server)
+				}
+			}
+		}, repointInterval)
+	}()
+}
+
+// repoint replaces volume by a copy naming the preferred server of its
+// export. The source of a PV cannot be changed, so the PV is deleted and
+// created again with the same name and claim, which binds the claim again.
+// Running pods keep their mounts; new mounts use the new server.
+func (p *nfsProvisioner) repoint(ctx context.Context, volume *v1.PersistentVolume) error {
+	logger := klog.FromContext(ctx)
+
+	b, err := p.backendForVolume(ctx, volume)
+	if err != nil {
+		return err
+	}
+	defer b.release()
+	var class *storage.StorageClass
+	if name := storagehelpers.GetPersistentVolumeClass(volume); name != "" {
+		class, err = p.classes.Get(name)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	servers, err := p.serversFor(class, b)
+	if err != nil {
+		return err
+	}
+	server, err := p.preferredServer(servers)
+	if err != nil {
+		return err
+	}
+	pvServer, serverAnnotations, err := resolveServer(ctx, class, server)
+	if err != nil {
+		return err
+	}
+
+	replacement := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        volume.Name,
+			Labels:      volume.Labels,
+			Annotations: map[string]string{},
+			Finalizers:  volume.Finalizers,
+		},
+		Spec: *volume.Spec.DeepCopy(),
+	}
+	for k, v := range volume.Annotations {
+		replacement.Annotations[k] = v
+	}
+	replacement.Annotations[annRepointedFrom] = volume.Spec.NFS.Server
+	delete(replacement.Annotations, annExportServer)
+	delete(replacement.Annotations, annServerHostname)
+	delete(replacement.Annotations, annServerAddresses)
+	if pvServer != b.server {
+		replacement.Annotations[annExportServer] = b.server
+	}
+	for k, v := range serverAnnotations {
+		replacement.Annotations[k] = v
+	}
+	replacement.Spec.NFS.Server = pvServer
+	if ref := replacement.Spec.ClaimRef; ref != nil {
+		ref.ResourceVersion = ""
+	}
+
+	// Whatever happens next, deleting the PV object must never look like a
+	// reason to reclaim the directory.
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"finalizers": nil, "resourceVersion": volume.ResourceVersion},
+		"spec":     map[string]interface{}{"persistentVolumeReclaimPolicy": v1.PersistentVolumeReclaimRetain},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := p.client.CoreV1().PersistentVolumes().Patch(ctx, volume.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return err
+	}
+	logger.Info("repointing volume", "pv", volume.Name, "from", volume.Spec.NFS.Server, "to", pvServer)
+	uid := volume.UID
+	err = p.client.CoreV1().PersistentVolumes().Delete(ctx, volume.Name, metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &uid}})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	// The old PV is gone: insist on creating the replacement, and leave
+	// its manifest in the log if that fails.
+	err = wait.ExponentialBackoffWithContext(ctx, wait.Backoff{Duration: time.Second, Factor: 2, Steps: 8, Cap: time.Minute}, func(ctx context.Context) (bool, error) {
+		_, err := p.client.CoreV1().PersistentVolumes().Create(ctx, replacement, metav1.CreateOptions{})
+		switch {
+		case err == nil:
+			return true, nil
+		case apierrors.IsAlreadyExists(err):
+			// The old PV is still being deleted.
+			return false, nil
+		}
+		logger.Error(err, "failed to create repointed volume, retrying", "pv", replacement.Name)
+		return false, nil
+	})
+	if err != nil {
+		manifest, _ := json.Marshal(replacement)
+		return fmt.Errorf("PV %s was deleted but could not be created again, create it from %s: %v", volume.Name, manifest, err)
+	}
+	return nil
+}
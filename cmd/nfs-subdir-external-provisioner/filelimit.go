@@ -0,0 +1,114 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// annMaxFiles records on a PV the maxFiles parameter of its class, the
+	// number of files and directories its volume may hold.
+	annMaxFiles = "nfs.io/max-files"
+	// annProjectID records on a PV the XFS project whose inode limit
+	// enforces annMaxFiles on the server.
+	annProjectID = "nfs.io/project-id"
+)
+
+// fileLimiter is implemented by the exporters that can limit the number of
+// files of a volume on the server itself, with XFS project quotas.
+type fileLimiter interface {
+	// limitFiles makes path the directory of project and limits it to files
+	// inodes, or lifts the limit of project if files is 0.
+	limitFiles(ctx context.Context, path string, project uint32, files int64) error
+}
+
+// maxFiles returns the maxFiles parameter of class, 0 if it sets none.
+func maxFiles(class *storage.StorageClass) (int64, error) {
+	value := class.Parameters["maxFiles"]
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid maxFiles %q, must be a positive number", value)
+	}
+	return n, nil
+}
+
+// projectID returns the XFS project of the volume called pvName, derived
+// from its name, never 0, which is the default project.
+func projectID(pvName string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(pvName))
+	return h.Sum32()&0x7fffffff | 1
+}
+
+// xfsLimitFiles runs xfs_quota over ssh to implement fileLimiter.limitFiles
+// on the file system holding path.
+func xfsLimitFiles(ctx context.Context, ssh *sshClient, path string, project uint32, files int64) error {
+	if strings.ContainsAny(path, "'\n") {
+		return fmt.Errorf("unable to limit %q", path)
+	}
+	commands := fmt.Sprintf("-c 'limit -p ihard=%d %d'", files, project)
+	if files > 0 {
+		commands = fmt.Sprintf("-c 'project -s -p %s %d' ", path, project) + commands
+	}
+	_, err := ssh.run(ctx, fmt.Sprintf(`mnt=$(df --output=target '%s' | tail -n 1) && xfs_quota -x %s "$mnt"`, path, commands))
+	return err
+}
+
+func (c *exportfsClient) limitFiles(ctx context.Context, path string, project uint32, files int64) error {
+	return xfsLimitFiles(ctx, c.ssh, path, project, files)
+}
+
+func (c *ganeshaClient) limitFiles(ctx context.Context, path string, project uint32, files int64) error {
+	return xfsLimitFiles(ctx, c.ssh, path, project, files)
+}
+
+// checkFileLimit records an event on the claim of the volume measured in u
+// when it goes over its file limit, or back under it, since previous.
+func (s *usageScanner) checkFileLimit(ctx context.Context, u volumeUsage, previous volumeUsage, measured bool) {
+	if u.FileLimit == 0 {
+		return
+	}
+	over := u.Files > u.FileLimit
+	if measured && over == (previous.FileLimit > 0 && previous.Files > previous.FileLimit) {
+		return
+	}
+	if !over && !measured {
+		return
+	}
+	claim, err := s.p.claims.PersistentVolumeClaims(u.Namespace).Get(u.PVC)
+	if err != nil {
+		klog.FromContext(ctx).V(2).Info("no claim to report the file limit of a volume to", "pv", u.PV, "err", err)
+		return
+	}
+	if over {
+		s.p.recorder.Eventf(claim, v1.EventTypeWarning, "FileLimitExceeded", "The volume holds %d files and directories, over its limit of %d", u.Files, u.FileLimit)
+	} else {
+		s.p.recorder.Eventf(claim, v1.EventTypeNormal, "FileLimitRestored", "The volume holds %d files and directories, within its limit of %d", u.Files, u.FileLimit)
+	}
+}
@@ -0,0 +1,252 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+// annClassFull marks a StorageClass whose exports are all short of free
+// space. Its claims wait until the mark is removed.
+const annClassFull = "nfs.io/full"
+
+var (
+	exportSizeDesc = prometheus.NewDesc(metricsNamespace+"_export_size_bytes",
+		"Size of the file system of an export.",
+		[]string{"export", "server", "path"}, nil)
+	exportFreeDesc = prometheus.NewDesc(metricsNamespace+"_export_free_bytes",
+		"Space left on the file system of an export for unprivileged users.",
+		[]string{"export", "server", "path"}, nil)
+)
+
+// exportSpaceStatus is the last measure of the file system of an export.
+type exportSpaceStatus struct {
+	server, path string
+	size, free   uint64
+}
+
+// spaceMonitor measures the free space of the exports of the provisioner,
+// and warns when it falls below threshold percent of their size.
+type spaceMonitor struct {
+	p           *nfsProvisioner
+	threshold   int
+	markClasses bool
+
+	mu    sync.Mutex
+	space map[string]exportSpaceStatus
+	// low are the exports below the threshold, by NFSExport name, the
+	// default export being "".
+	low map[string]bool
+}
+
+// startSpaceMonitor measures the exports every interval until ctx is done.
+// The default export is always measured, NFSExports while they are mounted.
+func (p *nfsProvisioner) startSpaceMonitor(ctx context.Context, interval time.Duration, threshold int, markClasses bool) *spaceMonitor {
+	m := &spaceMonitor{
+		p:           p,
+		threshold:   threshold,
+		markClasses: markClasses,
+		space:       map[string]exportSpaceStatus{},
+		low:         map[string]bool{},
+	}
+	prometheus.MustRegister(m)
+	go wait.UntilWithContext(ctx, m.check, interval)
+	return m
+}
+
+// statExport measures the file system mounted at target. A hung NFS mount
+// blocks forever, so it gives up after backendProbeTimeout.
+func statExport(target string) (uint64, uint64, error) {
+	type result struct {
+		size, free uint64
+		err        error
+	}
+	done := make(chan result, 1)
+	go func() {
+		size, free, err := exportSpace(target)
+		done <- result{size, free, err}
+	}()
+	select {
+	case r := <-done:
+		return r.size, r.free, r.err
+	case <-time.After(backendProbeTimeout):
+		return 0, 0, fmt.Errorf("statfs of %s timed out after %s", target, backendProbeTimeout)
+	}
+}
+
+func (m *spaceMonitor) check(ctx context.Context) {
+	logger := klog.FromContext(ctx)
+	backends := m.p.mountedBackends()
+	m.mu.Lock()
+	previous := m.low
+	m.mu.Unlock()
+	space := map[string]exportSpaceStatus{}
+	low := map[string]bool{}
+	for name, b := range backends {
+		size, free, err := statExport(b.mountPath)
+		if err != nil {
+			logger.Error(err, "failed to measure the free space of export", "export", name, "path", b.mountPath)
+			low[name] = previous[name]
+			continue
+		}
+		space[name] = exportSpaceStatus{server: b.server, path: b.path, size: size, free: free}
+		low[name] = size > 0 && free*100 < size*uint64(m.threshold)
+		if low[name] == previous[name] {
+			continue
+		}
+		source := mountSource(b.server, b.path)
+		if low[name] {
+			logger.Info("export is short of free space", "export", source, "free", free, "size", size)
+			message := fmt.Sprintf("Export %s has %d%% of its space free, below the %d%% threshold", source, free*100/size, m.threshold)
+			m.event(name, v1.EventTypeWarning, "ExportLowOnSpace", message)
+			m.p.notify(notification{Event: notifyLowSpace, Export: source, Message: message}, "")
+		} else {
+			logger.Info("export has free space again", "export", source, "free", free, "size", size)
+			m.event(name, v1.EventTypeNormal, "ExportLowOnSpace", fmt.Sprintf("Export %s has %d%% of its space free again", source, free*100/size))
+		}
+	}
+	m.mu.Lock()
+	m.space, m.low = space, low
+	m.mu.Unlock()
+
+	if m.markClasses {
+		m.markFullClasses(ctx, low)
+	}
+}
+
+// lowExports returns the exports below the threshold as of the last check,
+// sorted.
+func (m *spaceMonitor) lowExports() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var exports []string
+	for name, low := range m.low {
+		if !low {
+			continue
+		}
+		if s, ok := m.space[name]; ok {
+			exports = append(exports, mountSource(s.server, s.path))
+		} else if name == "" {
+			exports = append(exports, mountSource(m.p.server, m.p.path))
+		} else {
+			exports = append(exports, name)
+		}
+	}
+	sort.Strings(exports)
+	return exports
+}
+
+// classExports returns the names of the NFSExports the volumes of class may
+// be provisioned on, "" standing for the default export. Classes on the
+// export of a backend Secret are not monitored.
+func classExports(class *storage.StorageClass) []string {
+	if class.Parameters["backendSecretName"] != "" {
+		return nil
+	}
+	names := splitList(class.Parameters["export"])
+	if len(names) == 0 {
+		return []string{""}
+	}
+	for i := range names {
+		names[i] = strings.TrimSpace(names[i])
+	}
+	return names
+}
+
+// classes returns the StorageClasses of the provisioner.
+func (m *spaceMonitor) classes() []*storage.StorageClass {
+	all, _ := m.p.classes.List(labels.Everything())
+	var classes []*storage.StorageClass
+	for _, class := range all {
+		if m.p.owns(class.Provisioner) {
+			classes = append(classes, class)
+		}
+	}
+	return classes
+}
+
+// event records an event on the StorageClasses provisioning on the export
+// called name.
+func (m *spaceMonitor) event(name, eventType, reason, message string) {
+	for _, class := range m.classes() {
+		for _, export := range classExports(class) {
+			if export == name {
+				m.p.recorder.Event(class, eventType, reason, message)
+				break
+			}
+		}
+	}
+}
+
+// markFullClasses sets annClassFull on the StorageClasses whose exports are
+// all low, and removes it from the others.
+func (m *spaceMonitor) markFullClasses(ctx context.Context, low map[string]bool) {
+	logger := klog.FromContext(ctx)
+	for _, class := range m.classes() {
+		exports := classExports(class)
+		full := len(exports) > 0
+		for _, name := range exports {
+			full = full && low[name]
+		}
+		_, marked := class.Annotations[annClassFull]
+		if full == marked {
+			continue
+		}
+		var value interface{}
+		if full {
+			value = "true"
+		}
+		patch, err := json.Marshal(map[string]interface{}{"metadata": map[string]interface{}{"annotations": map[string]interface{}{annClassFull: value}}})
+		if err != nil {
+			continue
+		}
+		if _, err := m.p.client.StorageV1().StorageClasses().Patch(ctx, class.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			logger.Error(err, "failed to mark StorageClass", "storageClass", class.Name, "full", full)
+		}
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *spaceMonitor) Describe(ch chan<- *prometheus.Desc) {
+	ch <- exportSizeDesc
+	ch <- exportFreeDesc
+}
+
+// Collect implements prometheus.Collector.
+func (m *spaceMonitor) Collect(ch chan<- prometheus.Metric) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name, s := range m.space {
+		ch <- prometheus.MustNewConstMetric(exportSizeDesc, prometheus.GaugeValue, float64(s.size), name, s.server, s.path)
+		ch <- prometheus.MustNewConstMetric(exportFreeDesc, prometheus.GaugeValue, float64(s.free), name, s.server, s.path)
+	}
+}
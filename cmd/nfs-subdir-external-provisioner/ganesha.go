@@ -0,0 +1,191 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	ganeshaExportsDir     = "/etc/ganesha/exports.d"
+	ganeshaFirstExportID  = 1000
+	defaultGaneshaOptions = "Access_Type=RW,Squash=No_Root_Squash"
+	defaultGaneshaFSAL    = "VFS"
+
+	ganeshaExportMgr = "dbus-send --system --print-reply --dest=org.ganesha.nfsd /org/ganesha/nfsd/ExportMgr org.ganesha.nfsd.exportmgr."
+)
+
+var (
+	// ganeshaOption matches a Key=Value export option that is safe to put in
+	// an EXPORT block and on a shell command line.
+	ganeshaOption = regexp.MustCompile(`^([A-Za-z_]+)=([A-Za-z0-9_.:/]+)$`)
+	// ganeshaExportID matches the export IDs in the reply to ShowExports.
+	ganeshaExportID = regexp.MustCompile(`uint16 (\d+)`)
+	// ganeshaExportsFileName matches the base names ganeshaExportsFile
+	// gives the exports files of volumes, named after PVs.
+	ganeshaExportsFileName = regexp.MustCompile(`^nfs-subdir-[a-z0-9]([-a-z0-9.]*[a-z0-9])?\.conf$`)
+)
+
+// ganeshaClient manages dedicated per-volume exports on an NFS-Ganesha
+// server over SSH. Each export is an EXPORT block in a file of
+// /etc/ganesha/exports.d, added to and removed from the running server with
+// its D-Bus export manager, so that no restart or full reload is needed.
+// Including the directory in ganesha.conf makes the exports survive server
+// restarts.
+type ganeshaClient struct {
+	ssh *sshClient
+
+	// mu serializes the allocation of export IDs.
+	mu sync.Mutex
+}
+
+// ganeshaExportsFile returns the exports file of the volume called name.
+func ganeshaExportsFile(name string) string {
+	return ganeshaExportsDir + "/nfs-subdir-" + name + ".conf"
+}
+
+// export exports path to clients with options, a comma separated list of
+// EXPORT block parameters where FSAL sets the name of the FSAL. It returns
+// the exports file of the volume called name.
+func (c *ganeshaClient) export(ctx context.Context, name, path string, clients []string, options string) (string, error) {
+	if options == "" {
+		options = defaultGaneshaOptions
+	}
+	fsal := defaultGaneshaFSAL
+	var params []string
+	for _, option := range strings.Split(options, ",") {
+		m := ganeshaOption.FindStringSubmatch(option)
+		if m == nil {
+			return "", fmt.Errorf("invalid export option %q", option)
+		}
+		if m[1] == "FSAL" {
+			fsal = m[2]
+			continue
+		}
+		params = append(params, m[1]+" = "+m[2]+";")
+	}
+	for _, client := range clients {
+		if !exportToken.MatchString(client) {
+			return "", fmt.Errorf("invalid export client %q", client)
+		}
+	}
+	if strings.ContainsAny(path, "'\"\\\n") {
+		return "", fmt.Errorf("unable to export %q", path)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id, err := c.nextExportID(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	block := []string{
+		"EXPORT {",
+		fmt.Sprintf("\tExport_Id = %d;", id),
+		fmt.Sprintf("\tPath = %q;", path),
+		fmt.Sprintf("\tPseudo = %q;", path),
+	}
+	if len(clients) == 1 && clients[0] == "*" {
+		block = append(block, indent(params, "\t")...)
+	} else {
+		// Only the listed clients get the requested access.
+		block = append(block, "\tAccess_Type = None;", "\tCLIENT {", "\t\tClients = "+strings.Join(clients, ", ")+";")
+		block = append(block, indent(params, "\t\t")...)
+		block = append(block, "\t}")
+	}
+	block = append(block, "\tFSAL {", "\t\tName = "+fsal+";", "\t}", "}")
+
+	file := ganeshaExportsFile(name)
+	command := fmt.Sprintf("mkdir -p %s && printf '%%s\\n' '%s' > %s && { %sAddExport string:%s string:'EXPORT(Export_Id=%d)' || { rm -f %s; exit 1; }; }",
+		ganeshaExportsDir, strings.Join(block, "\n"), file, ganeshaExportMgr, file, id, file)
+	_, err = c.ssh.run(ctx, command)
+	return file, err
+}
+
+// nextExportID returns the lowest export ID, from ganeshaFirstExportID up,
+// that the server does not use. c.mu must be held.
+func (c *ganeshaClient) nextExportID(ctx context.Context) (int, error) {
+	out, err := c.ssh.run(ctx, ganeshaExportMgr+"ShowExports")
+	if err != nil {
+		return 0, err
+	}
+	used := map[int]bool{}
+	for _, m := range ganeshaExportID.FindAllStringSubmatch(out, -1) {
+		id, _ := strconv.Atoi(m[1])
+		used[id] = true
+	}
+	for id := ganeshaFirstExportID; id <= 0xffff; id++ {
+		if !used[id] {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("no free export ID on %s", c.ssh.address)
+}
+
+// checkGaneshaExportsFile refuses file unless it is an exports file export
+// could have created, like checkExportsFile.
+func checkGaneshaExportsFile(file string) error {
+	if path.Clean(file) != file || path.Dir(file) != ganeshaExportsDir || !ganeshaExportsFileName.MatchString(path.Base(file)) {
+		return fmt.Errorf("refusing to remove %q, not an exports file of a volume", file)
+	}
+	return nil
+}
+
+// unexport removes the export defined in file, an exports file created by
+// export, from the server and deletes file.
+func (c *ganeshaClient) unexport(ctx context.Context, file string) error {
+	if err := checkGaneshaExportsFile(file); err != nil {
+		return err
+	}
+	command := fmt.Sprintf("[ -f '%s' ] || exit 0; id=$(sed -n 's/^\\tExport_Id = \\([0-9]*\\);$/\\1/p' '%s') && %sRemoveExport uint16:$id && rm -f '%s'",
+		file, file, ganeshaExportMgr, file)
+	_, err := c.ssh.run(ctx, command)
+	return err
+}
+
+// usage returns the apparent size of the regular files below path and the
+// number of entries below it, like diskUsage, measured on the server rather
+// than walked over NFS.
+func (c *ganeshaClient) usage(ctx context.Context, path string) (int64, int64, error) {
+	if strings.ContainsAny(path, "'\n") {
+		return 0, 0, fmt.Errorf("unable to measure %q", path)
+	}
+	out, err := c.ssh.run(ctx, fmt.Sprintf(`find '%s' -mindepth 1 -printf '%%y %%s\n' | awk '{n++} $1 == "f" {b += $2} END {printf "%%d %%d\n", b, n}'`, path))
+	if err != nil {
+		return 0, 0, err
+	}
+	var bytes, files int64
+	if _, err := fmt.Sscanf(out, "%d %d", &bytes, &files); err != nil {
+		return 0, 0, fmt.Errorf("unexpected usage of %s: %q", path, out)
+	}
+	return bytes, files, nil
+}
+
+func indent(lines []string, prefix string) []string {
+	indented := make([]string, len(lines))
+	for i, line := range lines {
+		indented[i] = prefix + line
+	}
+	return indented
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import "testing"
+
+func TestCheckGaneshaExportsFile(t *testing.T) {
+	tests := []struct {
+		file    string
+		wantErr bool
+	}{
+		{file: ganeshaExportsFile("pvc-0b8e5e3c-8d7a-4c52-a0b5-4c1a3f0e9d21")},
+		{file: ganeshaExportsFile("a")},
+		{file: ganeshaExportsFile("volume.backup-1")},
+		{file: "/etc/ganesha/exports.d/../ganesha.conf", wantErr: true},
+		{file: "/etc/ganesha/exports.d/nfs-subdir-../../ganesha.conf", wantErr: true},
+		{file: "/etc/ganesha/exports.d/../exports.d/nfs-subdir-a.conf", wantErr: true},
+		{file: "/etc/ganesha/exports.d//nfs-subdir-a.conf", wantErr: true},
+		{file: "/etc/ganesha/exports.d/sub/nfs-subdir-a.conf", wantErr: true},
+		{file: "/etc/ganesha/exports.d/other.conf", wantErr: true},
+		{file: "/etc/ganesha/exports.d/*", wantErr: true},
+		{file: "/etc/ganesha/exports.d/nfs-subdir-*.conf", wantErr: true},
+		{file: "/etc/ganesha/exports.d/nfs-subdir-a.conf.bak", wantErr: true},
+		{file: "/etc/ganesha/exports.d/nfs-subdir-.conf", wantErr: true},
+		{file: "/etc/ganesha/exports.d/nfs-subdir-A.conf", wantErr: true},
+		{file: "/etc/ganesha/exports.d/nfs-subdir-a.conf;reboot", wantErr: true},
+		{file: "/etc/ganesha/exports.d/nfs-subdir-a'.conf", wantErr: true},
+		{file: "/etc/exports.d/nfs-subdir-a.conf", wantErr: true},
+		{file: "etc/ganesha/exports.d/nfs-subdir-a.conf", wantErr: true},
+		{file: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			if err := checkGaneshaExportsFile(tt.file); (err != nil) != tt.wantErr {
+				t.Errorf("checkGaneshaExportsFile() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	storage "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// annVolumeGID on a PV is a group the kubelet adds to the supplemental
+// groups of the pods using it.
+const annVolumeGID = "pv.beta.kubernetes.io/gid"
+
+// The default range of the GIDs allocated to volumes, as with the GlusterFS
+// provisioner.
+const (
+	defaultGIDMin = 2000
+	defaultGIDMax = 1<<31 - 1
+)
+
+// gidAllocator hands every volume of the StorageClasses that set gidMin or
+// gidMax a GID of its own, used by no other PV. The GIDs in use are read
+// from the annVolumeGID annotations of the PVs, so that nothing but them
+// needs to be persisted.
+type gidAllocator struct {
+	mu       sync.Mutex
+	reserved map[string]gidReservation
+}
+
+// gidReservation is a GID allocated to a volume being provisioned, whose PV
+// has not shown up yet.
+type gidReservation struct {
+	gid     int
+	expires time.Time
+}
+
+func newGIDAllocator() *gidAllocator {
+	return &gidAllocator{reserved: map[string]gidReservation{}}
+}
+
+// gidRange returns the range of the GIDs allocated to the volumes of class,
+// with ok false if it allocates none.
+func gidRange(class *storage.StorageClass) (lo, hi int, ok bool, err error) {
+	minValue, maxValue := class.Parameters["gidMin"], class.Parameters["gidMax"]
+	if minValue == "" && maxValue == "" {
+		return 0, 0, false, nil
+	}
+	lo, hi = defaultGIDMin, defaultGIDMax
+	if minValue != "" {
+		if lo, err = parseID("gidMin", minValue); err != nil {
+			return 0, 0, false, err
+		}
+	}
+	if maxValue != "" {
+		if hi, err = parseID("gidMax", maxValue); err != nil {
+			return 0, 0, false, err
+		}
+	}
+	if lo > hi {
+		return 0, 0, false, fmt.Errorf("gidMin %d exceeds gidMax %d", lo, hi)
+	}
+	return lo, hi, true, nil
+}
+
+// allocateGID returns the lowest GID of the range [lo, hi] that no PV, nor
+// volume being provisioned, uses, and reserves it for the volume called
+// pvName. The reservation lasts until the PV shows up, or until releaseGID
+// is called when provisioning fails.
+func (p *nfsProvisioner) allocateGID(pvName string, lo, hi int) (int, error) {
+	a := p.gids
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	volumes, err := p.volumes.List(labels.Everything())
+	if err != nil {
+		return 0, err
+	}
+	used := map[int]bool{}
+	existing := map[string]bool{}
+	for _, volume := range volumes {
+		existing[volume.Name] = true
+		if gid, err := strconv.Atoi(volume.Annotations[annVolumeGID]); err == nil {
+			used[gid] = true
+		}
+	}
+	now := p.clock.Now()
+	for name, r := range a.reserved {
+		if existing[name] || now.After(r.expires) {
+			delete(a.reserved, name)
+			continue
+		}
+		used[r.gid] = true
+	}
+	for gid := lo; gid <= hi; gid++ {
+		if !used[gid] {
+			a.reserved[pvName] = gidReservation{gid: gid, expires: now.Add(reservationTimeout)}
+			return gid, nil
+		}
+	}
+	return 0, fmt.Errorf("all the GIDs from %d to %d are allocated", lo, hi)
+}
+
+// releaseGID drops the GID reserved for the volume called pvName, if any.
+func (p *nfsProvisioner) releaseGID(pvName string) {
+	p.gids.mu.Lock()
+	defer p.gids.mu.Unlock()
+	delete(p.gids.reserved, pvName)
+}
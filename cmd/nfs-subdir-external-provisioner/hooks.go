@@ -0,0 +1,178 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// The hooks run around volume operations.
+const (
+	hookPreProvision  = "pre-provision"
+	hookPostProvision = "post-provision"
+	hookPreDelete     = "pre-delete"
+	hookPostDelete    = "post-delete"
+)
+
+// hookOutputLimit bounds how much of the output of a failed hook ends up in
+// its error.
+const hookOutputLimit = 1024
+
+// hookPayload describes the operation a hook runs for. It is posted to
+// webhooks, and written to the standard input of executables.
+type hookPayload struct {
+	Hook         string `json:"hook"`
+	PV           string `json:"pv"`
+	PVC          string `json:"pvc,omitempty"`
+	Namespace    string `json:"namespace,omitempty"`
+	StorageClass string `json:"storageClass,omitempty"`
+	Server       string `json:"server,omitempty"`
+	// Path is the directory of the volume on the export, LocalPath where
+	// the provisioner reaches it. For post-delete hooks, LocalPath is where
+	// the directory was left, e.g. the archive.
+	Path      string `json:"path,omitempty"`
+	LocalPath string `json:"localPath,omitempty"`
+	// Action is what a deletion did: delete, archive, retain or skip.
+	Action string `json:"action,omitempty"`
+	// Error is why the operation failed, for post hooks.
+	Error  string                    `json:"error,omitempty"`
+	Claim  *v1.PersistentVolumeClaim `json:"claim,omitempty"`
+	Volume *v1.PersistentVolume      `json:"volume,omitempty"`
+}
+
+// checkHook checks that hook, the value of a hook flag, is an http(s) URL or
+// the absolute path of an executable.
+func checkHook(hook string) error {
+	if hook == "" || filepath.IsAbs(hook) {
+		return nil
+	}
+	if u, err := url.Parse(hook); err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" {
+		return nil
+	}
+	return fmt.Errorf("%q is neither an http(s) URL nor an absolute path", hook)
+}
+
+// hook returns the hook configured for name, "" when there is none.
+func (c *config) hook(name string) string {
+	switch name {
+	case hookPreProvision:
+		return c.PreProvisionHook
+	case hookPostProvision:
+		return c.PostProvisionHook
+	case hookPreDelete:
+		return c.PreDeleteHook
+	case hookPostDelete:
+		return c.PostDeleteHook
+	}
+	return ""
+}
+
+// runHook runs the hook configured for payload.Hook, if any, and returns
+// its failure: a non-zero exit status, or a status other than 2xx.
+func (p *nfsProvisioner) runHook(ctx context.Context, payload hookPayload) error {
+	settings := p.settings()
+	hook := settings.hook(payload.Hook)
+	if hook == "" {
+		return nil
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, settings.hookTimeout())
+	defer cancel()
+	err = traced(ctx, "Hook", func() error {
+		if filepath.IsAbs(hook) {
+			return execHook(ctx, hook, payload, data)
+		}
+		return postHook(ctx, hook, data)
+	}, "hook", payload.Hook, "pv", payload.PV)
+	if err != nil {
+		return fmt.Errorf("%s hook failed: %v", payload.Hook, err)
+	}
+	klog.FromContext(ctx).V(2).Info("ran hook", "hook", payload.Hook, "pv", payload.PV)
+	return nil
+}
+
+// execHook runs the executable path with data on its standard input. The
+// main fields of payload are passed in the environment as well, for shell
+// scripts.
+func execHook(ctx context.Context, path string, payload hookPayload, data []byte) error {
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = append(os.Environ(),
+		"NFS_HOOK="+payload.Hook,
+		"NFS_PV="+payload.PV,
+		"NFS_PVC="+payload.PVC,
+		"NFS_NAMESPACE="+payload.Namespace,
+		"NFS_STORAGE_CLASS="+payload.StorageClass,
+		"NFS_SERVER="+payload.Server,
+		"NFS_PATH="+payload.Path,
+		"NFS_LOCAL_PATH="+payload.LocalPath,
+		"NFS_ACTION="+payload.Action,
+		"NFS_ERROR="+payload.Error,
+	)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if out := strings.TrimSpace(string(output)); out != "" {
+			if len(out) > hookOutputLimit {
+				out = out[len(out)-hookOutputLimit:]
+			}
+			return fmt.Errorf("%v: %s", err, out)
+		}
+		return err
+	}
+	return nil
+}
+
+// postHook posts data to the webhook at target.
+func postHook(ctx context.Context, target string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// The error of the client repeats the URL, which may hold a token.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("unable to post to %s: %v", redactURL(target), err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, hookOutputLimit))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
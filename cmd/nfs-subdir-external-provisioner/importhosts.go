@@ -0,0 +1,199 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxImportRedirects bounds the redirects followed by a download.
+const maxImportRedirects = 10
+
+// restrictedNetworks are the addresses an import may only download from when
+// --import-allowed-hosts lets it: the CGNAT and unique local ranges, which
+// netip does not count as private, besides those it does.
+var restrictedNetworks = []netip.Prefix{
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("fc00::/7"),
+}
+
+// importGuard decides which hosts NfsVolumeImports may download from. The
+// URLs of imports are written by the users of a namespace, and the
+// provisioner must not fetch, on their behalf, what only its own network
+// position reaches: cloud metadata, in-cluster services or the API server.
+type importGuard struct {
+	// names are the host names of --import-allowed-hosts, a leading "*."
+	// matching any subdomain.
+	names []string
+	// networks are the CIDRs of --import-allowed-hosts.
+	networks []netip.Prefix
+}
+
+// parseImportAllowedHosts parses the comma separated host names, *.domain
+// wildcards and CIDRs of list.
+func parseImportAllowedHosts(list string) (*importGuard, error) {
+	g := &importGuard{}
+	for _, item := range splitList(list) {
+		if prefix, err := netip.ParsePrefix(item); err == nil {
+			g.networks = append(g.networks, prefix.Masked())
+			continue
+		}
+		if addr, err := netip.ParseAddr(item); err == nil {
+			g.networks = append(g.networks, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		name := strings.ToLower(strings.TrimSuffix(item, "."))
+		if strings.ContainsAny(strings.TrimPrefix(name, "*."), "*/:@ ") || name == "" {
+			return nil, fmt.Errorf("%q is not a host name, *.domain or CIDR", item)
+		}
+		g.names = append(g.names, name)
+	}
+	return g, nil
+}
+
+// restricted tells whether g has a list, outside of which nothing is allowed.
+func (g *importGuard) restricted() bool {
+	return len(g.names) > 0 || len(g.networks) > 0
+}
+
+// nameListed tells whether host is one of the names of g.
+func (g *importGuard) nameListed(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, name := range g.names {
+		if suffix, ok := strings.CutPrefix(name, "*"); ok {
+			if strings.HasSuffix(host, suffix) {
+				return true
+			}
+		} else if host == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkAddr tells why an import may not connect to addr, an address of
+// host, if it may not.
+func (g *importGuard) checkAddr(host string, addr netip.Addr) error {
+	addr = addr.Unmap()
+	if g.nameListed(host) {
+		return nil
+	}
+	for _, network := range g.networks {
+		if network.Contains(addr) {
+			return nil
+		}
+	}
+	if g.restricted() {
+		return fmt.Errorf("%s is not in --import-allowed-hosts", host)
+	}
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || inNetworks(restrictedNetworks, addr) {
+		return fmt.Errorf("%s resolves to %s, which imports may only download from when --import-allowed-hosts lists it", host, addr)
+	}
+	return nil
+}
+
+func inNetworks(networks []netip.Prefix, addr netip.Addr) bool {
+	for _, network := range networks {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkURL refuses u before connecting to it, as far as its scheme and host
+// tell. The addresses a host name resolves to are checked when dialing.
+func (g *importGuard) checkURL(u *url.URL) error {
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return fmt.Errorf("unsupported URL scheme %q, must be http or https", u.Scheme)
+	}
+	host := u.Hostname()
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return g.checkAddr(host, addr)
+	}
+	if g.restricted() && len(g.networks) == 0 && !g.nameListed(host) {
+		return fmt.Errorf("%s is not in --import-allowed-hosts", host)
+	}
+	return nil
+}
+
+// dial connects to an address of the host of address that g allows. The
+// host is resolved here, so that the address checked is the one connected
+// to.
+func (g *importGuard) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	var addrs []netip.Addr
+	if addr, err := netip.ParseAddr(host); err == nil {
+		addrs = []netip.Addr{addr}
+	} else if addrs, err = net.DefaultResolver.LookupNetIP(ctx, "ip", host); err != nil {
+		return nil, err
+	}
+	var errs []error
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	for _, addr := range addrs {
+		if err := g.checkAddr(host, addr); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(addr.Unmap().String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("%s has no address", host)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// client returns an HTTP client connecting only where g allows, redirects
+// included. It ignores the proxy settings of the environment, as a proxy
+// would connect on its behalf.
+func (g *importGuard) client() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = g.dial
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxImportRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxImportRedirects)
+			}
+			return g.checkURL(req.URL)
+		},
+	}
+}
+
+// importGuard returns the guard of the downloads of imports, from the
+// current --import-allowed-hosts.
+func (p *nfsProvisioner) importGuard() *importGuard {
+	// Validated with the rest of the configuration.
+	g, _ := parseImportAllowedHosts(p.settings().ImportAllowedHosts)
+	return g
+}
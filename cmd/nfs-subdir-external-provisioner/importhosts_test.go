@@ -0,0 +1,164 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+)
+
+func TestParseImportAllowedHosts(t *testing.T) {
+	tests := []struct {
+		list    string
+		wantErr bool
+	}{
+		{list: ""},
+		{list: "*.amazonaws.com, minio.storage.svc.cluster.local., 10.20.0.0/16, fd00::1"},
+		{list: "*", wantErr: true},
+		{list: "a.*.com", wantErr: true},
+		{list: "http://example.com", wantErr: true},
+		{list: "user@example.com", wantErr: true},
+		{list: "example.com:443", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.list, func(t *testing.T) {
+			if _, err := parseImportAllowedHosts(tt.list); (err != nil) != tt.wantErr {
+				t.Errorf("parseImportAllowedHosts() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestImportGuardCheckAddr(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed string
+		host    string
+		addr    string
+		wantErr bool
+	}{
+		{name: "public address", host: "example.com", addr: "93.184.215.14"},
+		{name: "public IPv6 address", host: "example.com", addr: "2606:2800:21f:cb07::1"},
+		{name: "loopback", host: "localhost", addr: "127.0.0.1", wantErr: true},
+		{name: "IPv4-mapped loopback", host: "localhost", addr: "::ffff:127.0.0.1", wantErr: true},
+		{name: "IPv6 loopback", host: "localhost", addr: "::1", wantErr: true},
+		{name: "metadata service", host: "169.254.169.254", addr: "169.254.169.254", wantErr: true},
+		{name: "private", host: "kubernetes.default.svc", addr: "10.96.0.1", wantErr: true},
+		{name: "shared address space", host: "filer", addr: "100.64.0.1", wantErr: true},
+		{name: "unique local", host: "filer", addr: "fd00::1", wantErr: true},
+		{name: "unspecified", host: "0.0.0.0", addr: "0.0.0.0", wantErr: true},
+		{name: "listed name", allowed: "minio.storage.svc", host: "MINIO.storage.svc.", addr: "10.96.0.9"},
+		{name: "listed wildcard", allowed: "*.svc.cluster.local", host: "minio.storage.svc.cluster.local", addr: "10.96.0.9"},
+		{name: "wildcard without subdomain", allowed: "*.svc.cluster.local", host: "svc.cluster.local", addr: "10.96.0.9", wantErr: true},
+		{name: "listed network", allowed: "10.20.0.0/16", host: "filer", addr: "10.20.1.2"},
+		{name: "listed address", allowed: "fd00::1", host: "filer", addr: "fd00::1"},
+		{name: "public address outside a list", allowed: "*.amazonaws.com", host: "example.com", addr: "93.184.215.14", wantErr: true},
+		{name: "private address outside a list", allowed: "10.20.0.0/16", host: "filer", addr: "10.30.1.2", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := parseImportAllowedHosts(tt.allowed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := g.checkAddr(tt.host, netip.MustParseAddr(tt.addr)); (err != nil) != tt.wantErr {
+				t.Errorf("checkAddr() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestImportGuardCheckURL(t *testing.T) {
+	tests := []struct {
+		allowed string
+		url     string
+		wantErr bool
+	}{
+		{url: "https://example.com/archive.tar.gz"},
+		{url: "http://example.com:8080/archive.tar.gz"},
+		{url: "file:///etc/passwd", wantErr: true},
+		{url: "ftp://example.com/archive.tar.gz", wantErr: true},
+		{url: "http://127.0.0.1/", wantErr: true},
+		{url: "http://[::1]:8080/", wantErr: true},
+		{url: "http://169.254.169.254/latest/meta-data/", wantErr: true},
+		{allowed: "*.example.com", url: "https://downloads.example.com/a"},
+		{allowed: "*.example.com", url: "https://example.org/a", wantErr: true},
+		// A name may resolve into a listed network, which dial checks.
+		{allowed: "10.20.0.0/16", url: "https://filer.internal/a"},
+		{allowed: "10.20.0.0/16", url: "https://10.20.0.5/a"},
+		{allowed: "10.20.0.0/16", url: "https://10.30.0.5/a", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.allowed+" "+tt.url, func(t *testing.T) {
+			g, err := parseImportAllowedHosts(tt.allowed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := g.checkURL(u); (err != nil) != tt.wantErr {
+				t.Errorf("checkURL() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestImportGuardClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		default:
+			w.Write([]byte("archive"))
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		allowed string
+		path    string
+		wantErr bool
+	}{
+		{name: "loopback refused", path: "/archive", wantErr: true},
+		{name: "listed loopback", allowed: "127.0.0.0/8", path: "/archive"},
+		{name: "redirect to the metadata service", allowed: "127.0.0.0/8", path: "/redirect", wantErr: true},
+		{name: "redirect loop", allowed: "127.0.0.0/8", path: "/loop", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := parseImportAllowedHosts(tt.allowed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := g.client().Get(server.URL + tt.path)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Get() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
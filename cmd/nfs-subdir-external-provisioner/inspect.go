@@ -0,0 +1,165 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/sig-storage-lib-external-provisioner/v10/controller"
+)
+
+const (
+	// annInspect on a PVC asks for a read-only view of an archived directory
+	// of the export instead of a new volume.
+	annInspect = "nfs.io/inspect"
+	// annInspectOf marks the PVs created for annInspect with the directory
+	// they show. Their directory is never reclaimed.
+	annInspectOf = "nfs.io/inspect-of"
+
+	archivePrefix = "archived-"
+)
+
+// inspectable returns the directory, relative to the export mounted at
+// mountPath, that claim may inspect through a StorageClass with the given
+// parameters. Only archives can be inspected, and only those of the
+// namespace of claim unless the class allows any.
+func inspectable(claim *v1.PersistentVolumeClaim, parameters map[string]string, mountPath, metadataFile, target string) (string, error) {
+	if allowed, _ := strconv.ParseBool(parameters["allowInspection"]); !allowed {
+		return "", fmt.Errorf("StorageClass does not set allowInspection, %s cannot be used", annInspect)
+	}
+	clean := filepath.Clean(target)
+	top := strings.SplitN(clean, string(filepath.Separator), 2)[0]
+	if filepath.IsAbs(clean) || top == ".." || !strings.HasPrefix(top, archivePrefix) {
+		return "", fmt.Errorf("%s must name an archived directory of the export, not %q", annInspect, target)
+	}
+	if anyNamespace, _ := strconv.ParseBool(parameters["inspectAnyNamespace"]); anyNamespace {
+		return clean, nil
+	}
+	// Not from the name of the archive: namespaces may be prefixes of each
+	// other, such as team and team-b.
+	namespace, err := archiveNamespace(mountPath, metadataFile, clean)
+	if err != nil {
+		return "", fmt.Errorf("unable to tell the namespace of %s: %v", clean, err)
+	}
+	if namespace != claim.Namespace {
+		return "", fmt.Errorf("%s is not an archive of namespace %s", clean, claim.Namespace)
+	}
+	return clean, nil
+}
+
+// archiveNamespace returns the namespace of the claim of the archive holding
+// dir, relative to the export mounted at mountPath: the one of the manifest
+// next to the archive or, for archives made before manifests were written,
+// of the metadata file of the volume.
+func archiveNamespace(mountPath, metadataFile, dir string) (string, error) {
+	parts := strings.Split(dir, string(filepath.Separator))
+	// Archives kept in a directory, with an archivePattern such as
+	// archived-${.PVC.namespace}/..., have their manifest below it.
+	for i := range parts {
+		archive := filepath.Join(mountPath, filepath.Join(parts[:i+1]...))
+		data, err := os.ReadFile(archive + archiveManifestSuffix)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		var manifest archiveManifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return "", fmt.Errorf("invalid manifest %s: %v", archive+archiveManifestSuffix, err)
+		}
+		return manifest.Namespace, nil
+	}
+	if metadataFile != "" {
+		if metadata, err := readMetadataFile(filepath.Join(mountPath, parts[0], metadataFile)); err == nil {
+			return metadata.Namespace, nil
+		}
+	}
+	return "", fmt.Errorf("%s has neither a manifest nor a metadata file", parts[0])
+}
+
+// provisionInspection returns a read-only PV showing the archived directory
+// target of the export b, on server, to the claim of options. Nothing is
+// created on the export.
+func (p *nfsProvisioner) provisionInspection(ctx context.Context, options controller.ProvisionOptions, b *backend, server, target string) (*v1.PersistentVolume, controller.ProvisioningState, error) {
+	dir, err := inspectable(options.PVC, options.StorageClass.Parameters, b.mountPath, p.settings().MetadataFile, target)
+	if err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	fullPath := filepath.Join(b.mountPath, dir)
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return nil, controller.ProvisioningFinished, fmt.Errorf("unable to inspect %s: %v", dir, err)
+	}
+	if !info.IsDir() {
+		return nil, controller.ProvisioningFinished, fmt.Errorf("unable to inspect %s: not a directory", dir)
+	}
+
+	mountOptions, err := claimMountOptions(options.StorageClass, options.PVC, p.classMountOptions(options.StorageClass))
+	if err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	accessModes, err := volumeAccessModes(options.PVC)
+	if err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	affinity, err := nodeAffinity(options.StorageClass, b)
+	if err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	if err := checkSelectedNode(options.SelectedNode, affinity); err != nil {
+		return nil, controller.ProvisioningReschedule, err
+	}
+
+	pv := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        options.PVName,
+			Annotations: map[string]string{annInspectOf: dir},
+		},
+		Spec: v1.PersistentVolumeSpec{
+			PersistentVolumeReclaimPolicy: v1.PersistentVolumeReclaimDelete,
+			AccessModes:                   accessModes,
+			MountOptions:                  mountOptions,
+			NodeAffinity:                  affinity,
+			Capacity: v1.ResourceList{
+				v1.ResourceStorage: options.PVC.Spec.Resources.Requests[v1.ResourceStorage],
+			},
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				NFS: &v1.NFSVolumeSource{
+					Server:   server,
+					Path:     filepath.Join(b.path, dir),
+					ReadOnly: true,
+				},
+			},
+		},
+	}
+	if server != b.server {
+		metav1.SetMetaDataAnnotation(&pv.ObjectMeta, annExportServer, b.server)
+	}
+	klog.FromContext(ctx).Info("inspecting archived directory", "path", fullPath, "pv", options.PVName)
+	return pv, controller.ProvisioningFinished, nil
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestInspectable(t *testing.T) {
+	const metadataFile = ".nfs-provisioner.json"
+	export := t.TempDir()
+	// The files of the export: archives, their manifests and the metadata
+	// files of the volumes.
+	for name, content := range map[string]string{
+		"archived-team-b-data-pvc-1/file":               "",
+		"archived-team-b-data-pvc-1.manifest.json":      `{"namespace": "team-b"}`,
+		"archived-team-data-pvc-2/sub/file":             "",
+		"archived-team-data-pvc-2.manifest.json":        `{"namespace": "team"}`,
+		"archived-team-b/data-pvc-3/file":               "",
+		"archived-team-b/data-pvc-3.manifest.json":      `{"namespace": "team-b"}`,
+		"archived-legacy/" + metadataFile:               `{"namespace": "team"}`,
+		"archived-unknown/file":                         "",
+		"archived-orphan/file":                          "",
+		"archived-orphan.manifest.json":                 `{}`,
+		"archived-forged/" + metadataFile:               `{"namespace": "team-b"}`,
+		"archived-forged.manifest.json":                 `{"namespace": "team"}`,
+		"archived-team-b-data-pvc-4/fake.manifest.json": `{"namespace": "team"}`,
+		"archived-team-b-data-pvc-4/fake/file":          "",
+		"archived-team-b-data-pvc-4.manifest.json":      `{"namespace": "team-b"}`,
+		"default-data-pvc-5/file":                       "",
+		"default-data-pvc-5/" + metadataFile:            `{"namespace": "team"}`,
+		"archived-team-broken/file":                     "",
+		"archived-team-broken.manifest.json":            `{`,
+	} {
+		path := filepath.Join(export, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name       string
+		namespace  string
+		target     string
+		parameters map[string]string
+		want       string
+		wantErr    bool
+	}{
+		{name: "own archive", namespace: "team-b", target: "archived-team-b-data-pvc-1", want: "archived-team-b-data-pvc-1"},
+		{name: "archive of a namespace named longer", namespace: "team", target: "archived-team-b-data-pvc-1", wantErr: true},
+		{name: "directory of own archive", namespace: "team", target: "archived-team-data-pvc-2/sub/", want: "archived-team-data-pvc-2/sub"},
+		{name: "directory of an archive of another namespace", namespace: "team-b", target: "archived-team-data-pvc-2/sub", wantErr: true},
+		{name: "archive kept in a directory", namespace: "team-b", target: "archived-team-b/data-pvc-3", want: "archived-team-b/data-pvc-3"},
+		{name: "archive kept in a directory of another namespace", namespace: "team", target: "archived-team-b/data-pvc-3", wantErr: true},
+		{name: "directory holding archives", namespace: "team-b", target: "archived-team-b", wantErr: true},
+		{name: "archive made before manifests", namespace: "team", target: "archived-legacy", want: "archived-legacy"},
+		{name: "archive made before manifests, of another namespace", namespace: "team-b", target: "archived-legacy", wantErr: true},
+		{name: "archive telling no namespace", namespace: "team", target: "archived-unknown", wantErr: true},
+		{name: "archive of no claim", namespace: "team", target: "archived-orphan", wantErr: true},
+		{name: "manifest over metadata file", namespace: "team-b", target: "archived-forged", wantErr: true},
+		{name: "manifest inside the archive", namespace: "team", target: "archived-team-b-data-pvc-4/fake", wantErr: true},
+		{name: "invalid manifest", namespace: "team", target: "archived-team-broken", wantErr: true},
+		{name: "any namespace", namespace: "team", target: "archived-team-b-data-pvc-1", parameters: map[string]string{"inspectAnyNamespace": "true"}, want: "archived-team-b-data-pvc-1"},
+		{name: "volume", namespace: "team", target: "default-data-pvc-5", wantErr: true},
+		{name: "out of the export", namespace: "team", target: "../archived-team-data-pvc-2", wantErr: true},
+		{name: "absolute", namespace: "team", target: "/archived-team-data-pvc-2", wantErr: true},
+		{name: "inspection not allowed", namespace: "team", target: "archived-team-data-pvc-2", parameters: map[string]string{"allowInspection": "false"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parameters := map[string]string{"allowInspection": "true"}
+			for key, value := range tt.parameters {
+				parameters[key] = value
+			}
+			claim := &v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "inspect", Namespace: tt.namespace}}
+			got, err := inspectable(claim, parameters, export, metadataFile, tt.target)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("inspectable() error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("inspectable() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	storage "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultKerberosFlavor = "krb5"
+
+	secretKeytabKey   = "keytab"
+	secretKrb5ConfKey = "krb5.conf"
+)
+
+// keytabHeader starts every keytab in the format written by MIT and Heimdal
+// Kerberos, version 0x502. What follows is a list of entries, so keytabs are
+// merged by concatenating their entries.
+var keytabHeader = []byte{0x05, 0x02}
+
+// kerberosFlavor returns the krb5 security flavor selected by options, or ""
+// when they do not select one.
+func kerberosFlavor(options []string) string {
+	var flavor string
+	for _, o := range splitMountOptions(options) {
+		if sec, ok := strings.CutPrefix(o, "sec="); ok {
+			flavor = ""
+			for _, f := range strings.Split(sec, ":") {
+				if strings.HasPrefix(f, "krb5") {
+					flavor = f
+					break
+				}
+			}
+		}
+	}
+	return flavor
+}
+
+// kerberosMountOptions returns the mount options of the PVs of class: when
+// the class has a Kerberos Secret but its mount options select no security
+// flavor, consumers mount with sec=krb5.
+func kerberosMountOptions(class *storage.StorageClass, options []string) []string {
+	if class.Parameters["kerberosSecretName"] == "" || kerberosFlavor(options) != "" {
+		return options
+	}
+	for _, o := range splitMountOptions(options) {
+		if strings.HasPrefix(o, "sec=") {
+			return options
+		}
+	}
+	return append(append([]string{}, options...), "sec="+defaultKerberosFlavor)
+}
+
+// kerberosCredentials writes the keytabs of the Kerberos Secrets referenced
+// by StorageClasses to a single keytab file, where an rpc.gssd running next
+// to the provisioner finds them when the kernel mounts a krb5 export.
+type kerberosCredentials struct {
+	keytabFile string
+	configFile string
+
+	mu      sync.Mutex
+	keytabs map[string][]byte
+	config  []byte
+}
+
+func newKerberosCredentials(keytabFile, configFile string) *kerberosCredentials {
+	return &kerberosCredentials{keytabFile: keytabFile, configFile: configFile, keytabs: map[string][]byte{}}
+}
+
+// install adds keytab, and replaces the krb5.conf if config is not empty, for
+// the Secret called key. Files are only written when their content changes.
+func (k *kerberosCredentials) install(key string, keytab, config []byte) error {
+	if !bytes.HasPrefix(keytab, keytabHeader) {
+		return fmt.Errorf("%s is not a version 0x502 keytab", key)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if previous, ok := k.keytabs[key]; !ok || !bytes.Equal(previous, keytab) {
+		k.keytabs[key] = keytab
+		keys := make([]string, 0, len(k.keytabs))
+		for key := range k.keytabs {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		merged := append([]byte{}, keytabHeader...)
+		for _, key := range keys {
+			merged = append(merged, k.keytabs[key][len(keytabHeader):]...)
+		}
+		if err := writeFileAtomic(k.keytabFile, merged); err != nil {
+			if ok {
+				k.keytabs[key] = previous
+			} else {
+				delete(k.keytabs, key)
+			}
+			return err
+		}
+	}
+	if len(config) > 0 && k.configFile != "" && !bytes.Equal(k.config, config) {
+		if err := writeFileAtomic(k.configFile, config); err != nil {
+			return err
+		}
+		k.config = config
+	}
+	return nil
+}
+
+// writeFileAtomic replaces file with data, readable by its owner only, so
+// that readers never see a partial file.
+func writeFileAtomic(file string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(file), "."+filepath.Base(file))
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// installKerberosCredentials installs the credentials of the Kerberos Secret
+// of class, if it has one, so that the exports of the class can be mounted.
+func (p *nfsProvisioner) installKerberosCredentials(ctx context.Context, class *storage.StorageClass) error {
+	name := class.Parameters["kerberosSecretName"]
+	if name == "" {
+		return nil
+	}
+	namespace := class.Parameters["kerberosSecretNamespace"]
+	if namespace == "" {
+		return fmt.Errorf("StorageClass %s sets kerberosSecretName, but not kerberosSecretNamespace", class.Name)
+	}
+	if p.kerberos == nil {
+		return fmt.Errorf("StorageClass %s sets kerberosSecretName, but --kerberos-keytab is not set", class.Name)
+	}
+	secret, err := p.client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to get Secret %s/%s: %v", namespace, name, err)
+	}
+	keytab, ok := secret.Data[secretKeytabKey]
+	if !ok {
+		return fmt.Errorf("secret %s/%s has no %s", namespace, name, secretKeytabKey)
+	}
+	return p.kerberos.install(namespace+"/"+name, keytab, secret.Data[secretKrb5ConfKey])
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+
+	storage "k8s.io/api/storage/v1"
+)
+
+// shardWidth is the number of hex digits of the shard directories of the
+// sharded layout, which spreads volumes over 256 of them.
+const shardWidth = 2
+
+// layoutDirectory returns where the directory dir of a new volume goes on
+// the export, according to the directoryLayout parameter of class: as is
+// with "flat", the default, or under a shard directory named after the first
+// digits of its SHA-256 with "sharded", e.g. "3f/default-data-pvc-1234".
+// Volumes record their full path, so deleting and archiving them do not
+// depend on the layout.
+func layoutDirectory(class *storage.StorageClass, dir string) (string, error) {
+	switch layout := class.Parameters["directoryLayout"]; layout {
+	case "", "flat":
+		return dir, nil
+	case "sharded":
+		sum := sha256.Sum256([]byte(dir))
+		return filepath.Join(hex.EncodeToString(sum[:])[:shardWidth], dir), nil
+	default:
+		return "", fmt.Errorf("invalid directoryLayout %q, must be flat or sharded", layout)
+	}
+}
+
+// volumeDirectory returns where the directory dir of a new volume is in the
+// export of b. dir may come from the labels and annotations of the claim,
+// through pathPattern, so it is refused unless it lies below the root of
+// the export: not in the directory of another member cluster, of another
+// export, nor the root itself.
+func volumeDirectory(b *backend, dir string) (string, error) {
+	fullPath := filepath.Join(b.mountPath, dir)
+	if fullPath == filepath.Clean(b.mountPath) || !isSubpath(b.mountPath, fullPath) {
+		return "", fmt.Errorf("directory %s is not below the root of the export", dir)
+	}
+	return fullPath, nil
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	storage "k8s.io/api/storage/v1"
+)
+
+func TestLayoutDirectory(t *testing.T) {
+	tests := []struct {
+		layout  string
+		dir     string
+		want    string
+		wantErr bool
+	}{
+		{dir: "default-data-pvc-1234", want: "default-data-pvc-1234"},
+		{layout: "flat", dir: "team-a/data", want: "team-a/data"},
+		{layout: "sharded", dir: "default-data-pvc-1234", want: "73/default-data-pvc-1234"},
+		{layout: "sharded", dir: "team-a/data", want: "2c/team-a/data"},
+		{layout: "hashed", dir: "default-data-pvc-1234", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.layout+"/"+tt.dir, func(t *testing.T) {
+			class := &storage.StorageClass{Parameters: map[string]string{}}
+			if tt.layout != "" {
+				class.Parameters["directoryLayout"] = tt.layout
+			}
+			got, err := layoutDirectory(class, tt.dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("layoutDirectory() error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("layoutDirectory() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVolumeDirectory(t *testing.T) {
+	b := &backend{mountPath: "/persistentvolumes/east-1"}
+	tests := []struct {
+		dir     string
+		want    string
+		wantErr bool
+	}{
+		{dir: "team-a-data-pvc-1", want: "/persistentvolumes/east-1/team-a-data-pvc-1"},
+		{dir: "team-a/data", want: "/persistentvolumes/east-1/team-a/data"},
+		{dir: "team-a/../data", want: "/persistentvolumes/east-1/data"},
+		{dir: "/team-a/data", want: "/persistentvolumes/east-1/team-a/data"},
+		{dir: "../west-1/team-a-data", wantErr: true},
+		{dir: "team-a/../../west-1", wantErr: true},
+		{dir: "..", wantErr: true},
+		{dir: ".", wantErr: true},
+		{dir: "team-a/..", wantErr: true},
+		{dir: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			got, err := volumeDirectory(b, tt.dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("volumeDirectory() error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("volumeDirectory() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
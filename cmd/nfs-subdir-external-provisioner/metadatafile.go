@@ -0,0 +1,101 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/sig-storage-lib-external-provisioner/v10/controller"
+)
+
+// volumeMetadata is written to the --metadata-file of every volume
+// directory, so that the directory can be traced back to its claim without
+// the PV, e.g. after losing the cluster.
+type volumeMetadata struct {
+	Provisioner  string                           `json:"provisioner"`
+	PV           string                           `json:"pv"`
+	PVC          string                           `json:"pvc"`
+	Namespace    string                           `json:"namespace"`
+	UID          string                           `json:"uid"`
+	StorageClass string                           `json:"storageClass"`
+	Server       string                           `json:"server"`
+	Path         string                           `json:"path"`
+	Capacity     string                           `json:"capacity"`
+	AccessModes  []v1.PersistentVolumeAccessMode  `json:"accessModes,omitempty"`
+	MountOptions []string                         `json:"mountOptions,omitempty"`
+	Reclaim      v1.PersistentVolumeReclaimPolicy `json:"reclaimPolicy,omitempty"`
+	NodeAffinity *v1.VolumeNodeAffinity           `json:"nodeAffinity,omitempty"`
+	Created      time.Time                        `json:"created"`
+}
+
+// writeMetadataFile writes the metadata of pv, provisioned for the claim of
+// options in the directory at fullPath in the provisioner, to the file
+// called name in the directory.
+func (p *nfsProvisioner) writeMetadataFile(name string, options controller.ProvisionOptions, pv *v1.PersistentVolume, fullPath string) error {
+	capacity := pv.Spec.Capacity[v1.ResourceStorage]
+	data, err := json.MarshalIndent(volumeMetadata{
+		Provisioner:  p.name,
+		PV:           pv.Name,
+		PVC:          options.PVC.Name,
+		Namespace:    options.PVC.Namespace,
+		UID:          string(options.PVC.UID),
+		StorageClass: options.StorageClass.Name,
+		Server:       pv.Spec.NFS.Server,
+		Path:         pv.Spec.NFS.Path,
+		Capacity:     capacity.String(),
+		AccessModes:  pv.Spec.AccessModes,
+		MountOptions: pv.Spec.MountOptions,
+		Reclaim:      pv.Spec.PersistentVolumeReclaimPolicy,
+		NodeAffinity: pv.Spec.NodeAffinity,
+		Created:      p.clock.Now().UTC(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	// The directory may be reused, or filled from an import, with a link
+	// or a special file in place of the metadata file.
+	path := filepath.Join(fullPath, name)
+	if info, err := os.Lstat(path); err == nil && !info.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", path)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC|syscall.O_NOFOLLOW, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// readMetadataFile reads the metadata file at path.
+func readMetadataFile(path string) (volumeMetadata, error) {
+	var metadata volumeMetadata
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return metadata, err
+	}
+	err = json.Unmarshal(data, &metadata)
+	return metadata, err
+}
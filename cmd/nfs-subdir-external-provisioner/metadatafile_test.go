@@ -0,0 +1,91 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/sig-storage-lib-external-provisioner/v10/controller"
+)
+
+func TestWriteMetadataFile(t *testing.T) {
+	const name = ".nfs-provisioner.json"
+	tests := []struct {
+		name string
+		// existing puts something at path, the metadata file, beforehand;
+		// outside is a file out of the volume.
+		existing func(path, outside string) error
+		wantErr  bool
+	}{
+		{name: "new", existing: func(string, string) error { return nil }},
+		{name: "stale", existing: func(path, _ string) error { return os.WriteFile(path, []byte(`{"pv": "pvc-0"}`), 0o644) }},
+		{name: "link out of the volume", existing: func(path, outside string) error { return os.Symlink(outside, path) }, wantErr: true},
+		{name: "dangling link", existing: func(path, outside string) error { return os.Symlink(outside+".new", path) }, wantErr: true},
+		{name: "directory", existing: func(path, _ string) error { return os.Mkdir(path, 0o755) }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmp := t.TempDir()
+			dir, outside := filepath.Join(tmp, "volume"), filepath.Join(tmp, "outside")
+			if err := os.Mkdir(dir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(outside, []byte("untouched"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join(dir, name)
+			if err := tt.existing(path, outside); err != nil {
+				t.Fatal(err)
+			}
+
+			p := &nfsProvisioner{name: "nfs", clock: clocktesting.NewFakePassiveClock(time.Now())}
+			options := controller.ProvisionOptions{
+				PVC:          &v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "team-a"}},
+				StorageClass: &storage.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "nfs"}},
+			}
+			pv := &v1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{Name: "pvc-1"},
+				Spec: v1.PersistentVolumeSpec{PersistentVolumeSource: v1.PersistentVolumeSource{
+					NFS: &v1.NFSVolumeSource{Server: "10.0.0.1", Path: "/srv/nfs/team-a-data-pvc-1"},
+				}},
+			}
+			err := p.writeMetadataFile(name, options, pv, dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("writeMetadataFile() error = %v, want error %v", err, tt.wantErr)
+			}
+			if data, _ := os.ReadFile(outside); string(data) != "untouched" {
+				t.Errorf("wrote %q out of the volume", data)
+			}
+			if _, err := os.Lstat(outside + ".new"); !os.IsNotExist(err) {
+				t.Errorf("created a file out of the volume: %v", err)
+			}
+			if tt.wantErr {
+				return
+			}
+			if metadata, err := readMetadataFile(path); err != nil || metadata.PV != "pvc-1" || metadata.Namespace != "team-a" {
+				t.Errorf("metadata file = %+v, %v, want that of pvc-1", metadata, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	storagehelpers "k8s.io/component-helpers/storage/volume"
+	"sigs.k8s.io/sig-storage-lib-external-provisioner/v10/controller/metrics"
+)
+
+const (
+	metricsNamespace = "nfs_subdir_external_provisioner"
+
+	annSelectedNode = "volume.kubernetes.io/selected-node"
+)
+
+var (
+	provisionedVolumes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "provisioned_volumes_total",
+		Help:      "Volumes provisioned, by StorageClass and kind: ephemeral for generic ephemeral volumes, or persistent.",
+	}, []string{"storageclass", "kind"})
+	deletedVolumes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "deleted_volumes_total",
+		Help:      "Volumes deleted, by StorageClass, kind and what was done with their directory: delete, archive, retain or skip.",
+	}, []string{"storageclass", "kind", "action"})
+)
+
+// registerMetrics registers the controller library metrics and our own on
+// the default registry, and returns the library metrics for the controller
+// to update.
+func (p *nfsProvisioner) registerMetrics() metrics.Metrics {
+	m := metrics.New("controller")
+	prometheus.MustRegister(
+		m.PersistentVolumeClaimProvisionTotal,
+		m.PersistentVolumeClaimProvisionFailedTotal,
+		m.PersistentVolumeClaimProvisionDurationSeconds,
+		m.PersistentVolumeDeleteTotal,
+		m.PersistentVolumeDeleteFailedTotal,
+		m.PersistentVolumeDeleteDurationSeconds,
+		provisionedVolumes,
+		deletedVolumes,
+		quarantinedVolumes,
+	)
+
+	for _, operation := range []string{"provision", "delete"} {
+		operation := operation
+		prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   metricsNamespace,
+			Name:        "pending_operations",
+			Help:        "Claims waiting to be provisioned or volumes waiting to be deleted. Suitable as an autoscaling signal.",
+			ConstLabels: prometheus.Labels{"operation": operation},
+		}, func() float64 {
+			provision, del := p.pendingOperations()
+			if operation == "delete" {
+				return float64(del)
+			}
+			return float64(provision)
+		}))
+	}
+	// Deletions and archivings have workers of their own, both counted as
+	// pending delete operations.
+	for operation, limiter := range map[string]*workerLimiter{"provision": p.provisionWorkers, "delete": p.deleteWorkers, "archive": p.archiveWorkers} {
+		limiter := limiter
+		labels := prometheus.Labels{"operation": operation}
+
+		prometheus.MustRegister(
+			prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+				Namespace:   metricsNamespace,
+				Name:        "worker_threads",
+				Help:        "Number of calls allowed to run concurrently.",
+				ConstLabels: labels,
+			}, func() float64 {
+				limit, _ := limiter.usage()
+				return float64(limit)
+			}),
+			prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+				Namespace:   metricsNamespace,
+				Name:        "busy_worker_threads",
+				Help:        "Number of calls currently running.",
+				ConstLabels: labels,
+			}, func() float64 {
+				_, active := limiter.usage()
+				return float64(active)
+			}),
+		)
+	}
+
+	return m
+}
+
+// pendingOperations counts, from the informer caches, the claims of this
+// provisioner that are waiting for a volume and the released volumes that
+// are waiting to be deleted.
+func (p *nfsProvisioner) pendingOperations() (int, int) {
+	var provision, del int
+
+	claims, _ := p.claims.List(labels.Everything())
+	for _, claim := range claims {
+		if claim.Spec.VolumeName != "" || claim.DeletionTimestamp != nil {
+			continue
+		}
+		// Block claims are refused, however long they wait.
+		if claim.Spec.VolumeMode != nil && *claim.Spec.VolumeMode == v1.PersistentVolumeBlock {
+			continue
+		}
+		class, err := p.classes.Get(storagehelpers.GetPersistentVolumeClaimClass(claim))
+		if err != nil || !p.owns(class.Provisioner) {
+			continue
+		}
+		// As are the claims of access modes their class does not grant.
+		if checkAllowedAccessModes(class, claim) != nil {
+			continue
+		}
+		// Delayed binding claims are not actionable until a node is selected.
+		if class.VolumeBindingMode != nil && *class.VolumeBindingMode == storage.VolumeBindingWaitForFirstConsumer && claim.Annotations[annSelectedNode] == "" {
+			continue
+		}
+		provision++
+	}
+
+	volumes, _ := p.volumes.List(labels.Everything())
+	for _, volume := range volumes {
+		if !p.owns(volume.Annotations[annProvisionedBy]) {
+			continue
+		}
+		// Quarantined volumes wait for an operator, not for a worker.
+		if _, ok := volume.Annotations[annQuarantined]; ok {
+			continue
+		}
+		if volume.Status.Phase == v1.VolumeReleased && volume.Spec.PersistentVolumeReclaimPolicy == v1.PersistentVolumeReclaimDelete {
+			del++
+		}
+	}
+
+	return provision, del
+}
@@ -0,0 +1,224 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// mountFlags maps the generic mount options to their mount(2) flags. Every
+// other option is passed to the kernel NFS client as is.
+var mountFlags = map[string]uintptr{
+	"ro":       syscall.MS_RDONLY,
+	"nosuid":   syscall.MS_NOSUID,
+	"nodev":    syscall.MS_NODEV,
+	"noexec":   syscall.MS_NOEXEC,
+	"noatime":  syscall.MS_NOATIME,
+	"relatime": syscall.MS_RELATIME,
+}
+
+// mountNFS mounts server:path at target with the kernel NFS client directly,
+// so that no mount.nfs helper is needed in the image. The kernel does not
+// resolve names, so server is resolved here.
+func mountNFS(ctx context.Context, server, path, target string, options []string) error {
+	addrs, err := net.DefaultResolver.LookupIP(ctx, "ip", server)
+	if err != nil {
+		return fmt.Errorf("unable to resolve %s: %v", server, err)
+	}
+	if len(addrs) == 0 {
+		return fmt.Errorf("unable to resolve %s", server)
+	}
+
+	var flags uintptr
+	data := []string{"addr=" + addrs[0].String()}
+	for _, option := range options {
+		if flag, ok := mountFlags[option]; ok {
+			flags |= flag
+			continue
+		}
+		if option != "rw" {
+			data = append(data, option)
+		}
+	}
+
+	source := mountSource(server, path)
+	if err := syscall.Mount(source, target, "nfs", flags, strings.Join(data, ",")); err != nil {
+		return fmt.Errorf("unable to mount %s at %s: %v", source, target, err)
+	}
+	return nil
+}
+
+// unmountNFS lazily unmounts target, so that a dead server cannot block it.
+func unmountNFS(target string) error {
+	return syscall.Unmount(target, syscall.MNT_DETACH)
+}
+
+// exportSpace returns the size of the file system mounted at target and the
+// space left on it for unprivileged users, in bytes.
+func exportSpace(target string) (uint64, uint64, error) {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(target, &fs); err != nil {
+		return 0, 0, err
+	}
+	return fs.Blocks * uint64(fs.Bsize), fs.Bavail * uint64(fs.Bsize), nil
+}
+
+// preserveOwner gives path, without following symbolic links, the owner and
+// group of the file described by info.
+func preserveOwner(path string, info fs.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	return os.Lchown(path, int(stat.Uid), int(stat.Gid))
+}
+
+// fileOwner returns the owner and group of the file described by info.
+func fileOwner(info fs.FileInfo) (int, int, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	return int(stat.Uid), int(stat.Gid), true
+}
+
+// setXattr sets the extended attribute name of path to data.
+func setXattr(path, name string, data []byte) error {
+	return syscall.Setxattr(path, name, data, 0)
+}
+
+// setFSIdentity makes every thread of the process access files as uid and
+// gid, with gid as its only supplementary group, while keeping its other
+// credentials: the NFS client sends the file system IDs to the server, so
+// that an export squashing root sees that user. Threads started later
+// inherit the IDs of the thread creating them.
+func setFSIdentity(uid, gid int) error {
+	if err := syscall.Setgroups([]int{gid}); err != nil {
+		return fmt.Errorf("unable to set the supplementary groups: %w", err)
+	}
+	// setfsgid and setfsuid return the previous ID and never fail, so they are
+	// called twice, the second call returning the ID the first one set.
+	for _, set := range []struct {
+		name string
+		trap uintptr
+		id   int
+	}{{"group", syscall.SYS_SETFSGID, gid}, {"user", syscall.SYS_SETFSUID, uid}} {
+		for i := 0; i < 2; i++ {
+			previous, _, errno := syscall.AllThreadsSyscall(set.trap, uintptr(set.id), 0, 0)
+			if errno == syscall.ENOTSUP {
+				return fmt.Errorf("unable to set the file system %s ID in a binary built with cgo, build it with CGO_ENABLED=0", set.name)
+			}
+			if errno != 0 {
+				return fmt.Errorf("unable to set the file system %s ID: %w", set.name, errno)
+			}
+			if i == 1 && int(previous) != set.id {
+				return fmt.Errorf("unable to set the file system %s ID to %d, the provisioner needs CAP_SETUID and CAP_SETGID", set.name, set.id)
+			}
+		}
+	}
+	return nil
+}
+
+// The loop device ioctls and flags, as in <linux/loop.h>.
+const (
+	loopSetFD         = 0x4C00
+	loopClearFD       = 0x4C01
+	loopSetStatus64   = 0x4C04
+	loopCtlGetFree    = 0x4C82
+	loopFlagAutoclear = 4
+	// loopInfo64Size and loopInfo64Flags are the size of struct loop_info64
+	// and the offset of its lo_flags.
+	loopInfo64Size  = 232
+	loopInfo64Flags = 52
+)
+
+// mountImage attaches the file system image at image to a free loop device
+// and mounts it at target. The device is detached when it is unmounted.
+func mountImage(image, target, fsType string, readOnly bool) error {
+	mode, flags := os.O_RDWR, uintptr(0)
+	if readOnly {
+		mode, flags = os.O_RDONLY, syscall.MS_RDONLY
+	}
+	img, err := os.OpenFile(image, mode, 0)
+	if err != nil {
+		return err
+	}
+	defer img.Close()
+	ctl, err := os.OpenFile("/dev/loop-control", os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("unable to allocate a loop device: %v", err)
+	}
+	defer ctl.Close()
+
+	// Another process may take the free device first.
+	var loop *os.File
+	for attempt := 0; loop == nil; attempt++ {
+		n, _, errno := syscall.Syscall(syscall.SYS_IOCTL, ctl.Fd(), loopCtlGetFree, 0)
+		if errno != 0 {
+			return fmt.Errorf("unable to allocate a loop device: %v", errno)
+		}
+		dev, err := os.OpenFile(fmt.Sprintf("/dev/loop%d", n), mode, 0)
+		if err != nil {
+			return err
+		}
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, dev.Fd(), loopSetFD, img.Fd())
+		switch {
+		case errno == 0:
+			loop = dev
+		case errno == syscall.EBUSY && attempt < 5:
+			_ = dev.Close()
+		default:
+			_ = dev.Close()
+			return fmt.Errorf("unable to attach %s to %s: %v", image, dev.Name(), errno)
+		}
+	}
+	defer loop.Close()
+
+	var info [loopInfo64Size]byte
+	binary.NativeEndian.PutUint32(info[loopInfo64Flags:], loopFlagAutoclear)
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, loop.Fd(), loopSetStatus64, uintptr(unsafe.Pointer(&info[0]))); errno != 0 {
+		_, _, _ = syscall.Syscall(syscall.SYS_IOCTL, loop.Fd(), loopClearFD, 0)
+		return fmt.Errorf("unable to set up %s: %v", loop.Name(), errno)
+	}
+	if err := syscall.Mount(loop.Name(), target, fsType, flags, ""); err != nil {
+		_, _, _ = syscall.Syscall(syscall.SYS_IOCTL, loop.Fd(), loopClearFD, 0)
+		return fmt.Errorf("unable to mount %s at %s: %v", image, target, err)
+	}
+	return nil
+}
+
+// unmountImage unmounts the image mounted at target by mountImage, which
+// detaches its loop device.
+func unmountImage(target string) error {
+	return syscall.Unmount(target, 0)
+}
+
+// lockExclusive takes a POSIX write lock on the whole of f without waiting,
+// which NFS servers share between their clients.
+func lockExclusive(f *os.File) error {
+	lock := syscall.Flock_t{Type: syscall.F_WRLCK, Whence: io.SeekStart}
+	return syscall.FcntlFlock(f.Fd(), syscall.F_SETLK, &lock)
+}
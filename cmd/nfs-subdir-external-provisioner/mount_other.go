@@ -0,0 +1,68 @@
+//go:build !linux
+
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+)
+
+var errMountUnsupported = errors.New("mounting NFS exports is only supported on Linux")
+
+func mountNFS(ctx context.Context, server, path, target string, options []string) error {
+	return errMountUnsupported
+}
+
+func unmountNFS(target string) error {
+	return errMountUnsupported
+}
+
+func exportSpace(target string) (uint64, uint64, error) {
+	return 0, 0, errors.New("measuring the free space of exports is only supported on Linux")
+}
+
+func preserveOwner(path string, info fs.FileInfo) error {
+	return nil
+}
+
+func fileOwner(info fs.FileInfo) (int, int, bool) {
+	return 0, 0, false
+}
+
+func setXattr(path, name string, data []byte) error {
+	return errors.New("setting extended attributes is only supported on Linux")
+}
+
+func setFSIdentity(uid, gid int) error {
+	return errors.New("accessing files as another user is only supported on Linux")
+}
+
+func mountImage(image, target, fsType string, readOnly bool) error {
+	return errMountUnsupported
+}
+
+func unmountImage(target string) error {
+	return errMountUnsupported
+}
+
+func lockExclusive(f *os.File) error {
+	return errors.New("locking files is only supported on Linux")
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+// mountTable is the mount table of the process.
+const mountTable = "/proc/mounts"
+
+// mountVerifyBackoff spaces the checks of the mount at startup, in case the
+// export is still being mounted.
+var mountVerifyBackoff = wait.Backoff{Duration: time.Second, Factor: 2, Steps: 6}
+
+// mountEntry is a line of the mount table.
+type mountEntry struct {
+	source, target, fsType string
+}
+
+// unescapeMountField decodes the octal escapes, such as \040 for a space, of
+// a field of the mount table.
+func unescapeMountField(field string) string {
+	var b strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+4 <= len(field) {
+			if c, err := strconv.ParseUint(field[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(field[i])
+	}
+	return b.String()
+}
+
+// mountAt returns the entry of the mount table that target is the mount
+// point of. When several file systems are mounted there, the last one hides
+// the others.
+func mountAt(table, target string) (*mountEntry, error) {
+	f, err := os.Open(table)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var found *mountEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		if entry := (mountEntry{unescapeMountField(fields[0]), unescapeMountField(fields[1]), fields[2]}); entry.target == target {
+			found = &entry
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("nothing is mounted at %s", target)
+	}
+	return found, nil
+}
+
+// splitMountSource splits the source of an NFS mount into its server,
+// unbracketed, and path.
+func splitMountSource(source string) (string, string, bool) {
+	if strings.HasPrefix(source, "[") {
+		end := strings.Index(source, "]:")
+		if end < 0 {
+			return "", "", false
+		}
+		return source[1:end], source[end+2:], true
+	}
+	server, exportPath, ok := strings.Cut(source, ":")
+	return server, exportPath, ok
+}
+
+// verifyMount checks that mountPath is an NFS mount of exportPath from one
+// of servers, so that volumes are not created in the file system of the
+// container when the export is not mounted. A server mounted by address
+// matches a configured hostname resolving to it.
+func verifyMount(ctx context.Context, mountPath string, servers []string, exportPath string) error {
+	target, err := filepath.EvalSymlinks(mountPath)
+	if err != nil {
+		return err
+	}
+	entry, err := mountAt(mountTable, target)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(entry.fsType, "nfs") {
+		return fmt.Errorf("%s is a %s mount of %s, not an NFS mount", mountPath, entry.fsType, entry.source)
+	}
+	server, mountedPath, ok := splitMountSource(entry.source)
+	if !ok {
+		return fmt.Errorf("%s is a mount of %s, not of an NFS export", mountPath, entry.source)
+	}
+	if path.Clean("/"+mountedPath) != path.Clean("/"+exportPath) {
+		return fmt.Errorf("%s is a mount of %s, not of %s", mountPath, entry.source, exportPath)
+	}
+	server, err = canonicalServer(server)
+	if err != nil {
+		return fmt.Errorf("%s is a mount of %s: %v", mountPath, entry.source, err)
+	}
+	for _, s := range servers {
+		if s == server {
+			return nil
+		}
+		addrs, err := lookupServer(ctx, s)
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if addr == server {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%s is a mount of %s, not of a server of %s: %s", mountPath, entry.source, exportPath, strings.Join(servers, ", "))
+}
+
+// waitForMount checks the mount of the export until it is the configured
+// one, with backoff, and returns the last error if it never is.
+func waitForMount(ctx context.Context, mountPath string, servers []string, exportPath string) error {
+	logger := klog.FromContext(ctx)
+	var lastErr error
+	err := wait.ExponentialBackoffWithContext(ctx, mountVerifyBackoff, func(ctx context.Context) (bool, error) {
+		lastErr = verifyMount(ctx, mountPath, servers, exportPath)
+		if lastErr != nil {
+			logger.Info("the export is not mounted as configured, retrying", "path", mountPath, "reason", lastErr.Error())
+		}
+		return lastErr == nil, nil
+	})
+	if err != nil && lastErr != nil {
+		return lastErr
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"k8s.io/klog/v2"
+)
+
+// mountExport mounts --nfs-path at --mount-path for --mount-export, from the
+// first of servers that accepts it, with --export-mount-options. An export
+// already mounted there as configured, say by an earlier run, is used as is.
+// It tells whether it mounted the export, in which case the provisioner
+// unmounts it when it shuts down.
+func mountExport(ctx context.Context, cfg *config, servers []string) (bool, error) {
+	logger := klog.FromContext(ctx).WithValues("path", cfg.MountPath)
+	if err := os.MkdirAll(cfg.MountPath, 0o755); err != nil {
+		return false, fmt.Errorf("unable to create the mount path: %v", err)
+	}
+	if err := verifyMount(ctx, cfg.MountPath, servers, cfg.NFSPath); err == nil {
+		logger.Info("the export is already mounted")
+		return false, nil
+	}
+	options := cfg.exportMountOptions()
+	var errs []error
+	for _, server := range servers {
+		err := mountNFS(ctx, server, cfg.NFSPath, cfg.MountPath, options)
+		if err == nil {
+			logger.Info("mounted the export", "server", server, "options", options)
+			return true, nil
+		}
+		logger.Info("unable to mount the export", "server", server, "reason", err.Error())
+		errs = append(errs, err)
+	}
+	return false, errors.Join(errs...)
+}
+
+// exportMountOptions returns the options of --export-mount-options.
+func (c *config) exportMountOptions() []string {
+	return splitList(c.ExportMountOptions)
+}
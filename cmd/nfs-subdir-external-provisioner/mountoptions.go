@@ -0,0 +1,176 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+)
+
+// annMountOptions on a PVC holds comma separated NFS mount options for its
+// PV, among the allowedMountOptions of its class.
+const annMountOptions = "nfs.io/mount-options"
+
+// mountOptionSynonyms maps the names of mount options to the name of another
+// option setting the same thing: flags that are not negated with a "no"
+// prefix to their opposite, and vers to nfsvers.
+var mountOptionSynonyms = map[string]string{
+	"soft":  "hard",
+	"async": "sync",
+	"ro":    "rw",
+	"vers":  "nfsvers",
+}
+
+// mountSecurityProfiles maps the mountSecurityProfile StorageClass parameter
+// to the options it enforces on every PV of the class.
+var mountSecurityProfiles = map[string][]string{
+	"hardened": {"nosuid", "nodev"},
+	"strict":   {"nosuid", "nodev", "noexec"},
+}
+
+// relaxedMountOptions maps hardening options to the options that undo them.
+var relaxedMountOptions = map[string]string{
+	"nosuid": "suid",
+	"nodev":  "dev",
+	"noexec": "exec",
+}
+
+// splitMountOptions flattens mount options, which may each hold several
+// comma separated options, into one option per element.
+func splitMountOptions(options []string) []string {
+	var result []string
+	for _, option := range options {
+		for _, o := range strings.Split(option, ",") {
+			if o = strings.TrimSpace(o); o != "" {
+				result = append(result, o)
+			}
+		}
+	}
+	return result
+}
+
+// enforceMountSecurityProfile appends the options required by profile to
+// options. Options that would undo the profile are refused rather than
+// silently dropped.
+func enforceMountSecurityProfile(profile string, options []string) ([]string, error) {
+	if profile == "" {
+		return options, nil
+	}
+	required, ok := mountSecurityProfiles[profile]
+	if !ok {
+		return nil, fmt.Errorf("unknown mountSecurityProfile %q", profile)
+	}
+
+	present := map[string]bool{}
+	for _, o := range splitMountOptions(options) {
+		present[o] = true
+	}
+
+	result := append([]string{}, options...)
+	for _, o := range required {
+		if relaxed := relaxedMountOptions[o]; present[relaxed] {
+			return nil, fmt.Errorf("mount option %q is not allowed by mountSecurityProfile %q", relaxed, profile)
+		}
+		if !present[o] {
+			result = append(result, o)
+		}
+	}
+	return result, nil
+}
+
+// classMountOptions returns the mount options of class, or
+// --default-mount-options if it has none.
+func (p *nfsProvisioner) classMountOptions(class *storage.StorageClass) []string {
+	if len(class.MountOptions) > 0 {
+		return class.MountOptions
+	}
+	return splitMountOptions([]string{p.settings().DefaultMountOptions})
+}
+
+// mountOptionName returns the name of option, which options setting the same
+// thing share: the key of key=value options, and that of the flag for the
+// flag and its opposite, e.g. atime for noatime, and that of synonyms.
+func mountOptionName(option string) string {
+	name, _, _ := strings.Cut(option, "=")
+	if synonym, ok := mountOptionSynonyms[name]; ok {
+		return synonym
+	}
+	if strings.HasPrefix(name, "no") && !strings.Contains(option, "=") {
+		return strings.TrimPrefix(name, "no")
+	}
+	return name
+}
+
+// mountOptionValue returns the value of a key=value option, or the flag
+// option itself.
+func mountOptionValue(option string) string {
+	if _, value, ok := strings.Cut(option, "="); ok {
+		return value
+	}
+	return option
+}
+
+// allowedMountOptions returns the names of the mount options that the
+// claims of class may set with annMountOptions, from its
+// allowedMountOptions parameter. Allowing a flag allows its opposite too.
+func allowedMountOptions(class *storage.StorageClass) (map[string]bool, error) {
+	allowed := map[string]bool{}
+	for _, name := range splitMountOptions([]string{class.Parameters["allowedMountOptions"]}) {
+		if strings.ContainsAny(name, "= \t\"'") {
+			return nil, fmt.Errorf("invalid allowedMountOptions entry %q, must be the name of a mount option such as rsize or noatime", name)
+		}
+		allowed[mountOptionName(name)] = true
+	}
+	return allowed, nil
+}
+
+// claimMountOptions returns options, the mount options of class, with those
+// of the annMountOptions annotation of claim appended, replacing the options
+// of class that set the same thing. Options that class does not allow are
+// refused.
+func claimMountOptions(class *storage.StorageClass, claim *v1.PersistentVolumeClaim, options []string) ([]string, error) {
+	value, ok := claim.Annotations[annMountOptions]
+	if !ok {
+		return options, nil
+	}
+	allowed, err := allowedMountOptions(class)
+	if err != nil {
+		return nil, err
+	}
+	requested := splitMountOptions([]string{value})
+	overridden := map[string]bool{}
+	for _, o := range requested {
+		if strings.ContainsAny(o, " \t\"'") {
+			return nil, fmt.Errorf("invalid mount option %q in %s", o, annMountOptions)
+		}
+		name := mountOptionName(o)
+		if !allowed[name] {
+			return nil, fmt.Errorf("mount option %q of %s is not among the allowedMountOptions of StorageClass %s", o, annMountOptions, class.Name)
+		}
+		overridden[name] = true
+	}
+	var result []string
+	for _, o := range splitMountOptions(options) {
+		if !overridden[mountOptionName(o)] {
+			result = append(result, o)
+		}
+	}
+	return append(result, requested...), nil
+}
@@ -0,0 +1,259 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// movePartialPrefix marks a directory being copied across file systems.
+	movePartialPrefix = ".partial-"
+	// moveProgressInterval is how often the progress of a copy is logged.
+	moveProgressInterval = 30 * time.Second
+)
+
+// moveDir renames the directory src to dst. When they are on different file
+// systems, which NFS servers exporting nested file systems report as EXDEV,
+// src is copied next to dst under a partial name, the copy checked against
+// src, renamed to dst, and only then src removed. A failed copy is removed,
+// and the next attempt starts over.
+func moveDir(ctx context.Context, src, dst string) error {
+	err := os.Rename(src, dst)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	logger := klog.FromContext(ctx)
+	logger.Info("directories are on different file systems, copying", "from", src, "to", dst)
+	partial := filepath.Join(filepath.Dir(dst), movePartialPrefix+filepath.Base(dst))
+	if err := os.RemoveAll(partial); err != nil {
+		return err
+	}
+	pr := progressFrom(ctx)
+	c := &treeCopier{src: src, dst: partial, sums: map[string][]byte{}, logger: logger, progress: pr, throttle: throttleFrom(ctx), next: time.Now().Add(moveProgressInterval)}
+	pr.setPhase(phaseCopying)
+	err = traced(ctx, "CopyDirectory", func() error { return c.copy(ctx) }, "from", src, "to", partial)
+	if err == nil {
+		pr.setPhase(phaseVerifying)
+		err = traced(ctx, "VerifyCopy", c.verify, "path", partial)
+	}
+	if err != nil {
+		_ = os.RemoveAll(partial)
+		return fmt.Errorf("unable to copy %s to %s: %w", src, dst, err)
+	}
+	logger.Info("copied directory", "from", src, "to", dst, "files", c.files, "bytes", c.bytes)
+	if err := os.Rename(partial, dst); err != nil {
+		return err
+	}
+	return traced(ctx, "RemoveAll", func() error { return removeTree(ctx, src) }, "path", src)
+}
+
+// treeCopier copies a directory tree with its modes, owners, times and
+// symbolic links, recording the checksums of the files it copies.
+type treeCopier struct {
+	src, dst     string
+	files, bytes int64
+	// sums are the SHA-256 of the files copied, by path relative to src.
+	sums map[string][]byte
+
+	logger   klog.Logger
+	progress *progress
+	throttle *ioThrottle
+	next     time.Time
+}
+
+func (c *treeCopier) copy(ctx context.Context) error {
+	var dirs []string
+	err := filepath.WalkDir(c.src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(c.src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(c.dst, rel)
+		if err := c.throttle.op(ctx); err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			if err := os.Mkdir(target, 0700); err != nil {
+				return err
+			}
+			// Directories get their mode and times once their content is
+			// written.
+			dirs = append(dirs, rel)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if err := os.Symlink(link, target); err != nil {
+				return err
+			}
+		case d.Type().IsRegular():
+			sum, err := copyRegularFile(ctx, path, target, info, c.throttle)
+			if err != nil {
+				return err
+			}
+			c.sums[rel] = sum
+			c.bytes += info.Size()
+			c.progress.copy(0, info.Size())
+		default:
+			// Devices, sockets and pipes have no place in a volume.
+			return nil
+		}
+		c.files++
+		c.progress.copy(1, 0)
+		if now := time.Now(); now.After(c.next) {
+			c.logger.Info("copying directory", "from", c.src, "files", c.files, "bytes", c.bytes)
+			c.next = now.Add(moveProgressInterval)
+		}
+		return preserveOwner(target, info)
+	})
+	if err != nil {
+		return err
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		info, err := os.Lstat(filepath.Join(c.src, dirs[i]))
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(c.dst, dirs[i])
+		if err := os.Chmod(target, info.Mode()&(fs.ModePerm|fs.ModeSetuid|fs.ModeSetgid|fs.ModeSticky)); err != nil {
+			return err
+		}
+		if err := os.Chtimes(target, info.ModTime(), info.ModTime()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyRegularFile copies src, described by info, to the new file dst no
+// faster than throttle allows, and returns the SHA-256 of what it read.
+func copyRegularFile(ctx context.Context, src, dst string, info fs.FileInfo, throttle *ioThrottle) ([]byte, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.New()
+	_, err = io.Copy(out, io.TeeReader(throttle.reader(ctx, in), hash))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(dst, info.Mode()&(fs.ModePerm|fs.ModeSetuid|fs.ModeSetgid|fs.ModeSticky))
+	}
+	if err == nil {
+		err = os.Chtimes(dst, info.ModTime(), info.ModTime())
+	}
+	return hash.Sum(nil), err
+}
+
+// verify checks that the copy has the entries of src, with the same types,
+// sizes and symbolic links, and that its files have the content read from
+// src.
+func (c *treeCopier) verify() error {
+	var checked int64
+	err := filepath.WalkDir(c.src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(c.src, path)
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && d.Type()&fs.ModeSymlink == 0 && !d.Type().IsRegular() {
+			return nil
+		}
+		want, err := d.Info()
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(c.dst, rel)
+		got, err := os.Lstat(target)
+		if err != nil {
+			return err
+		}
+		if got.Mode().Type() != want.Mode().Type() {
+			return fmt.Errorf("%s has type %s in the copy, %s in the original", rel, got.Mode().Type(), want.Mode().Type())
+		}
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			wantLink, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if gotLink, err := os.Readlink(target); err != nil || gotLink != wantLink {
+				return fmt.Errorf("symbolic link %s differs in the copy", rel)
+			}
+		case d.Type().IsRegular():
+			if got.Size() != want.Size() {
+				return fmt.Errorf("%s is %d bytes in the copy, %d in the original", rel, got.Size(), want.Size())
+			}
+			sum, err := fileSHA256(target)
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(sum, c.sums[rel]) {
+				return fmt.Errorf("%s differs in the copy", rel)
+			}
+		}
+		checked++
+		return nil
+	})
+	if err == nil && checked != c.files {
+		err = fmt.Errorf("the original has %d entries, %d were copied", checked, c.files)
+	}
+	return err
+}
+
+func fileSHA256(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// writeTree creates, under root, a volume with nested directories, files, a
+// symbolic link and modes to preserve.
+func writeTree(t *testing.T, root string) {
+	t.Helper()
+	modified := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, dir := range []string{"data/nested", "empty"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, content := range map[string]string{"data/file": "hello", "data/nested/other": "world!", "empty-file": ""} {
+		path := filepath.Join(root, name)
+		if err := os.WriteFile(path, []byte(content), 0o640); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modified, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("data/file", filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(root, "data"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func copiedTree(t *testing.T, src, dst string) *treeCopier {
+	t.Helper()
+	c := &treeCopier{src: src, dst: dst, sums: map[string][]byte{}, logger: klog.Background(), next: time.Now().Add(moveProgressInterval)}
+	if err := c.copy(context.Background()); err != nil {
+		t.Fatalf("copy() = %v", err)
+	}
+	return c
+}
+
+func TestTreeCopierCopy(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "volume")
+	writeTree(t, src)
+	dst := filepath.Join(t.TempDir(), "copy")
+	c := copiedTree(t, src, dst)
+	if err := c.verify(); err != nil {
+		t.Fatalf("verify() of an untouched copy = %v", err)
+	}
+	if c.files != 8 || c.bytes != 11 {
+		t.Errorf("copied %d entries and %d bytes, want 8 and 11", c.files, c.bytes)
+	}
+
+	tests := []struct {
+		name string
+		// check tells whether the copy differs from the original.
+		check func(src, dst fs.FileInfo) bool
+		path  string
+	}{
+		{name: "directory mode", path: "data", check: func(src, dst fs.FileInfo) bool { return src.Mode() != dst.Mode() }},
+		{name: "file mode", path: "data/file", check: func(src, dst fs.FileInfo) bool { return src.Mode() != dst.Mode() }},
+		{name: "file time", path: "data/nested/other", check: func(src, dst fs.FileInfo) bool { return !src.ModTime().Equal(dst.ModTime()) }},
+		{name: "symbolic link", path: "link", check: func(src, dst fs.FileInfo) bool { return dst.Mode()&fs.ModeSymlink == 0 }},
+		{name: "empty directory", path: "empty", check: func(src, dst fs.FileInfo) bool { return !dst.IsDir() }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := os.Lstat(filepath.Join(src, tt.path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.Lstat(filepath.Join(dst, tt.path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.check(want, got) {
+				t.Errorf("%s not preserved: %v %v, want %v %v", tt.name, got.Mode(), got.ModTime(), want.Mode(), want.ModTime())
+			}
+		})
+	}
+}
+
+func TestTreeCopierVerify(t *testing.T) {
+	tests := []struct {
+		name string
+		// tamper changes the copy at dst.
+		tamper func(dst string) error
+	}{
+		{name: "content changed", tamper: func(dst string) error {
+			return os.WriteFile(filepath.Join(dst, "data/file"), []byte("HELLO"), 0o640)
+		}},
+		{name: "file truncated", tamper: func(dst string) error {
+			return os.Truncate(filepath.Join(dst, "data/nested/other"), 3)
+		}},
+		{name: "file missing", tamper: func(dst string) error {
+			return os.Remove(filepath.Join(dst, "empty-file"))
+		}},
+		{name: "directory missing", tamper: func(dst string) error {
+			return os.Remove(filepath.Join(dst, "empty"))
+		}},
+		{name: "link retargeted", tamper: func(dst string) error {
+			link := filepath.Join(dst, "link")
+			if err := os.Remove(link); err != nil {
+				return err
+			}
+			return os.Symlink("data/nested/other", link)
+		}},
+		{name: "file replaced by a directory", tamper: func(dst string) error {
+			path := filepath.Join(dst, "empty-file")
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			return os.Mkdir(path, 0o755)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := filepath.Join(t.TempDir(), "volume")
+			writeTree(t, src)
+			dst := filepath.Join(t.TempDir(), "copy")
+			c := copiedTree(t, src, dst)
+			if err := tt.tamper(dst); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.verify(); err == nil {
+				t.Errorf("verify() accepted a copy whose %s", tt.name)
+			}
+		})
+	}
+}
+
+func TestMoveDirRenames(t *testing.T) {
+	root := t.TempDir()
+	src, dst := filepath.Join(root, "volume"), filepath.Join(root, "archived-volume")
+	writeTree(t, src)
+	if err := moveDir(context.Background(), src, dst); err != nil {
+		t.Fatalf("moveDir() = %v", err)
+	}
+	if _, err := os.Lstat(src); !os.IsNotExist(err) {
+		t.Errorf("the original is still there: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, "data/file")); err != nil || string(data) != "hello" {
+		t.Errorf("moved file = %q, %v, want hello", data, err)
+	}
+	if _, err := os.Lstat(filepath.Join(root, movePartialPrefix+"archived-volume")); !os.IsNotExist(err) {
+		t.Errorf("a partial copy was left: %v", err)
+	}
+}
@@ -0,0 +1,226 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/sig-storage-lib-external-provisioner/v10/controller"
+)
+
+// memberCluster is one of the --clusters, a further cluster whose volumes
+// are provisioned on the export of the provisioner, in a directory named
+// after it.
+type memberCluster struct {
+	name       string
+	kubeconfig string
+	// context is the context of kubeconfig to use, its current one when
+	// empty.
+	context string
+}
+
+// parseClusters parses the comma separated name=kubeconfig or
+// name=kubeconfig#context entries of list.
+func parseClusters(list string) ([]memberCluster, error) {
+	var clusters []memberCluster
+	seen := map[string]bool{}
+	for _, item := range splitList(list) {
+		name, source, ok := strings.Cut(item, "=")
+		if !ok || source == "" {
+			return nil, fmt.Errorf("%q is not name=kubeconfig", item)
+		}
+		if len(validation.IsDNS1123Label(name)) > 0 {
+			return nil, fmt.Errorf("cluster name %q must be a DNS label such as east-1", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("cluster %s is given twice", name)
+		}
+		seen[name] = true
+		c := memberCluster{name: name}
+		c.kubeconfig, c.context, _ = strings.Cut(source, "#")
+		if !filepath.IsAbs(c.kubeconfig) {
+			return nil, fmt.Errorf("the kubeconfig of cluster %s must be an absolute path", name)
+		}
+		clusters = append(clusters, c)
+	}
+	return clusters, nil
+}
+
+// restConfig returns the client configuration of c, and the namespace of
+// its context, where the leader election lease is kept.
+func (c memberCluster) restConfig() (*rest.Config, string, error) {
+	loader := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: c.kubeconfig},
+		&clientcmd.ConfigOverrides{CurrentContext: c.context})
+	config, err := loader.ClientConfig()
+	if err != nil {
+		return nil, "", err
+	}
+	namespace, _, err := loader.Namespace()
+	if err != nil {
+		return nil, "", err
+	}
+	return config, namespace, nil
+}
+
+// clusterDirectory tells, for a directory of a new volume on b, whether it
+// would land in the directory of a member cluster.
+func (p *nfsProvisioner) clusterDirectory(b *backend, dir string) (string, bool) {
+	if b.mountPath != p.mountPath {
+		return "", false
+	}
+	first, _, _ := strings.Cut(filepath.ToSlash(filepath.Clean(dir)), "/")
+	for _, m := range p.clusterMembers() {
+		if name := filepath.Base(m.mountPath); name == first {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// addMember adds m to the members of p, with the configuration in effect,
+// in case a reload happened while it was starting.
+func (p *nfsProvisioner) addMember(m *nfsProvisioner) {
+	p.cfgMu.Lock()
+	defer p.cfgMu.Unlock()
+	m.cfgMu.Lock()
+	m.cfg = p.cfg
+	m.cfgMu.Unlock()
+	m.policy.Store(p.policy.Load())
+	p.members = append(p.members, m)
+}
+
+// clusterMembers returns the members of p started so far.
+func (p *nfsProvisioner) clusterMembers() []*nfsProvisioner {
+	p.cfgMu.RLock()
+	defer p.cfgMu.RUnlock()
+	return p.members
+}
+
+// startMember provisions the volumes of the member cluster c until ctx is
+// done, in the directory of its name on the export. The member shares the
+// mount, worker pools, throttle, logs and notifiers of p, and its settings,
+// but watches the claims and PVs of its own cluster, with a controller and
+// a leader election of its own there, and reaps the orphans of its
+// directory against them. NFSExports, quotas and the other background
+// checks of p are not available to it.
+func (p *nfsProvisioner) startMember(ctx context.Context, c memberCluster) (*nfsProvisioner, error) {
+	logger := klog.FromContext(ctx).WithValues("cluster", c.name)
+	cfg := p.settings()
+	config, namespace, err := c.restConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load the kubeconfig of cluster %s: %v", c.name, err)
+	}
+	config.QPS = float32(cfg.KubeAPIQPS)
+	config.Burst = cfg.KubeAPIBurst
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	mountPath := filepath.Join(p.mountPath, c.name)
+	if err := os.MkdirAll(mountPath, 0777); err != nil {
+		return nil, fmt.Errorf("unable to create the directory of cluster %s: %v", c.name, err)
+	}
+
+	factory := informers.NewSharedInformerFactory(clientset, cfg.resyncPeriod())
+	claimInformer := factory.Core().V1().PersistentVolumeClaims()
+	volumeInformer := factory.Core().V1().PersistentVolumes()
+	classInformer := factory.Storage().V1().StorageClasses()
+	m := &nfsProvisioner{
+		client:           clientset,
+		name:             p.name,
+		server:           p.server,
+		path:             path.Join(p.path, c.name),
+		mountPath:        mountPath,
+		mountOpts:        p.mountOpts,
+		audit:            p.audit,
+		operations:       newOperationTracker(p.clock),
+		exporter:         p.exporter,
+		kerberos:         p.kerberos,
+		watchdog:         p.watchdog,
+		locks:            p.locks,
+		versions:         p.versions,
+		rquota:           p.rquota,
+		shareAudit:       p.shareAudit,
+		throttle:         p.throttle,
+		notifier:         p.notifier,
+		claims:           claimInformer.Lister(),
+		volumes:          volumeInformer.Lister(),
+		classes:          classInformer.Lister(),
+		maxWorkers:       p.maxWorkers,
+		provisionWorkers: p.provisionWorkers,
+		deleteWorkers:    p.deleteWorkers,
+		archiveWorkers:   p.archiveWorkers,
+		adopted:          p.adopted,
+		mkfsCommand:      p.mkfsCommand,
+		cfg:              cfg,
+		clock:            p.clock,
+		random:           p.random,
+		gids:             newGIDAllocator(),
+	}
+	m.policy.Store(p.policy.Load())
+	broadcaster := record.NewBroadcaster(record.WithContext(ctx))
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+	m.recorder = broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: cfg.ProvisionerName})
+
+	pc := controller.NewProvisionController(
+		logger,
+		clientset,
+		cfg.ProvisionerName,
+		m,
+		controller.LeaderElection(cfg.LeaderElection && !cfg.ActiveActive),
+		controller.LeaderElectionNamespace(namespace),
+		controller.ClaimsInformer(claimInformer.Informer()),
+		controller.VolumesInformer(volumeInformer.Informer()),
+		controller.ClassesInformer(classInformer.Informer()),
+		controller.NodesLister(factory.Core().V1().Nodes().Lister()),
+		controller.AdditionalProvisionerNames(p.adopted),
+		controller.Threadiness(cfg.MaxWorkerThreads),
+		controller.ResyncPeriod(cfg.resyncPeriod()),
+		controller.RateLimiter(retryRateLimiter(cfg.retryDelays())),
+		controller.FailedProvisionThreshold(cfg.FailedProvisionLimit),
+		controller.FailedDeleteThreshold(cfg.FailedDeleteLimit),
+		controller.AddFinalizer(cfg.PVFinalizer),
+	)
+	m.startRepointing(ctx, volumeInformer.Informer())
+	m.startDeletePurger(ctx)
+	if grace := cfg.orphanGracePeriod(); grace > 0 {
+		m.runOrphanReaper(ctx, volumeInformer.Informer(), grace, splitList(cfg.OrphanExclude), cfg.OrphanAction)
+	}
+	factory.Start(ctx.Done())
+	go func() {
+		factory.WaitForCacheSync(ctx.Done())
+		logger.Info("provisioning the volumes of cluster", "path", m.path)
+		pc.Run(ctx)
+	}()
+	return m, nil
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestAddMember(t *testing.T) {
+	current := &config{ProvisionerName: "current"}
+	p := &nfsProvisioner{cfg: current}
+	p.policy.Store(newPolicyClient("http://policy.example.com"))
+
+	// Readers of the members, such as a shutdown, run along.
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				for _, m := range p.clusterMembers() {
+					m.settings()
+				}
+			}
+		}
+	}()
+	for i := 0; i < 3; i++ {
+		p.addMember(&nfsProvisioner{cfg: &config{ProvisionerName: "stale"}})
+	}
+	close(stop)
+	wg.Wait()
+
+	members := p.clusterMembers()
+	if len(members) != 3 {
+		t.Fatalf("%d members, want 3", len(members))
+	}
+	for _, m := range members {
+		if m.settings() != current {
+			t.Errorf("member configuration = %q, want the one in effect", m.settings().ProvisionerName)
+		}
+		if m.policy.Load() != p.policy.Load() {
+			t.Errorf("member policy is not the one in effect")
+		}
+	}
+}
+
+func TestParseClusters(t *testing.T) {
+	tests := []struct {
+		list    string
+		want    []memberCluster
+		wantErr bool
+	}{
+		{list: ""},
+		{list: "east-1=/etc/clusters/east", want: []memberCluster{{name: "east-1", kubeconfig: "/etc/clusters/east"}}},
+		{
+			list: " east-1=/etc/clusters/kubeconfig#east , west-1=/etc/clusters/kubeconfig#west,",
+			want: []memberCluster{
+				{name: "east-1", kubeconfig: "/etc/clusters/kubeconfig", context: "east"},
+				{name: "west-1", kubeconfig: "/etc/clusters/kubeconfig", context: "west"},
+			},
+		},
+		{list: "east-1", wantErr: true},
+		{list: "east-1=", wantErr: true},
+		{list: "East_1=/etc/clusters/east", wantErr: true},
+		{list: "=/etc/clusters/east", wantErr: true},
+		{list: "east-1=clusters/east", wantErr: true},
+		{list: "east-1=#east", wantErr: true},
+		{list: "east-1=/etc/clusters/east,east-1=/etc/clusters/other", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.list, func(t *testing.T) {
+			got, err := parseClusters(tt.list)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseClusters() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseClusters() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// namespacePattern matches namespace names: an exact name, or a regular
+// expression between slashes, anchored at both ends.
+type namespacePattern struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// parseNamespacePatterns parses a comma separated list of namespace
+// patterns.
+func parseNamespacePatterns(list string) ([]namespacePattern, error) {
+	var patterns []namespacePattern
+	for _, item := range splitList(list) {
+		if len(item) > 1 && strings.HasPrefix(item, "/") && strings.HasSuffix(item, "/") {
+			re, err := regexp.Compile("^(?:" + item[1:len(item)-1] + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("invalid namespace pattern %s: %v", item, err)
+			}
+			patterns = append(patterns, namespacePattern{re: re})
+			continue
+		}
+		patterns = append(patterns, namespacePattern{name: item})
+	}
+	return patterns, nil
+}
+
+// matchNamespace reports whether namespace matches one of patterns.
+func matchNamespace(patterns []namespacePattern, namespace string) bool {
+	for _, pattern := range patterns {
+		if pattern.re != nil && pattern.re.MatchString(namespace) || pattern.re == nil && pattern.name == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// checkNamespace refuses claims of namespaces this provisioner does not
+// serve: those matching --denied-namespaces, and, when --allowed-namespaces
+// or --allowed-namespace-selector is set, those matching neither.
+func (p *nfsProvisioner) checkNamespace(ctx context.Context, namespace string) error {
+	cfg := p.settings()
+	// The patterns and selector were validated with the configuration.
+	denied, _ := parseNamespacePatterns(cfg.DeniedNamespaces)
+	if matchNamespace(denied, namespace) {
+		return fmt.Errorf("namespace %s is denied by the provisioner", namespace)
+	}
+	allowed, _ := parseNamespacePatterns(cfg.AllowedNamespaces)
+	if len(allowed) == 0 && cfg.NamespaceSelector == "" {
+		return nil
+	}
+	if matchNamespace(allowed, namespace) {
+		return nil
+	}
+	if cfg.NamespaceSelector != "" {
+		selector, _ := labels.Parse(cfg.NamespaceSelector)
+		ns, err := p.client.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("unable to check namespace %s: %v", namespace, err)
+		}
+		if selector.Matches(labels.Set(ns.Labels)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("namespace %s is not allowed to provision volumes of this provisioner", namespace)
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// annServerHostname records on a PV naming a pinned address the
+	// hostname it was resolved from.
+	annServerHostname = "nfs.io/server-hostname"
+	// annServerAddresses records on a PV the comma separated addresses its
+	// server hostname resolved to when it was provisioned.
+	annServerAddresses = "nfs.io/server-addresses"
+
+	serverCheckInterval = 5 * time.Minute
+)
+
+// canonicalServer returns server the way PVs name it: IP addresses in their
+// canonical form, IPv6 ones without brackets as the kubelet adds them and
+// IPv4-mapped ones as IPv4, and
+// hostnames in lower case without a trailing dot.
+func canonicalServer(server string) (string, error) {
+	s := strings.TrimSpace(server)
+	bracketed := strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]")
+	if bracketed {
+		s = s[1 : len(s)-1]
+	}
+	if addr, err := netip.ParseAddr(s); err == nil {
+		if bracketed && !addr.Is6() {
+			return "", fmt.Errorf("invalid NFS server %q, only IPv6 addresses are bracketed", server)
+		}
+		return addr.Unmap().String(), nil
+	}
+	if bracketed || strings.Contains(s, ":") {
+		return "", fmt.Errorf("invalid NFS server %q, not an IPv6 address", server)
+	}
+	s = strings.TrimSuffix(strings.ToLower(s), ".")
+	if errs := validation.IsDNS1123Subdomain(s); len(errs) > 0 {
+		return "", fmt.Errorf("invalid NFS server %q: %s", server, strings.Join(errs, ", "))
+	}
+	return s, nil
+}
+
+// serverList returns the canonical servers of a comma separated list.
+func serverList(list string) ([]string, error) {
+	var servers []string
+	for _, item := range splitList(list) {
+		server, err := canonicalServer(item)
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, server)
+	}
+	return servers, nil
+}
+
+// mountSource returns the source to mount path of server with, bracketing
+// IPv6 addresses.
+func mountSource(server, path string) string {
+	if strings.Contains(server, ":") {
+		return "[" + server + "]:" + path
+	}
+	return server + ":" + path
+}
+
+// lookupServer returns the addresses server resolves to, in the order of
+// preference of the resolver. An address resolves to itself.
+func lookupServer(ctx context.Context, server string) ([]string, error) {
+	if addr, err := netip.ParseAddr(server); err == nil {
+		return []string{addr.String()}, nil
+	}
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", server)
+	if err != nil {
+		return nil, err
+	}
+	var addrs []string
+	for _, ip := range ips {
+		addrs = append(addrs, ip.Unmap().String())
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("%s has no address", server)
+	}
+	return addrs, nil
+}
+
+// resolveServer applies the serverResolution parameter of class to server,
+// returning the server the PV names and the annotations recording what it
+// resolved to. With "pin" the PV names the first address of the hostname,
+// with "verify" it keeps the hostname, which must resolve. Either way later
+// DNS changes are reported by startServerChecks.
+func resolveServer(ctx context.Context, class *storage.StorageClass, server string) (string, map[string]string, error) {
+	var mode string
+	if class != nil {
+		mode = class.Parameters["serverResolution"]
+	}
+	switch mode {
+	case "", "none":
+		return server, nil, nil
+	case "pin", "verify":
+	default:
+		return "", nil, fmt.Errorf("invalid serverResolution %q, must be none, verify or pin", mode)
+	}
+	if _, err := netip.ParseAddr(server); err == nil {
+		return server, nil, nil
+	}
+	addrs, err := lookupServer(ctx, server)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to resolve NFS server %s: %v", server, err)
+	}
+	pinned := addrs[0]
+	sort.Strings(addrs)
+	annotations := map[string]string{annServerAddresses: strings.Join(addrs, ",")}
+	if mode == "verify" {
+		return server, annotations, nil
+	}
+	annotations[annServerHostname] = server
+	return pinned, annotations, nil
+}
+
+// startServerChecks resolves again, every serverCheckInterval until ctx is
+// done, the server hostnames of the PVs provisioned with serverResolution,
+// and records a warning on those left behind: a pinned PV whose address is
+// gone no longer reaches its server, and the existing mounts of a PV whose
+// addresses are all gone stay on the old ones.
+func (p *nfsProvisioner) startServerChecks(ctx context.Context, volumes cache.SharedIndexInformer) {
+	logger := klog.FromContext(ctx)
+	warned := map[string]string{}
+	go func() {
+		if !cache.WaitForCacheSync(ctx.Done(), volumes.HasSynced) {
+			return
+		}
+		wait.UntilWithContext(ctx, func(ctx context.Context) {
+			list, err := p.volumes.List(labels.Everything())
+			if err != nil {
+				logger.Error(err, "failed to list volumes to check their servers")
+				return
+			}
+			resolved := map[string][]string{}
+			for _, volume := range list {
+				recorded := volume.Annotations[annServerAddresses]
+				if recorded == "" || volume.Spec.NFS == nil || !p.owns(volume.Annotations[annProvisionedBy]) {
+					continue
+				}
+				// A pinned PV only reaches the address it names.
+				hostname, expected := volume.Annotations[annServerHostname], []string{volume.Spec.NFS.Server}
+				if hostname == "" {
+					hostname, expected = volume.Spec.NFS.Server, splitList(recorded)
+				}
+				addrs, ok := resolved[hostname]
+				if !ok {
+					addrs, err = lookupServer(ctx, hostname)
+					if err != nil {
+						logger.Error(err, "failed to resolve NFS server", "server", hostname)
+					}
+					resolved[hostname] = addrs
+				}
+				if addrs == nil || sharesAddress(addrs, expected) {
+					delete(warned, volume.Name)
+					continue
+				}
+				sorted := append([]string{}, addrs...)
+				sort.Strings(sorted)
+				current := strings.Join(sorted, ",")
+				if warned[volume.Name] == current {
+					continue
+				}
+				warned[volume.Name] = current
+				p.warnServerChanged(ctx, volume, hostname, recorded, current)
+			}
+		}, serverCheckInterval)
+	}()
+}
+
+// warnServerChanged reports that hostname, which resolved to recorded when
+// volume was provisioned, now resolves to current.
+func (p *nfsProvisioner) warnServerChanged(ctx context.Context, volume *v1.PersistentVolume, hostname, recorded, current string) {
+	message := fmt.Sprintf("NFS server %s resolved to %s when this volume was provisioned, and now resolves to %s", hostname, recorded, current)
+	if volume.Annotations[annServerHostname] != "" {
+		message += fmt.Sprintf("; the volume is pinned to %s and will fail to mount if the server has moved", volume.Spec.NFS.Server)
+	} else {
+		message += "; existing mounts still use the old address"
+	}
+	klog.FromContext(ctx).Info("NFS server address changed", "pv", volume.Name, "server", hostname, "was", recorded, "now", current)
+	p.recorder.Event(volume, v1.EventTypeWarning, "ServerAddressChanged", message)
+}
+
+// sharesAddress reports whether a and b have an address in common.
+func sharesAddress(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
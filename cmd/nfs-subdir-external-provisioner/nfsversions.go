@@ -0,0 +1,196 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	nfsProgram  = 100003
+	nfsPort     = "2049"
+	nfsNull     = 0
+	nfsCompound = 1
+	// nfs4ErrMinorVersMismatch answers a COMPOUND of an unsupported minor
+	// version of NFSv4.
+	nfs4ErrMinorVersMismatch = 10021
+	// nfsVersionsTTL is how long the versions of a server are remembered.
+	nfsVersionsTTL = 10 * time.Minute
+)
+
+// probeNFSVersions returns the NFS versions, as nfsvers mount option values,
+// that server supports over TCP: NFSv2 and v3 when they answer a NULL call,
+// and each minor version of NFSv4 when it runs an empty COMPOUND.
+func probeNFSVersions(ctx context.Context, server string) ([]string, error) {
+	addr := net.JoinHostPort(server, nfsPort)
+	var versions []string
+	for _, version := range []uint32{2, 3} {
+		_, err := rpcCall(ctx, addr, nfsProgram, version, nfsNull, nil)
+		var mismatch rpcAcceptError
+		switch {
+		case err == nil:
+			versions = append(versions, fmt.Sprint(version))
+		case !errors.As(err, &mismatch):
+			return nil, err
+		}
+	}
+	for minor := uint32(0); minor <= 2; minor++ {
+		// An empty tag, the minor version and no operations.
+		args := binary.BigEndian.AppendUint32(xdrString(nil, ""), minor)
+		args = binary.BigEndian.AppendUint32(args, 0)
+		reply, err := rpcCall(ctx, addr, nfsProgram, 4, nfsCompound, args)
+		var mismatch rpcAcceptError
+		switch {
+		case errors.As(err, &mismatch):
+			// No NFSv4 at all.
+			return versions, nil
+		case err != nil:
+			return nil, err
+		case len(reply) >= 4 && binary.BigEndian.Uint32(reply) == nfs4ErrMinorVersMismatch:
+		default:
+			versions = append(versions, fmt.Sprintf("4.%d", minor))
+		}
+	}
+	return versions, nil
+}
+
+// nfsVersionCache remembers the NFS versions of the servers provisioned on.
+type nfsVersionCache struct {
+	mu      sync.Mutex
+	servers map[string]nfsVersionProbe
+}
+
+// nfsVersionProbe is the outcome of probeNFSVersions.
+type nfsVersionProbe struct {
+	versions []string
+	err      error
+	at       time.Time
+}
+
+func newNFSVersionCache() *nfsVersionCache {
+	return &nfsVersionCache{servers: map[string]nfsVersionProbe{}}
+}
+
+// versions returns the NFS versions server supports, probing it unless it
+// was probed less than nfsVersionsTTL ago.
+func (c *nfsVersionCache) versions(ctx context.Context, server string) ([]string, error) {
+	c.mu.Lock()
+	probe, ok := c.servers[server]
+	c.mu.Unlock()
+	if ok && time.Since(probe.at) < nfsVersionsTTL {
+		return probe.versions, probe.err
+	}
+	// Concurrent provisioning may probe a server twice, which is harmless.
+	versions, err := probeNFSVersions(ctx, server)
+	c.mu.Lock()
+	c.servers[server] = nfsVersionProbe{versions: versions, err: err, at: time.Now()}
+	c.mu.Unlock()
+	return versions, err
+}
+
+// requestedNFSVersion returns the NFS version mount options ask for with
+// nfsvers or vers, and minorversion, as an nfsvers value such as 4.1, empty
+// if they leave it to negotiation.
+func requestedNFSVersion(options []string) string {
+	var version, minor string
+	for _, o := range splitMountOptions(options) {
+		name, value, _ := strings.Cut(o, "=")
+		switch name {
+		case "nfsvers", "vers":
+			version = value
+		case "minorversion":
+			minor = value
+		}
+	}
+	if version == "4" && minor != "" {
+		version += "." + minor
+	}
+	return version
+}
+
+// supportsNFSVersion reports whether version, an nfsvers value, is among
+// the versions of a server. A bare 4 is supported by any minor version.
+func supportsNFSVersion(versions []string, version string) bool {
+	for _, v := range versions {
+		if v == version || version == "4" && strings.HasPrefix(v, "4.") {
+			return true
+		}
+	}
+	return false
+}
+
+// mountOptionConflicts describes the mount options that contradict each
+// other: two values of the same option, a flag and its opposite, and NFSv4
+// over UDP.
+func mountOptionConflicts(options []string) []string {
+	var conflicts []string
+	byName := map[string]string{}
+	for _, o := range splitMountOptions(options) {
+		name := mountOptionName(o)
+		previous, ok := byName[name]
+		switch {
+		case !ok:
+			byName[name] = o
+		case mountOptionValue(previous) != mountOptionValue(o):
+			conflicts = append(conflicts, fmt.Sprintf("%s conflicts with %s", o, previous))
+		}
+	}
+	if version := requestedNFSVersion(options); strings.HasPrefix(version, "4") {
+		for _, o := range splitMountOptions(options) {
+			if o == "udp" || o == "proto=udp" || o == "proto=udp6" {
+				conflicts = append(conflicts, fmt.Sprintf("%s conflicts with nfsvers=%s, NFSv4 needs TCP", o, version))
+			}
+		}
+	}
+	sort.Strings(conflicts)
+	return conflicts
+}
+
+// checkMountOptions warns, with events on claim, about the mount options of
+// its PV that conflict, or ask for an NFS version that server does not
+// support, rather than letting pods fail to mount it.
+func (p *nfsProvisioner) checkMountOptions(ctx context.Context, claim *v1.PersistentVolumeClaim, server string, options []string) {
+	logger := klog.FromContext(ctx)
+	if conflicts := mountOptionConflicts(options); len(conflicts) > 0 {
+		logger.Info("warning: conflicting mount options", "options", options, "conflicts", conflicts)
+		p.recorder.Eventf(claim, v1.EventTypeWarning, "MountOptionConflict", "The mount options of the volume conflict, pods may fail to mount it: %s", strings.Join(conflicts, ", "))
+	}
+	version := requestedNFSVersion(options)
+	if p.versions == nil || version == "" {
+		return
+	}
+	versions, err := p.versions.versions(ctx, server)
+	if err != nil {
+		logger.V(2).Info("unable to probe the NFS versions of the server", "server", server, "err", err)
+		return
+	}
+	if !supportsNFSVersion(versions, version) {
+		logger.Info("warning: NFS version not supported by the server", "server", server, "nfsvers", version, "supported", versions)
+		p.recorder.Eventf(claim, v1.EventTypeWarning, "UnsupportedNFSVersion", "The volume is mounted with nfsvers=%s, but %s only supports %s, pods will fail to mount it", version, server, strings.Join(versions, ", "))
+	}
+}
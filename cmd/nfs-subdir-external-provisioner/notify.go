@@ -0,0 +1,414 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+)
+
+// The events notifications are sent for.
+const (
+	notifyProvisionFailed = "provision-failed"
+	notifyLowSpace        = "low-space"
+	notifyArchived        = "archived"
+	notifyQuarantined     = "quarantined"
+)
+
+var notifyEvents = []string{notifyProvisionFailed, notifyLowSpace, notifyArchived, notifyQuarantined}
+
+// The kinds of notifiers, chosen by the scheme of their URL.
+const (
+	notifierWebhook = "webhook"
+	notifierSlack   = "slack"
+	notifierNATS    = "nats"
+	notifierKafka   = "kafka"
+)
+
+const (
+	// notifyQueueSize bounds the notifications waiting to be sent; beyond
+	// it, new ones are dropped rather than holding up volume operations.
+	notifyQueueSize = 256
+	// notifyTimeout bounds the delivery of a notification to one notifier.
+	notifyTimeout = 10 * time.Second
+	// notifyRepeatInterval is how long a failure that keeps recurring, such
+	// as the provisioning of the same claim, is not notified again.
+	notifyRepeatInterval = time.Hour
+)
+
+var notificationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metricsNamespace,
+	Name:      "notifications_total",
+	Help:      "Notifications by kind of notifier and result: sent, failed or dropped because too many were waiting.",
+}, []string{"notifier", "result"})
+
+// notification is what notifiers are sent, as JSON.
+type notification struct {
+	Event        string    `json:"event"`
+	Time         time.Time `json:"time"`
+	Provisioner  string    `json:"provisioner"`
+	Message      string    `json:"message"`
+	PV           string    `json:"pv,omitempty"`
+	PVC          string    `json:"pvc,omitempty"`
+	Namespace    string    `json:"namespace,omitempty"`
+	StorageClass string    `json:"storageClass,omitempty"`
+	Export       string    `json:"export,omitempty"`
+	// Location is where an archived directory went.
+	Location string `json:"location,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// key identifies what n is about, the key of Kafka records.
+func (n notification) key() string {
+	switch {
+	case n.PV != "":
+		return n.PV
+	case n.PVC != "":
+		return n.Namespace + "/" + n.PVC
+	}
+	return n.Export
+}
+
+// notifyTarget is a notifier parsed from --notifiers.
+type notifyTarget struct {
+	kind string
+	url  *url.URL
+	// subject is the NATS subject events are published under.
+	subject string
+}
+
+// String names t in logs and errors without the path or credentials of its
+// URL, which often hold a token.
+func (t notifyTarget) String() string {
+	return t.kind + " " + t.url.Host
+}
+
+// parseNotifier parses a notifier URL: an http(s) webhook, slack+https:// for
+// a Slack incoming webhook, nats://host:port/subject or
+// kafka+http(s):// for the topic URL of a Kafka REST proxy.
+func parseNotifier(raw string) (notifyTarget, error) {
+	kind, rest := notifierWebhook, raw
+	if prefix, after, ok := strings.Cut(raw, "+"); ok && (prefix == notifierSlack || prefix == notifierKafka) {
+		kind, rest = prefix, after
+	}
+	u, err := url.Parse(rest)
+	if err != nil || u.Host == "" {
+		return notifyTarget{}, fmt.Errorf("%q is not a notifier URL", redactURL(raw))
+	}
+	t := notifyTarget{kind: kind, url: u}
+	switch {
+	case u.Scheme == "nats" && kind == notifierWebhook:
+		t.kind = notifierNATS
+		t.subject = strings.Trim(u.Path, "/")
+		if t.subject == "" || strings.ContainsAny(t.subject, " \t*>/") {
+			return notifyTarget{}, fmt.Errorf("the NATS URL needs a subject, such as nats://%s/storage.nfs", u.Host)
+		}
+	case u.Scheme != "http" && u.Scheme != "https":
+		return notifyTarget{}, fmt.Errorf("%q is neither an http(s), slack+https, kafka+http(s) nor nats URL", redactURL(raw))
+	}
+	return t, nil
+}
+
+// parseNotifiers parses the comma separated notifier URLs of list.
+func parseNotifiers(list string) ([]notifyTarget, error) {
+	var targets []notifyTarget
+	for _, raw := range splitList(list) {
+		t, err := parseNotifier(raw)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+// checkNotifyEvents checks that list names known events only.
+func checkNotifyEvents(list string) error {
+	for _, event := range splitList(list) {
+		known := false
+		for _, e := range notifyEvents {
+			known = known || e == event
+		}
+		if !known {
+			return fmt.Errorf("unknown event %q, expected some of %s", event, strings.Join(notifyEvents, ", "))
+		}
+	}
+	return nil
+}
+
+// notifier sends notifications to the --notifiers in the background, one at
+// a time, so that a slow notifier never holds up volume operations.
+type notifier struct {
+	provisioner string
+	clock       clock.PassiveClock
+	queue       chan notification
+	// pending counts the notifications queued or being delivered.
+	pending atomic.Int64
+
+	mu sync.Mutex
+	// sent is when recurring notifications were last sent, by repeat key.
+	sent map[string]time.Time
+}
+
+// startNotifier sends the notifications of p until ctx is done.
+func (p *nfsProvisioner) startNotifier(ctx context.Context) *notifier {
+	n := &notifier{
+		provisioner: p.name,
+		clock:       p.clock,
+		queue:       make(chan notification, notifyQueueSize),
+		sent:        map[string]time.Time{},
+	}
+	prometheus.MustRegister(notificationsTotal)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-n.queue:
+				p.deliver(ctx, event)
+				n.pending.Add(-1)
+			}
+		}
+	}()
+	return n
+}
+
+// notify queues event for the notifiers configured for it. A non-empty
+// repeatKey identifies a failure that may recur, which is notified once per
+// notifyRepeatInterval only.
+func (p *nfsProvisioner) notify(event notification, repeatKey string) {
+	n := p.notifier
+	settings := p.settings()
+	if n == nil || settings.Notifiers == "" {
+		return
+	}
+	if events := splitList(settings.NotifyEvents); len(events) > 0 {
+		wanted := false
+		for _, e := range events {
+			wanted = wanted || e == event.Event
+		}
+		if !wanted {
+			return
+		}
+	}
+	now := n.clock.Now()
+	if repeatKey != "" && !n.due(event.Event+"/"+repeatKey, now) {
+		return
+	}
+	event.Time = now.UTC()
+	event.Provisioner = n.provisioner
+	n.pending.Add(1)
+	select {
+	case n.queue <- event:
+	default:
+		n.pending.Add(-1)
+		targets, _ := parseNotifiers(settings.Notifiers)
+		for _, t := range targets {
+			notificationsTotal.WithLabelValues(t.kind, "dropped").Inc()
+		}
+		klog.Background().Info("warning: too many notifications waiting, dropped one", "event", event.Event, "message", event.Message)
+	}
+}
+
+// flush waits up to timeout for the queued notifications to be delivered,
+// and returns the number of those left.
+func (n *notifier) flush(ctx context.Context, timeout time.Duration) int64 {
+	if n.pending.Load() > 0 && timeout > 0 {
+		_ = wait.PollUntilContextTimeout(ctx, drainPollInterval, timeout, true, func(context.Context) (bool, error) {
+			return n.pending.Load() == 0, nil
+		})
+	}
+	return n.pending.Load()
+}
+
+// due tells whether the recurring notification key is to be sent at now,
+// and if so records that it was.
+func (n *notifier) due(key string, now time.Time) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for k, sent := range n.sent {
+		if now.Sub(sent) >= notifyRepeatInterval {
+			delete(n.sent, k)
+		}
+	}
+	if _, ok := n.sent[key]; ok {
+		return false
+	}
+	n.sent[key] = now
+	return true
+}
+
+// deliver sends event to every notifier, logging those that fail. Failed
+// notifications are not retried.
+func (p *nfsProvisioner) deliver(ctx context.Context, event notification) {
+	logger := klog.FromContext(ctx)
+	targets, err := parseNotifiers(p.settings().Notifiers)
+	if err != nil {
+		logger.Error(err, "invalid notifiers")
+		return
+	}
+	for _, t := range targets {
+		sendCtx, cancel := context.WithTimeout(ctx, notifyTimeout)
+		err := t.send(sendCtx, event)
+		cancel()
+		if err != nil {
+			notificationsTotal.WithLabelValues(t.kind, "failed").Inc()
+			logger.Error(err, "failed to send notification", "notifier", t.String(), "event", event.Event)
+			continue
+		}
+		notificationsTotal.WithLabelValues(t.kind, "sent").Inc()
+		logger.V(4).Info("sent notification", "notifier", t.String(), "event", event.Event)
+	}
+}
+
+// send delivers event to t.
+func (t notifyTarget) send(ctx context.Context, event notification) error {
+	var data []byte
+	var err error
+	contentType := "application/json"
+	switch t.kind {
+	case notifierSlack:
+		data, err = json.Marshal(map[string]string{"text": fmt.Sprintf("%s: %s", event.Provisioner, event.Message)})
+	case notifierKafka:
+		// The v2 API of the Confluent REST proxy.
+		contentType = "application/vnd.kafka.json.v2+json"
+		data, err = json.Marshal(map[string]interface{}{
+			"records": []map[string]interface{}{{"key": event.key(), "value": event}},
+		})
+	default:
+		data, err = json.Marshal(event)
+	}
+	if err != nil {
+		return err
+	}
+	if t.kind == notifierNATS {
+		return publishNATS(ctx, t.url, t.subject+"."+event.Event, data)
+	}
+	return t.post(ctx, contentType, data)
+}
+
+// post posts data to the URL of t.
+func (t notifyTarget) post(ctx context.Context, contentType string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url.String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// The error of the client repeats the URL, which may hold a token.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, hookOutputLimit))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s returned %s: %s", t, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// publishNATS publishes data to subject on the NATS server at u, over a
+// connection of its own, and waits for the server to acknowledge it. The
+// user and password of u, or its user alone as a token, authenticate the
+// connection. Servers requiring TLS are not supported.
+func publishNATS(ctx context.Context, u *url.URL, subject string, data []byte) error {
+	address := u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	info, ok := strings.CutPrefix(strings.TrimSpace(line), "INFO ")
+	if !ok {
+		return fmt.Errorf("unexpected greeting from the NATS server: %q", strings.TrimSpace(line))
+	}
+	var server struct {
+		TLSRequired bool `json:"tls_required"`
+	}
+	if err := json.Unmarshal([]byte(info), &server); err == nil && server.TLSRequired {
+		return fmt.Errorf("the NATS server requires TLS, which is not supported")
+	}
+
+	connect := map[string]interface{}{"verbose": false, "pedantic": false, "lang": "go", "name": "nfs-subdir-external-provisioner"}
+	if user := u.User; user != nil {
+		if password, ok := user.Password(); ok {
+			connect["user"], connect["pass"] = user.Username(), password
+		} else {
+			connect["auth_token"] = user.Username()
+		}
+	}
+	options, err := json.Marshal(connect)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "CONNECT %s\r\nPUB %s %d\r\n%s\r\nPING\r\n", options, subject, len(data), data)
+	if _, err := conn.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	// The PONG answering the PING tells that the publication went through.
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("NATS server: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNotifierFlush(t *testing.T) {
+	tests := []struct {
+		name    string
+		pending int64
+		// delivery is how long the pending notifications take to deliver.
+		delivery time.Duration
+		timeout  time.Duration
+		want     int64
+	}{
+		{name: "nothing queued", timeout: time.Second},
+		{name: "delivered in time", pending: 2, delivery: 50 * time.Millisecond, timeout: 5 * time.Second},
+		{name: "delivered too late", pending: 2, delivery: time.Hour, timeout: 200 * time.Millisecond, want: 2},
+		{name: "no time left", pending: 1, delivery: time.Hour, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := &notifier{queue: make(chan notification, notifyQueueSize)}
+			n.pending.Add(tt.pending)
+			timer := time.AfterFunc(tt.delivery, func() { n.pending.Add(-tt.pending) })
+			defer timer.Stop()
+
+			if got := n.flush(context.Background(), tt.timeout); got != tt.want {
+				t.Errorf("flush() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// operation is a Provision or Delete call that has not returned yet.
+type operation struct {
+	Kind    string    `json:"kind"`
+	PV      string    `json:"pv"`
+	PVC     string    `json:"pvc,omitempty"`
+	Started time.Time `json:"started"`
+}
+
+// operationFailure records consecutive failures of the same operation, which
+// is what a stuck reclaim loop looks like from the inside.
+type operationFailure struct {
+	Kind        string    `json:"kind"`
+	PV          string    `json:"pv"`
+	Count       int       `json:"count"`
+	LastError   string    `json:"lastError"`
+	LastAttempt time.Time `json:"lastAttempt"`
+}
+
+// operationTracker keeps track of in-flight and repeatedly failing
+// operations.
+type operationTracker struct {
+	mu       sync.Mutex
+	inFlight map[string]*operation
+	failures map[string]*operationFailure
+	// unsaved are the volumes provisioned lately, whose PV the controller
+	// may still be creating, by the time they were provisioned.
+	unsaved map[string]time.Time
+	clock   clock.PassiveClock
+}
+
+func newOperationTracker(clock clock.PassiveClock) *operationTracker {
+	return &operationTracker{
+		clock:    clock,
+		inFlight: map[string]*operation{},
+		failures: map[string]*operationFailure{},
+		unsaved:  map[string]time.Time{},
+	}
+}
+
+// start registers an operation and returns the function to call with its
+// result once it is done.
+func (t *operationTracker) start(kind, pv, pvc string) func(error) {
+	key := kind + "/" + pv
+	op := &operation{Kind: kind, PV: pv, PVC: pvc, Started: t.clock.Now()}
+
+	t.mu.Lock()
+	t.inFlight[key] = op
+	t.mu.Unlock()
+
+	return func(err error) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.inFlight, key)
+		if err == nil {
+			delete(t.failures, key)
+			return
+		}
+		f, ok := t.failures[key]
+		if !ok {
+			f = &operationFailure{Kind: kind, PV: pv}
+			t.failures[key] = f
+		}
+		f.Count++
+		f.LastError = err.Error()
+		f.LastAttempt = t.clock.Now()
+	}
+}
+
+// snapshot returns copies of the in-flight operations and failures, oldest
+// first.
+func (t *operationTracker) snapshot() ([]operation, []operationFailure) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ops := make([]operation, 0, len(t.inFlight))
+	for _, op := range t.inFlight {
+		ops = append(ops, *op)
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i].Started.Before(ops[j].Started) })
+
+	failures := make([]operationFailure, 0, len(t.failures))
+	for _, f := range t.failures {
+		failures = append(failures, *f)
+	}
+	sort.Slice(failures, func(i, j int) bool { return failures[i].LastAttempt.Before(failures[j].LastAttempt) })
+
+	return ops, failures
+}
+
+// provisioned records that the volume called pv was provisioned, and that
+// its PV is about to be created. Volumes provisioned more than
+// reservationTimeout ago are forgotten.
+func (t *operationTracker) provisioned(pv string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.clock.Now()
+	for name, provisioned := range t.unsaved {
+		if now.Sub(provisioned) > reservationTimeout {
+			delete(t.unsaved, name)
+		}
+	}
+	t.unsaved[pv] = now
+}
+
+// unsavedVolumes returns the volumes recorded by provisioned for which saved
+// returns false, and forgets the others.
+func (t *operationTracker) unsavedVolumes(saved func(pv string) bool) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var names []string
+	for name := range t.unsaved {
+		if saved(name) {
+			delete(t.unsaved, name)
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,317 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// orphansFile records, at the root of an export, since when each of its
+	// orphaned directories has been seen without a PV.
+	orphansFile = ".nfs-provisioner-orphans.json"
+	// orphanReapInterval is how often the exports are searched for orphaned
+	// directories.
+	orphanReapInterval = time.Hour
+
+	orphanActionArchive = "archive"
+	orphanActionDelete  = "delete"
+)
+
+var reapedDirectories = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metricsNamespace,
+	Name:      "reaped_directories_total",
+	Help:      "Orphaned directories archived or deleted by the reaper, by action and result.",
+}, []string{"action", "result"})
+
+// orphanReaper archives, or deletes, the directories of the exports that
+// have belonged to no PV for longer than grace, leaving alone those matching
+// the exclude patterns.
+type orphanReaper struct {
+	p       *nfsProvisioner
+	grace   time.Duration
+	exclude []string
+	action  string
+}
+
+// startOrphanReaper reaps the orphaned directories of the mounted exports
+// every orphanReapInterval until ctx is done.
+func (p *nfsProvisioner) startOrphanReaper(ctx context.Context, volumes cache.SharedIndexInformer, grace time.Duration, exclude []string, action string) {
+	prometheus.MustRegister(reapedDirectories)
+	p.runOrphanReaper(ctx, volumes, grace, exclude, action)
+}
+
+// runOrphanReaper is startOrphanReaper without registering the metrics, for
+// the members of p, which reap their own directory against their own PVs.
+func (p *nfsProvisioner) runOrphanReaper(ctx context.Context, volumes cache.SharedIndexInformer, grace time.Duration, exclude []string, action string) {
+	r := &orphanReaper{p: p, grace: grace, exclude: exclude, action: action}
+	go func() {
+		if !cache.WaitForCacheSync(ctx.Done(), volumes.HasSynced) {
+			return
+		}
+		wait.UntilWithContext(ctx, r.reap, orphanReapInterval)
+	}()
+}
+
+func (r *orphanReaper) reap(ctx context.Context) {
+	ctx = withThrottle(ctx, r.p.throttle)
+	logger := klog.FromContext(ctx)
+	// A stale mount would hang the walk of the exports.
+	if err := r.p.watchdog.healthy(); err != nil {
+		logger.V(2).Info("not reaping orphaned directories", "reason", err.Error())
+		return
+	}
+	volumes, err := r.p.volumes.List(labels.Everything())
+	if err != nil {
+		logger.Error(err, "failed to list volumes to reap orphaned directories")
+		return
+	}
+	for _, b := range r.p.mountedBackends() {
+		if err := r.reapExport(ctx, b, volumes); err != nil {
+			logger.Error(err, "failed to reap the orphaned directories of export", "export", mountSource(b.server, b.path))
+		}
+	}
+}
+
+// reapExport finds the orphaned directories of the export of b, and reaps
+// those orphaned for longer than the grace period.
+func (r *orphanReaper) reapExport(ctx context.Context, b *backend, volumes []*v1.PersistentVolume) error {
+	logger := klog.FromContext(ctx)
+	if err := checkExportRoot(b.mountPath); errors.Is(err, errExportEmpty) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	// The directory of any PV is taken, whatever its provisioner, so that
+	// the volumes of another provisioner on the same export are safe.
+	v := &verifier{
+		export:  b.mountPath,
+		server:  b.server,
+		nfsPath: b.path,
+		owns:    func(string) bool { return true },
+		checks:  map[string]bool{checkOrphans: true},
+	}
+	known := map[string]bool{}
+	for _, volume := range volumes {
+		if dir, ok := v.directory(volume); ok {
+			known[dir] = true
+		}
+	}
+	// The directories of the member clusters hold volumes this reaper knows
+	// nothing of; each member reaps its own.
+	if b.mountPath == r.p.mountPath {
+		for _, m := range r.p.clusterMembers() {
+			known[filepath.Base(m.mountPath)] = true
+		}
+	}
+	// Excluded directories are walked around as if they were volumes, so
+	// that the directories holding them are not reaped either.
+	for _, pattern := range r.exclude {
+		matches, _ := filepath.Glob(filepath.Join(b.mountPath, filepath.FromSlash(pattern)))
+		for _, match := range matches {
+			if rel, err := filepath.Rel(b.mountPath, match); err == nil {
+				known[filepath.ToSlash(rel)] = true
+			}
+		}
+	}
+	if err := v.findOrphans(known); err != nil {
+		return err
+	}
+
+	statePath := filepath.Join(b.mountPath, orphansFile)
+	seen, err := readOrphans(statePath)
+	if err != nil {
+		return err
+	}
+	now := r.p.clock.Now().UTC()
+	orphaned := map[string]time.Time{}
+	for _, f := range v.found {
+		since, ok := seen[f.dir]
+		if !ok {
+			logger.Info("found orphaned directory", "export", mountSource(b.server, b.path), "dir", f.dir, "reapAfter", r.grace)
+			since = now
+		}
+		if now.Sub(since) < r.grace {
+			orphaned[f.dir] = since
+			continue
+		}
+		if err := r.reapDir(ctx, b, f.dir, since); err != nil {
+			logger.Error(err, "failed to reap orphaned directory", "export", mountSource(b.server, b.path), "dir", f.dir)
+			orphaned[f.dir] = since
+		}
+	}
+	return writeOrphans(statePath, orphaned)
+}
+
+// reapDir archives, or deletes, the orphaned directory dir of the export of
+// b, orphaned since since.
+func (r *orphanReaper) reapDir(ctx context.Context, b *backend, dir string, since time.Time) error {
+	logger := klog.FromContext(ctx)
+	settings := r.p.settings()
+	local := filepath.Join(b.mountPath, filepath.FromSlash(dir))
+	record := auditRecord{Server: b.server, Path: path.Join(b.path, dir)}
+	if settings.MetadataFile != "" {
+		if metadata, err := readMetadataFile(filepath.Join(local, settings.MetadataFile)); err == nil {
+			record.PV, record.PVC, record.Namespace, record.StorageClass = metadata.PV, metadata.PVC, metadata.Namespace, metadata.StorageClass
+		}
+	}
+
+	var err error
+	var archivePath string
+	if r.action == orphanActionDelete {
+		record.Action = auditDelete
+		err = r.deleteDir(ctx, record, local)
+	} else {
+		record.Action = auditArchive
+		archivePath, err = r.archiveDir(ctx, b, record, local)
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		// Gone already, e.g. reaped by another replica.
+		return nil
+	}
+	r.p.audit.log(record, err)
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	reapedDirectories.WithLabelValues(r.action, result).Inc()
+	if err == nil {
+		r.p.shareAudit.log(b, shareAuditRecord{
+			Action:       record.Action,
+			PV:           record.PV,
+			PVC:          record.PVC,
+			Namespace:    record.Namespace,
+			StorageClass: record.StorageClass,
+			Path:         record.Path,
+			Destination:  b.serverPath(archivePath),
+		})
+		logger.Info("reaped orphaned directory", "export", mountSource(b.server, b.path), "dir", dir, "action", r.action, "orphanedSince", since)
+	}
+	return err
+}
+
+// archiveDir moves the orphaned directory local to an archive at the root of
+// the export of b, with a manifest telling what it was. It returns where the
+// archive is.
+func (r *orphanReaper) archiveDir(ctx context.Context, b *backend, record auditRecord, local string) (string, error) {
+	rel, err := filepath.Rel(b.mountPath, local)
+	if err != nil {
+		return "", err
+	}
+	name := archivePrefix + strings.ReplaceAll(filepath.ToSlash(rel), "/", "-")
+	archivePath := filepath.Join(b.mountPath, name)
+	if _, err := os.Stat(archivePath); err == nil {
+		archivePath += "-" + r.p.random.suffix()
+	}
+	if err := moveDir(ctx, local, archivePath); err != nil {
+		return "", err
+	}
+	name, _ = filepath.Rel(b.mountPath, archivePath)
+	manifest := archiveManifest{
+		Archive:      filepath.ToSlash(name),
+		Format:       "directory",
+		Archived:     r.p.clock.Now().UTC(),
+		PV:           record.PV,
+		PVC:          record.PVC,
+		Namespace:    record.Namespace,
+		StorageClass: record.StorageClass,
+		Server:       record.Server,
+		Path:         record.Path,
+	}
+	if err := writeArchiveManifest(ctx, manifest, b.mountPath, name); err != nil {
+		klog.FromContext(ctx).Error(err, "failed to write the manifest of the archive", "path", archivePath)
+	}
+	return archivePath, nil
+}
+
+// deleteDir removes the orphaned directory local, after consulting the
+// policy endpoint if one is configured.
+func (r *orphanReaper) deleteDir(ctx context.Context, record auditRecord, local string) error {
+	if policy := r.p.policy.Load(); policy != nil {
+		input := policyInput{
+			Action:       "delete",
+			PV:           record.PV,
+			PVC:          record.PVC,
+			Namespace:    record.Namespace,
+			StorageClass: record.StorageClass,
+			Server:       record.Server,
+			Path:         record.Path,
+		}
+		decision, reason, err := policy.check(ctx, input)
+		switch {
+		case err != nil:
+			return fmt.Errorf("unable to consult deletion policy: %v", err)
+		case decision == policyDeny, decision == policyDefer:
+			return fmt.Errorf("deletion of %s refused by policy: %s", record.Path, reason)
+		}
+	}
+	if _, err := os.Lstat(local); err != nil {
+		return err
+	}
+	return removeTree(ctx, local)
+}
+
+// readOrphans reads the orphans file at path, empty if there is none.
+func readOrphans(path string) (map[string]time.Time, error) {
+	orphans := map[string]time.Time{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return orphans, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &orphans); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", path, err)
+	}
+	return orphans, nil
+}
+
+// writeOrphans replaces the orphans file at path, removing it when there
+// are no orphans.
+func writeOrphans(path string, orphans map[string]time.Time) error {
+	if len(orphans) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(orphans, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
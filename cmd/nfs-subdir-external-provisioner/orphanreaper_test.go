@@ -0,0 +1,190 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestReapExport(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		dirs    []string
+		action  string
+		exclude []string
+		// members are the member clusters of the provisioner.
+		members []string
+		// seen tells how long ago each directory was first found orphaned.
+		seen      map[string]time.Duration
+		wantDirs  []string
+		wantState []string
+	}{
+		{name: "volume", dirs: []string{"default-data-pvc-1"}, wantDirs: []string{"default-data-pvc-1"}},
+		{
+			name:      "newly orphaned",
+			dirs:      []string{"default-data-pvc-1", "old"},
+			wantDirs:  []string{"default-data-pvc-1", "old"},
+			wantState: []string{"old"},
+		},
+		{
+			name:      "orphaned within the grace period",
+			dirs:      []string{"old"},
+			seen:      map[string]time.Duration{"old": time.Hour},
+			wantDirs:  []string{"old"},
+			wantState: []string{"old"},
+		},
+		{
+			name:     "orphaned past the grace period, archived",
+			dirs:     []string{"old"},
+			seen:     map[string]time.Duration{"old": 48 * time.Hour},
+			wantDirs: []string{"archived-old"},
+		},
+		{
+			name:     "parent of no volume archived whole",
+			dirs:     []string{"team-a/data", "team-a/old"},
+			seen:     map[string]time.Duration{"team-a": 48 * time.Hour},
+			wantDirs: []string{"archived-team-a"},
+		},
+		{
+			name:     "orphaned past the grace period, deleted",
+			dirs:     []string{"default-data-pvc-1", "old"},
+			action:   orphanActionDelete,
+			seen:     map[string]time.Duration{"old": 48 * time.Hour},
+			wantDirs: []string{"default-data-pvc-1"},
+		},
+		{
+			name:     "claimed again",
+			dirs:     []string{"default-data-pvc-1"},
+			seen:     map[string]time.Duration{"default-data-pvc-1": 48 * time.Hour},
+			wantDirs: []string{"default-data-pvc-1"},
+		},
+		{
+			name:     "excluded",
+			dirs:     []string{"keep/me", "scratch-1", "scratch-2"},
+			exclude:  []string{"keep", "scratch-*"},
+			seen:     map[string]time.Duration{"keep": 48 * time.Hour, "scratch-1": 48 * time.Hour},
+			wantDirs: []string{"keep", "scratch-1", "scratch-2"},
+		},
+		{
+			name:     "directory of a member cluster",
+			dirs:     []string{"east-1/team-a-data-pvc-9", "east-1/old", "west"},
+			members:  []string{"east-1"},
+			action:   orphanActionDelete,
+			seen:     map[string]time.Duration{"east-1": 48 * time.Hour, "west": 48 * time.Hour},
+			wantDirs: []string{"east-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			export := t.TempDir()
+			for _, dir := range tt.dirs {
+				if err := os.MkdirAll(filepath.Join(export, dir), 0o755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			statePath := filepath.Join(export, orphansFile)
+			if tt.seen != nil {
+				seen := map[string]time.Time{}
+				for dir, age := range tt.seen {
+					seen[dir] = now.Add(-age)
+				}
+				if err := writeOrphans(statePath, seen); err != nil {
+					t.Fatal(err)
+				}
+			}
+			p := &nfsProvisioner{cfg: &config{}, mountPath: export, clock: clocktesting.NewFakePassiveClock(now), random: newRandomSource(1)}
+			for _, name := range tt.members {
+				p.addMember(&nfsProvisioner{mountPath: filepath.Join(export, name)})
+			}
+			action := tt.action
+			if action == "" {
+				action = orphanActionArchive
+			}
+			r := &orphanReaper{p: p, grace: 24 * time.Hour, exclude: tt.exclude, action: action}
+			b := &backend{server: "10.0.0.1", path: "/srv/nfs", mountPath: export}
+			volume := &v1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{Name: "pvc-1", Annotations: map[string]string{annProvisionedBy: "nfs"}},
+				Spec: v1.PersistentVolumeSpec{PersistentVolumeSource: v1.PersistentVolumeSource{
+					NFS: &v1.NFSVolumeSource{Server: "10.0.0.1", Path: "/srv/nfs/default-data-pvc-1"},
+				}},
+			}
+
+			if err := r.reapExport(context.Background(), b, []*v1.PersistentVolume{volume}); err != nil {
+				t.Fatalf("reapExport() = %v", err)
+			}
+			entries, err := os.ReadDir(export)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var dirs []string
+			for _, entry := range entries {
+				if entry.IsDir() {
+					dirs = append(dirs, entry.Name())
+				}
+			}
+			if !reflect.DeepEqual(dirs, tt.wantDirs) {
+				t.Errorf("directories left = %v, want %v", dirs, tt.wantDirs)
+			}
+			// Archives have a manifest telling what they were.
+			for _, dir := range tt.wantDirs {
+				_, err := os.Stat(filepath.Join(export, dir+archiveManifestSuffix))
+				if want := strings.HasPrefix(dir, archivePrefix); (err == nil) != want {
+					t.Errorf("manifest of %s present = %v, want %v", dir, err == nil, want)
+				}
+			}
+			state, err := readOrphans(statePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for dir, since := range state {
+				got = append(got, dir)
+				if age, ok := tt.seen[dir]; ok && !since.Equal(now.Add(-age)) {
+					t.Errorf("%s orphaned since %v, want %v", dir, since, now.Add(-age))
+				}
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.wantState) {
+				t.Errorf("orphans recorded = %v, want %v", got, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestReapExportEmpty(t *testing.T) {
+	export := t.TempDir()
+	p := &nfsProvisioner{cfg: &config{}, clock: clocktesting.NewFakePassiveClock(time.Now())}
+	r := &orphanReaper{p: p, action: orphanActionDelete}
+	if err := r.reapExport(context.Background(), &backend{server: "10.0.0.1", path: "/srv/nfs", mountPath: export}, nil); err != nil {
+		t.Fatalf("reapExport() of an empty export = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(export, orphansFile)); !os.IsNotExist(err) {
+		t.Errorf("orphans file written to an empty export: %v", err)
+	}
+}
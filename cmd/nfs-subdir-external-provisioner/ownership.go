@@ -0,0 +1,141 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+)
+
+// parseID parses the numeric user or group ID value of the parameter name.
+func parseID(name, value string) (int, error) {
+	id, err := strconv.ParseUint(value, 10, 31)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q, must be a numeric ID", name, value)
+	}
+	return int(id), nil
+}
+
+// The annotations of a claim asking for its directory to be owned by a user
+// and group, among those allowed by the allowedUIDs and allowedGIDs
+// parameters of its class.
+const (
+	annUID = "nfs.io/uid"
+	annGID = "nfs.io/gid"
+)
+
+// parseIDRanges parses the value of the parameter name, a comma separated
+// list of IDs and ranges of IDs, such as 1000-1999,5000.
+func parseIDRanges(name, value string) ([][2]int, error) {
+	var ranges [][2]int
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		first, last, isRange := strings.Cut(field, "-")
+		lo, err := strconv.ParseUint(first, 10, 31)
+		hi := lo
+		if err == nil && isRange {
+			hi, err = strconv.ParseUint(last, 10, 31)
+		}
+		if err != nil || hi < lo {
+			return nil, fmt.Errorf("invalid %s %q, must be IDs and ranges of IDs such as 1000-1999,5000", name, value)
+		}
+		ranges = append(ranges, [2]int{int(lo), int(hi)})
+	}
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("empty %s", name)
+	}
+	return ranges, nil
+}
+
+// claimID returns the ID the annotation ann of claim asks for, -1 if it has
+// none, checking that the allowed parameter of class allows it.
+func claimID(class *storage.StorageClass, claim *v1.PersistentVolumeClaim, ann, allowed string) (int, error) {
+	value, ok := claim.Annotations[ann]
+	if !ok {
+		return -1, nil
+	}
+	id, err := parseID(ann, value)
+	if err != nil {
+		return 0, err
+	}
+	if class.Parameters[allowed] == "" {
+		return 0, fmt.Errorf("claim sets %s, but StorageClass %s sets no %s", ann, class.Name, allowed)
+	}
+	ranges, err := parseIDRanges(allowed, class.Parameters[allowed])
+	if err != nil {
+		return 0, err
+	}
+	for _, r := range ranges {
+		if id >= r[0] && id <= r[1] {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("%s %d is not among the %s %s of StorageClass %s", ann, id, allowed, class.Parameters[allowed], class.Name)
+}
+
+// directoryOwnership returns the user and group the new directory of claim,
+// of class, is given to, -1 to leave them to the NFS server, and mode with
+// the setgid bit added if the class sets setgid, so that the files created in
+// it inherit that group rather than the primary group of their creator. The
+// annUID and annGID annotations of the claim take precedence over the gid
+// parameter of the class.
+func directoryOwnership(class *storage.StorageClass, claim *v1.PersistentVolumeClaim, mode os.FileMode) (int, int, os.FileMode, error) {
+	uid, err := claimID(class, claim, annUID, "allowedUIDs")
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	gid, err := claimID(class, claim, annGID, "allowedGIDs")
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if value := class.Parameters["gid"]; value != "" && gid < 0 {
+		if gid, err = parseID("gid", value); err != nil {
+			return 0, 0, 0, err
+		}
+	}
+	if value := class.Parameters["setgid"]; value != "" {
+		setgid, err := strconv.ParseBool(value)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("invalid setgid %q, must be true or false", value)
+		}
+		if setgid {
+			mode |= os.ModeSetgid
+		}
+	}
+	return uid, gid, mode, nil
+}
+
+// describeOwner describes the user and group a directory is given to, -1
+// standing for one left unchanged.
+func describeOwner(uid, gid int) string {
+	switch {
+	case gid < 0:
+		return fmt.Sprintf("user %d", uid)
+	case uid < 0:
+		return fmt.Sprintf("group %d", gid)
+	default:
+		return fmt.Sprintf("user %d and group %d", uid, gid)
+	}
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/sig-storage-lib-external-provisioner/v10/controller"
+)
+
+const (
+	// annVolumeGroup on a PVC names the group of volumes it belongs to, in
+	// its namespace. The volumes of a group are placed on the same export.
+	// It is copied to the PV as namespace/group.
+	annVolumeGroup = "nfs.io/volume-group"
+)
+
+// volumeGroup returns the group of claim, qualified with its namespace, or
+// "" if it has none.
+func volumeGroup(claim *v1.PersistentVolumeClaim) string {
+	group := claim.Annotations[annVolumeGroup]
+	if group == "" {
+		return ""
+	}
+	return claim.Namespace + "/" + group
+}
+
+// rankExports orders names by rendezvous hashing with key: every key gets
+// its own stable order, which only changes for the names added or removed.
+func rankExports(names []string, key string) []string {
+	weight := func(name string) uint64 {
+		sum := sha256.Sum256([]byte(key + "\x00" + name))
+		return binary.BigEndian.Uint64(sum[:8])
+	}
+	ranked := append([]string{}, names...)
+	sort.SliceStable(ranked, func(i, j int) bool { return weight(ranked[i]) > weight(ranked[j]) })
+	return ranked
+}
+
+// placeVolume picks, among the NFSExports called names, the export of the
+// volume of options. A volume joins the export of the other volumes of its
+// group. Otherwise the exports are tried in their rendezvous order for the
+// group, or for the volume, skipping those too small for it. When the claim
+// waits for its first consumer, a volume without group goes to the closest
+// export the selected node reaches.
+func (p *nfsProvisioner) placeVolume(ctx context.Context, names []string, options controller.ProvisionOptions) (*backend, error) {
+	claim := options.PVC
+	group := volumeGroup(claim)
+	if group != "" {
+		b, err := p.groupBackend(ctx, names, group)
+		if b != nil || err != nil {
+			if err == nil {
+				err = b.checkCapacity(claim)
+			}
+			return b, err
+		}
+	}
+
+	key := group
+	if key == "" {
+		key = options.PVName
+	}
+	ranked := rankExports(names, key)
+	// The first member of a group places the whole group, wherever its own
+	// consumer runs.
+	if node := options.SelectedNode; node != nil && group == "" {
+		ranked = p.closestExports(ranked, node)
+		if len(ranked) == 0 {
+			return nil, fmt.Errorf("%w: node %s reaches none of the NFSExports %s", errUnreachable, node.Name, strings.Join(names, ", "))
+		}
+	}
+	var errs []string
+	for _, name := range ranked {
+		b, err := p.exports.get(ctx, name)
+		if err == nil {
+			err = b.checkCapacity(claim)
+		}
+		if err == nil {
+			return b, nil
+		}
+		b.release()
+		errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+		if group != "" {
+			// Members provisioned concurrently must not spread, so a
+			// group never moves past the first export in its order.
+			break
+		}
+	}
+	return nil, fmt.Errorf("no NFSExport can hold the volume: %s", strings.Join(errs, "; "))
+}
+
+// closestExports returns the NFSExports of names that node reaches, those
+// whose nodeAffinity selects it first, then those reached by every node. The
+// order of names is kept otherwise.
+func (p *nfsProvisioner) closestExports(names []string, node *v1.Node) []string {
+	var near, anywhere, invalid []string
+	for _, name := range names {
+		_, spec, err := p.exports.object(name)
+		switch {
+		case err != nil:
+			// Tried last, to report why it cannot be used.
+			invalid = append(invalid, name)
+		case spec.NodeAffinity == "":
+			anywhere = append(anywhere, name)
+		case reaches(node, spec.NodeAffinity):
+			near = append(near, name)
+		}
+	}
+	return append(append(near, anywhere...), invalid...)
+}
+
+// groupBackend returns the export of the volumes of group, if any of them
+// exists yet.
+func (p *nfsProvisioner) groupBackend(ctx context.Context, names []string, group string) (*backend, error) {
+	volumes, err := p.volumes.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, volume := range volumes {
+		if volume.Annotations[annVolumeGroup] != group || !p.owns(volume.Annotations[annProvisionedBy]) || volume.Spec.NFS == nil {
+			continue
+		}
+		b, err := p.backendForVolume(ctx, volume)
+		if err != nil {
+			return nil, fmt.Errorf("unable to find the export of volume group %s: %v", group, err)
+		}
+		for _, name := range names {
+			if b.mountPath == p.exports.mountPath(name) {
+				return b, nil
+			}
+		}
+		b.release()
+		return nil, fmt.Errorf("volume group %s is on an export this StorageClass does not use", group)
+	}
+	return nil, nil
+}
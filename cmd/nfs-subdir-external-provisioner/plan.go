@@ -0,0 +1,241 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/yaml"
+)
+
+// consolidationSource is an export to consolidate, mounted locally.
+type consolidationSource struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// plannedMove maps one top-level directory of a source onto the target.
+type plannedMove struct {
+	Source string `json:"source"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Bytes  int64  `json:"bytes"`
+	Files  int64  `json:"files"`
+}
+
+// planConflict is a directory name claimed by more than one source, or
+// already present on the target.
+type planConflict struct {
+	Directory  string   `json:"directory"`
+	Sources    []string `json:"sources"`
+	OnTarget   bool     `json:"onTarget,omitempty"`
+	Resolution string   `json:"resolution"`
+}
+
+// consolidationPlan is the reviewable output of plan-consolidation.
+type consolidationPlan struct {
+	Generated         time.Time             `json:"generated"`
+	Sources           []consolidationSource `json:"sources"`
+	Target            string                `json:"target"`
+	Bandwidth         string                `json:"bandwidth"`
+	TotalBytes        int64                 `json:"totalBytes"`
+	TotalFiles        int64                 `json:"totalFiles"`
+	EstimatedTransfer string                `json:"estimatedTransfer"`
+	Moves             []plannedMove         `json:"moves"`
+	Conflicts         []planConflict        `json:"conflicts,omitempty"`
+	Errors            []string              `json:"errors,omitempty"`
+}
+
+// sourceFlag collects repeated --source flags of the form [name=]path.
+type sourceFlag []consolidationSource
+
+func (s *sourceFlag) String() string {
+	parts := make([]string, 0, len(*s))
+	for _, source := range *s {
+		parts = append(parts, source.Name+"="+source.Path)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (s *sourceFlag) Set(value string) error {
+	name, path, ok := strings.Cut(value, "=")
+	if !ok {
+		path = value
+		name = filepath.Base(filepath.Clean(value))
+	}
+	if path == "" || name == "" {
+		return fmt.Errorf("invalid source %q", value)
+	}
+	*s = append(*s, consolidationSource{Name: name, Path: path})
+	return nil
+}
+
+func runPlanConsolidation(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	var sources sourceFlag
+	fs.Var(&sources, "source", "Mounted source export, as [name=]path. Repeat for every export.")
+	target := fs.String("target", "", "Mounted target export.")
+	bandwidth := fs.String("bandwidth", "100Mi", "Expected copy throughput per second, as a quantity.")
+	format := fs.String("format", "yaml", "Output format: yaml or json.")
+	output := fs.String("output", "", "File to write the plan to. Defaults to standard output.")
+	deterministic := fs.Bool("deterministic", false, "Use a fixed clock, so that the same layout always produces the same plan.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if len(sources) == 0 || *target == "" {
+		return fmt.Errorf("at least one --source and a --target are required")
+	}
+	rate, err := resource.ParseQuantity(*bandwidth)
+	if err != nil || rate.Value() <= 0 {
+		return fmt.Errorf("invalid --bandwidth %q", *bandwidth)
+	}
+
+	clock, _ := newTimeSources(*deterministic)
+	plan, err := planConsolidation(ctx, sources, *target, rate, clock)
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	switch *format {
+	case "yaml":
+		data, err = yaml.Marshal(plan)
+	case "json":
+		data, err = json.MarshalIndent(plan, "", "  ")
+	default:
+		return fmt.Errorf("unknown --format %q", *format)
+	}
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// planConsolidation maps every top-level directory of the sources onto the
+// target, measures it, and records name conflicts. Conflicting directories
+// are mapped to "<source>-<directory>", followed by -2, -3... when that name
+// is taken as well, so the plan stays executable.
+func planConsolidation(ctx context.Context, sources []consolidationSource, target string, bandwidth resource.Quantity, clock clock.PassiveClock) (*consolidationPlan, error) {
+	logger := klog.FromContext(ctx)
+	plan := &consolidationPlan{
+		Generated: clock.Now().UTC(),
+		Sources:   sources,
+		Target:    target,
+		Bandwidth: bandwidth.String() + "/s",
+	}
+
+	claimed := map[string][]string{}
+	var moves []plannedMove
+	for _, source := range sources {
+		entries, err := os.ReadDir(source.Path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read source %s: %v", source.Path, err)
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			from := filepath.Join(source.Path, entry.Name())
+			logger.Info("measuring", "path", from)
+			bytes, files, err := diskUsage(ctx, from, nil)
+			if err != nil {
+				plan.Errors = append(plan.Errors, fmt.Sprintf("%s: %v", from, err))
+			}
+			moves = append(moves, plannedMove{Source: source.Name, From: from, Bytes: bytes, Files: files})
+			claimed[entry.Name()] = append(claimed[entry.Name()], source.Name)
+		}
+	}
+
+	conflicting := map[string]bool{}
+	for name, owners := range claimed {
+		_, err := os.Stat(filepath.Join(target, name))
+		onTarget := err == nil
+		if len(owners) > 1 || onTarget {
+			conflicting[name] = true
+			plan.Conflicts = append(plan.Conflicts, planConflict{
+				Directory: name,
+				Sources:   owners,
+				OnTarget:  onTarget,
+			})
+		}
+	}
+	sort.Slice(plan.Conflicts, func(i, j int) bool { return plan.Conflicts[i].Directory < plan.Conflicts[j].Directory })
+
+	// The names kept as they are, and those given to renamed directories so
+	// far, which no other directory may be renamed to.
+	taken := map[string]bool{}
+	for name := range claimed {
+		if !conflicting[name] {
+			taken[name] = true
+		}
+	}
+	renamed := map[string][]string{}
+	for i := range moves {
+		name := filepath.Base(moves[i].From)
+		if conflicting[name] {
+			to := freeName(target, moves[i].Source+"-"+name, taken)
+			renamed[name] = append(renamed[name], to)
+			name = to
+		}
+		taken[name] = true
+		moves[i].To = filepath.Join(target, name)
+		plan.TotalBytes += moves[i].Bytes
+		plan.TotalFiles += moves[i].Files
+	}
+	plan.Moves = moves
+	for i := range plan.Conflicts {
+		plan.Conflicts[i].Resolution = "renamed to " + strings.Join(renamed[plan.Conflicts[i].Directory], ", ")
+	}
+
+	seconds := float64(plan.TotalBytes) / float64(bandwidth.Value())
+	plan.EstimatedTransfer = (time.Duration(seconds) * time.Second).String()
+	return plan, nil
+}
+
+// freeName returns name, or name followed by the first of -2, -3... that is
+// neither taken nor present on target, as planConsolidation tells.
+func freeName(target, name string, taken map[string]bool) string {
+	candidate := name
+	for n := 2; ; n++ {
+		if _, err := os.Stat(filepath.Join(target, candidate)); !taken[candidate] && err != nil {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s-%d", name, n)
+	}
+}
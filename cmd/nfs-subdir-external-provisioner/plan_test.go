@@ -0,0 +1,116 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestPlanConsolidationRenames(t *testing.T) {
+	type source struct {
+		name string
+		// dir is the directory of the source, under which are dirs.
+		dir  string
+		dirs []string
+	}
+	tests := []struct {
+		name    string
+		sources []source
+		target  []string
+		// want are the destinations, by directory of a source.
+		want map[string]string
+	}{
+		{
+			name:    "no conflict",
+			sources: []source{{name: "a", dir: "a", dirs: []string{"x"}}, {name: "b", dir: "b", dirs: []string{"y"}}},
+			want:    map[string]string{"a/x": "x", "b/y": "y"},
+		},
+		{
+			name:    "claimed by two sources",
+			sources: []source{{name: "a", dir: "a", dirs: []string{"x"}}, {name: "b", dir: "b", dirs: []string{"x"}}},
+			want:    map[string]string{"a/x": "a-x", "b/x": "b-x"},
+		},
+		{
+			name:    "on the target",
+			sources: []source{{name: "a", dir: "a", dirs: []string{"x"}}},
+			target:  []string{"x"},
+			want:    map[string]string{"a/x": "a-x"},
+		},
+		{
+			name:    "renamed onto a directory of the target",
+			sources: []source{{name: "a", dir: "a", dirs: []string{"x"}}},
+			target:  []string{"x", "a-x"},
+			want:    map[string]string{"a/x": "a-x-2"},
+		},
+		{
+			name:    "renamed onto a directory of a source",
+			sources: []source{{name: "a", dir: "a", dirs: []string{"x", "a-x"}}, {name: "b", dir: "b", dirs: []string{"x"}}},
+			want:    map[string]string{"a/x": "a-x-2", "a/a-x": "a-x", "b/x": "b-x"},
+		},
+		{
+			name:    "sources of the same name",
+			sources: []source{{name: "export", dir: "a", dirs: []string{"x"}}, {name: "export", dir: "b", dirs: []string{"x"}}},
+			want:    map[string]string{"a/x": "export-x", "b/x": "export-x-2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			target := filepath.Join(root, "target")
+			mkdirs(t, target, tt.target)
+			var sources []consolidationSource
+			for _, s := range tt.sources {
+				path := filepath.Join(root, s.dir)
+				mkdirs(t, path, s.dirs)
+				sources = append(sources, consolidationSource{Name: s.name, Path: path})
+			}
+
+			plan, err := planConsolidation(context.Background(), sources, target, resource.MustParse("100Mi"), clocktesting.NewFakePassiveClock(time.Time{}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]string{}
+			for _, move := range plan.Moves {
+				rel, _ := filepath.Rel(root, move.From)
+				got[filepath.ToSlash(rel)] = filepath.Base(move.To)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("destinations = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func mkdirs(t *testing.T, parent string, names []string) {
+	t.Helper()
+	if err := os.MkdirAll(parent, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		if err := os.Mkdir(filepath.Join(parent, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	policyTimeout = 10 * time.Second
+)
+
+type policyDecision string
+
+const (
+	policyAllow policyDecision = "allow"
+	policyDeny  policyDecision = "deny"
+	policyDefer policyDecision = "defer"
+)
+
+// policyInput describes a destructive operation. It is sent to the policy
+// endpoint as the "input" document, the way OPA expects it.
+type policyInput struct {
+	Action       string `json:"action"`
+	PV           string `json:"pv"`
+	PVC          string `json:"pvc,omitempty"`
+	Namespace    string `json:"namespace,omitempty"`
+	StorageClass string `json:"storageClass,omitempty"`
+	Server       string `json:"server"`
+	Path         string `json:"path"`
+	// Archive is the archived directory purge-archives removes, relative to
+	// the export.
+	Archive string `json:"archive,omitempty"`
+}
+
+// policyResult is the decision returned by the policy endpoint. OPA wraps it
+// in a "result" field; a bare boolean is accepted as allow/deny.
+type policyResult struct {
+	Decision policyDecision `json:"decision"`
+	Allow    *bool          `json:"allow"`
+	Reason   string         `json:"reason"`
+}
+
+// policyClient consults an external policy engine before data is destroyed.
+type policyClient struct {
+	url    string
+	client *http.Client
+}
+
+func newPolicyClient(url string) *policyClient {
+	return &policyClient{
+		url:    url,
+		client: &http.Client{Timeout: policyTimeout},
+	}
+}
+
+// check posts the operation to the policy endpoint and returns its decision.
+// Any failure to obtain a decision is returned as an error so the operation
+// is retried rather than performed unchecked.
+func (c *policyClient) check(ctx context.Context, input policyInput) (policyDecision, string, error) {
+	body, err := json.Marshal(map[string]interface{}{"input": input})
+	if err != nil {
+		return "", "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("policy endpoint unreachable: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("policy endpoint returned %s", resp.Status)
+	}
+
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return "", "", fmt.Errorf("invalid policy response: %v", err)
+	}
+	if len(envelope.Result) == 0 {
+		// OPA omits "result" when the queried rule is undefined.
+		return policyDeny, "policy is undefined", nil
+	}
+
+	var allowed bool
+	if err := json.Unmarshal(envelope.Result, &allowed); err == nil {
+		if allowed {
+			return policyAllow, "", nil
+		}
+		return policyDeny, "", nil
+	}
+
+	var result policyResult
+	if err := json.Unmarshal(envelope.Result, &result); err != nil {
+		return "", "", fmt.Errorf("invalid policy result: %v", err)
+	}
+	switch result.Decision {
+	case policyAllow, policyDeny, policyDefer:
+		return result.Decision, result.Reason, nil
+	case "":
+		if result.Allow != nil && *result.Allow {
+			return policyAllow, result.Reason, nil
+		}
+		return policyDeny, result.Reason, nil
+	default:
+		return "", "", fmt.Errorf("unknown policy decision %q", result.Decision)
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		response   string
+		want       policyDecision
+		wantReason string
+		wantErr    bool
+	}{
+		{name: "allowed", response: `{"result": true}`, want: policyAllow},
+		{name: "denied", response: `{"result": false}`, want: policyDeny},
+		{name: "allow decision", response: `{"result": {"decision": "allow", "reason": "ok"}}`, want: policyAllow, wantReason: "ok"},
+		{name: "deny decision", response: `{"result": {"decision": "deny", "reason": "legal hold"}}`, want: policyDeny, wantReason: "legal hold"},
+		{name: "defer decision", response: `{"result": {"decision": "defer", "reason": "backup running"}}`, want: policyDefer, wantReason: "backup running"},
+		{name: "allow field", response: `{"result": {"allow": true}}`, want: policyAllow},
+		{name: "allow field false", response: `{"result": {"allow": false, "reason": "no"}}`, want: policyDeny, wantReason: "no"},
+		{name: "empty result object", response: `{"result": {}}`, want: policyDeny},
+		{name: "undefined", response: `{}`, want: policyDeny, wantReason: "policy is undefined"},
+		{name: "unknown decision", response: `{"result": {"decision": "maybe"}}`, wantErr: true},
+		{name: "invalid result", response: `{"result": "yes"}`, wantErr: true},
+		{name: "invalid response", response: `not json`, wantErr: true},
+		{name: "server error", status: http.StatusInternalServerError, response: `{"result": true}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body struct {
+					Input policyInput `json:"input"`
+				}
+				if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+					t.Errorf("request = %s %s, want a JSON POST", r.Method, r.Header.Get("Content-Type"))
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Input.PV != "pvc-1" {
+					t.Errorf("input = %+v, %v, want the operation", body.Input, err)
+				}
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			got, reason, err := newPolicyClient(server.URL).check(context.Background(), policyInput{Action: "delete", PV: "pvc-1"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("check() error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want || reason != tt.wantReason {
+				t.Errorf("check() = %q, %q, want %q, %q", got, reason, tt.want, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestPolicyCheckUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+	if _, _, err := newPolicyClient(url).check(context.Background(), policyInput{Action: "delete"}); err == nil {
+		t.Errorf("check() of an unreachable endpoint succeeded")
+	}
+}
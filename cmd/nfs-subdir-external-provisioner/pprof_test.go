@@ -0,0 +1,45 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import "testing"
+
+func TestLoopbackAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		want    bool
+	}{
+		{address: "localhost:6060", want: true},
+		{address: "127.0.0.1:6060", want: true},
+		{address: "127.1.2.3:6060", want: true},
+		{address: "[::1]:6060", want: true},
+		{address: ":6060"},
+		{address: "0.0.0.0:6060"},
+		{address: "[::]:6060"},
+		{address: "10.0.0.5:6060"},
+		{address: "pod.example.com:6060"},
+		{address: "localhost.example.com:6060"},
+		{address: "localhost"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			if got := loopbackAddress(tt.address); got != tt.want {
+				t.Errorf("loopbackAddress() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// The ownership strategies say what happens when the permissions, owner,
+// ACLs or SELinux label of a new directory cannot be set.
+const (
+	// ownershipEnforce fails provisioning.
+	ownershipEnforce = "enforce"
+	// ownershipBestEffort provisions the volume anyway when it is refused
+	// the permission to, with a warning event.
+	ownershipBestEffort = "best-effort"
+	// ownershipSkip leaves new directories as the NFS server creates them.
+	ownershipSkip = "skip"
+)
+
+// The capabilities the provisioner may need, as numbered in
+// <linux/capability.h>.
+var capabilities = []struct {
+	bit  uint
+	name string
+	need string
+}{
+	{0, "CAP_CHOWN", "giving directories to the users of nfs.io/uid annotations, and to groups the provisioner is not a member of"},
+	{1, "CAP_DAC_OVERRIDE", "deleting and archiving volumes holding files the provisioner cannot write to"},
+	{3, "CAP_FOWNER", "setting the permissions, ACLs and SELinux labels of directories it does not own, e.g. reused ones"},
+	{21, "CAP_SYS_ADMIN", "mounting NFSExports"},
+}
+
+// applyOwnership runs set, a step setting the permissions, owner, ACLs or
+// label of the directory of claim, according to the ownership strategy.
+func (p *nfsProvisioner) applyOwnership(ctx context.Context, claim *v1.PersistentVolumeClaim, step string, set func() error) error {
+	strategy := p.settings().OwnershipStrategy
+	if strategy == ownershipSkip {
+		return nil
+	}
+	err := set()
+	if err != nil && strategy == ownershipBestEffort && errors.Is(err, fs.ErrPermission) {
+		klog.FromContext(ctx).Info("warning: not permitted to set up the directory, continuing", "step", step, "err", err)
+		p.recorder.Eventf(claim, v1.EventTypeWarning, "OwnershipNotApplied", "%s was not applied to the volume: %v", step, err)
+		return nil
+	}
+	return err
+}
+
+// effectiveCapabilities returns the effective capabilities of the process,
+// read from status, a /proc/<pid>/status file.
+func effectiveCapabilities(status string) (uint64, error) {
+	f, err := os.Open(status)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "CapEff:"); ok {
+			return strconv.ParseUint(strings.TrimSpace(value), 16, 64)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("no CapEff in %s", status)
+}
+
+// checkPrivileges checks at startup that the provisioner can do its job
+// with the user and capabilities it runs with: create directories at the
+// root of the export mounted at mountPath and, unless the ownership strategy
+// is skip, set their permissions. Missing capabilities that only some
+// features need are logged, naming those features.
+func checkPrivileges(ctx context.Context, cfg *config, mountPath string) error {
+	logger := klog.FromContext(ctx)
+	uid, gid := os.Geteuid(), os.Getegid()
+	if fsUID, fsGID, ok := cfg.fsIdentity(); ok {
+		uid, gid = fsUID, fsGID
+	}
+	caps, err := effectiveCapabilities("/proc/self/status")
+	if err != nil {
+		logger.Info("warning: unable to read the capabilities of the provisioner", "err", err)
+	} else {
+		for _, c := range capabilities {
+			if caps&(1<<c.bit) != 0 {
+				continue
+			}
+			if c.name == "CAP_SYS_ADMIN" && cfg.EnableExports {
+				return fmt.Errorf("--enable-exports mounts NFSExports, which needs CAP_SYS_ADMIN: add it to the securityContext of the container, or disable NFSExports")
+			}
+			if c.name == "CAP_SYS_ADMIN" && cfg.Exports != "" {
+				return fmt.Errorf("--exports mounts its exports, which needs CAP_SYS_ADMIN: add it to the securityContext of the container")
+			}
+			if c.name != "CAP_SYS_ADMIN" {
+				logger.Info(fmt.Sprintf("the provisioner runs without %s, which is needed for %s", c.name, c.need), "uid", uid, "gid", gid)
+			}
+		}
+	}
+
+	probe, err := os.MkdirTemp(mountPath, ".nfs-provisioner-probe-")
+	if err != nil {
+		return fmt.Errorf("unable to create directories in %s as uid %d, gid %d: %v; give that user write access to the root of the export on the NFS server, or run as root on an export without root_squash", mountPath, uid, gid, err)
+	}
+	defer os.RemoveAll(probe)
+	if cfg.OwnershipStrategy == ownershipSkip {
+		return nil
+	}
+	if err := os.Chmod(probe, cfg.dirMode()); err != nil {
+		if cfg.OwnershipStrategy == ownershipBestEffort {
+			logger.Info(fmt.Sprintf("warning: unable to set the permissions of new directories in %s: %v; they keep those the NFS server gives them", mountPath, err), "uid", uid)
+			return nil
+		}
+		return fmt.Errorf("unable to set the permissions of new directories in %s as uid %d: %v; run as the user owning them, or set --ownership-strategy to best-effort or skip", mountPath, uid, err)
+	}
+	return nil
+}
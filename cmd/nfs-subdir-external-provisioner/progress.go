@@ -0,0 +1,200 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog/v2"
+)
+
+// The phases of a deletion reported as progress.
+const (
+	phaseRemoving  = "removing"
+	phaseCopying   = "copying"
+	phaseVerifying = "verifying the copy"
+	phaseUploading = "uploading"
+)
+
+type progressKey struct{}
+
+// progress counts what a deletion has done so far. Its methods may be called
+// concurrently, and on a nil progress, which counts nothing.
+type progress struct {
+	phase   atomic.Pointer[string]
+	removed atomic.Int64
+	copied  atomic.Int64
+	bytes   atomic.Int64
+}
+
+// progressFrom returns the progress of the deletion ctx belongs to, nil if it
+// is not reported.
+func progressFrom(ctx context.Context) *progress {
+	pr, _ := ctx.Value(progressKey{}).(*progress)
+	return pr
+}
+
+func (pr *progress) setPhase(phase string) {
+	if pr != nil {
+		pr.phase.Store(&phase)
+	}
+}
+
+// remove counts entries removed.
+func (pr *progress) remove(entries int64) {
+	if pr != nil {
+		pr.removed.Add(entries)
+	}
+}
+
+// copy counts files copied, and bytes moved.
+func (pr *progress) copy(files, bytes int64) {
+	if pr != nil {
+		pr.copied.Add(files)
+		pr.bytes.Add(bytes)
+	}
+}
+
+// Write counts the bytes written through it as moved.
+func (pr *progress) Write(b []byte) (int, error) {
+	pr.copy(0, int64(len(b)))
+	return len(b), nil
+}
+
+// totals returns the counters, for comparison between reports.
+func (pr *progress) totals() [3]int64 {
+	return [3]int64{pr.removed.Load(), pr.copied.Load(), pr.bytes.Load()}
+}
+
+// String describes the phase and counters, e.g. "copying, 1200 files and
+// 3Gi copied".
+func (pr *progress) String() string {
+	var parts []string
+	if phase := pr.phase.Load(); phase != nil {
+		parts = append(parts, *phase)
+	}
+	if n := pr.copied.Load(); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d files and %s copied", n, resource.NewQuantity(pr.bytes.Load(), resource.BinarySI)))
+	} else if n := pr.bytes.Load(); n > 0 {
+		parts = append(parts, fmt.Sprintf("%s moved", resource.NewQuantity(n, resource.BinarySI)))
+	}
+	if n := pr.removed.Load(); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d entries removed", n))
+	}
+	if len(parts) == 0 {
+		return "starting"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// reportProgress reports the progress of the deletion of volume every
+// --progress-interval, as a log line and an event on the PV, until the
+// returned function is called. Deletions done within an interval are not
+// reported at all.
+func (p *nfsProvisioner) reportProgress(ctx context.Context, volume *v1.PersistentVolume) (context.Context, func()) {
+	interval := p.settings().progressInterval()
+	if interval <= 0 {
+		return ctx, func() {}
+	}
+	logger := klog.FromContext(ctx).WithValues("pv", volume.Name)
+	pr := &progress{}
+	started := p.clock.Now()
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		var last [3]int64
+		reported := false
+		for {
+			select {
+			case <-done:
+				if reported {
+					logger.Info("deletion finished", "elapsed", p.clock.Since(started).Round(time.Second), "progress", pr.String())
+				}
+				return
+			case <-ticker.C:
+			}
+			elapsed := p.clock.Since(started).Round(time.Second)
+			message := fmt.Sprintf("Reclaiming the directory for %s: %s", elapsed, pr)
+			if totals := pr.totals(); reported && totals == last {
+				message += ", no progress since the last report"
+			} else {
+				last = totals
+			}
+			reported = true
+			logger.Info("deletion still running", "elapsed", elapsed, "progress", pr.String())
+			p.recorder.Event(volume, v1.EventTypeNormal, "DeletionProgress", message)
+		}
+	}()
+	return context.WithValue(ctx, progressKey{}, pr), func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// removeTree removes path and everything it contains like os.RemoveAll,
+// counting the entries it removes in the progress of ctx, if any, and no
+// faster than its throttle allows.
+func removeTree(ctx context.Context, path string) error {
+	pr := progressFrom(ctx)
+	throttle := throttleFrom(ctx)
+	if pr == nil && !throttle.limitsOps() {
+		return os.RemoveAll(path)
+	}
+	pr.setPhase(phaseRemoving)
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return removeEntry(ctx, pr, throttle, path, info.IsDir())
+}
+
+// removeEntry removes path, a directory if dir, depth first.
+func removeEntry(ctx context.Context, pr *progress, throttle *ioThrottle, path string, dir bool) error {
+	if dir {
+		entries, err := os.ReadDir(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		for _, entry := range entries {
+			if err := removeEntry(ctx, pr, throttle, filepath.Join(path, entry.Name()), entry.IsDir()); err != nil {
+				return err
+			}
+		}
+	}
+	if err := throttle.op(ctx); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	pr.remove(1)
+	return nil
+}
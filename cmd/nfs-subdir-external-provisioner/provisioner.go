@@ -18,35 +18,104 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
 	"errors"
 	"flag"
 	"fmt"
 	storagehelpers "k8s.io/component-helpers/storage/volume"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	v1 "k8s.io/api/core/v1"
 
 	storage "k8s.io/api/storage/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	storagelisters "k8s.io/client-go/listers/storage/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/sig-storage-lib-external-provisioner/v10/controller"
 )
 
-const (
-	provisionerNameKey = "PROVISIONER_NAME"
-)
-
 type nfsProvisioner struct {
-	client kubernetes.Interface
-	server string
-	path   string
+	client     kubernetes.Interface
+	name       string
+	server     string
+	path       string
+	mountPath  string
+	policy     atomic.Pointer[policyClient]
+	audit      *auditLogger
+	operations *operationTracker
+	exports    *exportManager
+	exporter   exporter
+	kerberos   *kerberosCredentials
+	quotas     *quotaManager
+	alerts     *quotaAlerter
+	watchdog   *mountWatchdog
+	shard      *shard
+	locks      *volumeLocks
+	gids       *gidAllocator
+	versions   *nfsVersionCache
+	rquota     *rquotaClient
+	usage      *usageScanner
+	space      *spaceMonitor
+	failures   deleteFailures
+	shareAudit *shareAuditLog
+	throttle   *ioThrottle
+	notifier   *notifier
+	// members provision the volumes of the --clusters. They are added
+	// while the provisioner already runs, under cfgMu.
+	members    []*nfsProvisioner
+	imports    dynamic.NamespaceableResourceInterface
+	attributes dynamic.NamespaceableResourceInterface
+	recorder   record.EventRecorder
+	// draining is set on shutdown, to refuse new operations.
+	draining atomic.Bool
+
+	// mountOpts are the options of the export when the provisioner mounts it
+	// itself, for --mount-export, and ownsMount is set when it did at
+	// startup, to unmount it on shutdown.
+	ownsMount bool
+	mountOpts []string
+
+	claims  corelisters.PersistentVolumeClaimLister
+	volumes corelisters.PersistentVolumeLister
+	classes storagelisters.StorageClassLister
+
+	maxWorkers       int
+	provisionWorkers *workerLimiter
+	deleteWorkers    *workerLimiter
+	archiveWorkers   *workerLimiter
+
+	// adopted are the names of other provisioners whose volumes this one
+	// manages.
+	adopted []string
+	// mkfsCommand formats the images of sizeEnforced volumes. It is run as
+	// the provisioner, so a change of the config file only takes effect on
+	// restart.
+	mkfsCommand string
+
+	cfgMu sync.RWMutex
+	cfg   *config
+
+	clock  clock.PassiveClock
+	random *randomSource
 }
 
 type pvcMetadata struct {
@@ -55,71 +124,306 @@ type pvcMetadata struct {
 	annotations map[string]string
 }
 
-var pattern = regexp.MustCompile(`\${\.PVC\.((labels|annotations)\.(.*?)|.*?)}`)
+var pattern = regexp.MustCompile(`\${\.PVC\.((labels|annotations)\.(.*?)|.*?)((?:\s*\|\s*\w+)*)\s*}`)
+
+// templateFuncs can be applied to a pathPattern value with a pipe, e.g.
+// ${.PVC.namespace | shortHash}.
+var templateFuncs = map[string]func(string) string{
+	"hash": func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	},
+	"shortHash": func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:4])
+	},
+}
+
+func newPVCMetadata(claim *v1.PersistentVolumeClaim) *pvcMetadata {
+	return &pvcMetadata{
+		data: map[string]string{
+			"name":      claim.Name,
+			"namespace": claim.Namespace,
+			"uid":       string(claim.UID),
+		},
+		labels:      claim.Labels,
+		annotations: claim.Annotations,
+	}
+}
 
-func (meta *pvcMetadata) stringParser(str string) string {
+func (meta *pvcMetadata) stringParser(str string) (string, error) {
 	result := pattern.FindAllStringSubmatch(str, -1)
 	for _, r := range result {
+		var value string
 		switch r[2] {
 		case "labels":
-			str = strings.ReplaceAll(str, r[0], meta.labels[r[3]])
+			value = meta.labels[r[3]]
 		case "annotations":
-			str = strings.ReplaceAll(str, r[0], meta.annotations[r[3]])
+			value = meta.annotations[r[3]]
 		default:
-			str = strings.ReplaceAll(str, r[0], meta.data[r[1]])
+			value = meta.data[r[1]]
+		}
+		for _, name := range strings.Split(r[4], "|")[1:] {
+			name = strings.TrimSpace(name)
+			fn, ok := templateFuncs[name]
+			if !ok {
+				return "", fmt.Errorf("unknown template function %q in %q", name, r[0])
+			}
+			value = fn(value)
 		}
+		str = strings.ReplaceAll(str, r[0], value)
 	}
 
-	return str
+	return str, nil
 }
 
-const (
-	mountPath = "/persistentvolumes"
-)
-
 var _ controller.Provisioner = &nfsProvisioner{}
+var _ controller.BlockProvisioner = &nfsProvisioner{}
+
+// SupportsBlock lets Block claims through to Provision, which refuses them
+// with an explanation rather than the generic error of the controller.
+func (p *nfsProvisioner) SupportsBlock(ctx context.Context) bool {
+	return true
+}
 
 func (p *nfsProvisioner) Provision(ctx context.Context, options controller.ProvisionOptions) (*v1.PersistentVolume, controller.ProvisioningState, error) {
+	p.provisionWorkers.acquire()
+	defer p.provisionWorkers.release()
+
+	name, err := volumeName(options.StorageClass, options.PVC, options.PVName)
+	if err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	options.PVName = name
+	finish := p.operations.start("provision", options.PVName, options.PVC.Namespace+"/"+options.PVC.Name)
+	// Registered before checking, so that drain waits for it if it started.
+	if p.draining.Load() {
+		finish(errShuttingDown)
+		return nil, controller.ProvisioningNoChange, errShuttingDown
+	}
+	ctx, end := startSpan(ctx, "Provision", "pv", options.PVName, "pvc", options.PVC.Name, "namespace", options.PVC.Namespace)
+	pv, state, err := p.provision(ctx, options)
+	end(err)
+	finish(err)
+	if err == nil {
+		p.operations.provisioned(options.PVName)
+		provisionedVolumes.WithLabelValues(options.StorageClass.Name, volumeKind(pv)).Inc()
+	}
+	if err != nil && p.quotas != nil {
+		p.quotas.release(options.PVName)
+	}
+	if err != nil {
+		p.releaseGID(options.PVName)
+	}
+	var ignored *controller.IgnoredError
+	if err != nil && err != errShuttingDown && !errors.As(err, &ignored) {
+		p.notify(notification{
+			Event:        notifyProvisionFailed,
+			PVC:          options.PVC.Name,
+			Namespace:    options.PVC.Namespace,
+			StorageClass: options.StorageClass.Name,
+			Message:      fmt.Sprintf("Provisioning a volume for claim %s/%s failed: %v", options.PVC.Namespace, options.PVC.Name, err),
+			Error:        err.Error(),
+		}, string(options.PVC.UID))
+	}
+
+	record := auditRecord{
+		Action:       auditProvision,
+		PVC:          options.PVC.Name,
+		Namespace:    options.PVC.Namespace,
+		PV:           options.PVName,
+		StorageClass: options.StorageClass.Name,
+	}
+	if pv != nil {
+		record.Server = pv.Spec.NFS.Server
+		record.Path = pv.Spec.NFS.Path
+	}
+	p.audit.log(record, err)
+
+	return pv, state, err
+}
+
+func (p *nfsProvisioner) provision(ctx context.Context, options controller.ProvisionOptions) (*v1.PersistentVolume, controller.ProvisioningState, error) {
 	logger := klog.FromContext(ctx)
 
+	// Let claims wait for the export rather than fail halfway through.
+	if err := p.watchdog.healthy(); err != nil {
+		return nil, controller.ProvisioningNoChange, fmt.Errorf("provisioning paused: %v", err)
+	}
+	if _, full := options.StorageClass.Annotations[annClassFull]; full {
+		return nil, controller.ProvisioningNoChange, fmt.Errorf("StorageClass %s is marked %s, waiting for free space on its exports", options.StorageClass.Name, annClassFull)
+	}
 	if options.PVC.Spec.Selector != nil {
 		return nil, controller.ProvisioningFinished, fmt.Errorf("claim Selector is not supported")
 	}
+	if mode := options.PVC.Spec.VolumeMode; mode != nil && *mode == v1.PersistentVolumeBlock {
+		return nil, controller.ProvisioningFinished, fmt.Errorf("volumeMode Block is not supported: the volumes of StorageClass %s are NFS directories, ask for volumeMode Filesystem", options.StorageClass.Name)
+	}
+	if err := p.checkNamespace(ctx, options.PVC.Namespace); err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	if err := checkAllowedAccessModes(options.StorageClass, options.PVC); err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	attributesClass := options.PVC.Spec.VolumeAttributesClassName
+	class, err := p.classWithAttributes(ctx, options.StorageClass, attributesClass)
+	if err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	options.StorageClass = class
+	if err := p.checkVolumeName(ctx, options); err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	size, err := volumeSize(options.StorageClass, options.PVC)
+	if err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	source, err := p.volumeImport(ctx, options.PVC)
+	if err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	if p.quotas != nil {
+		if err := p.quotas.reserve(options.PVC.Namespace, options.PVName, size); err != nil {
+			return nil, controller.ProvisioningFinished, err
+		}
+	}
 	logger.Info(fmt.Sprintf("nfs provisioner: VolumeOptions %v", options))
 
 	pvcNamespace := options.PVC.Namespace
 	pvcName := options.PVC.Name
 
 	pvName := strings.Join([]string{pvcNamespace, pvcName, options.PVName}, "-")
-
-	metadata := &pvcMetadata{
-		data: map[string]string{
-			"name":      pvcName,
-			"namespace": pvcNamespace,
-		},
-		labels:      options.PVC.Labels,
-		annotations: options.PVC.Annotations,
+	if _, ok := options.StorageClass.Parameters["pvNameTemplate"]; ok {
+		// The name already says what the claim is.
+		pvName = options.PVName
 	}
 
-	fullPath := filepath.Join(mountPath, pvName)
-	path := filepath.Join(p.path, pvName)
+	metadata := newPVCMetadata(options.PVC)
 
+	if err := p.installKerberosCredentials(ctx, options.StorageClass); err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	b, err := p.backendForClass(ctx, options)
+	if errors.Is(err, errUnreachable) {
+		return nil, controller.ProvisioningReschedule, err
+	}
+	if err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	defer b.release()
+	unlock, err := p.locks.lock(b.mountPath, options.PVName)
+	if err != nil {
+		return nil, controller.ProvisioningNoChange, err
+	}
+	defer unlock()
+	if p.locks != nil {
+		// Another replica may have provisioned the claim before this one
+		// got the lock, and its PV not have reached the informer yet.
+		if _, err := p.client.CoreV1().PersistentVolumes().Get(ctx, options.PVName, metav1.GetOptions{}); err == nil {
+			return nil, controller.ProvisioningNoChange, fmt.Errorf("PV %s was provisioned by another replica", options.PVName)
+		}
+	}
+	if err := b.checkCapacity(options.PVC); err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	if err := p.checkQuotaSpace(ctx, b, options.PVC); err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	dedicatedExport, err := p.dedicatedExport(options.StorageClass)
+	if err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	servers, err := p.serversFor(options.StorageClass, b)
+	if err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	server, err := p.preferredServer(servers)
+	if err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	if target, shared := options.PVC.Annotations[annInspect], options.StorageClass.Parameters["sharedPath"]; (target != "" || shared != "") && attributesClass != nil && *attributesClass != "" {
+		return nil, controller.ProvisioningFinished, fmt.Errorf("claims of existing directories cannot have a VolumeAttributesClass")
+	}
+	if target := options.PVC.Annotations[annInspect]; target != "" {
+		return p.provisionInspection(ctx, options, b, server, target)
+	}
+	if shared := options.StorageClass.Parameters["sharedPath"]; shared != "" {
+		return p.provisionShared(ctx, options, b, server, shared)
+	}
+	pvServer, serverAnnotations, err := resolveServer(ctx, options.StorageClass, server)
+	if err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+
+	dir := pvName
 	pathPattern, exists := options.StorageClass.Parameters["pathPattern"]
 	if exists {
-		customPath := metadata.stringParser(pathPattern)
+		customPath, err := metadata.stringParser(pathPattern)
+		if err != nil {
+			return nil, controller.ProvisioningFinished, err
+		}
 		if customPath != "" {
-			path = filepath.Join(p.path, customPath)
-			fullPath = filepath.Join(mountPath, customPath)
+			dir = customPath
 		}
 	}
+	dir, err = layoutDirectory(options.StorageClass, dir)
+	if err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	fullPath, err := volumeDirectory(b, dir)
+	if err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	if cluster, ok := p.clusterDirectory(b, dir); ok {
+		return nil, controller.ProvisioningFinished, fmt.Errorf("directory %s would be in the directory of cluster %s", dir, cluster)
+	}
+	path := filepath.Join(b.path, dir)
+	path, fullPath, err = p.avoidCollision(options.StorageClass, options.PVName, path, fullPath)
+	if err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
 
-	logger.Info(fmt.Sprintf("creating path %s", fullPath))
-	if err := os.MkdirAll(fullPath, 0o777); err != nil {
-		return nil, controller.ProvisioningFinished, errors.New("unable to create directory to provision new pv: " + err.Error())
+	mountOptions, err := claimMountOptions(options.StorageClass, options.PVC, p.classMountOptions(options.StorageClass))
+	if err != nil {
+		return nil, controller.ProvisioningFinished, err
 	}
-	err := os.Chmod(fullPath, 0o777)
+	mountOptions = xprtsecMountOptions(kerberosMountOptions(options.StorageClass, mountOptions), b.mountOptions)
+	// The provisioner reaches the export the way consumers do, so it has to
+	// support the transport security they are asked to use.
+	if err := checkXprtsec(mountOptions); err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	mountOptions, err = enforceMountSecurityProfile(options.StorageClass.Parameters["mountSecurityProfile"], mountOptions)
 	if err != nil {
-		return nil, "", err
+		return nil, controller.ProvisioningFinished, err
+	}
+	label, err := seLinuxLabel(options.StorageClass, options.PVC)
+	if err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	labelDirectory, labelMount, err := seLinuxLabelMode(options.StorageClass)
+	if err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	if label != "" && labelMount {
+		mountOptions = seLinuxMountOptions(label, mountOptions)
+	}
+	p.checkMountOptions(ctx, options.PVC, b.server, mountOptions)
+	affinity, err := nodeAffinity(options.StorageClass, b)
+	if err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	if err := checkSelectedNode(options.SelectedNode, affinity); err != nil {
+		return nil, controller.ProvisioningReschedule, err
+	}
+
+	accessModes, err := volumeAccessModes(options.PVC)
+	if err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	withLockFile, err := wantsLockFile(options.StorageClass, options.PVC)
+	if err != nil {
+		return nil, controller.ProvisioningFinished, err
 	}
 
 	pv := &v1.PersistentVolume{
@@ -128,68 +432,541 @@ func (p *nfsProvisioner) Provision(ctx context.Context, options controller.Provi
 		},
 		Spec: v1.PersistentVolumeSpec{
 			PersistentVolumeReclaimPolicy: *options.StorageClass.ReclaimPolicy,
-			AccessModes:                   options.PVC.Spec.AccessModes,
-			MountOptions:                  options.StorageClass.MountOptions,
+			AccessModes:                   accessModes,
+			MountOptions:                  mountOptions,
+			NodeAffinity:                  affinity,
+			VolumeAttributesClassName:     attributesClass,
 			Capacity: v1.ResourceList{
-				v1.ResourceName(v1.ResourceStorage): options.PVC.Spec.Resources.Requests[v1.ResourceName(v1.ResourceStorage)],
+				v1.ResourceName(v1.ResourceStorage): size,
 			},
 			PersistentVolumeSource: v1.PersistentVolumeSource{
 				NFS: &v1.NFSVolumeSource{
-					Server:   p.server,
+					Server:   pvServer,
 					Path:     path,
 					ReadOnly: false,
 				},
 			},
 		},
 	}
+	if err := applyPVMetadata(options.StorageClass, metadata, pv); err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	// Apply the template before creating the directory, so that a broken
+	// template does not leave one behind.
+	if template, ok := options.StorageClass.Parameters["pvTemplate"]; ok {
+		pv, err = applyPVTemplate(pv, template)
+		if err != nil {
+			return nil, controller.ProvisioningFinished, err
+		}
+	}
+	if pvServer != b.server {
+		metav1.SetMetaDataAnnotation(&pv.ObjectMeta, annExportServer, b.server)
+	}
+	for k, v := range serverAnnotations {
+		metav1.SetMetaDataAnnotation(&pv.ObjectMeta, k, v)
+	}
+	if isEphemeral(options.PVC) {
+		metav1.SetMetaDataAnnotation(&pv.ObjectMeta, annEphemeral, "true")
+	}
+	if group := volumeGroup(options.PVC); group != "" {
+		metav1.SetMetaDataAnnotation(&pv.ObjectMeta, annVolumeGroup, group)
+	}
+	if p.shard != nil {
+		metav1.SetMetaDataAnnotation(&pv.ObjectMeta, annShard, p.shard.name)
+	}
+	if name := options.StorageClass.Parameters["backendSecretName"]; name != "" {
+		metav1.SetMetaDataAnnotation(&pv.ObjectMeta, annBackendSecret, options.StorageClass.Parameters["backendSecretNamespace"]+"/"+name)
+	}
+
+	hook := hookPayload{
+		Hook:         hookPreProvision,
+		PV:           options.PVName,
+		PVC:          pvcName,
+		Namespace:    pvcNamespace,
+		StorageClass: options.StorageClass.Name,
+		Server:       pvServer,
+		Path:         path,
+		LocalPath:    fullPath,
+		Claim:        options.PVC,
+		Volume:       pv,
+	}
+	if err := p.runHook(ctx, hook); err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+
+	uid, gid, mode, err := directoryOwnership(options.StorageClass, options.PVC, p.settings().dirMode())
+	if err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	fileLimit, err := maxFiles(options.StorageClass)
+	if err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	if fileLimit > 0 {
+		metav1.SetMetaDataAnnotation(&pv.ObjectMeta, annMaxFiles, strconv.FormatInt(fileLimit, 10))
+	}
+	enforced, err := sizeEnforced(options.StorageClass)
+	if err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	if enforced {
+		if err := checkImageAccessModes(options.PVC); err != nil {
+			return nil, controller.ProvisioningFinished, err
+		}
+		if source != nil {
+			return nil, controller.ProvisioningFinished, fmt.Errorf("sizeEnforced volumes cannot be imported into")
+		}
+		metav1.SetMetaDataAnnotation(&pv.ObjectMeta, annImageFile, imageFile)
+	}
+	if lo, hi, ok, err := gidRange(options.StorageClass); err != nil {
+		return nil, controller.ProvisioningFinished, err
+	} else if ok {
+		if _, set := options.PVC.Annotations[annGID]; set {
+			return nil, controller.ProvisioningFinished, fmt.Errorf("claim sets %s, but StorageClass %s allocates GIDs", annGID, options.StorageClass.Name)
+		}
+		if gid, err = p.allocateGID(options.PVName, lo, hi); err != nil {
+			return nil, controller.ProvisioningFinished, err
+		}
+		metav1.SetMetaDataAnnotation(&pv.ObjectMeta, annVolumeGID, strconv.Itoa(gid))
+	}
+	if err := p.locks.fence(options.PVName); err != nil {
+		return nil, controller.ProvisioningNoChange, err
+	}
+	if err := b.checkMounted(); err != nil {
+		return nil, controller.ProvisioningNoChange, err
+	}
+	_, statErr := os.Lstat(fullPath)
+	created := os.IsNotExist(statErr)
+	logger.Info(fmt.Sprintf("creating path %s", fullPath))
+	if err := traced(ctx, "MkdirAll", func() error { return os.MkdirAll(fullPath, mode.Perm()) }, "path", fullPath); err != nil {
+		return nil, controller.ProvisioningFinished, errors.New("unable to create directory to provision new pv: " + err.Error())
+	}
+	// Changing the owner clears the setgid bit, so it goes before the mode.
+	if uid >= 0 || gid >= 0 {
+		err := p.applyOwnership(ctx, options.PVC, describeOwner(uid, gid), func() error {
+			return traced(ctx, "Chown", func() error { return os.Chown(fullPath, uid, gid) }, "path", fullPath)
+		})
+		if err != nil {
+			return nil, controller.ProvisioningFinished, fmt.Errorf("unable to give %s to %s: %v", path, describeOwner(uid, gid), err)
+		}
+	}
+	err = p.applyOwnership(ctx, options.PVC, fmt.Sprintf("mode %04o", uint32(mode.Perm())), func() error {
+		return traced(ctx, "Chmod", func() error { return os.Chmod(fullPath, mode) }, "path", fullPath)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	err = p.applyOwnership(ctx, options.PVC, "ACL", func() error {
+		return traced(ctx, "SetACL", func() error { return applyACLs(options.StorageClass, fullPath, mode) }, "path", fullPath)
+	})
+	if err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	if label != "" && labelDirectory {
+		err := p.applyOwnership(ctx, options.PVC, "SELinux label", func() error {
+			return traced(ctx, "SetSELinuxLabel", func() error { return setSELinuxLabel(fullPath, label) }, "path", fullPath)
+		})
+		if err != nil {
+			return nil, controller.ProvisioningFinished, err
+		}
+	}
+	if withLockFile {
+		err := traced(ctx, "CreateLockFile", func() error { return createLockFile(fullPath) }, "path", fullPath)
+		if err != nil {
+			return nil, controller.ProvisioningFinished, fmt.Errorf("unable to create the lock file of %s: %v", path, err)
+		}
+		metav1.SetMetaDataAnnotation(&pv.ObjectMeta, annLockFile, lockFile)
+	}
+	if enforced {
+		image := filepath.Join(fullPath, imageFile)
+		err := traced(ctx, "CreateImage", func() error { return createImage(ctx, image, size.Value(), p.mkfsCommand) }, "path", image)
+		if err != nil {
+			if created {
+				_ = os.RemoveAll(fullPath)
+			}
+			return nil, controller.ProvisioningFinished, fmt.Errorf("unable to create the image of %s: %v", path, err)
+		}
+	}
+	if skeleton := options.StorageClass.Parameters["skeletonPath"]; skeleton != "" {
+		if err := checkSkeletonPath(skeleton); err != nil {
+			return nil, controller.ProvisioningFinished, err
+		}
+		src := filepath.Join(b.mountPath, skeleton)
+		err := traced(ctx, "CopySkeleton", func() error { return copySkeleton(src, fullPath) }, "path", fullPath, "skeleton", src)
+		if err != nil {
+			return nil, controller.ProvisioningFinished, fmt.Errorf("unable to copy the skeleton %s into %s: %v", skeleton, path, err)
+		}
+	}
+	if source != nil {
+		p.recorder.Eventf(options.PVC, v1.EventTypeNormal, "ImportingVolume", "Filling the volume from %s", redactURL(source.URL))
+		err := traced(ctx, "ImportVolume", func() error { return p.importVolume(ctx, options.PVC, source, fullPath, size) }, "path", fullPath)
+		if err != nil {
+			// Leave no half filled directory for the next attempt to reuse.
+			if created {
+				_ = os.RemoveAll(fullPath)
+			}
+			return nil, controller.ProvisioningFinished, fmt.Errorf("unable to import %s into %s: %v", redactURL(source.URL), path, err)
+		}
+	}
+	if name := p.settings().MetadataFile; name != "" {
+		err := traced(ctx, "WriteMetadata", func() error {
+			return p.writeMetadataFile(name, options, pv, fullPath)
+		}, "path", fullPath)
+		if err != nil {
+			return nil, controller.ProvisioningFinished, fmt.Errorf("unable to write the metadata file of %s: %v", path, err)
+		}
+	}
+	hook.Hook = hookPostProvision
+	if err := p.runHook(ctx, hook); err != nil {
+		if created {
+			_ = os.RemoveAll(fullPath)
+		}
+		return nil, controller.ProvisioningFinished, err
+	}
+	if dedicatedExport {
+		file, err := p.exportVolume(ctx, options.StorageClass, options.PVName, path)
+		if err != nil {
+			return nil, controller.ProvisioningFinished, fmt.Errorf("unable to export %s: %v", path, err)
+		}
+		metav1.SetMetaDataAnnotation(&pv.ObjectMeta, annDedicatedExport, file)
+	}
+	// Dedicated exports are on the server the exporter manages, which can
+	// enforce the file limit itself; other volumes are only checked by the
+	// usage scanner.
+	if limiter, ok := p.exporter.(fileLimiter); ok && dedicatedExport && fileLimit > 0 {
+		project := projectID(options.PVName)
+		err := traced(ctx, "LimitFiles", func() error { return limiter.limitFiles(ctx, path, project, fileLimit) }, "path", path)
+		if err != nil {
+			return nil, controller.ProvisioningFinished, fmt.Errorf("unable to limit the files of %s: %v", path, err)
+		}
+		metav1.SetMetaDataAnnotation(&pv.ObjectMeta, annProjectID, strconv.FormatUint(uint64(project), 10))
+	}
 	return pv, controller.ProvisioningFinished, nil
 }
 
+// owns reports whether provisioner, a StorageClass provisioner or the
+// provisioned-by annotation of a PV, designates this provisioner.
+func (p *nfsProvisioner) owns(provisioner string) bool {
+	if provisioner == p.name {
+		return true
+	}
+	for _, name := range p.adopted {
+		if provisioner == name {
+			return true
+		}
+	}
+	return false
+}
+
+// podNamespace returns the namespace the provisioner runs in, default when
+// it runs outside of the cluster.
+func podNamespace() string {
+	if namespace := os.Getenv("POD_NAMESPACE"); namespace != "" {
+		return namespace
+	}
+	if data, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace"); err == nil {
+		return strings.TrimSpace(string(data))
+	}
+	return "default"
+}
+
 func (p *nfsProvisioner) Delete(ctx context.Context, volume *v1.PersistentVolume) error {
+	var claim string
+	if ref := volume.Spec.ClaimRef; ref != nil {
+		claim = ref.Namespace + "/" + ref.Name
+	}
+	// A quarantined volume would only take a worker to fail again.
+	if err := quarantined(volume); err != nil {
+		return err
+	}
+
+	finish := p.operations.start("delete", volume.Name, claim)
+	if p.draining.Load() {
+		finish(errShuttingDown)
+		return errShuttingDown
+	}
+	ctx, end := startSpan(ctx, "Delete", "pv", volume.Name, "claim", claim)
+	hook := hookPayload{
+		Hook:         hookPreDelete,
+		PV:           volume.Name,
+		StorageClass: storagehelpers.GetPersistentVolumeClass(volume),
+		Server:       volume.Spec.PersistentVolumeSource.NFS.Server,
+		Path:         volume.Spec.PersistentVolumeSource.NFS.Path,
+		Volume:       volume,
+	}
+	if ref := volume.Spec.ClaimRef; ref != nil {
+		hook.PVC, hook.Namespace = ref.Name, ref.Namespace
+	}
+	action, path := auditDelete, volume.Spec.PersistentVolumeSource.NFS.Path
+	err := p.runHook(ctx, hook)
+	if err == nil {
+		deleteCtx, stopProgress := p.reportProgress(withThrottle(ctx, p.throttle), volume)
+		action, path, err = p.delete(deleteCtx, volume)
+		stopProgress()
+		hook.Hook, hook.Action, hook.LocalPath = hookPostDelete, string(action), path
+		if err != nil {
+			hook.Error = err.Error()
+		}
+		// The volume is gone, or the deletion will be retried anyway.
+		if hookErr := p.runHook(ctx, hook); hookErr != nil {
+			klog.FromContext(ctx).Error(hookErr, "post-delete hook failed", "pv", volume.Name)
+			p.recorder.Event(volume, v1.EventTypeWarning, "HookFailed", hookErr.Error())
+		}
+	}
+	end(err)
+	finish(err)
+	if err != nil {
+		p.deleteFailed(ctx, volume, err)
+	} else {
+		p.failures.reset(volume.UID)
+		deletedVolumes.WithLabelValues(storagehelpers.GetPersistentVolumeClass(volume), volumeKind(volume), string(action)).Inc()
+		if action == auditArchive {
+			p.notify(notification{
+				Event:        notifyArchived,
+				PV:           volume.Name,
+				PVC:          hook.PVC,
+				Namespace:    hook.Namespace,
+				StorageClass: hook.StorageClass,
+				Location:     path,
+				Message:      fmt.Sprintf("Archived the directory of volume %s to %s", volume.Name, path),
+			}, "")
+		}
+	}
+
+	record := auditRecord{
+		Action:       action,
+		PV:           volume.Name,
+		StorageClass: storagehelpers.GetPersistentVolumeClass(volume),
+		Server:       volume.Spec.PersistentVolumeSource.NFS.Server,
+		Path:         path,
+	}
+	if claim := volume.Spec.ClaimRef; claim != nil {
+		record.PVC = claim.Name
+		record.Namespace = claim.Namespace
+	}
+	p.audit.log(record, err)
+
+	return err
+}
+
+// delete reclaims the directory backing volume. It returns the action taken
+// and the path that action was applied to. Deletions and archivings each wait
+// for a worker of their own, so that slow archivings do not hold up quick
+// deletions, nor the other way around.
+func (p *nfsProvisioner) delete(ctx context.Context, volume *v1.PersistentVolume) (action auditAction, location string, err error) {
 	logger := klog.FromContext(ctx)
 
 	path := volume.Spec.PersistentVolumeSource.NFS.Path
-	basePath := filepath.Base(path)
-	oldPath := strings.Replace(path, p.path, mountPath, 1)
+	if _, ok := volume.Annotations[annInspectOf]; ok {
+		// A view of an archive: the archive is not this volume's to reclaim.
+		return auditSkip, path, nil
+	}
+	if _, ok := volume.Annotations[annSharedPath]; ok {
+		// The shared directory outlives every claim bound to it.
+		return auditSkip, path, nil
+	}
+	// The export of a krb5 volume may have to be mounted with the
+	// credentials of its class. Without the class, whatever credentials are
+	// installed already have to do.
+	if kerberosFlavor(volume.Spec.MountOptions) != "" {
+		if class, err := p.getClassForVolume(ctx, volume); err == nil {
+			if err := p.installKerberosCredentials(ctx, class); err != nil {
+				return auditDelete, path, err
+			}
+		}
+	}
+	b, err := p.backendForVolume(ctx, volume)
+	if err != nil {
+		return auditDelete, path, err
+	}
+	defer b.release()
+	oldPath := b.localPath(path)
+	unlock, err := p.locks.lock(b.mountPath, volume.Name)
+	if err != nil {
+		return auditDelete, oldPath, err
+	}
+	defer unlock()
+	defer func() {
+		switch {
+		case err != nil:
+		case action == auditDelete:
+			p.shareAudit.logVolume(b, volume, action, "")
+		case action == auditArchive, action == auditDefer:
+			p.shareAudit.logVolume(b, volume, action, location)
+		}
+	}()
+
+	// Revoke the dedicated export first: whatever happens to the directory,
+	// clients should no longer reach it.
+	if file, ok := volume.Annotations[annDedicatedExport]; ok {
+		if p.exporter == nil {
+			return auditDelete, oldPath, fmt.Errorf("volume has the dedicated export %s, but --export-ssh-address is not set", file)
+		}
+		if err := traced(ctx, "Unexport", func() error { return p.exporter.unexport(ctx, file) }, "file", file); err != nil {
+			return auditDelete, oldPath, err
+		}
+	}
+	if value, ok := volume.Annotations[annProjectID]; ok {
+		// A stale limit only holds an unused project ID.
+		project, err := strconv.ParseUint(value, 10, 32)
+		if limiter, ok := p.exporter.(fileLimiter); ok && err == nil {
+			if err := limiter.limitFiles(ctx, path, uint32(project), 0); err != nil {
+				logger.Error(err, "failed to lift the file limit of the volume", "project", project)
+			}
+		}
+	}
 
 	if _, err := os.Stat(oldPath); os.IsNotExist(err) {
+		if err := b.checkMounted(); err != nil {
+			return auditDelete, oldPath, err
+		}
 		logger.Info(fmt.Sprintf("warning: path %s does not exist, deletion skipped", oldPath))
-		return nil
+		return auditSkip, oldPath, nil
 	}
-	// Get the storage class for this volume.
+	// Get the storage class for this volume, with the parameters of its
+	// VolumeAttributesClass.
 	storageClass, err := p.getClassForVolume(ctx, volume)
 	if err != nil {
-		return err
+		return auditDelete, oldPath, err
+	}
+	storageClass, err = p.classWithAttributes(ctx, storageClass, volume.Spec.VolumeAttributesClassName)
+	if err != nil {
+		return auditDelete, oldPath, err
 	}
 
 	// Determine if the "onDelete" parameter exists.
 	// If it exists and has a `delete` value, delete the directory.
 	// If it exists and has a `retain` value, safe the directory.
+	// When the class sets neither parameter, the provisioner-wide default
+	// applies. Generic ephemeral volumes follow ephemeralOnDelete instead, if
+	// the class sets it.
 	onDelete := storageClass.Parameters["onDelete"]
+	ephemeralOnDelete := storageClass.Parameters["ephemeralOnDelete"]
+	ephemeral := volumeKind(volume) == volumeKindEphemeral && ephemeralOnDelete != ""
+	if ephemeral {
+		onDelete = ephemeralOnDelete
+	} else if _, exists := storageClass.Parameters["archiveOnDelete"]; !exists && onDelete == "" {
+		onDelete = p.settings().DefaultOnDelete
+	}
 	switch onDelete {
 	case "delete":
-		return os.RemoveAll(oldPath)
+		return p.removeAll(ctx, volume, storageClass, b.mountPath, oldPath)
 	case "retain":
-		return nil
+		return auditRetain, oldPath, nil
 	}
 
 	// Determine if the "archiveOnDelete" parameter exists.
 	// If it exists and has a false value, delete the directory.
 	// Otherwise, archive it.
 	archiveOnDelete, exists := storageClass.Parameters["archiveOnDelete"]
-	if exists {
+	if exists && !ephemeral {
 		archiveBool, err := strconv.ParseBool(archiveOnDelete)
 		if err != nil {
-			return err
+			return auditDelete, oldPath, err
 		}
 		if !archiveBool {
-			return os.RemoveAll(oldPath)
+			return p.removeAll(ctx, volume, storageClass, b.mountPath, oldPath)
 		}
 	}
 
-	archivePath := filepath.Join(mountPath, "archived-"+basePath)
+	dir, err := filepath.Rel(b.mountPath, oldPath)
+	if err != nil {
+		return auditArchive, oldPath, err
+	}
+	if err := p.acquireArchiveWorker(volume); err != nil {
+		return auditArchive, oldPath, err
+	}
+	defer p.archiveWorkers.release()
+	if storageClass.Parameters["archiveS3URL"] != "" {
+		location, err := p.archiveToS3(ctx, volume, storageClass, oldPath, dir)
+		return auditArchive, location, err
+	}
+	now := p.clock.Now()
+	name, err := archiveName(volume, storageClass, dir, now, defaultArchivePattern)
+	if err != nil {
+		return auditArchive, oldPath, err
+	}
+	archivePath := filepath.Join(b.mountPath, name)
+	if _, err := os.Stat(archivePath); err == nil {
+		// An earlier volume with the same directory name was archived
+		// already; keep both.
+		archivePath += "-" + p.random.suffix()
+	}
+	if err := p.locks.fence(volume.Name); err != nil {
+		return auditArchive, oldPath, err
+	}
+	if err := os.MkdirAll(filepath.Dir(archivePath), 0777); err != nil {
+		return auditArchive, oldPath, err
+	}
 	logger.Info(fmt.Sprintf("archiving path %s to %s", oldPath, archivePath))
-	return os.Rename(oldPath, archivePath)
+	err = traced(ctx, "Rename", func() error { return moveDir(ctx, oldPath, archivePath) }, "from", oldPath, "to", archivePath)
+	if err != nil {
+		return auditArchive, archivePath, err
+	}
+	// The archive is made; a missing manifest only makes it harder to find.
+	name, _ = filepath.Rel(b.mountPath, archivePath)
+	manifest := newArchiveManifest(volume, storageClass, filepath.ToSlash(name), "directory", now)
+	if err := writeArchiveManifest(ctx, manifest, b.mountPath, name); err != nil {
+		logger.Error(err, "failed to write the manifest of the archive", "path", archivePath)
+	}
+	return auditArchive, archivePath, nil
+}
+
+// removeAll deletes the directory backing volume, at path on the export
+// mounted at mountPath, after consulting the policy endpoint if one is
+// configured. A denied deletion leaves the directory in place; a deferred
+// one is retried later. With a deleteDelay, the directory is only moved
+// aside. It returns the action taken and the path it was applied to.
+func (p *nfsProvisioner) removeAll(ctx context.Context, volume *v1.PersistentVolume, storageClass *storage.StorageClass, mountPath, path string) (auditAction, string, error) {
+	logger := klog.FromContext(ctx)
+	p.deleteWorkers.acquire()
+	defer p.deleteWorkers.release()
+
+	if policy := p.policy.Load(); policy != nil {
+		input := policyInput{
+			Action:       "delete",
+			PV:           volume.Name,
+			StorageClass: storageClass.Name,
+			Server:       volume.Spec.PersistentVolumeSource.NFS.Server,
+			Path:         volume.Spec.PersistentVolumeSource.NFS.Path,
+		}
+		if claim := volume.Spec.ClaimRef; claim != nil {
+			input.PVC = claim.Name
+			input.Namespace = claim.Namespace
+		}
+		var decision policyDecision
+		var reason string
+		err := traced(ctx, "PolicyCheck", func() (err error) {
+			decision, reason, err = policy.check(ctx, input)
+			return err
+		}, "url", policy.url)
+		if err != nil {
+			return auditDelete, path, fmt.Errorf("unable to consult deletion policy: %v", err)
+		}
+		switch decision {
+		case policyDeny:
+			logger.Info(fmt.Sprintf("deletion of %s denied by policy, retaining directory: %s", path, reason))
+			return auditRetain, path, nil
+		case policyDefer:
+			return auditDelete, path, fmt.Errorf("deletion of %s deferred by policy: %s", path, reason)
+		}
+	}
+
+	delay, err := deleteDelay(storageClass)
+	if err != nil {
+		return auditDelete, path, err
+	}
+	if err := p.locks.fence(volume.Name); err != nil {
+		return auditDelete, path, err
+	}
+	if delay > 0 {
+		if storageClass.Parameters["backendSecretName"] == "" {
+			target, err := p.deferDelete(ctx, volume, storageClass, mountPath, path, delay)
+			return auditDefer, target, err
+		}
+		logger.Info("warning: ignoring the deleteDelay of a class with backendSecretName", "storageClass", storageClass.Name)
+	}
+	return auditDelete, path, traced(ctx, "RemoveAll", func() error { return removeTree(ctx, path) }, "path", path)
 }
 
 // getClassForVolume returns StorageClass.
@@ -201,7 +978,11 @@ func (p *nfsProvisioner) getClassForVolume(ctx context.Context, pv *v1.Persisten
 	if className == "" {
 		return nil, fmt.Errorf("volume has no storage class")
 	}
-	class, err := p.client.StorageV1().StorageClasses().Get(ctx, className, metav1.GetOptions{})
+	var class *storage.StorageClass
+	err := traced(ctx, "GetStorageClass", func() (err error) {
+		class, err = p.client.StorageV1().StorageClasses().Get(ctx, className, metav1.GetOptions{})
+		return err
+	}, "storageClass", className)
 	if err != nil {
 		return nil, err
 	}
@@ -210,35 +991,42 @@ func (p *nfsProvisioner) getClassForVolume(ctx context.Context, pv *v1.Persisten
 }
 
 func main() {
-	flag.Parse()
-	_ = flag.Set("logtostderr", "true")
-
+	klog.InitFlags(nil)
 	ctx := context.Background()
 	logger := klog.FromContext(ctx)
 
-	server := os.Getenv("NFS_SERVER")
-	if server == "" {
-		logger.Error(nil, "NFS_SERVER not set")
-		os.Exit(1)
+	if ran, err := runCommand(ctx, os.Args[1:]); ran {
+		if err != nil {
+			logger.Error(err, "command failed")
+			os.Exit(1)
+		}
+		return
 	}
-	path := os.Getenv("NFS_PATH")
-	if path == "" {
-		logger.Error(nil, "NFS_PATH not set")
+
+	cfg, err := loadConfig(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		logger.Error(err, "invalid configuration")
 		os.Exit(1)
 	}
-	provisionerName := os.Getenv(provisionerNameKey)
-	if provisionerName == "" {
-		logger.Error(nil, fmt.Sprintf("environment variable %s is not set! Please set it.", provisionerNameKey))
+	_ = flag.Set("logtostderr", "true")
+	if cfg.LogLevel > 0 {
+		setLogLevel(cfg.LogLevel)
+	}
+
+	shutdownTracing, err := initTracing(ctx)
+	if err != nil {
+		logger.Error(err, "failed to initialize tracing")
 		os.Exit(1)
 	}
+	if cfg.EnablePprof {
+		servePprof(ctx, cfg.PprofAddress)
+	}
 
-	kubeconfig := os.Getenv("KUBECONFIG")
 	var config *rest.Config
-	if kubeconfig != "" {
+	if cfg.Kubeconfig != "" {
 		// Create an OutOfClusterConfig and use it to create a client for the controller
 		// to use to communicate with Kubernetes
-		var err error
-		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+		config, err = clientcmd.BuildConfigFromFlags("", cfg.Kubeconfig)
 		if err != nil {
 			logger.Error(err, "failed to create kubeconfig")
 			os.Exit(1)
@@ -246,25 +1034,215 @@ func main() {
 	} else {
 		// Create an InClusterConfig and use it to create a client for the controller
 		// to use to communicate with Kubernetes
-		var err error
 		config, err = rest.InClusterConfig()
 		if err != nil {
 			logger.Error(err, "failed to create in cluster config")
 			os.Exit(1)
 		}
 	}
+	config.QPS = float32(cfg.KubeAPIQPS)
+	config.Burst = cfg.KubeAPIBurst
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		logger.Error(err, "failed to create kubernetes client")
 		os.Exit(1)
 	}
 
+	// The controller and the provisioner share informers, so the provisioner
+	// can read the same caches.
+	factory := informers.NewSharedInformerFactory(clientset, cfg.resyncPeriod())
+	claimInformer := factory.Core().V1().PersistentVolumeClaims()
+	volumeInformer := factory.Core().V1().PersistentVolumes()
+	classInformer := factory.Storage().V1().StorageClasses()
+
+	clock, random := newTimeSources(cfg.Deterministic)
+	if cfg.Deterministic {
+		logger.Info("deterministic mode: using a fixed clock and random seed")
+	}
+
+	// Validated with the rest of the configuration.
+	server, _ := canonicalServer(cfg.NFSServer)
+	failover, _ := serverList(cfg.FailoverServers)
+	var ownsMount bool
+	var mountOptions []string
+	if cfg.MountExport {
+		mountOptions = cfg.exportMountOptions()
+		ownsMount, err = mountExport(ctx, cfg, append([]string{server}, failover...))
+		if err != nil {
+			logger.Error(err, "failed to mount the export")
+			os.Exit(1)
+		}
+	} else if cfg.VerifyMount {
+		if err := waitForMount(ctx, cfg.MountPath, append([]string{server}, failover...), cfg.NFSPath); err != nil {
+			logger.Error(err, "the export is not mounted at the mount path, refusing to create volumes in the container file system")
+			os.Exit(1)
+		}
+	}
+	if uid, gid, ok := cfg.fsIdentity(); ok {
+		if err := setFSIdentity(uid, gid); err != nil {
+			logger.Error(err, "failed to switch the user files are accessed as", "uid", uid, "gid", gid)
+			os.Exit(1)
+		}
+		logger.Info("accessing files as another user", "uid", uid, "gid", gid)
+	}
+	if err := checkPrivileges(ctx, cfg, cfg.MountPath); err != nil {
+		logger.Error(err, "the provisioner lacks the privileges it needs")
+		os.Exit(1)
+	}
+
 	// Create the provisioner: it implements the Provisioner interface expected by
 	// the controller
 	clientNFSProvisioner := &nfsProvisioner{
-		client: clientset,
-		server: server,
-		path:   path,
+		client:           clientset,
+		name:             cfg.ProvisionerName,
+		server:           server,
+		path:             cfg.NFSPath,
+		mountPath:        cfg.MountPath,
+		ownsMount:        ownsMount,
+		mountOpts:        mountOptions,
+		operations:       newOperationTracker(clock),
+		claims:           claimInformer.Lister(),
+		volumes:          volumeInformer.Lister(),
+		classes:          classInformer.Lister(),
+		maxWorkers:       cfg.MaxWorkerThreads,
+		provisionWorkers: newWorkerLimiter(cfg.WorkerThreads),
+		deleteWorkers:    newWorkerLimiter(cfg.WorkerThreads),
+		archiveWorkers:   newWorkerLimiter(cfg.archiveWorkerThreads()),
+		cfg:              cfg,
+		adopted:          cfg.adoptedProvisioners(),
+		mkfsCommand:      cfg.MkfsCommand,
+		clock:            clock,
+		random:           random,
+		gids:             newGIDAllocator(),
+		throttle:         newIOThrottle(int64(cfg.DeleteOpsRate), cfg.deleteBandwidth()),
+	}
+	if cfg.ProbeNFSVersions {
+		clientNFSProvisioner.versions = newNFSVersionCache()
+		if versions, err := clientNFSProvisioner.versions.versions(ctx, server); err != nil {
+			logger.Info("warning: unable to probe the NFS versions of the server", "server", server, "err", err.Error())
+		} else {
+			logger.Info("probed the NFS versions of the server", "server", server, "versions", versions)
+		}
+	}
+	if cfg.ShardName != "" {
+		clientNFSProvisioner.shard = &shard{name: cfg.ShardName, count: cfg.ShardCount, index: cfg.ShardIndex}
+		if cfg.ShardSelector != "" {
+			clientNFSProvisioner.shard.selector, _ = labels.Parse(cfg.ShardSelector)
+			clientNFSProvisioner.shard.namespaces = factory.Core().V1().Namespaces().Lister()
+		}
+	}
+	if cfg.ActiveActive {
+		hostname, _ := os.Hostname()
+		clientNFSProvisioner.locks = newVolumeLocks(hostname)
+	}
+	broadcaster := record.NewBroadcaster(record.WithContext(ctx))
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+	clientNFSProvisioner.recorder = broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: cfg.ProvisionerName})
+	if cfg.PolicyURL != "" {
+		clientNFSProvisioner.policy.Store(newPolicyClient(cfg.PolicyURL))
+	}
+	if cfg.AuditLog != "" {
+		clientNFSProvisioner.audit, err = newAuditLogger(cfg.AuditLog, clock)
+		if err != nil {
+			logger.Error(err, "failed to open audit log")
+			os.Exit(1)
+		}
+	}
+	if cfg.ExportAuditLog != "" {
+		clientNFSProvisioner.shareAudit = newShareAuditLog(cfg.ExportAuditLog, cfg.ProvisionerName, clock)
+	}
+	clientNFSProvisioner.notifier = clientNFSProvisioner.startNotifier(ctx)
+
+	if interval := cfg.mountWatchdogInterval(); interval > 0 {
+		clientNFSProvisioner.watchdog = clientNFSProvisioner.startMountWatchdog(ctx, interval, cfg.MountWatchdogFailures)
+	}
+	if cfg.HealthAddress != "" {
+		clientNFSProvisioner.serveHealth(ctx, cfg.HealthAddress)
+	}
+	if cfg.AdminAddress != "" {
+		var tlsConfig *tls.Config
+		if cfg.AdminTLSCert != "" {
+			tlsConfig, err = newAdminTLSConfig(ctx, cfg.AdminTLSCert, cfg.AdminTLSKey, cfg.AdminTLSClientCA)
+			if err != nil {
+				logger.Error(err, "failed to load admin server certificates")
+				os.Exit(1)
+			}
+		}
+		clientNFSProvisioner.serveAdmin(ctx, cfg.AdminAddress, tlsConfig, cfg.AdminAllowChanges)
+	}
+	if cfg.WebhookAddress != "" {
+		tlsConfig, err := newTLSReloader(ctx, "webhook server", cfg.WebhookTLSCert, cfg.WebhookTLSKey, "")
+		if err != nil {
+			logger.Error(err, "failed to load webhook server certificates")
+			os.Exit(1)
+		}
+		clientNFSProvisioner.serveWebhook(ctx, cfg.WebhookAddress, tlsConfig)
+	}
+	clientNFSProvisioner.handleShutdown(ctx, cfg.shutdownTimeout(), shutdownTracing)
+	go clientNFSProvisioner.watchConfig(ctx)
+
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		logger.Error(err, "failed to create dynamic client")
+		os.Exit(1)
+	}
+	if cfg.ConfigObject != "" {
+		clientNFSProvisioner.watchConfigObject(ctx, dynamicClient, cfg.ConfigObject)
+	}
+	if cfg.ExportSSHAddress != "" {
+		ssh, err := newSSHClient(cfg.ExportSSHAddress, cfg.ExportSSHUser, cfg.ExportSSHKey, cfg.ExportSSHKnownHosts)
+		if err != nil {
+			logger.Error(err, "failed to set up dedicated exports")
+			os.Exit(1)
+		}
+		if cfg.ExportServerType == "ganesha" {
+			clientNFSProvisioner.exporter = &ganeshaClient{ssh: ssh}
+		} else {
+			clientNFSProvisioner.exporter = &exportfsClient{ssh: ssh}
+		}
+	}
+	if cfg.KerberosKeytab != "" {
+		clientNFSProvisioner.kerberos = newKerberosCredentials(cfg.KerberosKeytab, cfg.KerberosConfig)
+	}
+	if cfg.EnableQuotas {
+		clientNFSProvisioner.quotas, err = clientNFSProvisioner.startQuotas(ctx, dynamicClient, volumeInformer.Informer())
+		if err != nil {
+			logger.Error(err, "failed to watch NFSQuotas")
+			os.Exit(1)
+		}
+	}
+	if cfg.EnableVolumeImports {
+		clientNFSProvisioner.imports = dynamicClient.Resource(volumeImportResource)
+	}
+	if cfg.EnableExports || cfg.Exports != "" {
+		// Validated with the rest of the configuration.
+		static, _ := parseStaticExports(cfg.Exports)
+		var exportsClient dynamic.Interface
+		if cfg.EnableExports {
+			exportsClient = dynamicClient
+		}
+		clientNFSProvisioner.exports, err = newExportManager(ctx, exportsClient, cfg.ExportsMountPath, static)
+		if err != nil {
+			logger.Error(err, "failed to watch NFSExports")
+			os.Exit(1)
+		}
+		if interval := cfg.exportHealthInterval(); interval > 0 {
+			clientNFSProvisioner.exports.startHealthChecks(ctx, interval)
+		}
+	}
+	if cfg.EnableAttributes {
+		resource, err := attributesClassResource(clientset.Discovery())
+		if err != nil {
+			logger.Error(err, "failed to find VolumeAttributesClasses")
+			os.Exit(1)
+		}
+		clientNFSProvisioner.attributes = dynamicClient.Resource(resource)
+	}
+	if cfg.EnableRquota {
+		clientNFSProvisioner.rquota = &rquotaClient{}
+	}
+	if cfg.EnableSnapshots {
+		clientNFSProvisioner.startSnapshotSchedules(ctx, dynamicClient, claimInformer.Informer(), volumeInformer.Informer())
 	}
 
 	// Start the provision controller which will dynamically provision efs NFS
@@ -272,10 +1250,81 @@ func main() {
 	pc := controller.NewProvisionController(
 		logger,
 		clientset,
-		provisionerName,
+		cfg.ProvisionerName,
 		clientNFSProvisioner,
+		// Shards elect a leader per shard, see runSharded.
+		controller.LeaderElection(cfg.LeaderElection && !cfg.ActiveActive && clientNFSProvisioner.shard == nil),
+		controller.ClaimsInformer(claimInformer.Informer()),
+		controller.VolumesInformer(volumeInformer.Informer()),
+		controller.ClassesInformer(classInformer.Informer()),
+		controller.NodesLister(factory.Core().V1().Nodes().Lister()),
+		controller.AdditionalProvisionerNames(cfg.adoptedProvisioners()),
+		controller.Threadiness(cfg.MaxWorkerThreads),
+		controller.ResyncPeriod(cfg.resyncPeriod()),
+		controller.RateLimiter(retryRateLimiter(cfg.retryDelays())),
+		controller.FailedProvisionThreshold(cfg.FailedProvisionLimit),
+		controller.FailedDeleteThreshold(cfg.FailedDeleteLimit),
+		controller.AddFinalizer(cfg.PVFinalizer),
+		controller.MetricsInstance(clientNFSProvisioner.registerMetrics()),
 	)
+	if err := tlsSupport(); err != nil {
+		logger.Info("StorageClasses and exports requesting xprtsec=tls or xprtsec=mtls will be refused", "reason", err.Error())
+	}
+	clientNFSProvisioner.startUsageRefresher(ctx, claimInformer.Informer())
+	if clientNFSProvisioner.attributes != nil {
+		clientNFSProvisioner.startAttributesModifier(ctx, claimInformer.Informer())
+	}
+	clientNFSProvisioner.startRepointing(ctx, volumeInformer.Informer())
+	clientNFSProvisioner.startDeletePurger(ctx)
+	clientNFSProvisioner.startServerChecks(ctx, volumeInformer.Informer())
+	clientNFSProvisioner.startClassChecks(ctx, classInformer.Informer())
+	if interval := cfg.stateBundleInterval(); interval > 0 {
+		clientNFSProvisioner.startStateBundle(ctx, volumeInformer.Informer(), interval)
+	}
+	if interval := cfg.usageScanInterval(); interval > 0 {
+		clientNFSProvisioner.usage = clientNFSProvisioner.startUsageScanner(ctx, volumeInformer.Informer(), interval, cfg.UsageScanRate)
+	}
+	if interval := cfg.usageAnnotationInterval(); interval > 0 {
+		clientNFSProvisioner.startUsageAnnotations(ctx, interval)
+	}
+	if interval := cfg.freeSpaceInterval(); interval > 0 {
+		clientNFSProvisioner.space = clientNFSProvisioner.startSpaceMonitor(ctx, interval, cfg.FreeSpaceThreshold, cfg.MarkFullClasses)
+	}
+	if interval := cfg.directoryCheckInterval(); interval > 0 {
+		clientNFSProvisioner.startDirectoryChecks(ctx, volumeInformer.Informer(), interval)
+	}
+	if cfg.StatusObject != "" {
+		clientNFSProvisioner.startStatusPublisher(ctx, dynamicClient, volumeInformer.Informer(), cfg.StatusObject, cfg.statusInterval())
+	}
+	// Validated with the rest of the configuration.
+	clusters, _ := parseClusters(cfg.Clusters)
+	if grace := cfg.orphanGracePeriod(); grace > 0 {
+		clientNFSProvisioner.startOrphanReaper(ctx, volumeInformer.Informer(), grace, splitList(cfg.OrphanExclude), cfg.OrphanAction)
+	}
+	if interval := cfg.usageReportInterval(); interval > 0 {
+		namespace, name := cfg.usageReportConfigMap()
+		clientNFSProvisioner.startUsageReport(ctx, interval, namespace, name, cfg.UsageReportTop)
+	}
+	if thresholds, _ := parseThresholds(cfg.QuotaAlertThresholds); len(thresholds) > 0 {
+		quotaInformer := factory.Core().V1().ResourceQuotas()
+		clientNFSProvisioner.startQuotaAlerts(ctx, quotaInformer.Informer(), quotaInformer.Lister(), thresholds)
+	}
+	for _, cluster := range clusters {
+		member, err := clientNFSProvisioner.startMember(ctx, cluster)
+		if err != nil {
+			logger.Error(err, "failed to start provisioning for cluster", "cluster", cluster.name)
+			os.Exit(1)
+		}
+		clientNFSProvisioner.addMember(member)
+	}
+	factory.Start(ctx.Done())
+	// Claims skipped for want of their namespace would wait for a resync.
+	factory.WaitForCacheSync(ctx.Done())
 
 	// Never stops.
-	pc.Run(context.Background())
+	if clientNFSProvisioner.shard != nil && cfg.LeaderElection && !cfg.ActiveActive {
+		clientNFSProvisioner.runSharded(context.Background(), pc)
+	} else {
+		pc.Run(context.Background())
+	}
 }
@@ -0,0 +1,145 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/sig-storage-lib-external-provisioner/v10/controller"
+)
+
+const (
+	apiGroup = "nfs.k8s-sigs.io"
+
+	conditionValid = "Valid"
+)
+
+var provisionerConfigResource = schema.GroupVersionResource{Group: apiGroup, Version: "v1alpha1", Resource: "nfsprovisionerconfigs"}
+
+// watchConfigObject applies the spec of the cluster-scoped NfsProvisionerConfig
+// called name, and reports in its status whether it was accepted. Deleting the
+// object reverts to the config file.
+func (p *nfsProvisioner) watchConfigObject(ctx context.Context, client dynamic.Interface, name string) {
+	logger := klog.FromContext(ctx).WithValues("nfsProvisionerConfig", name)
+
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(client, controller.DefaultResyncPeriod, "", func(options *metav1.ListOptions) {
+		options.FieldSelector = "metadata.name=" + name
+	})
+	informer := factory.ForResource(provisionerConfigResource).Informer()
+
+	apply := func(obj interface{}) {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return
+		}
+		var spec []byte
+		if s, found, _ := unstructured.NestedMap(u.Object, "spec"); found {
+			spec, _ = json.Marshal(s)
+		}
+		err := p.reconfigure(ctx, func(c *config) { c.spec = spec })
+		if err != nil {
+			logger.Error(err, "ignoring invalid NfsProvisionerConfig")
+		}
+		if err := updateConfigObjectStatus(ctx, client, u, err); err != nil {
+			logger.Error(err, "failed to update NfsProvisionerConfig status")
+		}
+	}
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    apply,
+		UpdateFunc: func(_, obj interface{}) { apply(obj) },
+		DeleteFunc: func(interface{}) {
+			if err := p.reconfigure(ctx, func(c *config) { c.spec = nil }); err != nil {
+				logger.Error(err, "failed to revert NfsProvisionerConfig")
+			}
+		},
+	})
+	if err != nil {
+		logger.Error(err, "failed to watch NfsProvisionerConfig")
+		return
+	}
+	factory.Start(ctx.Done())
+}
+
+// updateConfigObjectStatus sets the Valid condition of obj from the result of
+// applying its spec.
+func updateConfigObjectStatus(ctx context.Context, client dynamic.Interface, obj *unstructured.Unstructured, applyErr error) error {
+	condition := metav1.Condition{
+		Type:    conditionValid,
+		Status:  metav1.ConditionTrue,
+		Reason:  "Applied",
+		Message: "spec applied",
+	}
+	if applyErr != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "Invalid"
+		condition.Message = applyErr.Error()
+	}
+	return setStatusConditions(ctx, client.Resource(provisionerConfigResource), obj, condition)
+}
+
+// setStatusConditions sets conditions in the status of obj, observing the
+// current generation of obj. The status is only written when it changes.
+func setStatusConditions(ctx context.Context, client dynamic.ResourceInterface, obj *unstructured.Unstructured, conditions ...metav1.Condition) error {
+	var current []metav1.Condition
+	if raw, found, _ := unstructured.NestedSlice(obj.Object, "status", "conditions"); found {
+		for _, item := range raw {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			var c metav1.Condition
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, &c); err == nil {
+				current = append(current, c)
+			}
+		}
+	}
+	previous := append([]metav1.Condition(nil), current...)
+
+	for _, condition := range conditions {
+		condition.ObservedGeneration = obj.GetGeneration()
+		meta.SetStatusCondition(&current, condition)
+	}
+	if equality.Semantic.DeepEqual(previous, current) {
+		return nil
+	}
+
+	raw := make([]interface{}, 0, len(current))
+	for i := range current {
+		m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&current[i])
+		if err != nil {
+			return err
+		}
+		raw = append(raw, m)
+	}
+	obj = obj.DeepCopy()
+	if err := unstructured.SetNestedSlice(obj.Object, raw, "status", "conditions"); err != nil {
+		return err
+	}
+	_, err := client.UpdateStatus(ctx, obj, metav1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,258 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog/v2"
+)
+
+// parseAge parses a duration that may also be given in days, e.g. 90d.
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+func runPurgeArchives(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	export := fs.String("export", "", "Mounted export to purge the archives of.")
+	olderThan := fs.String("older-than", "", "Only remove archives archived longer ago than this, e.g. 90d or 720h.")
+	keepPerPVC := fs.Int("keep-per-pvc", 0, "Keep the newest archives of every claim, this many of them, whatever their age.")
+	largerThan := fs.String("larger-than", "", "Only remove archives at least this large, as a quantity, e.g. 10Gi.")
+	namespace := fs.String("namespace", "", "Only remove the archives of claims of this namespace.")
+	metadataFile := fs.String("metadata-file", ".nfs-provisioner.json", "Name of the metadata files of the volumes, read for archives without a manifest.")
+	policyURL := fs.String("policy-url", "", "OPA-compatible endpoint consulted before removing each archive, as the provisioner does before deletions.")
+	dryRun := fs.Bool("dry-run", false, "Print the archives that would be removed, without removing them.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	switch {
+	case *export == "":
+		return fmt.Errorf("--export is required")
+	case *olderThan == "" && *keepPerPVC == 0 && *largerThan == "":
+		return fmt.Errorf("at least one of --older-than, --keep-per-pvc and --larger-than is required")
+	case *keepPerPVC < 0:
+		return fmt.Errorf("--keep-per-pvc must not be negative")
+	}
+	var age time.Duration
+	if *olderThan != "" {
+		var err error
+		if age, err = parseAge(*olderThan); err != nil {
+			return fmt.Errorf("invalid --older-than: %v", err)
+		}
+	}
+	var minBytes int64
+	if *largerThan != "" {
+		q, err := resource.ParseQuantity(*largerThan)
+		if err != nil {
+			return fmt.Errorf("invalid --larger-than %q: %v", *largerThan, err)
+		}
+		minBytes = q.Value()
+	}
+
+	archives, err := listArchives(ctx, *export, *metadataFile)
+	if err != nil {
+		return err
+	}
+	logger := klog.FromContext(ctx)
+	candidates := make([]purgeCandidate, 0, len(archives))
+	for _, a := range archives {
+		if *namespace != "" && a.Namespace != *namespace {
+			continue
+		}
+		c := purgeCandidate{listedArchive: a, dir: filepath.Join(*export, filepath.FromSlash(a.Archive))}
+		if !a.Manifest {
+			// Without a manifest, the last change of the directory stands in
+			// for when it was archived, and its size has to be measured.
+			info, err := os.Stat(c.dir)
+			if err != nil {
+				logger.Error(err, "failed to read archive", "path", c.dir)
+				continue
+			}
+			c.Archived = info.ModTime()
+			if c.Bytes, c.Files, err = diskUsage(ctx, c.dir, nil); err != nil {
+				logger.Error(err, "failed to measure archive", "path", c.dir)
+				continue
+			}
+		}
+		candidates = append(candidates, c)
+	}
+
+	var policy *policyClient
+	if *policyURL != "" {
+		policy = newPolicyClient(*policyURL)
+	}
+	selected := selectPurge(candidates, time.Now().Add(-age), *olderThan != "", *keepPerPVC, minBytes)
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "ARCHIVE\tNAMESPACE\tPVC\tARCHIVED\tSIZE")
+	result := purge(ctx, w, *export, selected, policy, *dryRun)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	verb := "removed"
+	if *dryRun {
+		verb = "would be removed"
+	}
+	fmt.Printf("%d of %d archives %s, %s freed", result.removed, len(candidates), verb, resource.NewQuantity(result.freed, resource.BinarySI).String())
+	if result.retained > 0 {
+		fmt.Printf(", %d kept as the policy denied their removal", result.retained)
+	}
+	fmt.Println()
+	switch {
+	case result.failed > 0:
+		return fmt.Errorf("%d archives could not be removed, %d deferred by policy", result.failed, result.deferred)
+	case result.deferred > 0:
+		return fmt.Errorf("%d archives deferred by policy, run again later", result.deferred)
+	}
+	return nil
+}
+
+// purgeResult counts what purge did with the archives it was given.
+type purgeResult struct {
+	removed  int
+	retained int
+	deferred int
+	failed   int
+	freed    int64
+}
+
+// purge removes the archives of the export mounted at export, once policy,
+// if not nil, allows it, and prints those removed to w. As for deletions, a
+// denied removal keeps the archive, and a deferred one, or one the policy
+// could not be consulted on, is left for a later run. With dryRun, nothing
+// is removed, but the policy is still consulted.
+func purge(ctx context.Context, w io.Writer, export string, archives []purgeCandidate, policy *policyClient, dryRun bool) purgeResult {
+	logger := klog.FromContext(ctx)
+	var result purgeResult
+	for _, c := range archives {
+		if policy != nil {
+			input := policyInput{
+				Action:       "purge",
+				PV:           c.PV,
+				PVC:          c.PVC,
+				Namespace:    c.Namespace,
+				StorageClass: c.StorageClass,
+				Server:       c.Server,
+				Path:         c.Path,
+				Archive:      c.Archive,
+			}
+			decision, reason, err := policy.check(ctx, input)
+			switch {
+			case err != nil:
+				logger.Error(err, "unable to consult deletion policy", "path", c.dir)
+				result.failed++
+				continue
+			case decision == policyDeny:
+				logger.Info("removal denied by policy, keeping archive", "path", c.dir, "reason", reason)
+				result.retained++
+				continue
+			case decision == policyDefer:
+				logger.Info("removal deferred by policy", "path", c.dir, "reason", reason)
+				result.deferred++
+				continue
+			}
+		}
+		if !dryRun {
+			if err := removeArchive(export, c.dir); err != nil {
+				logger.Error(err, "failed to remove archive", "path", c.dir)
+				result.failed++
+				continue
+			}
+		}
+		result.removed++
+		result.freed += c.Bytes
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", c.Archive, dash(c.Namespace), dash(c.PVC), c.Archived.UTC().Format(time.RFC3339), resource.NewQuantity(c.Bytes, resource.BinarySI).String())
+	}
+	return result
+}
+
+// purgeCandidate is an archive purge-archives may remove.
+type purgeCandidate struct {
+	listedArchive
+	dir string
+}
+
+// selectPurge returns the candidates to remove, oldest first: those archived
+// before cutoff, if byAge, at least minBytes large, and not among the keep
+// newest archives of their claim. Archives whose claim is unknown are never
+// removed for keep alone.
+func selectPurge(candidates []purgeCandidate, cutoff time.Time, byAge bool, keep int, minBytes int64) []purgeCandidate {
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Archived.After(candidates[j].Archived) })
+	kept := map[string]int{}
+	var purge []purgeCandidate
+	for _, c := range candidates {
+		if keep > 0 {
+			if c.PVC == "" {
+				if !byAge && minBytes == 0 {
+					continue
+				}
+			} else {
+				claim := c.Namespace + "/" + c.PVC
+				if kept[claim] < keep {
+					kept[claim]++
+					continue
+				}
+			}
+		}
+		if byAge && !c.Archived.Before(cutoff) || c.Bytes < minBytes {
+			continue
+		}
+		purge = append(purge, c)
+	}
+	sort.SliceStable(purge, func(i, j int) bool { return purge[i].Archived.Before(purge[j].Archived) })
+	return purge
+}
+
+// removeArchive removes the archived directory dir of the export mounted at
+// export, its manifest, and the directory an archivePattern made to hold it
+// if it is left empty.
+func removeArchive(export, dir string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.Remove(dir + archiveManifestSuffix); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for parent := filepath.Dir(dir); parent != filepath.Clean(export) && parent != "."; parent = filepath.Dir(parent) {
+		// Fails, as intended, on directories holding other archives.
+		if os.Remove(parent) != nil {
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPurgeConsultsPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		status   int
+		dryRun   bool
+		want     purgeResult
+		// wantKept tells whether the archive is still there afterwards.
+		wantKept bool
+	}{
+		{name: "allowed", response: `{"result": true}`, want: purgeResult{removed: 1, freed: 1024}},
+		{name: "allowed by decision", response: `{"result": {"decision": "allow"}}`, want: purgeResult{removed: 1, freed: 1024}},
+		{name: "denied", response: `{"result": {"decision": "deny", "reason": "legal hold"}}`, want: purgeResult{retained: 1}, wantKept: true},
+		{name: "deferred", response: `{"result": {"decision": "defer"}}`, want: purgeResult{deferred: 1}, wantKept: true},
+		{name: "undefined", response: `{}`, want: purgeResult{retained: 1}, wantKept: true},
+		{name: "unreachable", status: http.StatusServiceUnavailable, want: purgeResult{failed: 1}, wantKept: true},
+		{name: "dry run", response: `{"result": true}`, dryRun: true, want: purgeResult{removed: 1, freed: 1024}, wantKept: true},
+		{name: "dry run denied", response: `{"result": false}`, dryRun: true, want: purgeResult{retained: 1}, wantKept: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input policyInput
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body struct {
+					Input policyInput `json:"input"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("invalid policy request: %v", err)
+				}
+				input = body.Input
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			export := t.TempDir()
+			dir := filepath.Join(export, "archived-team-a-data-pvc-1")
+			if err := os.Mkdir(dir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			c := purgeCandidate{dir: dir}
+			c.Archive, c.PV, c.PVC, c.Namespace, c.Bytes = "archived-team-a-data-pvc-1", "pvc-1", "data", "team-a", 1024
+
+			got := purge(context.Background(), io.Discard, export, []purgeCandidate{c}, newPolicyClient(server.URL), tt.dryRun)
+			if got != tt.want {
+				t.Errorf("purge() = %+v, want %+v", got, tt.want)
+			}
+			want := policyInput{Action: "purge", PV: "pvc-1", PVC: "data", Namespace: "team-a", Archive: "archived-team-a-data-pvc-1"}
+			if input != want {
+				t.Errorf("policy input = %+v, want %+v", input, want)
+			}
+			if _, err := os.Stat(dir); (err == nil) != tt.wantKept {
+				t.Errorf("archive kept = %v, want %v", err == nil, tt.wantKept)
+			}
+		})
+	}
+}
+
+func TestPurgeWithoutPolicy(t *testing.T) {
+	export := t.TempDir()
+	dir := filepath.Join(export, "archived-a")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	got := purge(context.Background(), io.Discard, export, []purgeCandidate{{dir: dir}}, nil, false)
+	if got != (purgeResult{removed: 1}) {
+		t.Errorf("purge() = %+v, want one archive removed", got)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("archive not removed: %v", err)
+	}
+}
+
+func TestSelectPurge(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	archive := func(name, namespace, pvc string, age time.Duration, bytes int64) purgeCandidate {
+		c := purgeCandidate{}
+		c.Archive, c.Namespace, c.PVC, c.Archived, c.Bytes = name, namespace, pvc, now.Add(-age), bytes
+		return c
+	}
+	candidates := []purgeCandidate{
+		archive("a1", "team-a", "data", 100*day, 10),
+		archive("a2", "team-a", "data", 50*day, 20),
+		archive("a3", "team-a", "data", 10*day, 30),
+		archive("b1", "team-b", "data", 200*day, 1000),
+		archive("o1", "", "", 300*day, 5),
+	}
+	tests := []struct {
+		name     string
+		olderBy  time.Duration
+		keep     int
+		minBytes int64
+		want     []string
+	}{
+		{name: "by age", olderBy: 90 * day, want: []string{"o1", "b1", "a1"}},
+		{name: "by age, keeping the newest", olderBy: 40 * day, keep: 2, want: []string{"o1", "a1"}},
+		{name: "keep alone", keep: 1, want: []string{"a1", "a2"}},
+		{name: "keep alone spares the unknown claims", keep: 2, want: []string{"a1"}},
+		{name: "by size", minBytes: 20, want: []string{"b1", "a2", "a3"}},
+		{name: "by size and age", olderBy: 40 * day, minBytes: 20, want: []string{"b1", "a2"}},
+		{name: "by size, keeping the newest", keep: 1, minBytes: 10, want: []string{"a1", "a2"}},
+		{name: "nothing old enough", olderBy: 400 * day},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			given := append([]purgeCandidate(nil), candidates...)
+			var got []string
+			for _, c := range selectPurge(given, now.Add(-tt.olderBy), tt.olderBy > 0, tt.keep, tt.minBytes) {
+				got = append(got, c.Archive)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectPurge() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
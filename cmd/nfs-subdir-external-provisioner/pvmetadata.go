@@ -0,0 +1,107 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// reservedAnnotationPrefixes are those of the annotations that the
+// provisioner and Kubernetes read from PVs, which pvAnnotations cannot set.
+var reservedAnnotationPrefixes = []string{"nfs.io/", "pv.kubernetes.io/", "volume.kubernetes.io/"}
+
+// pvMetadataTemplates returns the keys and value templates of the
+// parameter name of class, pvLabels or pvAnnotations, a comma separated list
+// of key=template, nil if it is not set.
+func pvMetadataTemplates(class *storage.StorageClass, name string) (map[string]string, error) {
+	value := class.Parameters[name]
+	if value == "" {
+		return nil, nil
+	}
+	templates := map[string]string{}
+	for _, entry := range strings.Split(value, ",") {
+		key, template, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid %s entry %q, must be key=template", name, entry)
+		}
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid %s key %q: %s", name, key, strings.Join(errs, ", "))
+		}
+		if _, ok := templates[key]; ok {
+			return nil, fmt.Errorf("%s sets %q twice", name, key)
+		}
+		if name == "pvAnnotations" {
+			for _, prefix := range reservedAnnotationPrefixes {
+				if strings.HasPrefix(key, prefix) {
+					return nil, fmt.Errorf("pvAnnotations cannot set %q, the %s annotations are reserved", key, prefix)
+				}
+			}
+		}
+		if _, err := checkTemplate(template); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", name, err)
+		}
+		templates[key] = template
+	}
+	return templates, nil
+}
+
+// applyPVMetadata sets the labels and annotations that the pvLabels and
+// pvAnnotations parameters of class build from the metadata of the claim.
+func applyPVMetadata(class *storage.StorageClass, metadata *pvcMetadata, pv *v1.PersistentVolume) error {
+	labels, err := pvMetadataTemplates(class, "pvLabels")
+	if err != nil {
+		return err
+	}
+	for _, key := range sortedKeys(labels) {
+		value, err := metadata.stringParser(labels[key])
+		if err != nil {
+			return err
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Errorf("pvLabels gives label %s the invalid value %q: %s", key, value, strings.Join(errs, ", "))
+		}
+		metav1.SetMetaDataLabel(&pv.ObjectMeta, key, value)
+	}
+	annotations, err := pvMetadataTemplates(class, "pvAnnotations")
+	if err != nil {
+		return err
+	}
+	for _, key := range sortedKeys(annotations) {
+		value, err := metadata.stringParser(annotations[key])
+		if err != nil {
+			return err
+		}
+		metav1.SetMetaDataAnnotation(&pv.ObjectMeta, key, value)
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
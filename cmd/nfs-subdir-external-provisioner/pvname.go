@@ -0,0 +1,88 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/sig-storage-lib-external-provisioner/v10/controller"
+)
+
+// checkPVNameTemplate checks the syntax of a pvNameTemplate, and that what
+// it adds to the PVC fields it uses fits in a PV name.
+func checkPVNameTemplate(template string) error {
+	rest, err := checkTemplate(template)
+	if err != nil {
+		return fmt.Errorf("invalid pvNameTemplate: %v", err)
+	}
+	if errs := validation.IsDNS1123Subdomain(rest); len(errs) > 0 {
+		return fmt.Errorf("invalid pvNameTemplate %q, PV names must be lowercase DNS subdomains: %s", template, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// volumeName returns the name of the PV of claim: what the pvNameTemplate
+// parameter of class makes of it, or name, the pvc-<uid> given by the
+// controller.
+func volumeName(class *storage.StorageClass, claim *v1.PersistentVolumeClaim, name string) (string, error) {
+	template, ok := class.Parameters["pvNameTemplate"]
+	if !ok {
+		return name, nil
+	}
+	if err := checkPVNameTemplate(template); err != nil {
+		return "", err
+	}
+	custom, err := newPVCMetadata(claim).stringParser(template)
+	if err != nil {
+		return "", err
+	}
+	if errs := validation.IsDNS1123Subdomain(custom); len(errs) > 0 {
+		return "", fmt.Errorf("pvNameTemplate gives the invalid PV name %q: %s", custom, strings.Join(errs, ", "))
+	}
+	return custom, nil
+}
+
+// checkVolumeName makes sure that no PV has the templated name of the PV of
+// a claim yet. The controller only knows of the pvc-<uid> name, so it would
+// provision a claim again until it is bound, and take a PV of the same name
+// left by another claim, retained or not, for its own.
+func (p *nfsProvisioner) checkVolumeName(ctx context.Context, options controller.ProvisionOptions) error {
+	if _, ok := options.StorageClass.Parameters["pvNameTemplate"]; !ok {
+		return nil
+	}
+	volume, err := p.client.CoreV1().PersistentVolumes().Get(ctx, options.PVName, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return err
+	case volume.Spec.ClaimRef != nil && volume.Spec.ClaimRef.UID == options.PVC.UID:
+		return &controller.IgnoredError{Reason: fmt.Sprintf("PV %s of the claim is provisioned already", options.PVName)}
+	}
+	owner := "no claim"
+	if ref := volume.Spec.ClaimRef; ref != nil {
+		owner = fmt.Sprintf("claim %s/%s", ref.Namespace, ref.Name)
+	}
+	return fmt.Errorf("pvNameTemplate names the PV %s, which already exists for %s: delete it, or include ${.PVC.uid} in the template", options.PVName, owner)
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"sigs.k8s.io/yaml"
+)
+
+// applyPVTemplate strategic-merges template, a partial PersistentVolume in
+// YAML or JSON, into pv. The template may add anything the provisioner does
+// not set itself, but it may not rename the PV or point it at another
+// directory.
+func applyPVTemplate(pv *v1.PersistentVolume, template string) (*v1.PersistentVolume, error) {
+	patch, err := yaml.YAMLToJSON([]byte(template))
+	if err != nil {
+		return nil, fmt.Errorf("invalid pvTemplate: %v", err)
+	}
+	original, err := json.Marshal(pv)
+	if err != nil {
+		return nil, err
+	}
+	merged, err := strategicpatch.StrategicMergePatch(original, patch, v1.PersistentVolume{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to apply pvTemplate: %v", err)
+	}
+
+	result := &v1.PersistentVolume{}
+	if err := json.Unmarshal(merged, result); err != nil {
+		return nil, fmt.Errorf("unable to apply pvTemplate: %v", err)
+	}
+	switch {
+	case result.Name != pv.Name:
+		return nil, fmt.Errorf("pvTemplate must not change the PV name")
+	case !equality.Semantic.DeepEqual(result.Spec.PersistentVolumeSource, pv.Spec.PersistentVolumeSource):
+		return nil, fmt.Errorf("pvTemplate must not change the volume source")
+	case !equality.Semantic.DeepEqual(result.Spec.AccessModes, pv.Spec.AccessModes):
+		return nil, fmt.Errorf("pvTemplate must not change the access modes, which are those of the claim")
+	case result.Spec.VolumeMode != nil && *result.Spec.VolumeMode == v1.PersistentVolumeBlock:
+		return nil, fmt.Errorf("pvTemplate must not set volumeMode Block, NFS volumes are file systems")
+	}
+	return result, nil
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	storagehelpers "k8s.io/component-helpers/storage/volume"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/sig-storage-lib-external-provisioner/v10/controller"
+)
+
+// annQuarantined marks the PVs whose directory failed to be reclaimed
+// --delete-quarantine-threshold times in a row, with the time they were
+// quarantined. Their deletion is not retried until it is removed.
+const annQuarantined = "nfs.io/quarantined"
+
+var quarantinedVolumes = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metricsNamespace,
+	Name:      "quarantined_volumes_total",
+	Help:      "Volumes quarantined after their directory failed to be reclaimed repeatedly, by StorageClass.",
+}, []string{"storageclass"})
+
+// errnoHints explain the file system errors that keep a directory from being
+// removed or moved.
+var errnoHints = map[syscall.Errno]string{
+	syscall.EPERM:     "files may be immutable or append-only, see lsattr",
+	syscall.EACCES:    "the provisioner lacks permission, e.g. on a root-squashed export or a directory of another owner",
+	syscall.ESTALE:    "stale file handle, the directory may have been replaced or moved on the server",
+	syscall.EBUSY:     "a file is in use, e.g. a mount point inside the directory",
+	syscall.ENOTEMPTY: "files keep appearing, e.g. .nfsXXXX files of files still open on a client",
+	syscall.EROFS:     "the export is read-only",
+	syscall.EIO:       "the server reported an I/O error",
+}
+
+// deleteFailures counts the failed deletions of each PV, by UID, since it
+// was last quarantined or the provisioner started.
+type deleteFailures struct {
+	mu     sync.Mutex
+	counts map[types.UID]int
+}
+
+// add counts a failed deletion of uid, and returns the failures so far.
+func (f *deleteFailures) add(uid types.UID) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.counts == nil {
+		f.counts = map[types.UID]int{}
+	}
+	f.counts[uid]++
+	return f.counts[uid]
+}
+
+// reset forgets the failed deletions of uid.
+func (f *deleteFailures) reset(uid types.UID) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.counts, uid)
+}
+
+// isFileSystemError tells whether err comes from the file system of the
+// export, rather than from the API server, a policy or a hook, which
+// quarantining the volume would not help with.
+func isFileSystemError(err error) bool {
+	var pathErr *os.PathError
+	var linkErr *os.LinkError
+	var errno syscall.Errno
+	return errors.As(err, &pathErr) || errors.As(err, &linkErr) || errors.As(err, &errno)
+}
+
+// deleteDiagnostics describes err for the operator looking into a
+// quarantined volume, with a hint at the likely cause.
+func deleteDiagnostics(err error) string {
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		if hint, ok := errnoHints[errno]; ok {
+			return fmt.Sprintf("%v (%s)", err, hint)
+		}
+	}
+	return err.Error()
+}
+
+// quarantined returns an error telling the controller to leave volume alone
+// if it is quarantined, nil otherwise.
+func quarantined(volume *v1.PersistentVolume) error {
+	since, ok := volume.Annotations[annQuarantined]
+	if !ok {
+		return nil
+	}
+	return &controller.IgnoredError{Reason: fmt.Sprintf("volume %s is quarantined since %s, remove the %s annotation to retry its deletion", volume.Name, since, annQuarantined)}
+}
+
+// deleteFailed counts the failed deletion of volume and, once it has failed
+// --delete-quarantine-threshold times in a row with a file system error while
+// the exports are healthy, quarantines it. Failures due to an unreachable
+// export are not counted, so that an outage does not quarantine every
+// volume deleted meanwhile.
+func (p *nfsProvisioner) deleteFailed(ctx context.Context, volume *v1.PersistentVolume, err error) {
+	threshold := p.settings().QuarantineThreshold
+	if threshold == 0 || !isFileSystemError(err) || p.watchdog.healthy() != nil {
+		return
+	}
+	failures := p.failures.add(volume.UID)
+	if failures < threshold {
+		return
+	}
+	logger := klog.FromContext(ctx)
+	since := p.clock.Now().UTC().Format(time.RFC3339)
+	patch, jsonErr := json.Marshal(map[string]interface{}{"metadata": map[string]interface{}{"annotations": map[string]interface{}{annQuarantined: since}}})
+	if jsonErr != nil {
+		return
+	}
+	if _, err := p.client.CoreV1().PersistentVolumes().Patch(ctx, volume.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		logger.Error(err, "failed to quarantine volume", "pv", volume.Name)
+		return
+	}
+	p.failures.reset(volume.UID)
+	quarantinedVolumes.WithLabelValues(storagehelpers.GetPersistentVolumeClass(volume)).Inc()
+	diagnostics := deleteDiagnostics(err)
+	logger.Info("warning: quarantined volume whose directory repeatedly failed to be reclaimed", "pv", volume.Name, "path", volume.Spec.NFS.Path, "failures", failures, "diagnostics", diagnostics)
+	p.recorder.Eventf(volume, v1.EventTypeWarning, "VolumeQuarantined",
+		"Reclaiming %s failed %d times, giving up until the %s annotation is removed: %s", volume.Spec.NFS.Path, failures, annQuarantined, diagnostics)
+	event := notification{
+		Event:        notifyQuarantined,
+		PV:           volume.Name,
+		StorageClass: storagehelpers.GetPersistentVolumeClass(volume),
+		Message:      fmt.Sprintf("Quarantined volume %s after reclaiming %s failed %d times", volume.Name, volume.Spec.NFS.Path, failures),
+		Error:        diagnostics,
+	}
+	if claim := volume.Spec.ClaimRef; claim != nil {
+		event.PVC, event.Namespace = claim.Name, claim.Namespace
+	}
+	p.notify(event, "")
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// annQuotaAlert on a namespace is the highest threshold, in percent, that
+	// the storage quotas of the namespace have reached. It is absent below
+	// the lowest threshold.
+	annQuotaAlert = "nfs.io/quota-alert"
+
+	storageClassQuotaSuffix = ".storageclass.storage.k8s.io/"
+)
+
+// parseThresholds parses comma separated percentages, returning them in
+// increasing order.
+func parseThresholds(list string) ([]int, error) {
+	var thresholds []int
+	for _, item := range splitList(list) {
+		t, err := strconv.Atoi(strings.TrimSuffix(item, "%"))
+		if err != nil || t <= 0 {
+			return nil, fmt.Errorf("invalid threshold %q", item)
+		}
+		thresholds = append(thresholds, t)
+	}
+	sort.Ints(thresholds)
+	return thresholds, nil
+}
+
+// quotaAlerter tracks the storage quotas of namespaces against thresholds.
+type quotaAlerter struct {
+	p          *nfsProvisioner
+	quotas     corelisters.ResourceQuotaLister
+	thresholds []int
+	queue      workqueue.RateLimitingInterface
+}
+
+// startQuotaAlerts annotates namespaces, and records an event, whenever the
+// usage of their quotas on claims of this provisioner crosses one of
+// thresholds, until ctx is done. The NFSQuotas of namespaces count too when
+// they are enforced.
+func (p *nfsProvisioner) startQuotaAlerts(ctx context.Context, quotas cache.SharedIndexInformer, lister corelisters.ResourceQuotaLister, thresholds []int) {
+	logger := klog.FromContext(ctx)
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	a := &quotaAlerter{p: p, quotas: lister, thresholds: thresholds, queue: queue}
+
+	enqueue := func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		if quota, ok := obj.(*v1.ResourceQuota); ok {
+			queue.Add(quota.Namespace)
+		}
+	}
+	_, err := quotas.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    enqueue,
+		UpdateFunc: func(_, obj interface{}) { enqueue(obj) },
+		DeleteFunc: enqueue,
+	})
+	if err != nil {
+		logger.Error(err, "failed to watch resource quotas")
+		return
+	}
+	p.alerts = a
+
+	go func() {
+		<-ctx.Done()
+		queue.ShutDown()
+	}()
+	go func() {
+		cache.WaitForCacheSync(ctx.Done(), quotas.HasSynced)
+		for {
+			item, quit := queue.Get()
+			if quit {
+				return
+			}
+			namespace := item.(string)
+			if err := a.sync(ctx, namespace); err != nil {
+				logger.Error(err, "failed to update quota alert", "namespace", namespace)
+				queue.AddRateLimited(namespace)
+			} else {
+				queue.Forget(namespace)
+			}
+			queue.Done(item)
+		}
+	}()
+}
+
+// usage returns the highest usage, in percent, of the quotas of namespace
+// that limit claims of this provisioner, along with the quota, such as
+// "ResourceQuota storage", and resource it was found on.
+func (a *quotaAlerter) usage(namespace string) (int64, string, string, error) {
+	quotas, err := a.quotas.ResourceQuotas(namespace).List(labels.Everything())
+	if err != nil {
+		return 0, "", "", err
+	}
+	var highest int64
+	var quotaName, resourceName string
+	for _, quota := range quotas {
+		for name, hard := range quota.Status.Hard {
+			if !a.limitsClaims(name) || hard.IsZero() {
+				continue
+			}
+			used := quota.Status.Used[name]
+			percent := used.MilliValue() * 100 / hard.MilliValue()
+			if percent > highest || quotaName == "" {
+				highest, quotaName, resourceName = percent, "ResourceQuota "+quota.Name, string(name)
+			}
+		}
+	}
+	if a.p.quotas != nil {
+		percent, name, resource, err := a.p.quotas.percentUsed(namespace)
+		if err != nil {
+			return 0, "", "", err
+		}
+		if name != "" && (percent > highest || quotaName == "") {
+			highest, quotaName, resourceName = percent, "NFSQuota "+name, resource
+		}
+	}
+	return highest, quotaName, resourceName, nil
+}
+
+// limitsClaims reports whether the quota resource name limits the claims of
+// this provisioner: the storage and claim counts of the whole namespace, or
+// of a StorageClass of this provisioner.
+func (a *quotaAlerter) limitsClaims(name v1.ResourceName) bool {
+	switch name {
+	case v1.ResourceRequestsStorage, v1.ResourcePersistentVolumeClaims:
+		return true
+	}
+	class, resource, ok := strings.Cut(string(name), storageClassQuotaSuffix)
+	if !ok || (resource != string(v1.ResourceRequestsStorage) && resource != string(v1.ResourcePersistentVolumeClaims)) {
+		return false
+	}
+	sc, err := a.p.classes.Get(class)
+	return err == nil && a.p.owns(sc.Provisioner)
+}
+
+// sync brings the quota alert of namespace up to date.
+func (a *quotaAlerter) sync(ctx context.Context, namespace string) error {
+	percent, quota, resource, err := a.usage(namespace)
+	if err != nil {
+		return err
+	}
+	level := 0
+	for _, t := range a.thresholds {
+		if percent >= int64(t) {
+			level = t
+		}
+	}
+
+	ns, err := a.p.client.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	previous, _ := strconv.Atoi(ns.Annotations[annQuotaAlert])
+	if previous == level {
+		return nil
+	}
+
+	var value interface{}
+	if level > 0 {
+		value = strconv.Itoa(level)
+	}
+	patch, err := json.Marshal(map[string]interface{}{"metadata": map[string]interface{}{"annotations": map[string]interface{}{annQuotaAlert: value}}})
+	if err != nil {
+		return err
+	}
+	if _, err := a.p.client.CoreV1().Namespaces().Patch(ctx, namespace, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return err
+	}
+
+	switch {
+	case level > previous:
+		a.p.recorder.Eventf(ns, v1.EventTypeWarning, "StorageQuotaThreshold", "%s of %s is %d%% used, reaching the %d%% threshold", resource, quota, percent, level)
+	case level > 0:
+		a.p.recorder.Eventf(ns, v1.EventTypeNormal, "StorageQuotaThreshold", "%s of %s is down to %d%% used, below the %d%% threshold", resource, quota, percent, previous)
+	default:
+		a.p.recorder.Eventf(ns, v1.EventTypeNormal, "StorageQuotaThreshold", "storage quotas are below the %d%% threshold", a.thresholds[0])
+	}
+	return nil
+}
@@ -0,0 +1,320 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/sig-storage-lib-external-provisioner/v10/controller"
+)
+
+// reservationTimeout bounds how long a provisioned volume counts against
+// the quotas of its namespace before its PV shows up.
+const reservationTimeout = 10 * time.Minute
+
+var quotaResource = schema.GroupVersionResource{Group: apiGroup, Version: "v1alpha1", Resource: "nfsquotas"}
+
+// nfsQuotaSpec is the spec of an NFSQuota object. Unset limits do not apply.
+type nfsQuotaSpec struct {
+	Volumes *int64             `json:"volumes,omitempty"`
+	Storage *resource.Quantity `json:"storage,omitempty"`
+}
+
+// quotaUsage is what the volumes of this provisioner use in a namespace.
+type quotaUsage struct {
+	Volumes int64             `json:"volumes"`
+	Storage resource.Quantity `json:"storage"`
+}
+
+// reservation is a volume being provisioned, whose PV does not exist yet.
+type reservation struct {
+	namespace string
+	size      resource.Quantity
+	expires   time.Time
+}
+
+// quotaManager enforces the NFSQuotas of namespaces on the volumes of this
+// provisioner, and keeps their status up to date.
+type quotaManager struct {
+	p      *nfsProvisioner
+	client dynamic.NamespaceableResourceInterface
+	lister cache.GenericLister
+	queue  workqueue.RateLimitingInterface
+
+	mu       sync.Mutex
+	reserved map[string]reservation
+}
+
+func parseQuotaSpec(obj *unstructured.Unstructured) (nfsQuotaSpec, error) {
+	var spec nfsQuotaSpec
+	raw, _, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil {
+		return spec, fmt.Errorf("NFSQuota %s/%s has an invalid spec: %v", obj.GetNamespace(), obj.GetName(), err)
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &spec); err != nil {
+		return spec, fmt.Errorf("invalid NFSQuota %s/%s: %v", obj.GetNamespace(), obj.GetName(), err)
+	}
+	return spec, nil
+}
+
+// startQuotas watches NFSQuotas, and the volumes of p they count, until ctx
+// is done.
+func (p *nfsProvisioner) startQuotas(ctx context.Context, client dynamic.Interface, volumes cache.SharedIndexInformer) (*quotaManager, error) {
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(client, controller.DefaultResyncPeriod)
+	informer := factory.ForResource(quotaResource)
+	m := &quotaManager{
+		p:        p,
+		client:   client.Resource(quotaResource),
+		lister:   informer.Lister(),
+		queue:    workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		reserved: map[string]reservation{},
+	}
+
+	enqueueQuota := func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		if u, ok := obj.(*unstructured.Unstructured); ok {
+			m.queue.Add(u.GetNamespace())
+		}
+	}
+	_, err := informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    enqueueQuota,
+		UpdateFunc: func(_, obj interface{}) { enqueueQuota(obj) },
+		DeleteFunc: enqueueQuota,
+	})
+	if err != nil {
+		return nil, err
+	}
+	enqueueVolume := func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		if volume, ok := obj.(*v1.PersistentVolume); ok && volume.Spec.ClaimRef != nil && p.owns(volume.Annotations[annProvisionedBy]) {
+			m.queue.Add(volume.Spec.ClaimRef.Namespace)
+		}
+	}
+	_, err = volumes.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    enqueueVolume,
+		UpdateFunc: func(_, obj interface{}) { enqueueVolume(obj) },
+		DeleteFunc: enqueueVolume,
+	})
+	if err != nil {
+		return nil, err
+	}
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+
+	logger := klog.FromContext(ctx)
+	go func() {
+		<-ctx.Done()
+		m.queue.ShutDown()
+	}()
+	go func() {
+		cache.WaitForCacheSync(ctx.Done(), volumes.HasSynced)
+		for {
+			item, quit := m.queue.Get()
+			if quit {
+				return
+			}
+			namespace := item.(string)
+			if err := m.updateStatus(ctx, namespace); err != nil {
+				logger.Error(err, "failed to update NFSQuota status", "namespace", namespace)
+				m.queue.AddRateLimited(namespace)
+			} else {
+				m.queue.Forget(namespace)
+			}
+			if p.alerts != nil {
+				p.alerts.queue.Add(namespace)
+			}
+			m.queue.Done(item)
+		}
+	}()
+	return m, nil
+}
+
+// quotas returns the NFSQuotas of namespace.
+func (m *quotaManager) quotas(namespace string) ([]*unstructured.Unstructured, error) {
+	objs, err := m.lister.ByNamespace(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	var quotas []*unstructured.Unstructured
+	for _, obj := range objs {
+		if u, ok := obj.(*unstructured.Unstructured); ok {
+			quotas = append(quotas, u)
+		}
+	}
+	return quotas, nil
+}
+
+// usage returns what the volumes of this provisioner use in namespace: the
+// number of PVs bound to its claims, whatever their phase, since released
+// ones keep their directory until they are reclaimed, and their capacity,
+// plus the volumes being provisioned. m.mu must be held.
+func (m *quotaManager) usage(namespace string) (quotaUsage, error) {
+	var usage quotaUsage
+	volumes, err := m.p.volumes.List(labels.Everything())
+	if err != nil {
+		return usage, err
+	}
+	existing := map[string]bool{}
+	for _, volume := range volumes {
+		existing[volume.Name] = true
+		if volume.Spec.ClaimRef == nil || volume.Spec.ClaimRef.Namespace != namespace || !m.p.owns(volume.Annotations[annProvisionedBy]) {
+			continue
+		}
+		usage.Volumes++
+		usage.Storage.Add(volume.Spec.Capacity[v1.ResourceStorage])
+	}
+	now := m.p.clock.Now()
+	for name, r := range m.reserved {
+		if existing[name] || now.After(r.expires) {
+			delete(m.reserved, name)
+			continue
+		}
+		if r.namespace == namespace {
+			usage.Volumes++
+			usage.Storage.Add(r.size)
+		}
+	}
+	return usage, nil
+}
+
+// reserve counts the volume called pvName, of size, against the NFSQuotas
+// of namespace, refusing it if that exceeds one of them. The reservation
+// lasts until the PV shows up, or until release is called when provisioning
+// fails.
+func (m *quotaManager) reserve(namespace, pvName string, size resource.Quantity) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	quotas, err := m.quotas(namespace)
+	if err != nil || len(quotas) == 0 {
+		return err
+	}
+	usage, err := m.usage(namespace)
+	if err != nil {
+		return err
+	}
+	for _, quota := range quotas {
+		spec, err := parseQuotaSpec(quota)
+		if err != nil {
+			return err
+		}
+		if spec.Volumes != nil && usage.Volumes+1 > *spec.Volumes {
+			return fmt.Errorf("NFSQuota %s of namespace %s allows %d volumes, and %d are used", quota.GetName(), namespace, *spec.Volumes, usage.Volumes)
+		}
+		storage := usage.Storage.DeepCopy()
+		storage.Add(size)
+		if spec.Storage != nil && storage.Cmp(*spec.Storage) > 0 {
+			return fmt.Errorf("NFSQuota %s of namespace %s allows %s, %s are used and the claim needs %s", quota.GetName(), namespace, spec.Storage.String(), usage.Storage.String(), size.String())
+		}
+	}
+	m.reserved[pvName] = reservation{namespace: namespace, size: size, expires: m.p.clock.Now().Add(reservationTimeout)}
+	return nil
+}
+
+// release drops the reservation of the volume called pvName.
+func (m *quotaManager) release(pvName string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.reserved, pvName)
+}
+
+// percentUsed returns the highest usage, in percent, of the NFSQuotas of
+// namespace, along with the quota and resource it was found on.
+func (m *quotaManager) percentUsed(namespace string) (int64, string, string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	quotas, err := m.quotas(namespace)
+	if err != nil || len(quotas) == 0 {
+		return 0, "", "", err
+	}
+	usage, err := m.usage(namespace)
+	if err != nil {
+		return 0, "", "", err
+	}
+	var highest int64
+	var quotaName, resourceName string
+	for _, quota := range quotas {
+		spec, err := parseQuotaSpec(quota)
+		if err != nil {
+			continue
+		}
+		if spec.Volumes != nil && *spec.Volumes > 0 {
+			if percent := usage.Volumes * 100 / *spec.Volumes; percent > highest || quotaName == "" {
+				highest, quotaName, resourceName = percent, quota.GetName(), "volumes"
+			}
+		}
+		if spec.Storage != nil && !spec.Storage.IsZero() {
+			if percent := usage.Storage.MilliValue() * 100 / spec.Storage.MilliValue(); percent > highest || quotaName == "" {
+				highest, quotaName, resourceName = percent, quota.GetName(), "storage"
+			}
+		}
+	}
+	return highest, quotaName, resourceName, nil
+}
+
+// updateStatus records in the status of the NFSQuotas of namespace what its
+// volumes use.
+func (m *quotaManager) updateStatus(ctx context.Context, namespace string) error {
+	m.mu.Lock()
+	quotas, err := m.quotas(namespace)
+	var usage quotaUsage
+	if err == nil {
+		usage, err = m.usage(namespace)
+	}
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	used, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&usage)
+	if err != nil {
+		return err
+	}
+	for _, quota := range quotas {
+		previous, _, _ := unstructured.NestedMap(quota.Object, "status", "used")
+		if equality.Semantic.DeepEqual(previous, used) {
+			continue
+		}
+		quota = quota.DeepCopy()
+		if err := unstructured.SetNestedMap(quota.Object, used, "status", "used"); err != nil {
+			return err
+		}
+		if _, err := m.client.Namespace(namespace).UpdateStatus(ctx, quota, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
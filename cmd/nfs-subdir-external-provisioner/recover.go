@@ -0,0 +1,242 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// recoveredVolume is a volume directory found by recover, along with the
+// objects recreated for it.
+type recoveredVolume struct {
+	dir   string
+	pv    *v1.PersistentVolume
+	claim *v1.PersistentVolumeClaim
+}
+
+func runRecover(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	export := fs.String("export", "", "Mounted export to recover the volumes of.")
+	metadataFile := fs.String("metadata-file", ".nfs-provisioner.json", "Name of the metadata files of the volumes, as --metadata-file of the provisioner.")
+	server := fs.String("nfs-server", "", "NFS server the recovered PVs name, instead of the one recorded in the metadata files, e.g. when the export was restored elsewhere.")
+	nfsPath := fs.String("nfs-path", "", "Path of the export on --nfs-server. Required with --nfs-server.")
+	provisioner := fs.String("provisioner-name", "", "Provisioner the recovered PVs belong to, instead of the one recorded.")
+	reclaim := fs.String("reclaim-policy", "Retain", "Reclaim policy of the recovered PVs: Retain, Delete, or empty to keep the recorded one.")
+	claims := fs.Bool("claims", false, "Also create the claims of the volumes, bound to them.")
+	dryRun := fs.Bool("dry-run", false, "Print the objects instead of creating them.")
+	kubeconfig := fs.String("kubeconfig", "", "Kubeconfig file. Defaults to $KUBECONFIG, ~/.kube/config, then the in-cluster config.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	switch {
+	case *export == "":
+		return fmt.Errorf("--export is required")
+	case (*server == "") != (*nfsPath == ""):
+		return fmt.Errorf("--nfs-server and --nfs-path must be set together")
+	case *reclaim != "" && *reclaim != string(v1.PersistentVolumeReclaimRetain) && *reclaim != string(v1.PersistentVolumeReclaimDelete):
+		return fmt.Errorf("--reclaim-policy must be Retain or Delete")
+	}
+	if *server != "" {
+		canonical, err := canonicalServer(*server)
+		if err != nil {
+			return err
+		}
+		*server = canonical
+	}
+
+	logger := klog.FromContext(ctx)
+	var volumes []recoveredVolume
+	err := filepath.WalkDir(*export, func(dir string, entry os.DirEntry, err error) error {
+		if err != nil {
+			logger.Error(err, "failed to walk the export", "path", dir)
+			return nil
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(*export, dir)
+		// Archived volumes were deleted.
+		if strings.HasPrefix(strings.SplitN(rel, string(filepath.Separator), 2)[0], archivePrefix) {
+			return filepath.SkipDir
+		}
+		metadata, err := readMetadataFile(filepath.Join(dir, *metadataFile))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			logger.Error(err, "failed to read the metadata file of a volume", "path", dir)
+			return filepath.SkipDir
+		}
+		if *server != "" {
+			metadata.Server = *server
+			metadata.Path = path.Join(*nfsPath, filepath.ToSlash(rel))
+		}
+		if *provisioner != "" {
+			metadata.Provisioner = *provisioner
+		}
+		if *reclaim != "" {
+			metadata.Reclaim = v1.PersistentVolumeReclaimPolicy(*reclaim)
+		}
+		volume, err := recoverVolume(rel, metadata, *claims)
+		if err != nil {
+			logger.Error(err, "failed to recover a volume", "path", dir)
+		} else {
+			volumes = append(volumes, volume)
+		}
+		// The content of a volume is not ours to walk.
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		for _, volume := range volumes {
+			objs := []interface{}{volume.pv}
+			if volume.claim != nil {
+				objs = append(objs, volume.claim)
+			}
+			for _, obj := range objs {
+				data, err := yaml.Marshal(obj)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("---\n%s", data)
+			}
+		}
+		return nil
+	}
+
+	client, err := commandClient(*kubeconfig)
+	if err != nil {
+		return err
+	}
+	var created, existing, failed int
+	for _, volume := range volumes {
+		_, err := client.CoreV1().PersistentVolumes().Create(ctx, volume.pv, metav1.CreateOptions{})
+		switch {
+		case apierrors.IsAlreadyExists(err):
+			logger.Info("PV exists already, skipping", "pv", volume.pv.Name, "directory", volume.dir)
+			existing++
+			continue
+		case err != nil:
+			logger.Error(err, "failed to create PV", "pv", volume.pv.Name, "directory", volume.dir)
+			failed++
+			continue
+		}
+		logger.Info("recovered PV", "pv", volume.pv.Name, "directory", volume.dir)
+		created++
+		if volume.claim == nil {
+			continue
+		}
+		_, err = client.CoreV1().PersistentVolumeClaims(volume.claim.Namespace).Create(ctx, volume.claim, metav1.CreateOptions{})
+		switch {
+		case apierrors.IsAlreadyExists(err):
+			logger.Info("claim exists already, skipping", "pvc", volume.claim.Name, "namespace", volume.claim.Namespace)
+		case err != nil:
+			logger.Error(err, "failed to create claim", "pvc", volume.claim.Name, "namespace", volume.claim.Namespace)
+			failed++
+		default:
+			logger.Info("recovered claim", "pvc", volume.claim.Name, "namespace", volume.claim.Namespace)
+		}
+	}
+	fmt.Printf("%d volumes found, %d PVs created, %d existing, %d failures\n", len(volumes), created, existing, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d objects could not be created", failed)
+	}
+	return nil
+}
+
+// recoverVolume returns the PV described by metadata, the metadata file of
+// the directory dir of the export, reserved for its claim, and with claims
+// the claim as well, bound to the PV.
+func recoverVolume(dir string, metadata volumeMetadata, claims bool) (recoveredVolume, error) {
+	capacity, err := resource.ParseQuantity(metadata.Capacity)
+	if err != nil {
+		return recoveredVolume{}, fmt.Errorf("invalid capacity %q: %v", metadata.Capacity, err)
+	}
+	if metadata.PV == "" || metadata.Server == "" || metadata.Path == "" {
+		return recoveredVolume{}, fmt.Errorf("the metadata file names no PV, server or path")
+	}
+	accessModes := metadata.AccessModes
+	if len(accessModes) == 0 {
+		accessModes = []v1.PersistentVolumeAccessMode{v1.ReadWriteMany}
+	}
+	pv := &v1.PersistentVolume{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "PersistentVolume"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        metadata.PV,
+			Annotations: map[string]string{annProvisionedBy: metadata.Provisioner},
+		},
+		Spec: v1.PersistentVolumeSpec{
+			PersistentVolumeReclaimPolicy: metadata.Reclaim,
+			AccessModes:                   accessModes,
+			MountOptions:                  metadata.MountOptions,
+			NodeAffinity:                  metadata.NodeAffinity,
+			StorageClassName:              metadata.StorageClass,
+			Capacity:                      v1.ResourceList{v1.ResourceStorage: capacity},
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				NFS: &v1.NFSVolumeSource{Server: metadata.Server, Path: metadata.Path},
+			},
+		},
+	}
+	if metadata.Reclaim == "" {
+		pv.Spec.PersistentVolumeReclaimPolicy = v1.PersistentVolumeReclaimRetain
+	}
+	if metadata.PVC != "" && metadata.Namespace != "" {
+		// Without a UID, any new claim of that name binds to the PV.
+		pv.Spec.ClaimRef = &v1.ObjectReference{Kind: "PersistentVolumeClaim", APIVersion: "v1", Namespace: metadata.Namespace, Name: metadata.PVC}
+	}
+	volume := recoveredVolume{dir: dir, pv: pv}
+	if claims && pv.Spec.ClaimRef != nil {
+		volume.claim = boundClaim(pv)
+	}
+	return volume, nil
+}
+
+// boundClaim returns the claim pv is reserved for, bound to it.
+func boundClaim(pv *v1.PersistentVolume) *v1.PersistentVolumeClaim {
+	class := pv.Spec.StorageClassName
+	return &v1.PersistentVolumeClaim{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "PersistentVolumeClaim"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pv.Spec.ClaimRef.Name,
+			Namespace: pv.Spec.ClaimRef.Namespace,
+		},
+		Spec: v1.PersistentVolumeClaimSpec{
+			AccessModes:      pv.Spec.AccessModes,
+			StorageClassName: &class,
+			VolumeName:       pv.Name,
+			Resources: v1.VolumeResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceStorage: pv.Spec.Capacity[v1.ResourceStorage]},
+			},
+		},
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"strconv"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// configReloadInterval is how often the config file is checked for
+	// changes. A ConfigMap mounted as a volume is updated by the kubelet by
+	// swapping a symlink, which file watches tend to miss, so poll instead.
+	configReloadInterval = 10 * time.Second
+)
+
+// watchConfig applies changes to the config file until ctx is done. Only
+// reloadable options take effect; changes to the others are logged and wait
+// for a restart. An invalid file is reported and the running configuration is
+// kept.
+func (p *nfsProvisioner) watchConfig(ctx context.Context) {
+	file := p.settings().file
+	if file == "" {
+		return
+	}
+	logger := klog.FromContext(ctx).WithValues("file", file)
+
+	last, _ := os.ReadFile(file)
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			logger.Error(err, "failed to read config file")
+			return
+		}
+		if bytes.Equal(data, last) {
+			return
+		}
+		last = data
+
+		if err := p.reconfigure(ctx, func(*config) {}); err != nil {
+			logger.Error(err, "ignoring invalid config file")
+		}
+	}, configReloadInterval)
+}
+
+// settings returns the configuration in effect. It must not be modified.
+func (p *nfsProvisioner) settings() *config {
+	p.cfgMu.RLock()
+	defer p.cfgMu.RUnlock()
+	return p.cfg
+}
+
+// reconfigure rebuilds the configuration once update has changed one of its
+// sources, and applies it. On error the configuration in effect is kept.
+func (p *nfsProvisioner) reconfigure(ctx context.Context, update func(*config)) error {
+	p.cfgMu.Lock()
+	defer p.cfgMu.Unlock()
+
+	sources := *p.cfg
+	update(&sources)
+	next, err := sources.reload()
+	if err != nil {
+		return err
+	}
+	p.applyConfig(ctx, p.cfg, next)
+	p.cfg = next
+	for _, m := range p.members {
+		m.cfgMu.Lock()
+		m.cfg = next
+		m.cfgMu.Unlock()
+		m.policy.Store(p.policy.Load())
+	}
+	return nil
+}
+
+// applyConfig applies the reloadable options that differ between old and
+// next.
+func (p *nfsProvisioner) applyConfig(ctx context.Context, old, next *config) {
+	logger := klog.FromContext(ctx)
+
+	oldOptions := old.options()
+	for i, o := range next.options() {
+		before, after := oldOptions[i].String(), o.String()
+		if before == after {
+			continue
+		}
+		if !o.reloadable {
+			logger.Info("configuration change requires a restart", "option", o.flag)
+			continue
+		}
+		logger.Info("applying configuration change", "option", o.flag, "old", before, "new", after)
+	}
+
+	if next.LogLevel != old.LogLevel {
+		setLogLevel(next.LogLevel)
+	}
+	if next.WorkerThreads != old.WorkerThreads {
+		limit := next.WorkerThreads
+		if limit > p.maxWorkers {
+			limit = p.maxWorkers
+		}
+		p.provisionWorkers.setLimit(limit)
+		p.deleteWorkers.setLimit(limit)
+	}
+	if next.archiveWorkerThreads() != old.archiveWorkerThreads() {
+		limit := next.archiveWorkerThreads()
+		if limit > p.maxWorkers {
+			limit = p.maxWorkers
+		}
+		p.archiveWorkers.setLimit(limit)
+	}
+	if next.DeleteOpsRate != old.DeleteOpsRate || next.DeleteBandwidth != old.DeleteBandwidth {
+		p.throttle.setLimits(int64(next.DeleteOpsRate), next.deleteBandwidth())
+	}
+	if next.PolicyURL != old.PolicyURL {
+		if next.PolicyURL == "" {
+			p.policy.Store(nil)
+		} else {
+			p.policy.Store(newPolicyClient(next.PolicyURL))
+		}
+	}
+}
+
+// setLogLevel changes the klog verbosity, as if -v had been given.
+func setLogLevel(level int) {
+	if err := flag.Set("v", strconv.Itoa(level)); err != nil {
+		klog.Background().Error(err, "failed to set log level")
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"time"
+)
+
+const (
+	rpcTimeout    = 10 * time.Second
+	rpcReplyLimit = 1 << 16
+)
+
+// rpcAcceptError is the status of a call the server accepted but did not
+// run, such as an unsupported program or version.
+type rpcAcceptError uint32
+
+func (e rpcAcceptError) Error() string {
+	return fmt.Sprintf("call failed with accept status %d", uint32(e))
+}
+
+// rpcCall makes an ONC RPC call with AUTH_NULL credentials over TCP to addr,
+// and returns the results of a successful reply.
+func rpcCall(ctx context.Context, addr string, program, version, procedure uint32, args []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, rpcTimeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	xid := rand.Uint32()
+	var msg []byte
+	// xid, CALL, RPC version 2, program, version, procedure, then AUTH_NULL
+	// credentials and verifier.
+	for _, v := range []uint32{xid, 0, 2, program, version, procedure, 0, 0, 0, 0} {
+		msg = binary.BigEndian.AppendUint32(msg, v)
+	}
+	msg = append(msg, args...)
+	record := binary.BigEndian.AppendUint32(nil, 1<<31|uint32(len(msg)))
+	if _, err := conn.Write(append(record, msg...)); err != nil {
+		return nil, err
+	}
+
+	var reply []byte
+	for last := false; !last; {
+		var mark [4]byte
+		if _, err := io.ReadFull(conn, mark[:]); err != nil {
+			return nil, err
+		}
+		n := binary.BigEndian.Uint32(mark[:])
+		last = n&(1<<31) != 0
+		n &^= 1 << 31
+		if len(reply)+int(n) > rpcReplyLimit {
+			return nil, fmt.Errorf("reply too large")
+		}
+		fragment := make([]byte, n)
+		if _, err := io.ReadFull(conn, fragment); err != nil {
+			return nil, err
+		}
+		reply = append(reply, fragment...)
+	}
+
+	// xid, REPLY, MSG_ACCEPTED, verifier flavor and length.
+	if len(reply) < 20 || binary.BigEndian.Uint32(reply) != xid || binary.BigEndian.Uint32(reply[4:]) != 1 {
+		return nil, fmt.Errorf("invalid reply")
+	}
+	if binary.BigEndian.Uint32(reply[8:]) != 0 {
+		return nil, fmt.Errorf("call rejected")
+	}
+	verifier := int(binary.BigEndian.Uint32(reply[16:]))
+	offset := 20 + (verifier+3)&^3
+	if len(reply) < offset+4 {
+		return nil, fmt.Errorf("invalid reply")
+	}
+	if status := binary.BigEndian.Uint32(reply[offset:]); status != 0 {
+		return nil, rpcAcceptError(status)
+	}
+	return reply[offset+4:], nil
+}
+
+// xdrString appends s to data as an XDR string.
+func xdrString(data []byte, s string) []byte {
+	data = binary.BigEndian.AppendUint32(data, uint32(len(s)))
+	data = append(data, s...)
+	for i := len(s); i%4 != 0; i++ {
+		data = append(data, 0)
+	}
+	return data
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// The ONC RPC programs spoken to query quotas, as in RFC 1833 and
+// <rpcsvc/rquota.x>.
+const (
+	portmapProgram  = 100000
+	portmapVersion  = 2
+	portmapGetPort  = 3
+	rquotaProgram   = 100011
+	rquotaVersion   = 2
+	rquotaGetQuota  = 1
+	rpcTCP          = 6
+	rquotaOK        = 1
+	rquotaNoQuota   = 2
+	rquotaPermError = 3
+)
+
+// The kinds of quotas rquotad reports on.
+const (
+	userQuota    = 0
+	groupQuota   = 1
+	projectQuota = 2
+)
+
+// errNoQuota is returned for IDs without a quota.
+var errNoQuota = errors.New("no quota")
+
+// remoteQuota is a quota reported by rquotad.
+type remoteQuota struct {
+	// Bytes and Files are in use; HardBytes and HardFiles are the hard
+	// limits, 0 when unlimited.
+	Bytes, Files         int64
+	HardBytes, HardFiles int64
+}
+
+// rquotaClient queries the rquotad of NFS servers, found through their
+// portmapper.
+type rquotaClient struct{}
+
+// getQuota returns the quota of kind of the user, group or project id on
+// the file system of path, on server.
+func (*rquotaClient) getQuota(ctx context.Context, server, path string, kind int, id uint32) (*remoteQuota, error) {
+	var args []byte
+	for _, v := range []uint32{rquotaProgram, rquotaVersion, rpcTCP, 0} {
+		args = binary.BigEndian.AppendUint32(args, v)
+	}
+	reply, err := rpcCall(ctx, net.JoinHostPort(server, "111"), portmapProgram, portmapVersion, portmapGetPort, args)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query the portmapper of %s: %v", server, err)
+	}
+	if len(reply) < 4 || binary.BigEndian.Uint32(reply) == 0 {
+		return nil, fmt.Errorf("%s runs no rquotad", server)
+	}
+	port := strconv.Itoa(int(binary.BigEndian.Uint32(reply)))
+
+	args = xdrString(nil, path)
+	args = binary.BigEndian.AppendUint32(args, uint32(kind))
+	args = binary.BigEndian.AppendUint32(args, id)
+	reply, err = rpcCall(ctx, net.JoinHostPort(server, port), rquotaProgram, rquotaVersion, rquotaGetQuota, args)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query the rquotad of %s: %v", server, err)
+	}
+	if len(reply) < 4 {
+		return nil, fmt.Errorf("short reply from the rquotad of %s", server)
+	}
+	switch status := binary.BigEndian.Uint32(reply); status {
+	case rquotaOK:
+	case rquotaNoQuota:
+		return nil, errNoQuota
+	case rquotaPermError:
+		return nil, fmt.Errorf("the rquotad of %s refused the query", server)
+	default:
+		return nil, fmt.Errorf("unexpected status %d from the rquotad of %s", status, server)
+	}
+	// bsize, active, bhardlimit, bsoftlimit, curblocks, fhardlimit,
+	// fsoftlimit, curfiles, btimeleft, ftimeleft.
+	if len(reply) < 4+10*4 {
+		return nil, fmt.Errorf("short reply from the rquotad of %s", server)
+	}
+	field := func(i int) int64 { return int64(binary.BigEndian.Uint32(reply[4+4*i:])) }
+	bsize := field(0)
+	return &remoteQuota{
+		HardBytes: field(2) * bsize,
+		Bytes:     field(4) * bsize,
+		HardFiles: field(5),
+		Files:     field(7),
+	}, nil
+}
+
+// volumeQuota returns the kind and ID of the quota that measures volume on
+// its own: the group of its allocated GID, or its XFS project.
+func volumeQuota(volume *v1.PersistentVolume) (int, uint32, bool) {
+	if gid, err := strconv.ParseUint(volume.Annotations[annVolumeGID], 10, 32); err == nil {
+		return groupQuota, uint32(gid), true
+	}
+	if project, err := strconv.ParseUint(volume.Annotations[annProjectID], 10, 32); err == nil {
+		return projectQuota, uint32(project), true
+	}
+	return 0, 0, false
+}
+
+// checkQuotaSpace refuses claims larger than what the quota of the user the
+// provisioner accesses files as has left on the export of b.
+func (p *nfsProvisioner) checkQuotaSpace(ctx context.Context, b *backend, claim *v1.PersistentVolumeClaim) error {
+	if p.rquota == nil {
+		return nil
+	}
+	uid := os.Geteuid()
+	if fsUID, _, ok := p.cfg.fsIdentity(); ok {
+		uid = fsUID
+	}
+	quota, err := p.rquota.getQuota(ctx, b.server, b.path, userQuota, uint32(uid))
+	if errors.Is(err, errNoQuota) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if quota.HardBytes == 0 {
+		return nil
+	}
+	left := resource.NewQuantity(quota.HardBytes-quota.Bytes, resource.BinarySI)
+	request := claim.Spec.Resources.Requests[v1.ResourceStorage]
+	if request.Cmp(*left) > 0 {
+		return fmt.Errorf("claim requests %s, more than the %s left in the quota of uid %d on %s:%s", request.String(), left.String(), uid, b.server, b.path)
+	}
+	return nil
+}
@@ -0,0 +1,354 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	s3AccessKeyKey    = "AWS_ACCESS_KEY_ID"
+	s3SecretKeyKey    = "AWS_SECRET_ACCESS_KEY"
+	s3SessionTokenKey = "AWS_SESSION_TOKEN"
+
+	// s3UnsignedPayload stands for the hash of bodies that are streamed.
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+
+	// s3PartSize is the size of the first parts of multipart uploads. It
+	// doubles every s3PartSizeStep parts, since an upload has at most 10000.
+	s3PartSize     = 16 << 20
+	s3PartSizeStep = 1000
+)
+
+// s3Client talks to an S3 compatible object store, signing its requests
+// with AWS Signature Version 4. Objects are addressed by path, which every
+// S3 compatible store supports.
+type s3Client struct {
+	endpoint     *url.URL
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	client       *http.Client
+}
+
+// newS3Client returns a client of the store at endpoint, the regional AWS
+// endpoint when empty, with the credentials of the Secret called name in
+// namespace. Without a Secret, requests are anonymous.
+func (p *nfsProvisioner) newS3Client(ctx context.Context, endpoint, region, namespace, name string) (*s3Client, error) {
+	if region == "" {
+		region = "us-east-1"
+	}
+	if endpoint == "" {
+		endpoint = "https://s3." + region + ".amazonaws.com"
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", endpoint)
+	}
+	c := &s3Client{endpoint: u, region: region, client: http.DefaultClient}
+	if name == "" {
+		return c, nil
+	}
+	secret, err := p.client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get S3 credentials Secret %s/%s: %v", namespace, name, err)
+	}
+	c.accessKey = string(secret.Data[s3AccessKeyKey])
+	c.secretKey = string(secret.Data[s3SecretKeyKey])
+	c.sessionToken = string(secret.Data[s3SessionTokenKey])
+	if c.accessKey == "" || c.secretKey == "" {
+		return nil, fmt.Errorf("S3 credentials Secret %s/%s needs %s and %s", namespace, name, s3AccessKeyKey, s3SecretKeyKey)
+	}
+	return c, nil
+}
+
+// parseS3URL splits an s3://bucket/key URL.
+func parseS3URL(raw string) (string, string, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "s3" || u.Host == "" || strings.Trim(u.Path, "/") == "" {
+		return "", "", fmt.Errorf("invalid S3 URL %q, must be s3://bucket/key", raw)
+	}
+	return u.Host, strings.TrimPrefix(u.Path, "/"), nil
+}
+
+// objectURL returns the URL of key in bucket.
+func (c *s3Client) objectURL(bucket, key string) *url.URL {
+	u := *c.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + bucket + "/" + key
+	return &u
+}
+
+// get returns the content of key in bucket.
+func (c *s3Client) get(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.objectURL(bucket, key).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req, s3UnsignedPayload)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// put stores body as key in bucket.
+func (c *s3Client) put(ctx context.Context, bucket, key string, body []byte, contentType string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.objectURL(bucket, key).String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := c.do(req, payloadHash(body))
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
+// upload stores the content of r as key in bucket, in parts when it does
+// not fit in one, and returns its size. Only a part is held in memory at a
+// time. A failed multipart upload is aborted.
+func (c *s3Client) upload(ctx context.Context, bucket, key string, r io.Reader, contentType string) (int64, error) {
+	buf := make([]byte, s3PartSize)
+	n, err := io.ReadFull(r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return int64(n), c.put(ctx, bucket, key, buf[:n], contentType)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := c.createMultipartUpload(ctx, bucket, key, contentType)
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	var parts []s3Part
+	for number := 1; n > 0; number++ {
+		etag, err := c.uploadPart(ctx, bucket, key, id, number, buf[:n])
+		if err != nil {
+			c.abortMultipartUpload(bucket, key, id)
+			return 0, err
+		}
+		parts = append(parts, s3Part{Number: number, ETag: etag})
+		size += int64(n)
+		if number%s3PartSizeStep == 0 {
+			buf = make([]byte, 2*len(buf))
+		}
+		n, err = io.ReadFull(r, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			c.abortMultipartUpload(bucket, key, id)
+			return 0, err
+		}
+	}
+	if err := c.completeMultipartUpload(ctx, bucket, key, id, parts); err != nil {
+		c.abortMultipartUpload(bucket, key, id)
+		return 0, err
+	}
+	return size, nil
+}
+
+// s3Part is an uploaded part of a multipart upload.
+type s3Part struct {
+	Number int    `xml:"PartNumber"`
+	ETag   string `xml:"ETag"`
+}
+
+func (c *s3Client) createMultipartUpload(ctx context.Context, bucket, key, contentType string) (string, error) {
+	u := c.objectURL(bucket, key)
+	u.RawQuery = "uploads="
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := c.doXML(req, nil, &result); err != nil {
+		return "", err
+	}
+	if result.UploadID == "" {
+		return "", fmt.Errorf("no upload ID for %s/%s", bucket, key)
+	}
+	return result.UploadID, nil
+}
+
+func (c *s3Client) uploadPart(ctx context.Context, bucket, key, id string, number int, body []byte) (string, error) {
+	u := c.objectURL(bucket, key)
+	u.RawQuery = url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {id}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.do(req, payloadHash(body))
+	if err != nil {
+		return "", err
+	}
+	_ = resp.Body.Close()
+	return resp.Header.Get("ETag"), nil
+}
+
+func (c *s3Client) completeMultipartUpload(ctx context.Context, bucket, key, id string, parts []s3Part) error {
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name `xml:"CompleteMultipartUpload"`
+		Parts   []s3Part `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+	u := c.objectURL(bucket, key)
+	u.RawQuery = url.Values{"uploadId": {id}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	return c.doXML(req, body, nil)
+}
+
+// abortMultipartUpload drops the parts of a failed upload, which would
+// otherwise be billed forever. It runs even when the context of the upload
+// is done.
+func (c *s3Client) abortMultipartUpload(bucket, key, id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	u := c.objectURL(bucket, key)
+	u.RawQuery = url.Values{"uploadId": {id}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u.String(), nil)
+	if err != nil {
+		return
+	}
+	if resp, err := c.do(req, payloadHash(nil)); err == nil {
+		_ = resp.Body.Close()
+	}
+}
+
+// doXML sends req, whose body is body, and decodes the XML answer into
+// result. S3 may report errors with a 200 status, in an Error document.
+func (c *s3Client) doXML(req *http.Request, body []byte, result interface{}) error {
+	resp, err := c.do(req, payloadHash(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var s3Err struct {
+		XMLName xml.Name
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if err := xml.Unmarshal(data, &s3Err); err == nil && s3Err.XMLName.Local == "Error" {
+		return fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Redacted(), s3Err.Code, s3Err.Message)
+	}
+	if result == nil {
+		return nil
+	}
+	return xml.Unmarshal(data, result)
+}
+
+func payloadHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// do signs and sends req, and turns error statuses into errors.
+func (c *s3Client) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	if c.accessKey != "" {
+		c.sign(req, payloadHash, time.Now().UTC())
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Redacted(), resp.Status, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+// sign adds the AWS Signature Version 4 headers to req.
+func (c *s3Client) sign(req *http.Request, payloadHash string, now time.Time) {
+	date := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", date)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if c.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + c.region + "/s3/aws4_request"
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + date + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := []byte("AWS4" + c.secretKey)
+	for _, part := range []string{day, c.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", c.accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(data))
+	return h.Sum(nil)
+}
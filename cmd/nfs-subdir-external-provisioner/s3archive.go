@@ -0,0 +1,183 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+	"k8s.io/klog/v2"
+)
+
+// parseS3Prefix splits an s3://bucket/prefix URL, the prefix being
+// optional.
+func parseS3Prefix(raw string) (string, string, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "s3" || u.Host == "" || u.RawQuery != "" {
+		return "", "", fmt.Errorf("invalid S3 URL %q, must be s3://bucket or s3://bucket/prefix", raw)
+	}
+	return u.Host, strings.Trim(u.Path, "/"), nil
+}
+
+// archiveToS3 uploads the directory of volume, at dir, or rel relative to
+// the export, to the bucket of the archiveS3URL parameter of class as a
+// tarball, with a manifest, then removes it. It returns the URL of the
+// archive.
+func (p *nfsProvisioner) archiveToS3(ctx context.Context, volume *v1.PersistentVolume, class *storage.StorageClass, dir, rel string) (string, error) {
+	bucket, prefix, err := parseS3Prefix(class.Parameters["archiveS3URL"])
+	if err != nil {
+		return "", err
+	}
+	namespace := class.Parameters["archiveS3SecretNamespace"]
+	if namespace == "" {
+		namespace = podNamespace()
+	}
+	client, err := p.newS3Client(ctx, class.Parameters["archiveS3Endpoint"], class.Parameters["archiveS3Region"], namespace, class.Parameters["archiveS3SecretName"])
+	if err != nil {
+		return "", err
+	}
+
+	now := p.clock.Now().UTC()
+	name, err := archiveName(volume, class, rel, now, defaultS3ArchivePattern)
+	if err != nil {
+		return "", err
+	}
+	name = filepath.ToSlash(name)
+	key := path.Join(prefix, name+".tar.gz")
+	location := "s3://" + bucket + "/" + key
+	manifest := newArchiveManifest(volume, class, location, "tar.gz", now)
+
+	klog.FromContext(ctx).Info(fmt.Sprintf("archiving path %s to %s", dir, location))
+	err = traced(ctx, "UploadArchive", func() error {
+		reader, writer := io.Pipe()
+		hash := sha256.New()
+		out := io.MultiWriter(writer, hash)
+		if pr := progressFrom(ctx); pr != nil {
+			pr.setPhase(phaseUploading)
+			out = io.MultiWriter(writer, hash, pr)
+		}
+		go func() {
+			var err error
+			manifest.Files, manifest.Bytes, err = writeTarGz(ctx, out, dir)
+			writer.CloseWithError(err)
+		}()
+		manifest.Size, err = client.upload(ctx, bucket, key, reader, "application/gzip")
+		// Unblock the writer when the upload gave up halfway.
+		reader.CloseWithError(errors.New("upload stopped"))
+		if err != nil {
+			return err
+		}
+		manifest.SHA256 = hex.EncodeToString(hash.Sum(nil))
+		data, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return err
+		}
+		return client.put(ctx, bucket, path.Join(prefix, name+archiveManifestSuffix), data, "application/json")
+	}, "path", dir, "archive", location)
+	if err != nil {
+		return location, fmt.Errorf("unable to archive %s to %s: %v", dir, location, err)
+	}
+
+	if err := p.locks.fence(volume.Name); err != nil {
+		return location, err
+	}
+	return location, traced(ctx, "RemoveAll", func() error { return removeTree(ctx, dir) }, "path", dir)
+}
+
+// writeTarGz writes the content of dir to w as a gzipped tarball, with
+// paths relative to dir, and returns the number of entries and the size of
+// the regular files. Devices, sockets and pipes are left out.
+func writeTarGz(ctx context.Context, w io.Writer, dir string) (int64, int64, error) {
+	throttle := throttleFrom(ctx)
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	var files, bytes int64
+	err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil || rel == "." {
+			return err
+		}
+		if err := throttle.op(ctx); err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		var link string
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			if link, err = os.Readlink(file); err != nil {
+				return err
+			}
+		case !d.IsDir() && !d.Type().IsRegular():
+			return nil
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if d.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		files++
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		// A file growing while it is archived would overflow its header.
+		n, err := io.Copy(tw, throttle.reader(ctx, io.LimitReader(f, header.Size)))
+		if err == nil && n < header.Size {
+			err = fmt.Errorf("%s shrank while it was archived", file)
+		}
+		bytes += n
+		return err
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := tw.Close(); err != nil {
+		return 0, 0, err
+	}
+	return files, bytes, gz.Close()
+}
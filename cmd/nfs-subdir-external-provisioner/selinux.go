@@ -0,0 +1,116 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+)
+
+// annSELinuxLevel on a claim replaces the level of the seLinuxLabel of its
+// class, e.g. with the MCS categories of the pods using it.
+const annSELinuxLevel = "nfs.io/selinux-level"
+
+// seLinuxXattr holds the SELinux label of a file.
+const seLinuxXattr = "security.selinux"
+
+var (
+	seLinuxLevelPattern = regexp.MustCompile(`^s\d+(-s\d+)?(:c\d+(\.c\d+)?(,c\d+(\.c\d+)?)*)?$`)
+	seLinuxNamePattern  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+)
+
+// checkSELinuxLabel checks that label is a user:role:type:level label.
+func checkSELinuxLabel(label string) error {
+	parts := strings.SplitN(label, ":", 4)
+	if len(parts) != 4 {
+		return fmt.Errorf("invalid SELinux label %q, must be user:role:type:level", label)
+	}
+	for _, name := range parts[:3] {
+		if !seLinuxNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid SELinux label %q, must be user:role:type:level", label)
+		}
+	}
+	if !seLinuxLevelPattern.MatchString(parts[3]) {
+		return fmt.Errorf("invalid SELinux level %q in %q, must be like s0 or s0:c1,c2", parts[3], label)
+	}
+	return nil
+}
+
+// seLinuxLabel returns the SELinux label of the volume of claim, of class:
+// the seLinuxLabel parameter of class, with the level of the claim's
+// annSELinuxLevel, if any. It is "" when the class sets no label.
+func seLinuxLabel(class *storage.StorageClass, claim *v1.PersistentVolumeClaim) (string, error) {
+	label := class.Parameters["seLinuxLabel"]
+	level, hasLevel := claim.Annotations[annSELinuxLevel]
+	if label == "" {
+		if hasLevel {
+			return "", fmt.Errorf("claim sets %s, but StorageClass %s sets no seLinuxLabel", annSELinuxLevel, class.Name)
+		}
+		return "", nil
+	}
+	if err := checkSELinuxLabel(label); err != nil {
+		return "", err
+	}
+	if hasLevel {
+		if !seLinuxLevelPattern.MatchString(level) {
+			return "", fmt.Errorf("invalid %s %q, must be like s0 or s0:c1,c2", annSELinuxLevel, level)
+		}
+		parts := strings.SplitN(label, ":", 4)
+		label = strings.Join(append(parts[:3], level), ":")
+	}
+	return label, nil
+}
+
+// seLinuxLabelMode returns how the seLinuxLabel of class is applied, from
+// its seLinuxLabelMode parameter: to the directory, which needs an export
+// supporting labeled NFS, with a context mount option, or both.
+func seLinuxLabelMode(class *storage.StorageClass) (bool, bool, error) {
+	switch mode := class.Parameters["seLinuxLabelMode"]; mode {
+	case "", "directory":
+		return true, false, nil
+	case "mount":
+		return false, true, nil
+	case "both":
+		return true, true, nil
+	default:
+		return false, false, fmt.Errorf("invalid seLinuxLabelMode %q, must be directory, mount or both", mode)
+	}
+}
+
+// seLinuxMountOptions appends to options a context option mounting the
+// volume with label, unless options set a context already.
+func seLinuxMountOptions(label string, options []string) []string {
+	for _, o := range splitMountOptions(options) {
+		if strings.HasPrefix(o, "context=") {
+			return options
+		}
+	}
+	return append(append([]string{}, options...), fmt.Sprintf("context=%q", label))
+}
+
+// setSELinuxLabel labels the directory at path with label, which needs an
+// export supporting labeled NFS.
+func setSELinuxLabel(path, label string) error {
+	if err := setXattr(path, seLinuxXattr, append([]byte(label), 0)); err != nil {
+		return fmt.Errorf("unable to set the SELinux label of %s, the export may not support labeled NFS: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"hash/fnv"
+	"os"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/sig-storage-lib-external-provisioner/v10/controller"
+)
+
+// annShard records on a PV the shard that provisioned it, which is the one
+// that deletes it, whatever becomes of the namespace of its claim.
+const annShard = "nfs.io/shard"
+
+// shard is the slice of the claims of a provisioner that one of several
+// instances sharing its name handles: those of the namespaces matching
+// selector, if set, whose UID hashes to index out of count, if count is set.
+type shard struct {
+	name       string
+	selector   labels.Selector
+	namespaces corelisters.NamespaceLister
+	count      int
+	index      int
+}
+
+// contains reports whether the claim of namespace with uid is in s.
+func (s *shard) contains(namespace string, uid types.UID) bool {
+	if s.count > 1 {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(uid))
+		if int(h.Sum32()%uint32(s.count)) != s.index {
+			return false
+		}
+	}
+	if s.selector != nil {
+		ns, err := s.namespaces.Get(namespace)
+		if err != nil || !s.selector.Matches(labels.Set(ns.Labels)) {
+			return false
+		}
+	}
+	return true
+}
+
+var _ controller.Qualifier = &nfsProvisioner{}
+var _ controller.DeletionGuard = &nfsProvisioner{}
+
+// ShouldProvision skips the claims outside of the shard of p.
+func (p *nfsProvisioner) ShouldProvision(ctx context.Context, claim *v1.PersistentVolumeClaim) bool {
+	return p.shard == nil || p.shard.contains(claim.Namespace, claim.UID)
+}
+
+// ShouldDelete skips the volumes outside of the shard of p.
+func (p *nfsProvisioner) ShouldDelete(ctx context.Context, volume *v1.PersistentVolume) bool {
+	return p.inShard(volume)
+}
+
+// inShard reports whether volume belongs to the shard of p: the shard that
+// provisioned it or, for volumes provisioned before sharding, the one its
+// claim falls in.
+func (p *nfsProvisioner) inShard(volume *v1.PersistentVolume) bool {
+	if p.shard == nil {
+		return true
+	}
+	if name, ok := volume.Annotations[annShard]; ok {
+		return name == p.shard.name
+	}
+	ref := volume.Spec.ClaimRef
+	if ref == nil {
+		return false
+	}
+	uid := ref.UID
+	if uid == "" {
+		uid = types.UID(volume.Name)
+	}
+	return p.shard.contains(ref.Namespace, uid)
+}
+
+// runSharded runs pc while this instance holds the lease of its shard. The
+// controller names its lease after the provisioner, which every shard
+// shares, so shards elect their leaders themselves.
+func (p *nfsProvisioner) runSharded(ctx context.Context, pc *controller.ProvisionController) {
+	logger := klog.FromContext(ctx)
+	hostname, _ := os.Hostname()
+	lock, err := resourcelock.New(resourcelock.LeasesResourceLock,
+		podNamespace(),
+		strings.ReplaceAll(p.name, "/", "-")+"-"+p.shard.name,
+		p.client.CoreV1(),
+		p.client.CoordinationV1(),
+		resourcelock.ResourceLockConfig{Identity: hostname + "_" + string(uuid.NewUUID()), EventRecorder: p.recorder})
+	if err != nil {
+		logger.Error(err, "failed to create the lease of the shard", "shard", p.shard.name)
+		os.Exit(1)
+	}
+	leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: controller.DefaultLeaseDuration,
+		RenewDeadline: controller.DefaultRenewDeadline,
+		RetryPeriod:   controller.DefaultRetryPeriod,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: pc.Run,
+			OnStoppedLeading: func() {
+				logger.Error(nil, "lost the lease of the shard", "shard", p.shard.name)
+				klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+			},
+		},
+	})
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	storagehelpers "k8s.io/component-helpers/storage/volume"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+)
+
+// shareLockAttempts bounds how long a record waits for another replica to
+// finish appending to the log, 50ms apart.
+const shareLockAttempts = 40
+
+// shareAuditRecord is a single line of the audit log kept on an export. Paths
+// are on the NFS server, so that they make sense to whoever reads the log
+// there.
+type shareAuditRecord struct {
+	Timestamp    time.Time   `json:"timestamp"`
+	Action       auditAction `json:"action"`
+	Provisioner  string      `json:"provisioner"`
+	Host         string      `json:"host,omitempty"`
+	PV           string      `json:"pv,omitempty"`
+	PVC          string      `json:"pvc,omitempty"`
+	Namespace    string      `json:"namespace,omitempty"`
+	StorageClass string      `json:"storageClass,omitempty"`
+	Path         string      `json:"path"`
+	Destination  string      `json:"destination,omitempty"`
+}
+
+// shareAuditLog appends the directories the provisioner deleted, archived or
+// moved aside to a log file at the root of their export, so that the filer
+// tells what happened to them without access to the cluster. A nil
+// shareAuditLog discards everything.
+type shareAuditLog struct {
+	mu          sync.Mutex
+	name        string
+	provisioner string
+	host        string
+	clock       clock.PassiveClock
+}
+
+// newShareAuditLog logs, as provisioner, to the file called name at the root
+// of each export.
+func newShareAuditLog(name, provisioner string, clock clock.PassiveClock) *shareAuditLog {
+	host := os.Getenv("POD_NAME")
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+	return &shareAuditLog{name: name, provisioner: provisioner, host: host, clock: clock}
+}
+
+// serverPath returns where location, a local path under the mount of b or an
+// object storage URL, is for the NFS server.
+func (b *backend) serverPath(location string) string {
+	rel, err := filepath.Rel(b.mountPath, location)
+	if err != nil || strings.HasPrefix(rel, "..") || !filepath.IsAbs(location) {
+		return location
+	}
+	return path.Join(b.path, filepath.ToSlash(rel))
+}
+
+// logVolume logs what action did to the directory of volume, on the export
+// of b, and where it went, if anywhere.
+func (l *shareAuditLog) logVolume(b *backend, volume *v1.PersistentVolume, action auditAction, destination string) {
+	record := shareAuditRecord{
+		Action:       action,
+		PV:           volume.Name,
+		StorageClass: storagehelpers.GetPersistentVolumeClass(volume),
+		Path:         volume.Spec.PersistentVolumeSource.NFS.Path,
+	}
+	if claim := volume.Spec.ClaimRef; claim != nil {
+		record.PVC = claim.Name
+		record.Namespace = claim.Namespace
+	}
+	if destination != "" {
+		record.Destination = b.serverPath(destination)
+	}
+	l.log(b, record)
+}
+
+// log appends record to the log of the export of b. Replicas sharing the
+// export take turns through a lock on the file, where the export supports
+// locking. Failures are logged, and the record is lost.
+func (l *shareAuditLog) log(b *backend, record shareAuditRecord) {
+	if l == nil {
+		return
+	}
+	record.Timestamp = l.clock.Now().UTC()
+	record.Provisioner, record.Host = l.provisioner, l.host
+	data, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	file := filepath.Join(b.mountPath, l.name)
+	logger := klog.Background().WithValues("file", file)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o640)
+	if err != nil {
+		logger.Error(err, "failed to open the audit log of the export")
+		return
+	}
+	defer func() { _ = f.Close() }()
+	for i := 0; i < shareLockAttempts; i++ {
+		err = lockExclusive(f)
+		if !errors.Is(err, syscall.EAGAIN) && !errors.Is(err, syscall.EACCES) {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if err != nil {
+		logger.V(2).Info("appending to the audit log of the export without a lock", "reason", err.Error())
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		logger.Error(err, "failed to write to the audit log of the export")
+	}
+}
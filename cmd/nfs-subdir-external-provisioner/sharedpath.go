@@ -0,0 +1,108 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/sig-storage-lib-external-provisioner/v10/controller"
+)
+
+// annSharedPath marks the PVs of a sharedPath StorageClass with the shared
+// directory, which deleting them leaves alone.
+const annSharedPath = "nfs.io/shared-path"
+
+// checkSharedPath checks that sharedPath is a directory of the export,
+// relative to its root.
+func checkSharedPath(sharedPath string) error {
+	clean := filepath.Clean(sharedPath)
+	if sharedPath == "" || filepath.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return fmt.Errorf("invalid sharedPath %q, must be a directory of the export, relative to its root", sharedPath)
+	}
+	return nil
+}
+
+// provisionShared binds the claim of options to the existing sharedPath
+// directory of its class, read-only, rather than to a directory of its own.
+func (p *nfsProvisioner) provisionShared(ctx context.Context, options controller.ProvisionOptions, b *backend, server, sharedPath string) (*v1.PersistentVolume, controller.ProvisioningState, error) {
+	if err := checkSharedPath(sharedPath); err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	for _, mode := range options.PVC.Spec.AccessModes {
+		if mode != v1.ReadOnlyMany {
+			return nil, controller.ProvisioningFinished, fmt.Errorf("StorageClass %s shares %s read-only, claims must ask for ReadOnlyMany, not %s", options.StorageClass.Name, sharedPath, mode)
+		}
+	}
+	dir := filepath.Clean(sharedPath)
+	info, err := os.Stat(filepath.Join(b.mountPath, dir))
+	if err != nil {
+		return nil, controller.ProvisioningFinished, fmt.Errorf("unable to share %s: %v", dir, err)
+	}
+	if !info.IsDir() {
+		return nil, controller.ProvisioningFinished, fmt.Errorf("unable to share %s: not a directory", dir)
+	}
+
+	mountOptions, err := claimMountOptions(options.StorageClass, options.PVC, p.classMountOptions(options.StorageClass))
+	if err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	affinity, err := nodeAffinity(options.StorageClass, b)
+	if err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	if err := checkSelectedNode(options.SelectedNode, affinity); err != nil {
+		return nil, controller.ProvisioningReschedule, err
+	}
+
+	pv := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        options.PVName,
+			Annotations: map[string]string{annSharedPath: dir},
+		},
+		Spec: v1.PersistentVolumeSpec{
+			PersistentVolumeReclaimPolicy: v1.PersistentVolumeReclaimDelete,
+			AccessModes:                   []v1.PersistentVolumeAccessMode{v1.ReadOnlyMany},
+			MountOptions:                  mountOptions,
+			NodeAffinity:                  affinity,
+			Capacity: v1.ResourceList{
+				v1.ResourceStorage: options.PVC.Spec.Resources.Requests[v1.ResourceStorage],
+			},
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				NFS: &v1.NFSVolumeSource{
+					Server:   server,
+					Path:     filepath.Join(b.path, dir),
+					ReadOnly: true,
+				},
+			},
+		},
+	}
+	if err := applyPVMetadata(options.StorageClass, newPVCMetadata(options.PVC), pv); err != nil {
+		return nil, controller.ProvisioningFinished, err
+	}
+	if server != b.server {
+		metav1.SetMetaDataAnnotation(&pv.ObjectMeta, annExportServer, b.server)
+	}
+	klog.FromContext(ctx).Info("sharing directory", "path", dir, "pv", options.PVName)
+	return pv, controller.ProvisioningFinished, nil
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// drainPollInterval is how often in-flight operations are checked while
+	// shutting down.
+	drainPollInterval = 100 * time.Millisecond
+	// tracingShutdownTimeout bounds the export of the spans still pending
+	// on shutdown, which an unreachable collector would otherwise block.
+	tracingShutdownTimeout = 5 * time.Second
+)
+
+var errShuttingDown = errors.New("the provisioner is shutting down")
+
+// handleShutdown drains the provisioner when the process is asked to stop,
+// unmounts the export if it mounted it, flushes the spans of the drained
+// operations with shutdownTracing, then exits. Cancelling the context of the
+// controller would not do: losing the leader election lease makes it exit
+// right away.
+func (p *nfsProvisioner) handleShutdown(ctx context.Context, timeout time.Duration, shutdownTracing func(context.Context) error) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	go func() {
+		sig := <-signals
+		klog.FromContext(ctx).Info("shutting down", "signal", sig.String(), "timeout", timeout)
+		p.drain(ctx, timeout)
+		if p.ownsMount {
+			if err := unmountNFS(p.mountPath); err != nil {
+				klog.FromContext(ctx).Error(err, "failed to unmount the export", "path", p.mountPath)
+			}
+		}
+		tracingCtx, cancel := context.WithTimeout(ctx, tracingShutdownTimeout)
+		if err := shutdownTracing(tracingCtx); err != nil {
+			klog.FromContext(ctx).Error(err, "failed to export the pending spans")
+		}
+		cancel()
+		klog.Flush()
+		os.Exit(0)
+	}()
+}
+
+// drain refuses new operations, and waits up to timeout for the in-flight
+// ones, and for the PVs of the volumes they provisioned, so that no
+// directory is left half created, deleted or archived, then for the
+// notifications they queued. The provisioners of the member clusters are
+// drained along. What is still running after timeout is logged.
+func (p *nfsProvisioner) drain(ctx context.Context, timeout time.Duration) {
+	logger := klog.FromContext(ctx)
+	deadline := time.Now().Add(timeout)
+	defer func() {
+		if p.notifier == nil {
+			return
+		}
+		if left := p.notifier.flush(ctx, time.Until(deadline)); left > 0 {
+			logger.Error(nil, "abandoning notifications not sent yet", "notifications", left)
+		}
+	}()
+	all := append([]*nfsProvisioner{p}, p.clusterMembers()...)
+	for _, q := range all {
+		q.draining.Store(true)
+	}
+
+	err := wait.PollUntilContextTimeout(ctx, drainPollInterval, timeout, true, func(context.Context) (bool, error) {
+		for _, q := range all {
+			ops, _ := q.operations.snapshot()
+			if len(ops) > 0 || len(q.operations.unsavedVolumes(q.saved)) > 0 {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err == nil {
+		logger.Info("all operations finished")
+		return
+	}
+	for _, q := range all {
+		ops, _ := q.operations.snapshot()
+		for _, op := range ops {
+			logger.Error(nil, "abandoning operation", "operation", op.Kind, "pv", op.PV, "pvc", op.PVC, "started", op.Started)
+		}
+		for _, pv := range q.operations.unsavedVolumes(q.saved) {
+			logger.Error(nil, "abandoning provisioned volume whose PV was not created", "pv", pv)
+		}
+	}
+}
+
+// saved tells whether the PV called pv exists.
+func (p *nfsProvisioner) saved(pv string) bool {
+	_, err := p.volumes.Get(pv)
+	return err == nil
+}
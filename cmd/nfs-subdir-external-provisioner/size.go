@@ -0,0 +1,82 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// sizeParameter returns the quantity of the parameter called name of
+// class, or nil if it is not set.
+func sizeParameter(class *storage.StorageClass, name string) (*resource.Quantity, error) {
+	value, ok := class.Parameters[name]
+	if !ok {
+		return nil, nil
+	}
+	q, err := resource.ParseQuantity(value)
+	if err != nil || q.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid %s %q, must be a positive quantity such as 1Gi", name, value)
+	}
+	return &q, nil
+}
+
+// volumeSize returns the capacity of the PV of claim in class: its request,
+// raised to the minSize parameter of class, or refused below it when
+// minSizePolicy is reject, then rounded up to a multiple of roundSizeTo.
+// Capacities above maxSize are refused: NFS does not enforce the size of a
+// volume, so this is the only bound on what a claim may believe it gets.
+func volumeSize(class *storage.StorageClass, claim *v1.PersistentVolumeClaim) (resource.Quantity, error) {
+	size := claim.Spec.Resources.Requests[v1.ResourceStorage]
+	minSize, err := sizeParameter(class, "minSize")
+	if err != nil {
+		return size, err
+	}
+	roundTo, err := sizeParameter(class, "roundSizeTo")
+	if err != nil {
+		return size, err
+	}
+	maxSize, err := sizeParameter(class, "maxSize")
+	if err != nil {
+		return size, err
+	}
+
+	if minSize != nil && size.Cmp(*minSize) < 0 {
+		switch policy := class.Parameters["minSizePolicy"]; policy {
+		case "", "raise":
+			size = minSize.DeepCopy()
+		case "reject":
+			return size, fmt.Errorf("claim requests %s, less than the minSize of %s of StorageClass %s", size.String(), minSize.String(), class.Name)
+		default:
+			return size, fmt.Errorf("invalid minSizePolicy %q, must be raise or reject", policy)
+		}
+	}
+	if roundTo != nil {
+		step := roundTo.Value()
+		rounded := (size.Value() + step - 1) / step * step
+		if rounded != size.Value() {
+			size = *resource.NewQuantity(rounded, roundTo.Format)
+		}
+	}
+	if maxSize != nil && size.Cmp(*maxSize) > 0 {
+		return size, fmt.Errorf("claim requests %s, more than the maxSize of %s of StorageClass %s", size.String(), maxSize.String(), class.Name)
+	}
+	return size, nil
+}
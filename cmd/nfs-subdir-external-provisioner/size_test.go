@@ -0,0 +1,71 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestVolumeSize(t *testing.T) {
+	tests := []struct {
+		name       string
+		parameters map[string]string
+		request    string
+		want       string
+		wantErr    bool
+	}{
+		{name: "no parameters", request: "1Gi", want: "1Gi"},
+		{name: "above minSize", parameters: map[string]string{"minSize": "1Gi"}, request: "2Gi", want: "2Gi"},
+		{name: "raised to minSize", parameters: map[string]string{"minSize": "1Gi"}, request: "100Mi", want: "1Gi"},
+		{name: "raised explicitly", parameters: map[string]string{"minSize": "1Gi", "minSizePolicy": "raise"}, request: "100Mi", want: "1Gi"},
+		{name: "rejected below minSize", parameters: map[string]string{"minSize": "1Gi", "minSizePolicy": "reject"}, request: "100Mi", wantErr: true},
+		{name: "minSize accepted with reject", parameters: map[string]string{"minSize": "1Gi", "minSizePolicy": "reject"}, request: "1Gi", want: "1Gi"},
+		{name: "invalid minSizePolicy", parameters: map[string]string{"minSize": "1Gi", "minSizePolicy": "grow"}, request: "100Mi", wantErr: true},
+		{name: "rounded up", parameters: map[string]string{"roundSizeTo": "1Gi"}, request: "1500Mi", want: "2Gi"},
+		{name: "already round", parameters: map[string]string{"roundSizeTo": "1Gi"}, request: "3Gi", want: "3Gi"},
+		{name: "raised then rounded", parameters: map[string]string{"minSize": "1500Mi", "roundSizeTo": "1Gi"}, request: "1Mi", want: "2Gi"},
+		{name: "at maxSize", parameters: map[string]string{"maxSize": "10Gi"}, request: "10Gi", want: "10Gi"},
+		{name: "above maxSize", parameters: map[string]string{"maxSize": "10Gi"}, request: "11Gi", wantErr: true},
+		{name: "rounded above maxSize", parameters: map[string]string{"roundSizeTo": "4Gi", "maxSize": "10Gi"}, request: "9Gi", wantErr: true},
+		{name: "invalid minSize", parameters: map[string]string{"minSize": "big"}, request: "1Gi", wantErr: true},
+		{name: "zero roundSizeTo", parameters: map[string]string{"roundSizeTo": "0"}, request: "1Gi", wantErr: true},
+		{name: "negative maxSize", parameters: map[string]string{"maxSize": "-1Gi"}, request: "1Gi", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			class := &storage.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "nfs"}, Parameters: tt.parameters}
+			claim := &v1.PersistentVolumeClaim{Spec: v1.PersistentVolumeClaimSpec{
+				Resources: v1.VolumeResourceRequirements{Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse(tt.request)}},
+			}}
+			got, err := volumeSize(class, claim)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("volumeSize() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if want := resource.MustParse(tt.want); got.Cmp(want) != 0 {
+				t.Errorf("volumeSize() = %s, want %s", got.String(), want.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	v1 "k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// imageFile is the file system image of the volumes of sizeEnforced
+	// classes, in their directory.
+	imageFile = "volume.img"
+	// annImageFile marks the PVs of sizeEnforced classes with the image in
+	// their directory.
+	annImageFile = "nfs.io/image-file"
+)
+
+// sizeEnforced reports whether the sizeEnforced parameter of class asks for
+// the volumes to be file system images of the requested size.
+func sizeEnforced(class *storage.StorageClass) (bool, error) {
+	value := class.Parameters["sizeEnforced"]
+	if value == "" {
+		return false, nil
+	}
+	enforced, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid sizeEnforced %q, must be true or false", value)
+	}
+	return enforced, nil
+}
+
+// checkImageAccessModes checks that claim asks for a volume only one node
+// mounts at a time, as an image file holds a file system that is not meant
+// to be shared.
+func checkImageAccessModes(claim *v1.PersistentVolumeClaim) error {
+	for _, mode := range claim.Spec.AccessModes {
+		if mode != v1.ReadWriteOnce && mode != v1.ReadWriteOncePod {
+			return fmt.Errorf("sizeEnforced volumes hold a file system image that a single node may mount, claims must ask for ReadWriteOnce or ReadWriteOncePod, not %s", mode)
+		}
+	}
+	return nil
+}
+
+// createImage creates a sparse image file of size bytes at path and formats
+// it with mkfs, a command line to which path is appended. An image left by
+// an earlier attempt is kept as it is.
+func createImage(ctx context.Context, path string, size int64, mkfs string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if os.IsExist(err) {
+		klog.FromContext(ctx).Info("reusing image file", "path", path)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	err = f.Truncate(size)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = formatImage(ctx, path, mkfs)
+	}
+	if err != nil {
+		_ = os.Remove(path)
+	}
+	return err
+}
+
+// formatImage runs mkfs on the image at path.
+func formatImage(ctx context.Context, path, mkfs string) error {
+	args := strings.Fields(mkfs)
+	if len(args) == 0 {
+		return fmt.Errorf("--mkfs-command is empty")
+	}
+	output, err := exec.CommandContext(ctx, args[0], append(args[1:], path)...).CombinedOutput()
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("unable to format %s: %s is not in the image of the provisioner, use one with e2fsprogs or set --mkfs-command", path, args[0])
+	}
+	if err != nil {
+		return fmt.Errorf("unable to format %s: %v: %s", path, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func runMountImage(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	image := fs.String("image", "", "Image file of a sizeEnforced volume, on its NFS mount.")
+	target := fs.String("target", "", "Directory to mount the file system of the image on.")
+	fsType := fs.String("fstype", "ext4", "Type of the file system of the image.")
+	readOnly := fs.Bool("read-only", false, "Mount the file system read-only.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *image == "" || *target == "" {
+		return fmt.Errorf("--image and --target are required")
+	}
+	if err := mountImage(*image, *target, *fsType, *readOnly); err != nil {
+		return err
+	}
+	logger := klog.FromContext(ctx)
+	logger.Info("mounted image, unmounting it on SIGTERM", "image", *image, "target", *target)
+	// Run as a sidecar, the command keeps the file system mounted for as long
+	// as the pod runs, and unmounts it when the pod stops.
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	<-ctx.Done()
+	if err := unmountImage(*target); err != nil {
+		return fmt.Errorf("unable to unmount %s: %v", *target, err)
+	}
+	logger.Info("unmounted image", "target", *target)
+	return nil
+}
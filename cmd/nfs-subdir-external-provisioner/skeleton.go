@@ -0,0 +1,128 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// checkSkeletonPath checks that skeletonPath, a directory on the export,
+// stays inside of it.
+func checkSkeletonPath(skeletonPath string) error {
+	clean := filepath.Clean(skeletonPath)
+	if skeletonPath == "" || filepath.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return fmt.Errorf("invalid skeletonPath %q, must be a directory of the export, relative to its root", skeletonPath)
+	}
+	return nil
+}
+
+// copySkeleton copies the content of the skeleton directory src into dst.
+// Entries that already exist in dst, in a reused directory, are left alone.
+func copySkeleton(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("unable to read the skeleton directory: %v", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("skeleton %s is not a directory", src)
+	}
+	if isSubpath(src, dst) {
+		return fmt.Errorf("skeleton %s holds the volume directory %s", src, dst)
+	}
+	return copyTree(src, dst)
+}
+
+// copyTree copies the content of the directory src into dst, keeping modes
+// and symbolic links. Entries that already exist in dst are left alone, and
+// entries removed while src is walked are skipped.
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			if err := os.Mkdir(target, info.Mode().Perm()); err != nil && !os.IsExist(err) {
+				return err
+			}
+			return os.Chmod(target, info.Mode().Perm())
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if err := os.Symlink(link, target); err != nil && !os.IsExist(err) {
+				return err
+			}
+		case d.Type().IsRegular():
+			if err := copyFile(path, target, info.Mode().Perm()); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		// Devices, sockets and pipes have no place in a volume.
+		return nil
+	})
+}
+
+// copyFile copies the regular file src to dst, unless dst exists. A partial
+// copy is removed.
+func copyFile(src, dst string, mode fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if os.IsExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		// The umask applies to the mode given to create.
+		err = os.Chmod(dst, mode)
+	}
+	if err != nil {
+		_ = os.Remove(dst)
+	}
+	return err
+}
@@ -0,0 +1,348 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/sig-storage-lib-external-provisioner/v10/controller"
+)
+
+const (
+	// snapshotDir holds the snapshots of the volumes of an export, at its
+	// root, in <namespace>/<schedule>/<claim>/<time> directories.
+	snapshotDir = ".snapshots"
+	// snapshotPartialPrefix marks a snapshot being copied.
+	snapshotPartialPrefix = ".partial-"
+	// snapshotTimeFormat names snapshots after the time they were scheduled
+	// at, in UTC, so that they sort by age.
+	snapshotTimeFormat = "20060102-1504"
+	// snapshotCheckInterval is how often schedules are checked for a due
+	// run.
+	snapshotCheckInterval = 30 * time.Second
+)
+
+var snapshotScheduleResource = schema.GroupVersionResource{Group: apiGroup, Version: "v1alpha1", Resource: "nfssnapshotschedules"}
+
+// snapshotScheduleSpec is the spec of an NfsSnapshotSchedule object.
+type snapshotScheduleSpec struct {
+	// Schedule is a cron expression, in UTC.
+	Schedule string `json:"schedule"`
+	// Retention is the number of snapshots kept per claim.
+	Retention int `json:"retention"`
+	// Selector selects the claims of the namespace to snapshot, all of
+	// them when nil.
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// snapshotScheduleStatus is the status of an NfsSnapshotSchedule object.
+type snapshotScheduleStatus struct {
+	LastScheduleTime   *metav1.Time `json:"lastScheduleTime,omitempty"`
+	LastSuccessfulTime *metav1.Time `json:"lastSuccessfulTime,omitempty"`
+	LastSnapshot       string       `json:"lastSnapshot,omitempty"`
+	Claims             int          `json:"claims"`
+	Message            string       `json:"message,omitempty"`
+}
+
+// snapshotScheduler copies the directories of the claims selected by
+// NfsSnapshotSchedules into the snapshot tree of their export, on schedule.
+type snapshotScheduler struct {
+	p      *nfsProvisioner
+	client dynamic.NamespaceableResourceInterface
+	lister cache.GenericLister
+
+	mu sync.Mutex
+	// running are the schedules being run, by namespace/name.
+	running map[string]bool
+}
+
+func parseSnapshotSchedule(obj *unstructured.Unstructured) (*snapshotScheduleSpec, *cronSchedule, error) {
+	var spec snapshotScheduleSpec
+	raw, _, err := unstructured.NestedMap(obj.Object, "spec")
+	if err == nil {
+		err = runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &spec)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid NfsSnapshotSchedule %s/%s: %v", obj.GetNamespace(), obj.GetName(), err)
+	}
+	if spec.Retention < 1 {
+		return nil, nil, fmt.Errorf("invalid NfsSnapshotSchedule %s/%s: retention must be at least 1", obj.GetNamespace(), obj.GetName())
+	}
+	cron, err := parseCron(spec.Schedule)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid NfsSnapshotSchedule %s/%s: %v", obj.GetNamespace(), obj.GetName(), err)
+	}
+	return &spec, cron, nil
+}
+
+// startSnapshotSchedules watches NfsSnapshotSchedules and runs them when
+// they are due, until ctx is done. Nothing runs before the claims and
+// volumes are known.
+func (p *nfsProvisioner) startSnapshotSchedules(ctx context.Context, client dynamic.Interface, claims, volumes cache.SharedIndexInformer) {
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(client, controller.DefaultResyncPeriod)
+	informer := factory.ForResource(snapshotScheduleResource)
+	s := &snapshotScheduler{
+		p:       p,
+		client:  client.Resource(snapshotScheduleResource),
+		lister:  informer.Lister(),
+		running: map[string]bool{},
+	}
+	// Instantiate the informer before starting the factory.
+	informer.Informer()
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+	go func() {
+		cache.WaitForCacheSync(ctx.Done(), claims.HasSynced, volumes.HasSynced)
+		wait.UntilWithContext(ctx, s.check, snapshotCheckInterval)
+	}()
+}
+
+// check starts the schedules that are due.
+func (s *snapshotScheduler) check(ctx context.Context) {
+	logger := klog.FromContext(ctx)
+	objs, err := s.lister.List(labels.Everything())
+	if err != nil {
+		logger.Error(err, "failed to list NfsSnapshotSchedules")
+		return
+	}
+	now := s.p.clock.Now().UTC()
+	for _, obj := range objs {
+		schedule, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		key := schedule.GetNamespace() + "/" + schedule.GetName()
+		spec, cron, err := parseSnapshotSchedule(schedule)
+		if err != nil {
+			logger.V(2).Info("skipping snapshot schedule", "schedule", key, "err", err)
+			continue
+		}
+		due := s.due(schedule, cron, now)
+		if due.IsZero() {
+			continue
+		}
+		s.mu.Lock()
+		if s.running[key] {
+			s.mu.Unlock()
+			continue
+		}
+		s.running[key] = true
+		s.mu.Unlock()
+		go func() {
+			defer func() {
+				s.mu.Lock()
+				delete(s.running, key)
+				s.mu.Unlock()
+			}()
+			s.run(ctx, schedule.DeepCopy(), spec, due)
+		}()
+	}
+}
+
+// due returns the latest time schedule was due at since its last run, or
+// its creation, the zero time if it is not due. Missed runs are not caught
+// up on one by one.
+func (s *snapshotScheduler) due(schedule *unstructured.Unstructured, cron *cronSchedule, now time.Time) time.Time {
+	last := schedule.GetCreationTimestamp().Time
+	if raw, found, _ := unstructured.NestedString(schedule.Object, "status", "lastScheduleTime"); found {
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			last = t
+		}
+	}
+	var due time.Time
+	for next := cron.next(last.UTC()); !next.IsZero() && !next.After(now); next = cron.next(next) {
+		due = next
+	}
+	return due
+}
+
+// run snapshots the claims selected by schedule, as scheduled at due, and
+// records the outcome in its status.
+func (s *snapshotScheduler) run(ctx context.Context, schedule *unstructured.Unstructured, spec *snapshotScheduleSpec, due time.Time) {
+	logger := klog.FromContext(ctx).WithValues("schedule", schedule.GetNamespace()+"/"+schedule.GetName())
+	name := due.Format(snapshotTimeFormat)
+	status := snapshotScheduleStatus{LastScheduleTime: &metav1.Time{Time: due}, LastSnapshot: name}
+	if raw, found, _ := unstructured.NestedString(schedule.Object, "status", "lastSuccessfulTime"); found {
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			status.LastSuccessfulTime = &metav1.Time{Time: t}
+		}
+	}
+
+	claims, err := s.claims(schedule.GetNamespace(), spec.Selector)
+	var failed []string
+	for _, claim := range claims {
+		if err := s.snapshot(ctx, schedule.GetName(), claim, name, spec.Retention); err != nil {
+			logger.Error(err, "failed to snapshot claim", "claim", claim.Namespace+"/"+claim.Name)
+			s.p.recorder.Eventf(claim, v1.EventTypeWarning, "SnapshotFailed", "Snapshot %s of schedule %s failed: %v", name, schedule.GetName(), err)
+			failed = append(failed, claim.Name)
+			continue
+		}
+		status.Claims++
+	}
+	switch {
+	case err != nil:
+		status.Message = err.Error()
+	case len(failed) > 0:
+		status.Message = fmt.Sprintf("snapshots failed for claims %s", strings.Join(failed, ", "))
+	default:
+		status.LastSuccessfulTime = &metav1.Time{Time: due}
+	}
+	if status.Message != "" {
+		s.p.recorder.Eventf(schedule, v1.EventTypeWarning, "SnapshotFailed", "Snapshot %s: %s", name, status.Message)
+	} else {
+		logger.Info("took snapshots", "snapshot", name, "claims", status.Claims)
+	}
+
+	raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&status)
+	if err == nil {
+		err = unstructured.SetNestedMap(schedule.Object, raw, "status")
+	}
+	if err == nil {
+		_, err = s.client.Namespace(schedule.GetNamespace()).UpdateStatus(ctx, schedule, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		logger.Error(err, "failed to update NfsSnapshotSchedule status")
+	}
+}
+
+// claims returns the bound claims of namespace matching selector.
+func (s *snapshotScheduler) claims(namespace string, selector *metav1.LabelSelector) ([]*v1.PersistentVolumeClaim, error) {
+	sel := labels.Everything()
+	if selector != nil {
+		var err error
+		if sel, err = metav1.LabelSelectorAsSelector(selector); err != nil {
+			return nil, fmt.Errorf("invalid selector: %v", err)
+		}
+	}
+	all, err := s.p.claims.PersistentVolumeClaims(namespace).List(sel)
+	if err != nil {
+		return nil, err
+	}
+	var claims []*v1.PersistentVolumeClaim
+	for _, claim := range all {
+		if claim.Spec.VolumeName != "" {
+			claims = append(claims, claim)
+		}
+	}
+	sort.Slice(claims, func(i, j int) bool { return claims[i].Name < claims[j].Name })
+	return claims, nil
+}
+
+// snapshot copies the directory of the volume of claim into the snapshot
+// called name, then removes its snapshots beyond retention. Volumes of other
+// provisioners are skipped. The copy is made under a partial name first, so
+// that a replica doing the same, or a crash, never leaves an incomplete
+// snapshot under its final name.
+func (s *snapshotScheduler) snapshot(ctx context.Context, schedule string, claim *v1.PersistentVolumeClaim, name string, retention int) error {
+	volume, err := s.p.volumes.Get(claim.Spec.VolumeName)
+	if err != nil || volume.Spec.NFS == nil || !s.p.owns(volume.Annotations[annProvisionedBy]) {
+		return nil
+	}
+	b, err := s.p.backendForVolume(ctx, volume)
+	if err != nil {
+		return err
+	}
+	defer b.release()
+	src := b.localPath(volume.Spec.NFS.Path)
+	if _, err := os.Stat(src); err != nil {
+		return fmt.Errorf("unable to read the volume directory: %v", err)
+	}
+	dir := filepath.Join(b.mountPath, snapshotDir, claim.Namespace, schedule, claim.Name)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	target := filepath.Join(dir, name)
+	if _, err := os.Lstat(target); err == nil {
+		return nil
+	}
+	partial := filepath.Join(dir, snapshotPartialPrefix+name)
+	if err := os.Mkdir(partial, 0700); err != nil {
+		if os.IsExist(err) {
+			// Another replica is at it.
+			return nil
+		}
+		return err
+	}
+	err = traced(ctx, "Snapshot", func() error {
+		if err := copyTree(src, partial); err != nil {
+			return err
+		}
+		info, err := os.Stat(src)
+		if err == nil {
+			err = os.Chmod(partial, info.Mode().Perm())
+		}
+		if err == nil {
+			err = os.Rename(partial, target)
+		}
+		return err
+	}, "pv", volume.Name, "snapshot", name)
+	if err != nil {
+		_ = os.RemoveAll(partial)
+		return err
+	}
+	return pruneSnapshots(dir, name, retention)
+}
+
+// pruneSnapshots removes the oldest snapshots in dir beyond retention, and
+// the partial ones older than the snapshot called current, left by a crash.
+func pruneSnapshots(dir, current string, retention int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var snapshots []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if partial, ok := strings.CutPrefix(name, snapshotPartialPrefix); ok {
+			if partial < current {
+				if err := os.RemoveAll(filepath.Join(dir, name)); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if entry.IsDir() && !strings.HasPrefix(name, ".") {
+			snapshots = append(snapshots, name)
+		}
+	}
+	// ReadDir sorts by name, hence by age.
+	for len(snapshots) > retention {
+		if err := os.RemoveAll(filepath.Join(dir, snapshots[0])); err != nil {
+			return err
+		}
+		snapshots = snapshots[1:]
+	}
+	return nil
+}
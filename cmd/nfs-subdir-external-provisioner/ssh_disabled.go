@@ -0,0 +1,38 @@
+//go:build nossh
+
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+)
+
+var errSSHUnsupported = errors.New("dedicated exports are not supported by this build (nossh)")
+
+type sshClient struct {
+	address string
+}
+
+func newSSHClient(address, user, keyFile, knownHostsFile string) (*sshClient, error) {
+	return nil, errSSHUnsupported
+}
+
+func (c *sshClient) run(ctx context.Context, command string) (string, error) {
+	return "", errSSHUnsupported
+}
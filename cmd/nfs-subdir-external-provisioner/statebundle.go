@@ -0,0 +1,275 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// stateBundleFile is the state bundle, at the root of the default
+	// export.
+	stateBundleFile = ".nfs-subdir-state.jsonl.gz"
+
+	// stateBundleSlack is how many superseded records the bundle may hold,
+	// on top of one per volume, before it is compacted.
+	stateBundleSlack = 1000
+)
+
+// bundleRecord is a line of the state bundle: the manifest of a volume, or
+// its removal. Later records of a volume supersede earlier ones.
+type bundleRecord struct {
+	Volume  string               `json:"volume"`
+	Time    time.Time            `json:"time"`
+	Deleted bool                 `json:"deleted,omitempty"`
+	PV      *v1.PersistentVolume `json:"pv,omitempty"`
+}
+
+// stateBundle is a gzip compressed JSON lines file holding the manifests of
+// the volumes of the provisioner, for importing them into another cluster.
+// Changes are appended as a new gzip member, which readers see as the
+// continuation of the same stream, and the file is only rewritten once the
+// superseded records outweigh the live ones.
+type stateBundle struct {
+	file string
+
+	// hashes holds the hash of the latest record of every live volume.
+	hashes  map[string]string
+	records int
+	compact bool
+}
+
+// readStateBundle returns the latest record of every volume in file, and the
+// number of records it holds. A bundle cut short by a crash is read up to the
+// cut, and reported as truncated.
+func readStateBundle(file string) (latest map[string]bundleRecord, records int, truncated bool, err error) {
+	latest = map[string]bundleRecord{}
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return latest, 0, false, nil
+	}
+	if err != nil {
+		return nil, 0, false, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err == io.EOF {
+		return latest, 0, true, nil
+	}
+	if err != nil {
+		return nil, 0, false, err
+	}
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(nil, 16<<20)
+	for scanner.Scan() {
+		var record bundleRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return latest, records, true, nil
+		}
+		records++
+		if record.Deleted {
+			delete(latest, record.Volume)
+		} else {
+			latest[record.Volume] = record
+		}
+	}
+	if scanner.Err() != nil {
+		return latest, records, true, nil
+	}
+	return latest, records, false, nil
+}
+
+func openStateBundle(file string) (*stateBundle, error) {
+	latest, records, truncated, err := readStateBundle(file)
+	if err != nil {
+		return nil, err
+	}
+	// Whatever is appended after a truncated member would never be read:
+	// rewrite the bundle on the next change, which the records lost to
+	// the truncation guarantee.
+	b := &stateBundle{file: file, hashes: map[string]string{}, records: records, compact: truncated}
+	for name, record := range latest {
+		b.hashes[name] = manifestHash(record.PV)
+	}
+	return b, nil
+}
+
+// volumeManifest returns the parts of volume needed to create it again.
+func volumeManifest(volume *v1.PersistentVolume) *v1.PersistentVolume {
+	manifest := &v1.PersistentVolume{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "PersistentVolume"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        volume.Name,
+			Labels:      volume.Labels,
+			Annotations: volume.Annotations,
+		},
+		Spec: *volume.Spec.DeepCopy(),
+	}
+	if ref := manifest.Spec.ClaimRef; ref != nil {
+		ref.ResourceVersion = ""
+	}
+	return manifest
+}
+
+func manifestHash(manifest *v1.PersistentVolume) string {
+	data, _ := json.Marshal(manifest)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// update brings the bundle up to date with volumes, appending the records of
+// the volumes that changed or went away.
+func (b *stateBundle) update(volumes []*v1.PersistentVolume, now time.Time) error {
+	var changes []bundleRecord
+	live := map[string]string{}
+	for _, volume := range volumes {
+		manifest := volumeManifest(volume)
+		hash := manifestHash(manifest)
+		live[volume.Name] = hash
+		if b.hashes[volume.Name] != hash {
+			changes = append(changes, bundleRecord{Volume: volume.Name, Time: now, PV: manifest})
+		}
+	}
+	var removed []string
+	for name := range b.hashes {
+		if _, ok := live[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+	for _, name := range removed {
+		changes = append(changes, bundleRecord{Volume: name, Time: now, Deleted: true})
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	if b.compact || b.records+len(changes) > 2*len(live)+stateBundleSlack {
+		// Rewrite the bundle with only the live volumes.
+		records := make([]bundleRecord, 0, len(volumes))
+		for _, volume := range volumes {
+			records = append(records, bundleRecord{Volume: volume.Name, Time: now, PV: volumeManifest(volume)})
+		}
+		if err := b.write(records, false); err != nil {
+			return err
+		}
+		b.records = len(records)
+		b.compact = false
+	} else {
+		if err := b.write(changes, true); err != nil {
+			return err
+		}
+		b.records += len(changes)
+	}
+	b.hashes = live
+	return nil
+}
+
+// write appends records to the bundle as a new gzip member, or replaces the
+// bundle with them.
+func (b *stateBundle) write(records []bundleRecord, appending bool) error {
+	var f *os.File
+	var err error
+	if appending {
+		f, err = os.OpenFile(b.file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	} else {
+		f, err = os.CreateTemp(filepath.Dir(b.file), "."+filepath.Base(b.file))
+	}
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(f)
+	encoder := json.NewEncoder(gz)
+	for i := range records {
+		if err = encoder.Encode(&records[i]); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = gz.Close()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if appending {
+		return err
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), b.file)
+}
+
+// startStateBundle rolls the volumes of the provisioner up into the state
+// bundle every interval, until ctx is done.
+func (p *nfsProvisioner) startStateBundle(ctx context.Context, volumes cache.SharedIndexInformer, interval time.Duration) {
+	logger := klog.FromContext(ctx)
+	file := filepath.Join(p.mountPath, stateBundleFile)
+	go func() {
+		// An unsynced cache would have every volume recorded as deleted.
+		if !cache.WaitForCacheSync(ctx.Done(), volumes.HasSynced) {
+			return
+		}
+		var bundle *stateBundle
+		wait.UntilWithContext(ctx, func(ctx context.Context) {
+			if bundle == nil {
+				var err error
+				if bundle, err = openStateBundle(file); err != nil {
+					logger.Error(err, "failed to read state bundle", "file", file)
+					return
+				}
+			}
+			volumes, err := p.volumes.List(labels.Everything())
+			if err != nil {
+				logger.Error(err, "failed to list volumes for the state bundle")
+				return
+			}
+			var owned []*v1.PersistentVolume
+			for _, volume := range volumes {
+				if p.owns(volume.Annotations[annProvisionedBy]) {
+					owned = append(owned, volume)
+				}
+			}
+			if err := bundle.update(owned, p.clock.Now().UTC()); err != nil {
+				logger.Error(err, "failed to update state bundle", "file", file)
+				// Start over from what made it to the file.
+				bundle = nil
+			}
+		}, interval)
+	}()
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// The conditions of an NfsProvisionerStatus.
+const (
+	conditionMountHealthy    = "MountHealthy"
+	conditionCapacityOK      = "CapacityOK"
+	conditionOrphansDetected = "OrphansDetected"
+	conditionArchiveBacklog  = "ArchiveBacklog"
+)
+
+// maxListedOrphans is the number of orphaned directories named in the
+// OrphansDetected condition.
+const maxListedOrphans = 10
+
+var provisionerStatusResource = schema.GroupVersionResource{Group: apiGroup, Version: "v1alpha1", Resource: "nfsprovisionerstatuses"}
+
+// startStatusPublisher publishes the results of the consistency checks in
+// the conditions of the cluster-scoped NfsProvisionerStatus called name,
+// creating it if needed, every interval until ctx is done.
+func (p *nfsProvisioner) startStatusPublisher(ctx context.Context, client dynamic.Interface, volumes cache.SharedIndexInformer, name string, interval time.Duration) {
+	statuses := client.Resource(provisionerStatusResource)
+	go func() {
+		if !cache.WaitForCacheSync(ctx.Done(), volumes.HasSynced) {
+			return
+		}
+		wait.UntilWithContext(ctx, func(ctx context.Context) { p.publishStatus(ctx, statuses, name) }, interval)
+	}()
+}
+
+// publishStatus runs the consistency checks once and sets their conditions
+// in the NfsProvisionerStatus called name.
+func (p *nfsProvisioner) publishStatus(ctx context.Context, client dynamic.ResourceInterface, name string) {
+	logger := klog.FromContext(ctx).WithValues("nfsProvisionerStatus", name)
+	obj, err := client.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		obj = &unstructured.Unstructured{}
+		obj.SetAPIVersion(provisionerStatusResource.GroupVersion().String())
+		obj.SetKind("NfsProvisionerStatus")
+		obj.SetName(name)
+		obj, err = client.Create(ctx, obj, metav1.CreateOptions{})
+	}
+	if err != nil {
+		logger.Error(err, "failed to get NfsProvisionerStatus")
+		return
+	}
+	conditions := []metav1.Condition{
+		p.mountCondition(),
+		p.capacityCondition(),
+		p.orphansCondition(ctx),
+		p.archivesCondition(),
+	}
+	if err := setStatusConditions(ctx, client, obj, conditions...); err != nil {
+		logger.Error(err, "failed to update NfsProvisionerStatus status")
+	}
+}
+
+// mountCondition reports whether the mount watchdog passes, and the file
+// systems of the mounted exports answer.
+func (p *nfsProvisioner) mountCondition() metav1.Condition {
+	c := metav1.Condition{Type: conditionMountHealthy}
+	if err := p.watchdog.healthy(); err != nil {
+		c.Status, c.Reason, c.Message = metav1.ConditionFalse, "ProbeFailing", err.Error()
+		return c
+	}
+	var failed []string
+	for _, b := range p.mountedBackends() {
+		if _, _, err := statExport(b.mountPath); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", mountSource(b.server, b.path), err))
+		}
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		c.Status, c.Reason, c.Message = metav1.ConditionFalse, "StatFailed", strings.Join(failed, "; ")
+		return c
+	}
+	c.Status, c.Reason, c.Message = metav1.ConditionTrue, "Mounted", "the exports are mounted and answer"
+	return c
+}
+
+// capacityCondition reports whether the exports have free space above the
+// threshold of the space monitor.
+func (p *nfsProvisioner) capacityCondition() metav1.Condition {
+	c := metav1.Condition{Type: conditionCapacityOK}
+	if p.space == nil {
+		c.Status, c.Reason, c.Message = metav1.ConditionUnknown, "NotMeasured", "the free space of the exports is not measured, see --free-space-interval"
+		return c
+	}
+	if low := p.space.lowExports(); len(low) > 0 {
+		c.Status, c.Reason = metav1.ConditionFalse, "LowOnSpace"
+		c.Message = fmt.Sprintf("below %d%% of free space: %s", p.space.threshold, strings.Join(low, ", "))
+		return c
+	}
+	c.Status, c.Reason = metav1.ConditionTrue, "SpaceAvailable"
+	c.Message = fmt.Sprintf("the exports have at least %d%% of their space free", p.space.threshold)
+	return c
+}
+
+// orphansCondition reports the directories of the mounted exports that
+// belong to no PV of the provisioner, as the orphans check of verify.
+func (p *nfsProvisioner) orphansCondition(ctx context.Context) metav1.Condition {
+	logger := klog.FromContext(ctx)
+	c := metav1.Condition{Type: conditionOrphansDetected}
+	// A stale mount would hang the walk of the exports.
+	if err := p.watchdog.healthy(); err != nil {
+		c.Status, c.Reason, c.Message = metav1.ConditionUnknown, "MountUnhealthy", err.Error()
+		return c
+	}
+	volumes, err := p.volumes.List(labels.Everything())
+	if err != nil {
+		c.Status, c.Reason, c.Message = metav1.ConditionUnknown, "CheckFailed", err.Error()
+		return c
+	}
+	var orphans, unchecked []string
+	for _, b := range p.mountedBackends() {
+		source := mountSource(b.server, b.path)
+		// An empty export holds no orphans.
+		if err := checkExportRoot(b.mountPath); errors.Is(err, errExportEmpty) {
+			continue
+		} else if err != nil {
+			logger.V(2).Info("not looking for orphaned directories on export", "export", source, "reason", err.Error())
+			unchecked = append(unchecked, source)
+			continue
+		}
+		v := &verifier{
+			export:  b.mountPath,
+			server:  b.server,
+			nfsPath: b.path,
+			owns:    p.owns,
+			checks:  map[string]bool{checkOrphans: true},
+		}
+		known := map[string]bool{}
+		for _, volume := range volumes {
+			if dir, ok := v.directory(volume); ok {
+				known[dir] = true
+			}
+		}
+		if err := v.findOrphans(known); err != nil {
+			logger.Error(err, "failed to look for orphaned directories on export", "export", source)
+			unchecked = append(unchecked, source)
+			continue
+		}
+		for _, f := range v.found {
+			orphans = append(orphans, mountSource(b.server, path.Join(b.path, f.dir)))
+		}
+	}
+	switch {
+	case len(orphans) > 0:
+		sort.Strings(orphans)
+		c.Status, c.Reason = metav1.ConditionTrue, "OrphansFound"
+		listed := orphans
+		if len(listed) > maxListedOrphans {
+			listed = listed[:maxListedOrphans]
+		}
+		c.Message = fmt.Sprintf("%d directories belong to no PV: %s", len(orphans), strings.Join(listed, ", "))
+		if len(orphans) > len(listed) {
+			c.Message += ", ..."
+		}
+	case len(unchecked) > 0:
+		sort.Strings(unchecked)
+		c.Status, c.Reason = metav1.ConditionUnknown, "CheckFailed"
+		c.Message = "unable to walk " + strings.Join(unchecked, ", ")
+	default:
+		c.Status, c.Reason, c.Message = metav1.ConditionFalse, "NoOrphans", "every directory belongs to a PV"
+	}
+	return c
+}
+
+// archivesCondition reports whether archives of deleted volumes are left on
+// the exports, as the usage scanner last counted them.
+func (p *nfsProvisioner) archivesCondition() metav1.Condition {
+	c := metav1.Condition{Type: conditionArchiveBacklog}
+	if p.usage == nil {
+		c.Status, c.Reason, c.Message = metav1.ConditionUnknown, "NotMeasured", "the archives are not counted, see --usage-scan-interval"
+		return c
+	}
+	total := p.usage.archiveTotal()
+	if total.count == 0 {
+		c.Status, c.Reason, c.Message = metav1.ConditionFalse, "NoArchives", "no archives are left on the exports"
+		return c
+	}
+	c.Status, c.Reason = metav1.ConditionTrue, "ArchivesLeft"
+	c.Message = fmt.Sprintf("%d archives hold %s, see purge-archives", total.count, resource.NewQuantity(total.bytes, resource.BinarySI))
+	return c
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/time/rate"
+)
+
+// minThrottleBurst is the least number of bytes read at once under a
+// bandwidth limit, so that low limits do not turn copies into a trickle of
+// tiny reads.
+const minThrottleBurst = 64 * 1024
+
+type throttleKey struct{}
+
+// ioThrottle bounds the file operations per second and the bandwidth of the
+// deletions, archivings and reaping of the provisioner, all of them together,
+// so that a mass cleanup does not saturate the NFS server. Its limits can be
+// changed while it is in use. A nil ioThrottle does not limit anything.
+type ioThrottle struct {
+	ops   *rate.Limiter
+	bytes *rate.Limiter
+}
+
+// newIOThrottle limits to ops file operations and bytes bytes per second, 0
+// meaning no limit.
+func newIOThrottle(ops, bytes int64) *ioThrottle {
+	t := &ioThrottle{ops: rate.NewLimiter(rate.Inf, 1), bytes: rate.NewLimiter(rate.Inf, minThrottleBurst)}
+	t.setLimits(ops, bytes)
+	return t
+}
+
+// setLimits changes the limits, 0 meaning no limit.
+func (t *ioThrottle) setLimits(ops, bytes int64) {
+	if ops > 0 {
+		t.ops.SetLimit(rate.Limit(ops))
+	} else {
+		t.ops.SetLimit(rate.Inf)
+	}
+	if bytes > 0 {
+		burst := bytes
+		if burst < minThrottleBurst {
+			burst = minThrottleBurst
+		}
+		t.bytes.SetBurst(int(burst))
+		t.bytes.SetLimit(rate.Limit(bytes))
+	} else {
+		t.bytes.SetLimit(rate.Inf)
+	}
+}
+
+// withThrottle returns a context whose file operations are limited by t.
+func withThrottle(ctx context.Context, t *ioThrottle) context.Context {
+	return context.WithValue(ctx, throttleKey{}, t)
+}
+
+// throttleFrom returns the throttle of ctx, nil if it has none.
+func throttleFrom(ctx context.Context) *ioThrottle {
+	t, _ := ctx.Value(throttleKey{}).(*ioThrottle)
+	return t
+}
+
+// limitsOps tells whether file operations have to wait for t.
+func (t *ioThrottle) limitsOps() bool {
+	return t != nil && t.ops.Limit() != rate.Inf
+}
+
+// op waits until a file operation is allowed.
+func (t *ioThrottle) op(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	return t.ops.Wait(ctx)
+}
+
+// reader returns r, reading no faster than the bandwidth limit of t.
+func (t *ioThrottle) reader(ctx context.Context, r io.Reader) io.Reader {
+	if t == nil {
+		return r
+	}
+	return &throttledReader{ctx: ctx, r: r, limiter: t.bytes}
+}
+
+type throttledReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rate.Limiter
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	if r.limiter.Limit() == rate.Inf {
+		return r.r.Read(p)
+	}
+	if burst := r.limiter.Burst(); len(p) > burst {
+		p = p[:burst]
+	}
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if waitErr := r.limiter.WaitN(r.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	corev1helpers "k8s.io/component-helpers/scheduling/corev1"
+)
+
+// nodeSelectorOperators maps the operators of label selectors to those of
+// node selectors.
+var nodeSelectorOperators = map[selection.Operator]v1.NodeSelectorOperator{
+	selection.In:           v1.NodeSelectorOpIn,
+	selection.Equals:       v1.NodeSelectorOpIn,
+	selection.DoubleEquals: v1.NodeSelectorOpIn,
+	selection.NotIn:        v1.NodeSelectorOpNotIn,
+	selection.NotEquals:    v1.NodeSelectorOpNotIn,
+	selection.Exists:       v1.NodeSelectorOpExists,
+	selection.DoesNotExist: v1.NodeSelectorOpDoesNotExist,
+	selection.GreaterThan:  v1.NodeSelectorOpGt,
+	selection.LessThan:     v1.NodeSelectorOpLt,
+}
+
+// errUnreachable is wrapped by the errors due to the selected node of a
+// claim being unable to reach its export, after which the claim is
+// rescheduled.
+var errUnreachable = errors.New("the selected node cannot reach the export")
+
+// selectorRequirements returns the node selector requirements of expr, a
+// label selector in the nodeAffinity syntax.
+func selectorRequirements(expr string) ([]v1.NodeSelectorRequirement, error) {
+	selector, err := labels.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	requirements, _ := selector.Requirements()
+	var required []v1.NodeSelectorRequirement
+	for _, r := range requirements {
+		required = append(required, v1.NodeSelectorRequirement{
+			Key:      r.Key(),
+			Operator: nodeSelectorOperators[r.Operator()],
+			Values:   r.Values().List(),
+		})
+	}
+	return required, nil
+}
+
+// nodeAffinity returns the node affinity of the PVs of class on the export
+// b, restricting them to the nodes able to reach it: those matching the
+// nodeAffinity parameter of class, a label selector such as
+// "topology.kubernetes.io/zone in (a,b),nfs-network", the nodeAffinity of
+// the NFSExport, and the allowedTopologies of class. It returns nil if none
+// is set.
+func nodeAffinity(class *storage.StorageClass, b *backend) (*v1.VolumeNodeAffinity, error) {
+	required, err := selectorRequirements(class.Parameters["nodeAffinity"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid nodeAffinity %q: %v", class.Parameters["nodeAffinity"], err)
+	}
+	// Validated with the NFSExport.
+	exportRequired, _ := selectorRequirements(b.nodeAffinity)
+	required = append(required, exportRequired...)
+
+	// Terms are ORed, the requirements of a term ANDed: every allowed
+	// topology becomes a term that also holds the other requirements.
+	var terms []v1.NodeSelectorTerm
+	for _, topology := range class.AllowedTopologies {
+		term := v1.NodeSelectorTerm{MatchExpressions: append([]v1.NodeSelectorRequirement{}, required...)}
+		for _, expr := range topology.MatchLabelExpressions {
+			term.MatchExpressions = append(term.MatchExpressions, v1.NodeSelectorRequirement{
+				Key:      expr.Key,
+				Operator: v1.NodeSelectorOpIn,
+				Values:   expr.Values,
+			})
+		}
+		terms = append(terms, term)
+	}
+	if len(terms) == 0 && len(required) > 0 {
+		terms = []v1.NodeSelectorTerm{{MatchExpressions: required}}
+	}
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	return &v1.VolumeNodeAffinity{Required: &v1.NodeSelector{NodeSelectorTerms: terms}}, nil
+}
+
+// checkSelectedNode refuses a PV with affinity for a claim waiting for its
+// first consumer on node, if node does not match it. node is nil for claims
+// bound immediately.
+func checkSelectedNode(node *v1.Node, affinity *v1.VolumeNodeAffinity) error {
+	if node == nil || affinity == nil {
+		return nil
+	}
+	matches, err := corev1helpers.MatchNodeSelectorTerms(node, affinity.Required)
+	if err != nil {
+		return err
+	}
+	if !matches {
+		return fmt.Errorf("%w: node %s does not match the node affinity of the volume", errUnreachable, node.Name)
+	}
+	return nil
+}
+
+// reaches reports whether node matches nodeAffinity, the node selector of
+// an NFSExport. Every node reaches an export without one.
+func reaches(node *v1.Node, nodeAffinity string) bool {
+	selector, err := labels.Parse(nodeAffinity)
+	return err == nil && selector.Matches(labels.Set(node.Labels))
+}
@@ -0,0 +1,41 @@
+//go:build notracing
+
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"os"
+
+	"k8s.io/klog/v2"
+)
+
+func initTracing(ctx context.Context) (func(context.Context) error, error) {
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != "" {
+		klog.FromContext(ctx).Info("tracing is not supported by this build (notracing), ignoring the OTLP endpoint")
+	}
+	return func(context.Context) error { return nil }, nil
+}
+
+func startSpan(ctx context.Context, name string, kv ...string) (context.Context, func(error)) {
+	return ctx, func(error) {}
+}
+
+func traced(ctx context.Context, name string, fn func() error, kv ...string) error {
+	return fn()
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+func runUndelete(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	export := fs.String("export", "", "Mounted export the volume was deleted from.")
+	list := fs.Bool("list", false, "List the deleted volumes that can be restored, instead of restoring one.")
+	pvName := fs.String("pv", "", "Name of the deleted PV to restore.")
+	pvc := fs.String("pvc", "", "Claim of the deleted volume to restore, as namespace/name. The most recently deleted volume of the claim is restored.")
+	recreatePV := fs.Bool("recreate-pv", true, "Create the PV again, reserved for its claim, once the directory is restored.")
+	claims := fs.Bool("claims", false, "Also create the claim of the volume, bound to it.")
+	dryRun := fs.Bool("dry-run", false, "Print what would be restored, without restoring it.")
+	kubeconfig := fs.String("kubeconfig", "", "Kubeconfig file. Defaults to $KUBECONFIG, ~/.kube/config, then the in-cluster config.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	switch {
+	case *export == "":
+		return fmt.Errorf("--export is required")
+	case *list:
+	case (*pvName == "") == (*pvc == ""):
+		return fmt.Errorf("one of --pv and --pvc is required")
+	case *claims && !*recreatePV:
+		return fmt.Errorf("--claims needs --recreate-pv")
+	}
+	pending, err := listPendingDeletes(ctx, *export)
+	if err != nil {
+		return err
+	}
+	if *list {
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "PV\tNAMESPACE\tPVC\tDIRECTORY\tDELETED\tEXPIRES")
+		for _, d := range pending {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", d.PV, dash(d.Namespace), dash(d.PVC), d.Directory, d.Archived.UTC().Format(time.RFC3339), d.Expires.UTC().Format(time.RFC3339))
+		}
+		return w.Flush()
+	}
+
+	var namespace, claim string
+	if *pvc != "" {
+		var ok bool
+		if namespace, claim, ok = strings.Cut(*pvc, "/"); !ok || namespace == "" || claim == "" {
+			return fmt.Errorf("--pvc must be namespace/name")
+		}
+	}
+	var found *pendingDelete
+	for i := range pending {
+		d := &pending[i]
+		if *pvName != "" && d.PV != *pvName || *pvc != "" && (d.Namespace != namespace || d.PVC != claim) {
+			continue
+		}
+		if found == nil || d.Archived.After(found.Archived) {
+			found = d
+		}
+	}
+	if found == nil {
+		return fmt.Errorf("no deleted volume of %s%s waits in %s", *pvName, *pvc, filepath.Join(*export, deletedDir))
+	}
+	return undelete(ctx, *export, found, *recreatePV, *claims, *dryRun, *kubeconfig)
+}
+
+// undelete moves the deleted directory d back to where it was on the export
+// mounted at export and, with recreatePV, creates its PV again, and with
+// claims its claim.
+func undelete(ctx context.Context, export string, d *pendingDelete, recreatePV, claims, dryRun bool, kubeconfig string) error {
+	logger := klog.FromContext(ctx)
+	source := filepath.Join(export, filepath.FromSlash(d.Archive))
+	target := filepath.Join(export, filepath.FromSlash(d.Directory))
+	if d.Directory == "" || strings.HasPrefix(filepath.Clean(d.Directory), "..") {
+		return fmt.Errorf("the manifest of %s names no directory on the export", source)
+	}
+	if _, err := os.Lstat(target); err == nil {
+		return fmt.Errorf("%s exists already, move it away to restore the volume", target)
+	}
+
+	var pv *v1.PersistentVolume
+	var pvc *v1.PersistentVolumeClaim
+	if recreatePV {
+		var err error
+		if pv, err = undeletedVolume(d); err != nil {
+			return err
+		}
+		if claims && pv.Spec.ClaimRef != nil {
+			pvc = boundClaim(pv)
+		}
+	}
+	if dryRun {
+		fmt.Printf("would move %s back to %s\n", source, target)
+		var objs []interface{}
+		if pv != nil {
+			objs = append(objs, pv)
+		}
+		if pvc != nil {
+			objs = append(objs, pvc)
+		}
+		for _, obj := range objs {
+			data, err := yaml.Marshal(obj)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("---\n%s", data)
+		}
+		return nil
+	}
+
+	if pv != nil {
+		client, err := commandClient(kubeconfig)
+		if err != nil {
+			return err
+		}
+		// Fail before moving anything if the PV name was taken since.
+		if _, err := client.CoreV1().PersistentVolumes().Get(ctx, pv.Name, metav1.GetOptions{}); err == nil {
+			return fmt.Errorf("PV %s exists already, restore the directory alone with --recreate-pv=false", pv.Name)
+		} else if !apierrors.IsNotFound(err) {
+			return err
+		}
+		if err := restoreDirectory(ctx, source, target); err != nil {
+			return err
+		}
+		if _, err := client.CoreV1().PersistentVolumes().Create(ctx, pv, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("restored %s, but failed to create PV %s: %v", target, pv.Name, err)
+		}
+		logger.Info("recreated PV", "pv", pv.Name)
+		if pvc != nil {
+			if _, err := client.CoreV1().PersistentVolumeClaims(pvc.Namespace).Create(ctx, pvc, metav1.CreateOptions{}); err != nil {
+				return fmt.Errorf("restored PV %s, but failed to create claim %s/%s: %v", pv.Name, pvc.Namespace, pvc.Name, err)
+			}
+			logger.Info("recreated claim", "pvc", pvc.Name, "namespace", pvc.Namespace)
+		}
+	} else if err := restoreDirectory(ctx, source, target); err != nil {
+		return err
+	}
+	fmt.Printf("restored %s to %s\n", d.PV, target)
+	return nil
+}
+
+// restoreDirectory moves the deleted directory source back to target, and
+// removes its manifest.
+func restoreDirectory(ctx context.Context, source, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0777); err != nil {
+		return err
+	}
+	if err := moveDir(ctx, source, target); err != nil {
+		return err
+	}
+	if err := os.Remove(source + archiveManifestSuffix); err != nil && !os.IsNotExist(err) {
+		klog.FromContext(ctx).Error(err, "failed to remove the manifest of the restored directory", "path", source+archiveManifestSuffix)
+	}
+	return nil
+}
+
+// undeletedVolume returns the PV of d as it was before it was deleted,
+// reserved for its claim so that a new claim of the same name binds to it.
+func undeletedVolume(d *pendingDelete) (*v1.PersistentVolume, error) {
+	if d.Volume == nil {
+		return nil, fmt.Errorf("the manifest of %s holds no PV", d.Archive)
+	}
+	old := d.Volume
+	annotations := map[string]string{}
+	for key, value := range old.Annotations {
+		switch key {
+		case "pv.kubernetes.io/bound-by-controller", annDirectoryMissing:
+		default:
+			annotations[key] = value
+		}
+	}
+	pv := &v1.PersistentVolume{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "PersistentVolume"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        old.Name,
+			Labels:      old.Labels,
+			Annotations: annotations,
+		},
+		Spec: *old.Spec.DeepCopy(),
+	}
+	if ref := old.Spec.ClaimRef; ref != nil {
+		// Without a UID, any new claim of that name binds to the PV.
+		pv.Spec.ClaimRef = &v1.ObjectReference{Kind: "PersistentVolumeClaim", APIVersion: "v1", Namespace: ref.Namespace, Name: ref.Name}
+	}
+	return pv, nil
+}
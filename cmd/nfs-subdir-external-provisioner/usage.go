@@ -0,0 +1,89 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+
+	"golang.org/x/time/rate"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// diskUsage walks root and returns the apparent size of its regular files
+// and the number of entries below it. With a limiter, every entry waits for
+// it, so that a scan does not hog the NFS server.
+func diskUsage(ctx context.Context, root string, limiter *rate.Limiter) (int64, int64, error) {
+	var bytes, files int64
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		if limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				return err
+			}
+		}
+		files++
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			bytes += info.Size()
+		}
+		return nil
+	})
+	return bytes, files, err
+}
+
+// measureVolume returns the apparent size and the number of entries of the
+// directory of volume, or the usage of its quota when rquotad reports it.
+func (p *nfsProvisioner) measureVolume(ctx context.Context, volume *v1.PersistentVolume, limiter *rate.Limiter) (int64, int64, error) {
+	b, err := p.backendForVolume(ctx, volume)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer b.release()
+	var bytes, files int64
+	err = traced(ctx, "DiskUsage", func() (err error) {
+		// Volumes with a dedicated export live on the server that exporter
+		// talks to, which may measure them directly.
+		if q, ok := p.exporter.(usageQuerier); ok && volume.Annotations[annDedicatedExport] != "" {
+			bytes, files, err = q.usage(ctx, volume.Spec.NFS.Path)
+			return err
+		}
+		// The quota of the GID or project of a volume counts its files
+		// alone, without walking them.
+		if kind, id, ok := volumeQuota(volume); ok && p.rquota != nil {
+			quota, err := p.rquota.getQuota(ctx, b.server, volume.Spec.NFS.Path, kind, id)
+			if err == nil {
+				bytes, files = quota.Bytes, quota.Files
+				return nil
+			}
+			klog.FromContext(ctx).V(4).Info("falling back to walking the volume", "pv", volume.Name, "err", err)
+		}
+		bytes, files, err = diskUsage(ctx, b.localPath(volume.Spec.NFS.Path), limiter)
+		return err
+	}, "pv", volume.Name)
+	return bytes, files, err
+}
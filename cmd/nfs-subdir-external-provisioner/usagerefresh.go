@@ -0,0 +1,128 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// annRefreshUsage on a PVC asks for its usage to be measured. It is
+	// removed once annUsedBytes has been updated.
+	annRefreshUsage = "nfs.io/refresh-usage"
+	annUsedBytes    = "nfs.io/used-bytes"
+	annUsedFiles    = "nfs.io/used-files"
+	annUsageTime    = "nfs.io/usage-scanned-at"
+)
+
+// startUsageRefresher measures the volumes of claims that request it with
+// annRefreshUsage, one at a time, until ctx is done.
+func (p *nfsProvisioner) startUsageRefresher(ctx context.Context, claims cache.SharedIndexInformer) {
+	logger := klog.FromContext(ctx)
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+
+	enqueue := func(obj interface{}) {
+		claim, ok := obj.(*v1.PersistentVolumeClaim)
+		if !ok || claim.Annotations[annRefreshUsage] != "true" {
+			return
+		}
+		if key, err := cache.MetaNamespaceKeyFunc(claim); err == nil {
+			queue.Add(key)
+		}
+	}
+	_, err := claims.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    enqueue,
+		UpdateFunc: func(_, obj interface{}) { enqueue(obj) },
+	})
+	if err != nil {
+		logger.Error(err, "failed to watch claims for usage refresh requests")
+		return
+	}
+
+	go func() {
+		<-ctx.Done()
+		queue.ShutDown()
+	}()
+	go func() {
+		cache.WaitForCacheSync(ctx.Done(), claims.HasSynced)
+		for {
+			item, quit := queue.Get()
+			if quit {
+				return
+			}
+			key := item.(string)
+			if err := p.refreshUsage(ctx, key); err != nil {
+				logger.Error(err, "failed to refresh usage", "claim", key)
+				queue.AddRateLimited(key)
+			} else {
+				queue.Forget(key)
+			}
+			queue.Done(item)
+		}
+	}()
+}
+
+// refreshUsage measures the volume of the claim with the given key, records
+// the result in its annotations and removes the request.
+func (p *nfsProvisioner) refreshUsage(ctx context.Context, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+	claim, err := p.claims.PersistentVolumeClaims(namespace).Get(name)
+	if err != nil || claim.Annotations[annRefreshUsage] != "true" {
+		// Gone, or already handled.
+		return nil
+	}
+
+	annotations := map[string]interface{}{annRefreshUsage: nil}
+	var volume *v1.PersistentVolume
+	if claim.Spec.VolumeName != "" {
+		volume, err = p.volumes.Get(claim.Spec.VolumeName)
+		if err != nil {
+			return err
+		}
+	}
+	if volume != nil && p.owns(volume.Annotations[annProvisionedBy]) && p.inShard(volume) && volume.Spec.NFS != nil {
+		bytes, files, err := p.measureVolume(ctx, volume, nil)
+		if err != nil {
+			return fmt.Errorf("unable to measure %s: %v", volume.Name, err)
+		}
+		annotations[annUsedBytes] = strconv.FormatInt(bytes, 10)
+		annotations[annUsedFiles] = strconv.FormatInt(files, 10)
+		annotations[annUsageTime] = p.clock.Now().UTC().Format(metav1.RFC3339Micro)
+	}
+	// Otherwise the claim is not bound to one of our volumes: only clear the
+	// request.
+
+	patch, err := json.Marshal(map[string]interface{}{"metadata": map[string]interface{}{"annotations": annotations}})
+	if err != nil {
+		return err
+	}
+	_, err = p.client.CoreV1().PersistentVolumeClaims(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+// usageReportKey is the key of the report in its ConfigMap.
+const usageReportKey = "report.json"
+
+// usageReport summarizes the space taken on the exports of the provisioner.
+type usageReport struct {
+	Generated  time.Time        `json:"generated"`
+	Volumes    int              `json:"volumes"`
+	Bytes      int64            `json:"bytes"`
+	Files      int64            `json:"files"`
+	Namespaces []namespaceUsage `json:"topNamespaces"`
+	Claims     []volumeUsage    `json:"topClaims"`
+	Exports    []exportReport   `json:"exports"`
+}
+
+// namespaceUsage is the space taken by the volumes of a namespace.
+type namespaceUsage struct {
+	Namespace string `json:"namespace"`
+	Volumes   int    `json:"volumes"`
+	Bytes     int64  `json:"bytes"`
+	Files     int64  `json:"files"`
+}
+
+// exportReport is the space taken on an export by archived directories, and
+// the number of directories no PV refers to.
+type exportReport struct {
+	Export       string `json:"export,omitempty"`
+	Server       string `json:"server"`
+	Path         string `json:"path"`
+	Archives     int    `json:"archives"`
+	ArchiveBytes int64  `json:"archiveBytes"`
+	Orphans      int    `json:"orphans"`
+}
+
+// startUsageReport publishes a usage report in the ConfigMap called name in
+// namespace every interval, until ctx is done. The usage of volumes is the
+// last measure of the usage scanner.
+func (p *nfsProvisioner) startUsageReport(ctx context.Context, interval time.Duration, namespace, name string, top int) {
+	go wait.UntilWithContext(ctx, func(ctx context.Context) {
+		logger := klog.FromContext(ctx)
+		report := p.usageReport(ctx, top)
+		if err := p.publishUsageReport(ctx, namespace, name, report); err != nil {
+			logger.Error(err, "failed to publish the usage report", "configMap", namespace+"/"+name)
+			return
+		}
+		logger.V(2).Info("published the usage report", "configMap", namespace+"/"+name, "volumes", report.Volumes)
+	}, interval)
+}
+
+// usageReport builds a report with the top consumers among namespaces and
+// claims.
+func (p *nfsProvisioner) usageReport(ctx context.Context, top int) *usageReport {
+	report := &usageReport{Generated: p.clock.Now().UTC()}
+	claims := p.usage.snapshot()
+	namespaces := map[string]*namespaceUsage{}
+	for _, u := range claims {
+		report.Volumes++
+		report.Bytes += u.Bytes
+		report.Files += u.Files
+		ns := namespaces[u.Namespace]
+		if ns == nil {
+			ns = &namespaceUsage{Namespace: u.Namespace}
+			namespaces[u.Namespace] = ns
+		}
+		ns.Volumes++
+		ns.Bytes += u.Bytes
+		ns.Files += u.Files
+	}
+	for _, ns := range namespaces {
+		report.Namespaces = append(report.Namespaces, *ns)
+	}
+	sort.Slice(report.Namespaces, func(i, j int) bool {
+		a, b := report.Namespaces[i], report.Namespaces[j]
+		return a.Bytes > b.Bytes || a.Bytes == b.Bytes && a.Namespace < b.Namespace
+	})
+	sort.SliceStable(claims, func(i, j int) bool { return claims[i].Bytes > claims[j].Bytes })
+	if len(report.Namespaces) > top {
+		report.Namespaces = report.Namespaces[:top]
+	}
+	if len(claims) > top {
+		claims = claims[:top]
+	}
+	report.Claims = claims
+
+	backends := p.mountedBackends()
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		e, err := p.exportReport(ctx, backends[name])
+		if err != nil {
+			klog.FromContext(ctx).Error(err, "failed to report on export", "export", mountSource(backends[name].server, backends[name].path))
+			continue
+		}
+		e.Export = name
+		report.Exports = append(report.Exports, *e)
+	}
+	return report
+}
+
+// exportReport measures the archived directories at the root of b, and
+// counts the directories there that hold no volume.
+func (p *nfsProvisioner) exportReport(ctx context.Context, b *backend) (*exportReport, error) {
+	entries, err := os.ReadDir(b.mountPath)
+	if err != nil {
+		return nil, err
+	}
+	// The top directories of the volumes on b.
+	used := map[string]bool{}
+	volumes, _ := p.volumes.List(labels.Everything())
+	for _, volume := range volumes {
+		source := volume.Spec.NFS
+		if source == nil || !isSubpath(b.path, source.Path) {
+			continue
+		}
+		rel, err := filepath.Rel(b.path, source.Path)
+		if err != nil || rel == "." {
+			continue
+		}
+		used[strings.Split(rel, string(filepath.Separator))[0]] = true
+	}
+
+	e := &exportReport{Server: b.server, Path: b.path}
+	for _, entry := range entries {
+		name := entry.Name()
+		switch {
+		case !entry.IsDir() || strings.HasPrefix(name, "."):
+		case strings.HasPrefix(name, archivePrefix):
+			bytes, _, err := diskUsage(ctx, filepath.Join(b.mountPath, name), p.usage.limiter)
+			if err != nil {
+				return nil, err
+			}
+			e.Archives++
+			e.ArchiveBytes += bytes
+		case !used[name]:
+			e.Orphans++
+		}
+	}
+	return e, nil
+}
+
+// publishUsageReport writes report to the ConfigMap called name in
+// namespace, creating it if needed.
+func (p *nfsProvisioner) publishUsageReport(ctx context.Context, namespace, name string, report *usageReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	configMaps := p.client.CoreV1().ConfigMaps(namespace)
+	cm, err := configMaps.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		cm = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Data:       map[string]string{usageReportKey: string(data)},
+		}
+		_, err = configMaps.Create(ctx, cm, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[usageReportKey] = string(data)
+	_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+	return err
+}
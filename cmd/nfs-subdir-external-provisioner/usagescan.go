@@ -0,0 +1,258 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	storagehelpers "k8s.io/component-helpers/storage/volume"
+	"k8s.io/klog/v2"
+)
+
+// volumeUsage is the last measure of a volume by the usage scanner.
+type volumeUsage struct {
+	PV           string    `json:"pv"`
+	Namespace    string    `json:"namespace,omitempty"`
+	PVC          string    `json:"pvc,omitempty"`
+	StorageClass string    `json:"storageClass,omitempty"`
+	Capacity     int64     `json:"capacity"`
+	Bytes        int64     `json:"bytes"`
+	Files        int64     `json:"files"`
+	FileLimit    int64     `json:"fileLimit,omitempty"`
+	Scanned      time.Time `json:"scanned"`
+}
+
+var (
+	volumeUsedBytesDesc = prometheus.NewDesc(metricsNamespace+"_volume_used_bytes",
+		"Apparent size of the files in the directory of a volume, as of the last scan.",
+		[]string{"pv", "namespace", "pvc", "storageclass"}, nil)
+	volumeUsedFilesDesc = prometheus.NewDesc(metricsNamespace+"_volume_used_files",
+		"Number of files and directories in the directory of a volume, as of the last scan.",
+		[]string{"pv", "namespace", "pvc", "storageclass"}, nil)
+	volumeFileLimitDesc = prometheus.NewDesc(metricsNamespace+"_volume_file_limit",
+		"Number of files and directories a volume may hold, from the maxFiles parameter of its StorageClass.",
+		[]string{"pv", "namespace", "pvc", "storageclass"}, nil)
+	volumeCapacityDesc = prometheus.NewDesc(metricsNamespace+"_volume_capacity_bytes",
+		"Capacity of a volume, as requested by its claim.",
+		[]string{"pv", "namespace", "pvc", "storageclass"}, nil)
+	usageScanDurationDesc = prometheus.NewDesc(metricsNamespace+"_usage_scan_duration_seconds",
+		"Duration of the last scan of all the volumes.", nil, nil)
+)
+
+// usageScanner measures every volume of the provisioner at an interval, at
+// a bounded number of files per second, and exports the results as metrics.
+type usageScanner struct {
+	p       *nfsProvisioner
+	limiter *rate.Limiter
+
+	mu       sync.Mutex
+	usage    map[string]volumeUsage
+	archives map[archiveGroup]archiveTotal
+	duration time.Duration
+}
+
+// startUsageScanner scans the volumes every interval, reading at most
+// filesPerSecond directory entries per second, until ctx is done.
+func (p *nfsProvisioner) startUsageScanner(ctx context.Context, volumes cache.SharedIndexInformer, interval time.Duration, filesPerSecond int) *usageScanner {
+	s := &usageScanner{
+		p:       p,
+		limiter: rate.NewLimiter(rate.Limit(filesPerSecond), filesPerSecond),
+		usage:   map[string]volumeUsage{},
+	}
+	prometheus.MustRegister(s)
+	go func() {
+		if !cache.WaitForCacheSync(ctx.Done(), volumes.HasSynced) {
+			return
+		}
+		wait.UntilWithContext(ctx, s.scan, interval)
+	}()
+	return s
+}
+
+// scan measures every bound volume of the provisioner once. Volumes that
+// are gone are dropped, those that fail to be measured keep their last
+// measure.
+func (s *usageScanner) scan(ctx context.Context) {
+	logger := klog.FromContext(ctx)
+	p := s.p
+	list, err := p.volumes.List(labels.Everything())
+	if err != nil {
+		logger.Error(err, "failed to list volumes to measure")
+		return
+	}
+	start := time.Now()
+	usage := map[string]volumeUsage{}
+	for _, volume := range list {
+		if !s.scanned(volume) {
+			continue
+		}
+		bytes, files, err := p.measureVolume(ctx, volume, s.limiter)
+		if ctx.Err() != nil {
+			return
+		}
+		s.mu.Lock()
+		previous, measured := s.usage[volume.Name]
+		s.mu.Unlock()
+		if err != nil {
+			logger.Error(err, "failed to measure volume", "pv", volume.Name)
+			if measured {
+				usage[volume.Name] = previous
+			}
+			continue
+		}
+		capacity := volume.Spec.Capacity[v1.ResourceStorage]
+		limit, _ := strconv.ParseInt(volume.Annotations[annMaxFiles], 10, 64)
+		usage[volume.Name] = volumeUsage{
+			PV:           volume.Name,
+			Namespace:    volume.Spec.ClaimRef.Namespace,
+			PVC:          volume.Spec.ClaimRef.Name,
+			StorageClass: storagehelpers.GetPersistentVolumeClass(volume),
+			Capacity:     capacity.Value(),
+			Bytes:        bytes,
+			Files:        files,
+			FileLimit:    limit,
+			Scanned:      p.clock.Now().UTC(),
+		}
+		s.checkFileLimit(ctx, usage[volume.Name], previous, measured)
+	}
+	s.mu.Lock()
+	previous := s.archives
+	s.mu.Unlock()
+	archives := p.measureArchives(ctx, s.limiter, previous)
+	if ctx.Err() != nil {
+		return
+	}
+	s.mu.Lock()
+	s.usage = usage
+	s.archives = archives
+	s.duration = time.Since(start)
+	s.mu.Unlock()
+	logger.V(2).Info("measured volumes", "volumes", len(usage), "archives", len(archives), "duration", time.Since(start))
+}
+
+// archiveTotal returns the number and size of the archives of all the
+// exports as of the last scan.
+func (s *usageScanner) archiveTotal() archiveTotal {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var total archiveTotal
+	for _, t := range s.archives {
+		total.count += t.count
+		total.bytes += t.bytes
+	}
+	return total
+}
+
+// scanned reports whether volume is one the scanner measures: a bound
+// volume of this instance with a directory of its own, not one shared with
+// other volumes.
+func (s *usageScanner) scanned(volume *v1.PersistentVolume) bool {
+	if !s.p.owns(volume.Annotations[annProvisionedBy]) || !s.p.inShard(volume) || volume.Spec.NFS == nil || volume.Spec.ClaimRef == nil {
+		return false
+	}
+	_, inspection := volume.Annotations[annInspectOf]
+	_, shared := volume.Annotations[annSharedPath]
+	return !inspection && !shared
+}
+
+// snapshot returns the last measures, by PV name.
+func (s *usageScanner) snapshot() []volumeUsage {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	usage := make([]volumeUsage, 0, len(s.usage))
+	for _, u := range s.usage {
+		usage = append(usage, u)
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i].PV < usage[j].PV })
+	return usage
+}
+
+// startUsageAnnotations copies the last measures of the usage scanner to the
+// annotations of the PVs every interval, until ctx is done, so that they show
+// in kubectl. PVs are only patched when a newer measure is available.
+func (p *nfsProvisioner) startUsageAnnotations(ctx context.Context, interval time.Duration) {
+	go wait.UntilWithContext(ctx, func(ctx context.Context) {
+		logger := klog.FromContext(ctx)
+		for _, u := range p.usage.snapshot() {
+			scanned := u.Scanned.Format(metav1.RFC3339Micro)
+			volume, err := p.volumes.Get(u.PV)
+			if err != nil || volume.Annotations[annUsageTime] == scanned {
+				continue
+			}
+			patch, err := json.Marshal(map[string]interface{}{"metadata": map[string]interface{}{"annotations": map[string]interface{}{
+				annUsedBytes: strconv.FormatInt(u.Bytes, 10),
+				annUsedFiles: strconv.FormatInt(u.Files, 10),
+				annUsageTime: scanned,
+			}}})
+			if err != nil {
+				continue
+			}
+			if _, err := p.client.CoreV1().PersistentVolumes().Patch(ctx, u.PV, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+				logger.Error(err, "failed to annotate volume with its usage", "pv", u.PV)
+			}
+		}
+	}, interval)
+}
+
+// Describe implements prometheus.Collector.
+func (s *usageScanner) Describe(ch chan<- *prometheus.Desc) {
+	ch <- volumeUsedBytesDesc
+	ch <- volumeUsedFilesDesc
+	ch <- volumeFileLimitDesc
+	ch <- volumeCapacityDesc
+	ch <- archivesDesc
+	ch <- archiveBytesDesc
+	ch <- usageScanDurationDesc
+}
+
+// Collect implements prometheus.Collector.
+func (s *usageScanner) Collect(ch chan<- prometheus.Metric) {
+	for _, u := range s.snapshot() {
+		labels := []string{u.PV, u.Namespace, u.PVC, u.StorageClass}
+		ch <- prometheus.MustNewConstMetric(volumeUsedBytesDesc, prometheus.GaugeValue, float64(u.Bytes), labels...)
+		ch <- prometheus.MustNewConstMetric(volumeUsedFilesDesc, prometheus.GaugeValue, float64(u.Files), labels...)
+		if u.FileLimit > 0 {
+			ch <- prometheus.MustNewConstMetric(volumeFileLimitDesc, prometheus.GaugeValue, float64(u.FileLimit), labels...)
+		}
+		ch <- prometheus.MustNewConstMetric(volumeCapacityDesc, prometheus.GaugeValue, float64(u.Capacity), labels...)
+	}
+	s.mu.Lock()
+	duration := s.duration
+	for group, total := range s.archives {
+		labels := []string{group.export, group.server, group.path, group.storageClass}
+		ch <- prometheus.MustNewConstMetric(archivesDesc, prometheus.GaugeValue, float64(total.count), labels...)
+		ch <- prometheus.MustNewConstMetric(archiveBytesDesc, prometheus.GaugeValue, float64(total.bytes), labels...)
+	}
+	s.mu.Unlock()
+	ch <- prometheus.MustNewConstMetric(usageScanDurationDesc, prometheus.GaugeValue, duration.Seconds())
+}
@@ -0,0 +1,326 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	storagehelpers "k8s.io/component-helpers/storage/volume"
+	"k8s.io/klog/v2"
+)
+
+// The checks of the verify command.
+const (
+	checkMissing     = "missing"
+	checkOrphans     = "orphans"
+	checkPaths       = "paths"
+	checkPermissions = "permissions"
+)
+
+// inconsistency is a disagreement between a PV and the export found by
+// verify.
+type inconsistency struct {
+	check, dir, pv, detail string
+}
+
+// verifier checks the PVs of a provisioner on an export against its
+// directories.
+type verifier struct {
+	client       kubernetes.Interface
+	export       string
+	server       string
+	nfsPath      string
+	owns         func(provisioner string) bool
+	metadataFile string
+	dirMode      os.FileMode
+	checks       map[string]bool
+
+	classes map[string]*storage.StorageClass
+	found   []inconsistency
+}
+
+func runVerify(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	export := fs.String("export", "", "Mounted export to verify.")
+	server := fs.String("nfs-server", "", "NFS server of the export, as the PVs name it.")
+	nfsPath := fs.String("nfs-path", "", "Path of the export on --nfs-server.")
+	provisioner := fs.String("provisioner-name", "", "Provisioner whose PVs are verified.")
+	metadataFile := fs.String("metadata-file", ".nfs-provisioner.json", "Name of the metadata files of the volumes, as --metadata-file of the provisioner, checked when present.")
+	dirMode := fs.String("directory-mode", "0777", "Permissions of provisioned directories, as --directory-mode of the provisioner.")
+	checks := fs.String("checks", strings.Join([]string{checkMissing, checkOrphans, checkPaths, checkPermissions}, ","), "Comma separated checks to run: missing, orphans, paths and permissions.")
+	kubeconfig := fs.String("kubeconfig", "", "Kubeconfig file. Defaults to $KUBECONFIG, ~/.kube/config, then the in-cluster config.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *export == "" || *server == "" || *nfsPath == "" || *provisioner == "" {
+		return fmt.Errorf("--export, --nfs-server, --nfs-path and --provisioner-name are required")
+	}
+	canonical, err := canonicalServer(*server)
+	if err != nil {
+		return err
+	}
+	mode, err := strconv.ParseUint(*dirMode, 8, 32)
+	if err != nil || len(*dirMode) > 4 {
+		return fmt.Errorf("--directory-mode must be octal permissions such as 0777")
+	}
+	v := &verifier{
+		export:       *export,
+		server:       canonical,
+		nfsPath:      path.Clean("/" + *nfsPath),
+		owns:         func(name string) bool { return name == *provisioner },
+		metadataFile: *metadataFile,
+		dirMode:      os.FileMode(mode),
+		checks:       map[string]bool{},
+		classes:      map[string]*storage.StorageClass{},
+	}
+	for _, check := range splitList(*checks) {
+		switch check {
+		case checkMissing, checkOrphans, checkPaths, checkPermissions:
+			v.checks[check] = true
+		default:
+			return fmt.Errorf("unknown check %q, must be missing, orphans, paths or permissions", check)
+		}
+	}
+	if v.client, err = commandClient(*kubeconfig); err != nil {
+		return err
+	}
+	if err := v.verify(ctx); err != nil {
+		return err
+	}
+	for _, f := range v.found {
+		fmt.Printf("%s\t%s\t%s\t%s\n", f.check, f.dir, dash(f.pv), f.detail)
+	}
+	if len(v.found) > 0 {
+		return fmt.Errorf("%d inconsistencies between the PVs and the export", len(v.found))
+	}
+	fmt.Println("the PVs and the export are consistent")
+	return nil
+}
+
+func (v *verifier) report(check, dir, pv, format string, args ...interface{}) {
+	if v.checks[check] {
+		v.found = append(v.found, inconsistency{check: check, dir: dir, pv: pv, detail: fmt.Sprintf(format, args...)})
+	}
+}
+
+// verify runs the checks, recording what they find.
+func (v *verifier) verify(ctx context.Context) error {
+	volumes, err := v.client.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	classes, err := v.client.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range classes.Items {
+		v.classes[classes.Items[i].Name] = &classes.Items[i]
+	}
+
+	// The directories of the PVs, relative to the export, and the PVs
+	// having them as their own.
+	known := map[string]bool{}
+	owners := map[string][]string{}
+	for i := range volumes.Items {
+		volume := &volumes.Items[i]
+		dir, ok := v.directory(volume)
+		if !ok {
+			continue
+		}
+		known[dir] = true
+		if _, shared := volume.Annotations[annSharedPath]; !shared {
+			owners[dir] = append(owners[dir], volume.Name)
+		}
+		v.verifyVolume(ctx, volume, dir)
+	}
+	for dir, names := range owners {
+		if len(names) > 1 {
+			sort.Strings(names)
+			v.report(checkPaths, dir, "", "directory of several PVs: %s", strings.Join(names, ", "))
+		}
+	}
+	if v.checks[checkOrphans] {
+		if err := v.findOrphans(known); err != nil {
+			return err
+		}
+	}
+	sort.Slice(v.found, func(i, j int) bool {
+		a, b := v.found[i], v.found[j]
+		if a.dir != b.dir {
+			return a.dir < b.dir
+		}
+		return a.check < b.check
+	})
+	return nil
+}
+
+// directory returns the directory of volume relative to the export, if it
+// is a PV of a provisioner v owns on it. Inspections of archives are left out.
+func (v *verifier) directory(volume *v1.PersistentVolume) (string, bool) {
+	source := volume.Spec.NFS
+	if !v.owns(volume.Annotations[annProvisionedBy]) || source == nil {
+		return "", false
+	}
+	if _, inspection := volume.Annotations[annInspectOf]; inspection {
+		return "", false
+	}
+	server, err := canonicalServer(source.Server)
+	if err != nil {
+		server = source.Server
+	}
+	if declared := volume.Annotations[annExportServer]; declared != "" {
+		server = declared
+	}
+	if server != v.server || !isSubpath(v.nfsPath, path.Clean(source.Path)) {
+		return "", false
+	}
+	rel, err := filepath.Rel(v.nfsPath, path.Clean(source.Path))
+	if err != nil || rel == "." {
+		return "", false
+	}
+	return rel, true
+}
+
+// verifyVolume checks that the directory dir of volume exists, is the one
+// its metadata file says, and has the owner and mode it was given.
+func (v *verifier) verifyVolume(ctx context.Context, volume *v1.PersistentVolume, dir string) {
+	local := filepath.Join(v.export, filepath.FromSlash(dir))
+	info, err := os.Lstat(local)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		v.report(checkMissing, dir, volume.Name, "directory is missing, the PV is %s", volume.Status.Phase)
+		return
+	case err != nil:
+		v.report(checkMissing, dir, volume.Name, "unable to check the directory: %v", err)
+		return
+	case !info.IsDir():
+		v.report(checkMissing, dir, volume.Name, "not a directory")
+		return
+	}
+	if v.metadataFile != "" {
+		metadata, err := readMetadataFile(filepath.Join(local, v.metadataFile))
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			v.report(checkPaths, dir, volume.Name, "unreadable metadata file: %v", err)
+		case metadata.PV != volume.Name:
+			v.report(checkPaths, dir, volume.Name, "the metadata file of the directory names PV %s", metadata.PV)
+		}
+	}
+	if v.checks[checkPermissions] {
+		v.verifyOwnership(ctx, volume, dir, info)
+	}
+}
+
+// verifyOwnership compares the owner, group and mode of the directory dir of
+// volume with those it was given when provisioned. Volumes whose class or
+// claim is gone, shared directories and volumes with a
+// VolumeAttributesClass are skipped, as is the mode of those with ACLs.
+func (v *verifier) verifyOwnership(ctx context.Context, volume *v1.PersistentVolume, dir string, info os.FileInfo) {
+	logger := klog.FromContext(ctx)
+	class := v.classes[storagehelpers.GetPersistentVolumeClass(volume)]
+	_, shared := volume.Annotations[annSharedPath]
+	if class == nil || shared || volume.Spec.ClaimRef == nil || volume.Spec.VolumeAttributesClassName != nil {
+		return
+	}
+	ref := volume.Spec.ClaimRef
+	claim, err := v.client.CoreV1().PersistentVolumeClaims(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	if err != nil || claim.UID != ref.UID {
+		logger.V(2).Info("not checking the permissions of a volume without its claim", "pv", volume.Name)
+		return
+	}
+	uid, gid, mode, err := directoryOwnership(class, claim, v.dirMode)
+	if err != nil {
+		logger.V(2).Info("not checking the permissions of a volume", "pv", volume.Name, "err", err)
+		return
+	}
+	if allocated, err := strconv.Atoi(volume.Annotations[annVolumeGID]); err == nil {
+		gid = allocated
+	}
+	if owner, group, ok := fileOwner(info); ok {
+		if uid >= 0 && owner != uid {
+			v.report(checkPermissions, dir, volume.Name, "owned by user %d, not %d", owner, uid)
+		}
+		if gid >= 0 && group != gid {
+			v.report(checkPermissions, dir, volume.Name, "owned by group %d, not %d", group, gid)
+		}
+	}
+	if class.Parameters["acl"] != "" || class.Parameters["defaultAcl"] != "" {
+		return
+	}
+	if got, want := unixMode(info.Mode()), unixMode(mode); got != want {
+		v.report(checkPermissions, dir, volume.Name, "mode %04o, not %04o", got, want)
+	}
+}
+
+// unixMode returns the permission and setgid bits of mode as in chmod.
+func unixMode(mode os.FileMode) uint32 {
+	bits := uint32(mode.Perm())
+	if mode&os.ModeSetgid != 0 {
+		bits |= 0o2000
+	}
+	return bits
+}
+
+// findOrphans reports the directories of the export that belong to no PV:
+// neither a directory of a PV in known, nor one holding them. Archives and
+// hidden directories, such as the snapshots of filers, are left out.
+func (v *verifier) findOrphans(known map[string]bool) error {
+	parents := map[string]bool{}
+	for dir := range known {
+		for parent := path.Dir(dir); parent != "."; parent = path.Dir(parent) {
+			parents[parent] = true
+		}
+	}
+	return filepath.WalkDir(v.export, func(local string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if local == v.export || !entry.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(v.export, local)
+		if err != nil {
+			return err
+		}
+		dir := filepath.ToSlash(rel)
+		name := entry.Name()
+		switch {
+		case known[dir]:
+			// The content of a volume is not ours to walk.
+			return filepath.SkipDir
+		case parents[dir]:
+			return nil
+		case strings.HasPrefix(name, ".") || name == "lost+found" || !strings.Contains(dir, "/") && strings.HasPrefix(name, archivePrefix):
+			return filepath.SkipDir
+		}
+		v.report(checkOrphans, dir, "", "directory of no PV")
+		return filepath.SkipDir
+	})
+}
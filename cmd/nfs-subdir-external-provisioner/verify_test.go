@@ -0,0 +1,80 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestFindOrphans(t *testing.T) {
+	tests := []struct {
+		name  string
+		dirs  []string
+		known []string
+		want  []string
+	}{
+		{name: "every directory a volume", dirs: []string{"a-data-pvc-1", "a-logs-pvc-2"}, known: []string{"a-data-pvc-1", "a-logs-pvc-2"}},
+		{name: "directory of no PV", dirs: []string{"a-data-pvc-1", "b-old-pvc-3"}, known: []string{"a-data-pvc-1"}, want: []string{"b-old-pvc-3"}},
+		{name: "content of a volume", dirs: []string{"a-data-pvc-1/sub/dir"}, known: []string{"a-data-pvc-1"}},
+		{
+			name:  "nested layout",
+			dirs:  []string{"team-a/data", "team-a/old", "3f/x-pvc-4", "3f/y-pvc-5"},
+			known: []string{"team-a/data", "3f/x-pvc-4"},
+			want:  []string{"3f/y-pvc-5", "team-a/old"},
+		},
+		{name: "parent of no volume", dirs: []string{"team-b/old/deeper"}, want: []string{"team-b"}},
+		{
+			name: "archives and hidden directories",
+			dirs: []string{"archived-a-data-pvc-1", ".snapshot/hourly", "lost+found", ".nfs-provisioner-deleted/x"},
+		},
+		{name: "archive below the root", dirs: []string{"team-a/archived-data"}, known: []string{"team-a/data"}, want: []string{"team-a/archived-data"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			export := t.TempDir()
+			for _, dir := range tt.dirs {
+				if err := os.MkdirAll(filepath.Join(export, dir), 0o755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// Files are never orphans.
+			if err := os.WriteFile(filepath.Join(export, "README"), nil, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			known := map[string]bool{}
+			for _, dir := range tt.known {
+				known[dir] = true
+			}
+			v := &verifier{export: export, checks: map[string]bool{checkOrphans: true}}
+			if err := v.findOrphans(known); err != nil {
+				t.Fatalf("findOrphans() = %v", err)
+			}
+			var got []string
+			for _, f := range v.found {
+				got = append(got, f.dir)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("orphans = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,407 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+)
+
+// attributesClassVersions are the versions VolumeAttributesClasses may be
+// served in, by order of preference.
+var attributesClassVersions = []string{"v1", "v1beta1", "v1alpha1"}
+
+// mutableParameters are the StorageClass parameters VolumeAttributesClasses
+// may set, which can change on existing volumes.
+var mutableParameters = map[string]bool{
+	"archiveOnDelete":   true,
+	"ephemeralOnDelete": true,
+	"gid":               true,
+	"maxFiles":          true,
+	"onDelete":          true,
+	"setgid":            true,
+}
+
+// infeasibleError is the error of the VolumeAttributesClasses that cannot
+// be applied, which stay so until the claim names another one.
+type infeasibleError struct {
+	err error
+}
+
+func (e *infeasibleError) Error() string { return e.err.Error() }
+
+func infeasible(format string, args ...interface{}) error {
+	return &infeasibleError{fmt.Errorf(format, args...)}
+}
+
+// isInfeasible reports whether err is an infeasibleError.
+func isInfeasible(err error) bool {
+	var e *infeasibleError
+	return errors.As(err, &e)
+}
+
+// attributesClassResource returns the resource of the VolumeAttributesClasses
+// served by the API server, in the version it prefers.
+func attributesClassResource(client discovery.DiscoveryInterface) (schema.GroupVersionResource, error) {
+	for _, version := range attributesClassVersions {
+		gv := schema.GroupVersion{Group: storage.GroupName, Version: version}
+		resources, err := client.ServerResourcesForGroupVersion(gv.String())
+		if err != nil {
+			continue
+		}
+		for _, r := range resources.APIResources {
+			if r.Name == "volumeattributesclasses" {
+				return gv.WithResource(r.Name), nil
+			}
+		}
+	}
+	return schema.GroupVersionResource{}, fmt.Errorf("the API server serves no VolumeAttributesClasses, enable the VolumeAttributesClass feature gate and the API version it needs")
+}
+
+// volumeAttributes returns the parameters of the VolumeAttributesClass
+// called name. Classes of other drivers, or setting parameters that cannot
+// change on existing volumes, are infeasible.
+func (p *nfsProvisioner) volumeAttributes(ctx context.Context, class *storage.StorageClass, name string) (map[string]string, error) {
+	if p.attributes == nil {
+		return nil, fmt.Errorf("claim refers to VolumeAttributesClass %s, but VolumeAttributesClasses are disabled", name)
+	}
+	obj, err := p.attributes.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	driver, _, _ := unstructured.NestedString(obj.Object, "driverName")
+	if !p.owns(driver) {
+		return nil, infeasible("VolumeAttributesClass %s is for driver %q, not %s", name, driver, p.name)
+	}
+	parameters, _, err := unstructured.NestedStringMap(obj.Object, "parameters")
+	if err != nil {
+		return nil, infeasible("VolumeAttributesClass %s: %v", name, err)
+	}
+	modified := withAttributes(class, parameters)
+	for key, value := range parameters {
+		if !mutableParameters[key] {
+			return nil, infeasible("VolumeAttributesClass %s sets %s, only %s can change on existing volumes", name, key, mutableParameterNames())
+		}
+		if err := checkParameter(modified, key, value); err != nil {
+			return nil, infeasible("VolumeAttributesClass %s: %v", name, err)
+		}
+	}
+	if problems := checkClass(modified); len(problems) > 0 {
+		return nil, infeasible("VolumeAttributesClass %s: %s", name, strings.Join(problems, ", "))
+	}
+	return parameters, nil
+}
+
+// withAttributes returns a copy of class whose parameters are overridden
+// by those of a VolumeAttributesClass.
+func withAttributes(class *storage.StorageClass, parameters map[string]string) *storage.StorageClass {
+	if len(parameters) == 0 {
+		return class
+	}
+	class = class.DeepCopy()
+	if class.Parameters == nil {
+		class.Parameters = map[string]string{}
+	}
+	for key, value := range parameters {
+		class.Parameters[key] = value
+	}
+	return class
+}
+
+// classWithAttributes returns class with the parameters of the
+// VolumeAttributesClass called name, if set, applied.
+func (p *nfsProvisioner) classWithAttributes(ctx context.Context, class *storage.StorageClass, name *string) (*storage.StorageClass, error) {
+	if name == nil || *name == "" {
+		return class, nil
+	}
+	parameters, err := p.volumeAttributes(ctx, class, *name)
+	if err != nil {
+		return nil, err
+	}
+	return withAttributes(class, parameters), nil
+}
+
+// startAttributesModifier applies the VolumeAttributesClasses that bound
+// claims switch to, one claim at a time, until ctx is done.
+func (p *nfsProvisioner) startAttributesModifier(ctx context.Context, claims cache.SharedIndexInformer) {
+	logger := klog.FromContext(ctx)
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+
+	enqueue := func(obj interface{}) {
+		claim, ok := obj.(*v1.PersistentVolumeClaim)
+		if !ok || !modifying(claim) {
+			return
+		}
+		if key, err := cache.MetaNamespaceKeyFunc(claim); err == nil {
+			queue.Add(key)
+		}
+	}
+	_, err := claims.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    enqueue,
+		UpdateFunc: func(_, obj interface{}) { enqueue(obj) },
+	})
+	if err != nil {
+		logger.Error(err, "failed to watch claims for VolumeAttributesClass changes")
+		return
+	}
+
+	go func() {
+		<-ctx.Done()
+		queue.ShutDown()
+	}()
+	go func() {
+		cache.WaitForCacheSync(ctx.Done(), claims.HasSynced)
+		for {
+			item, quit := queue.Get()
+			if quit {
+				return
+			}
+			key := item.(string)
+			if err := p.modifyVolume(ctx, key); err != nil {
+				logger.Error(err, "failed to modify volume", "claim", key)
+				queue.AddRateLimited(key)
+			} else {
+				queue.Forget(key)
+			}
+			queue.Done(item)
+		}
+	}()
+}
+
+// modifying reports whether claim, bound, asks for another
+// VolumeAttributesClass than the one its volume has.
+func modifying(claim *v1.PersistentVolumeClaim) bool {
+	target := claim.Spec.VolumeAttributesClassName
+	if claim.Spec.VolumeName == "" || target == nil || *target == "" {
+		return false
+	}
+	if status := claim.Status.ModifyVolumeStatus; status != nil && status.TargetVolumeAttributesClassName == *target && status.Status == v1.PersistentVolumeClaimModifyVolumeInfeasible {
+		// Until the claim asks for another class.
+		return false
+	}
+	current := claim.Status.CurrentVolumeAttributesClassName
+	return current == nil || *current != *target
+}
+
+// modifyVolume applies the VolumeAttributesClass the claim with the given
+// key asks for to its volume, then records it in the PV and the status of
+// the claim.
+func (p *nfsProvisioner) modifyVolume(ctx context.Context, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+	claim, err := p.claims.PersistentVolumeClaims(namespace).Get(name)
+	if err != nil || !modifying(claim) {
+		// Gone, or already handled.
+		return nil
+	}
+	volume, err := p.volumes.Get(claim.Spec.VolumeName)
+	if err != nil {
+		return err
+	}
+	if !p.owns(volume.Annotations[annProvisionedBy]) || !p.inShard(volume) || volume.Spec.NFS == nil {
+		return nil
+	}
+	target := *claim.Spec.VolumeAttributesClassName
+	if volume.Spec.VolumeAttributesClassName != nil && *volume.Spec.VolumeAttributesClassName == target {
+		// Applied already, only the status of the claim is behind.
+		return p.setModifyStatus(ctx, claim, target, "")
+	}
+
+	// Patching the status of the claim queues it again, so it is only
+	// marked in progress once the class is known to apply.
+	class, err := p.getClassForVolume(ctx, volume)
+	if err != nil {
+		return err
+	}
+	modified, err := p.classWithAttributes(ctx, class, &target)
+	if err == nil {
+		_, ok := volume.Annotations[annSharedPath]
+		if _, inspection := volume.Annotations[annInspectOf]; ok || inspection {
+			err = infeasible("volume %s is an existing directory, whose attributes cannot change", volume.Name)
+		}
+	}
+	switch {
+	case isInfeasible(err):
+		p.recorder.Event(claim, v1.EventTypeWarning, "VolumeModifyFailed", err.Error())
+		return p.setModifyStatus(ctx, claim, "", v1.PersistentVolumeClaimModifyVolumeInfeasible)
+	case apierrors.IsNotFound(err):
+		// Retried until the class shows up.
+		if statusErr := p.setModifyStatus(ctx, claim, "", v1.PersistentVolumeClaimModifyVolumePending); statusErr != nil {
+			return statusErr
+		}
+		return err
+	case err != nil:
+		return err
+	}
+	if err := p.setModifyStatus(ctx, claim, "", v1.PersistentVolumeClaimModifyVolumeInProgress); err != nil {
+		return err
+	}
+	err = traced(ctx, "ModifyVolume", func() error { return p.applyAttributes(ctx, claim, volume, class, modified, target) }, "pv", volume.Name, "volumeAttributesClass", target)
+	if err != nil {
+		p.recorder.Event(claim, v1.EventTypeWarning, "VolumeModifyFailed", err.Error())
+		return err
+	}
+	p.recorder.Eventf(claim, v1.EventTypeNormal, "VolumeModified", "Volume %s now has VolumeAttributesClass %s", volume.Name, target)
+	return p.setModifyStatus(ctx, claim, target, "")
+}
+
+// applyAttributes brings the directory of volume, bound to claim, from the
+// VolumeAttributesClass it has to target, whose parameters applied to class
+// are modified, and records target in the PV.
+func (p *nfsProvisioner) applyAttributes(ctx context.Context, claim *v1.PersistentVolumeClaim, volume *v1.PersistentVolume, class, modified *storage.StorageClass, target string) error {
+	current, err := p.classWithAttributes(ctx, class, volume.Spec.VolumeAttributesClassName)
+	if apierrors.IsNotFound(err) || isInfeasible(err) {
+		// The class the volume had is gone, or changed: what is on disk
+		// is compared to the StorageClass then.
+		current = class
+	} else if err != nil {
+		return err
+	}
+	b, err := p.backendForVolume(ctx, volume)
+	if err != nil {
+		return err
+	}
+	defer b.release()
+	dir := b.localPath(volume.Spec.NFS.Path)
+	annotations := map[string]interface{}{}
+
+	// Permissions: the group of the directory and its setgid bit.
+	_, oldGID, oldMode, err := directoryOwnership(current, claim, 0)
+	if err != nil {
+		return err
+	}
+	_, gid, mode, err := directoryOwnership(modified, claim, 0)
+	if err != nil {
+		return err
+	}
+	if gid != oldGID && gid >= 0 {
+		err := p.applyOwnership(ctx, claim, describeOwner(-1, gid), func() error {
+			return traced(ctx, "Chown", func() error { return os.Chown(dir, -1, gid) }, "path", dir)
+		})
+		if err != nil {
+			return fmt.Errorf("unable to give %s to %s: %v", volume.Spec.NFS.Path, describeOwner(-1, gid), err)
+		}
+	}
+	// Changing the group clears the setgid bit, so the mode is set again.
+	if gid != oldGID || mode != oldMode {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return err
+		}
+		perm := info.Mode().Perm() | info.Mode()&os.ModeSticky | mode&os.ModeSetgid
+		err = p.applyOwnership(ctx, claim, fmt.Sprintf("mode %04o", uint32(perm.Perm())), func() error {
+			return traced(ctx, "Chmod", func() error { return os.Chmod(dir, perm) }, "path", dir)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Quota limits: the file limit of the volume.
+	oldLimit, err := maxFiles(current)
+	if err != nil {
+		return err
+	}
+	fileLimit, err := maxFiles(modified)
+	if err != nil {
+		return err
+	}
+	if fileLimit != oldLimit {
+		if fileLimit > 0 {
+			annotations[annMaxFiles] = strconv.FormatInt(fileLimit, 10)
+		} else {
+			annotations[annMaxFiles] = nil
+		}
+		if limiter, ok := p.exporter.(fileLimiter); ok && volume.Annotations[annDedicatedExport] != "" {
+			project := projectID(volume.Name)
+			path := volume.Spec.NFS.Path
+			err := traced(ctx, "LimitFiles", func() error { return limiter.limitFiles(ctx, path, project, fileLimit) }, "path", path)
+			if err != nil {
+				return fmt.Errorf("unable to limit the files of %s: %v", path, err)
+			}
+			if fileLimit > 0 {
+				annotations[annProjectID] = strconv.FormatUint(uint64(project), 10)
+			}
+		}
+	}
+
+	// The deletion policy is read from the class of the volume when it is
+	// deleted, so recording target is enough for it.
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": annotations},
+		"spec":     map[string]interface{}{"volumeAttributesClassName": target},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = p.client.CoreV1().PersistentVolumes().Patch(ctx, volume.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// setModifyStatus records in the status of claim that its volume has the
+// VolumeAttributesClass current, if set, or that modifying it to the one it
+// asks for is in the given state.
+func (p *nfsProvisioner) setModifyStatus(ctx context.Context, claim *v1.PersistentVolumeClaim, current string, state v1.PersistentVolumeClaimModifyVolumeStatus) error {
+	if status := claim.Status.ModifyVolumeStatus; current == "" && status != nil && status.Status == state && status.TargetVolumeAttributesClassName == *claim.Spec.VolumeAttributesClassName {
+		return nil
+	}
+	status := map[string]interface{}{"modifyVolumeStatus": nil}
+	if current != "" {
+		status["currentVolumeAttributesClassName"] = current
+	} else {
+		status["modifyVolumeStatus"] = map[string]interface{}{
+			"targetVolumeAttributesClassName": *claim.Spec.VolumeAttributesClassName,
+			"status":                          state,
+		}
+	}
+	patch, err := json.Marshal(map[string]interface{}{"status": status})
+	if err != nil {
+		return err
+	}
+	_, err = p.client.CoreV1().PersistentVolumeClaims(claim.Namespace).Patch(ctx, claim.Name, types.MergePatchType, patch, metav1.PatchOptions{}, "status")
+	return err
+}
+
+// mutableParameterNames lists mutableParameters, for messages.
+func mutableParameterNames() string {
+	names := make([]string, 0, len(mutableParameters))
+	for name := range mutableParameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
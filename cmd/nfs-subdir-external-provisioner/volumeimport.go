@@ -0,0 +1,386 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+)
+
+const (
+	volumeImportKind = "NfsVolumeImport"
+
+	// volumeImportTimeout bounds the download and extraction of an import.
+	volumeImportTimeout = time.Hour
+	// volumeImportPrefix starts the name of the download of an import, next
+	// to the directory of the volume until it is extracted.
+	volumeImportPrefix = ".nfs-volume-import-"
+)
+
+var volumeImportResource = schema.GroupVersionResource{Group: apiGroup, Version: "v1alpha1", Resource: "nfsvolumeimports"}
+
+// volumeImportSpec is the spec of an NfsVolumeImport object: an archive to
+// fill the volumes of the claims referring to it with.
+type volumeImportSpec struct {
+	URL    string `json:"url"`
+	Format string `json:"format,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
+	S3     *struct {
+		Endpoint              string `json:"endpoint,omitempty"`
+		Region                string `json:"region,omitempty"`
+		CredentialsSecretName string `json:"credentialsSecretName,omitempty"`
+	} `json:"s3,omitempty"`
+}
+
+// importFormat returns the format of the archive of spec, from its URL when
+// not given.
+func (spec *volumeImportSpec) importFormat() (string, error) {
+	format := spec.Format
+	if format == "" {
+		name := strings.ToLower(spec.URL)
+		if i := strings.IndexAny(name, "?#"); i >= 0 {
+			name = name[:i]
+		}
+		switch {
+		case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+			format = "tar.gz"
+		case strings.HasSuffix(name, ".tar"):
+			format = "tar"
+		case strings.HasSuffix(name, ".zip"):
+			format = "zip"
+		}
+	}
+	switch format {
+	case "tar", "tar.gz", "zip":
+		return format, nil
+	case "":
+		return "", fmt.Errorf("unable to tell the format of %s from its name, set format", spec.URL)
+	default:
+		return "", fmt.Errorf("unsupported format %q, must be tar, tar.gz or zip", format)
+	}
+}
+
+// volumeImport returns the spec of the NfsVolumeImport the volume of claim is
+// to be filled from, nil if it has none.
+func (p *nfsProvisioner) volumeImport(ctx context.Context, claim *v1.PersistentVolumeClaim) (*volumeImportSpec, error) {
+	ref := claim.Spec.DataSourceRef
+	if ref == nil || ref.APIGroup == nil || *ref.APIGroup != apiGroup || ref.Kind != volumeImportKind {
+		return nil, nil
+	}
+	if p.imports == nil {
+		return nil, fmt.Errorf("claim refers to %s %s, but volume imports are disabled", volumeImportKind, ref.Name)
+	}
+	if ref.Namespace != nil && *ref.Namespace != "" && *ref.Namespace != claim.Namespace {
+		return nil, fmt.Errorf("%s %s/%s is not in the namespace of the claim", volumeImportKind, *ref.Namespace, ref.Name)
+	}
+	obj, err := p.imports.Namespace(claim.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get %s %s: %v", volumeImportKind, ref.Name, err)
+	}
+	raw, found, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil || !found {
+		return nil, fmt.Errorf("%s %s has no spec", volumeImportKind, ref.Name)
+	}
+	var spec volumeImportSpec
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &spec); err != nil {
+		return nil, fmt.Errorf("invalid %s %s: %v", volumeImportKind, ref.Name, err)
+	}
+	if _, err := spec.importFormat(); err != nil {
+		return nil, fmt.Errorf("invalid %s %s: %v", volumeImportKind, ref.Name, err)
+	}
+	if spec.SHA256 != "" {
+		if sum, err := hex.DecodeString(spec.SHA256); err != nil || len(sum) != sha256.Size {
+			return nil, fmt.Errorf("invalid %s %s: sha256 must be 64 hex digits", volumeImportKind, ref.Name)
+		}
+	}
+	return &spec, nil
+}
+
+// importVolume fills dir, the directory of a new volume of at most limit
+// bytes, with the archive of spec, after checking its checksum.
+func (p *nfsProvisioner) importVolume(ctx context.Context, claim *v1.PersistentVolumeClaim, spec *volumeImportSpec, dir string, limit resource.Quantity) error {
+	ctx, cancel := context.WithTimeout(ctx, volumeImportTimeout)
+	defer cancel()
+	logger := klog.FromContext(ctx)
+
+	body, err := p.openImport(ctx, claim.Namespace, spec)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	file := filepath.Join(filepath.Dir(dir), volumeImportPrefix+filepath.Base(dir))
+	f, err := os.OpenFile(file, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(file)
+	}()
+	maxBytes := limit.Value()
+	hash := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, hash), io.LimitReader(body, maxBytes+1))
+	if err != nil {
+		return fmt.Errorf("unable to download %s: %v", redactURL(spec.URL), err)
+	}
+	if n > maxBytes {
+		return fmt.Errorf("%s is larger than the %s volume", redactURL(spec.URL), limit.String())
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); spec.SHA256 != "" && !strings.EqualFold(sum, spec.SHA256) {
+		return fmt.Errorf("checksum mismatch for %s: got sha256 %s, want %s", redactURL(spec.URL), sum, spec.SHA256)
+	}
+	logger.Info("downloaded volume import", "url", redactURL(spec.URL), "bytes", n, "path", dir)
+
+	format, _ := spec.importFormat()
+	x := &extractor{root: dir, left: maxBytes, reserved: map[string]bool{lockFile: true}}
+	if name := p.settings().MetadataFile; name != "" {
+		x.reserved[name] = true
+	}
+	switch format {
+	case "zip":
+		err = x.zip(f, n)
+	default:
+		if _, err = f.Seek(0, io.SeekStart); err == nil {
+			err = x.tar(f, format == "tar.gz")
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("unable to extract %s: %v", redactURL(spec.URL), err)
+	}
+	return nil
+}
+
+// openImport starts the download of the archive of spec, from the hosts
+// --import-allowed-hosts allows only.
+func (p *nfsProvisioner) openImport(ctx context.Context, namespace string, spec *volumeImportSpec) (io.ReadCloser, error) {
+	guard := p.importGuard()
+	if strings.HasPrefix(spec.URL, "s3://") {
+		bucket, key, err := parseS3URL(spec.URL)
+		if err != nil {
+			return nil, err
+		}
+		var endpoint, region, secret string
+		if spec.S3 != nil {
+			endpoint, region, secret = spec.S3.Endpoint, spec.S3.Region, spec.S3.CredentialsSecretName
+		}
+		c, err := p.newS3Client(ctx, endpoint, region, namespace, secret)
+		if err != nil {
+			return nil, err
+		}
+		if err := guard.checkURL(c.endpoint); err != nil {
+			return nil, fmt.Errorf("unable to download from S3 endpoint %s: %v", c.endpoint.Host, err)
+		}
+		c.client = guard.client()
+		return c.get(ctx, bucket, key)
+	}
+	if !strings.HasPrefix(spec.URL, "https://") && !strings.HasPrefix(spec.URL, "http://") {
+		return nil, fmt.Errorf("unsupported URL %s, must be http, https or s3", redactURL(spec.URL))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, spec.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := guard.checkURL(req.URL); err != nil {
+		return nil, fmt.Errorf("unable to download %s: %v", redactURL(spec.URL), err)
+	}
+	resp, err := guard.client().Do(req)
+	if err != nil {
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("unable to download %s: %v", redactURL(spec.URL), err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("unable to download %s: %s", redactURL(spec.URL), resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// redactURL hides the password and the query of raw, which may hold the
+// signature of a presigned URL.
+func redactURL(raw string) string {
+	if u, err := url.Parse(raw); err == nil {
+		raw = u.Redacted()
+	}
+	if i := strings.IndexByte(raw, '?'); i >= 0 {
+		return raw[:i] + "?..."
+	}
+	return raw
+}
+
+// extractor writes the entries of an archive below root, refusing those
+// that would land outside of it, and at most left bytes in all.
+type extractor struct {
+	root string
+	left int64
+	// reserved are the names of the files the provisioner writes at the
+	// root of the volume, which the archive may not provide, lest it plant
+	// a link there for the provisioner to write through.
+	reserved map[string]bool
+}
+
+// target returns where the entry called name goes, making sure that no
+// directory on the way is a symbolic link, which an earlier entry may have
+// planted to write outside of root.
+func (x *extractor) target(name string) (string, error) {
+	clean := filepath.Clean("/" + filepath.FromSlash(name))
+	if clean == "/" {
+		return "", nil
+	}
+	dir := x.root
+	parts := strings.Split(strings.TrimPrefix(clean, "/"), string(filepath.Separator))
+	if len(parts) == 1 && x.reserved[parts[0]] {
+		return "", fmt.Errorf("%s is written by the provisioner", name)
+	}
+	for _, part := range parts[:len(parts)-1] {
+		dir = filepath.Join(dir, part)
+		info, err := os.Lstat(dir)
+		if os.IsNotExist(err) {
+			if err := os.Mkdir(dir, 0777); err != nil {
+				return "", err
+			}
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if !info.IsDir() {
+			return "", fmt.Errorf("%s goes through %s, which is not a directory", name, part)
+		}
+	}
+	return filepath.Join(dir, parts[len(parts)-1]), nil
+}
+
+// entry writes an entry of the archive: a directory, a regular file read
+// from r, or a symbolic link to link.
+func (x *extractor) entry(name string, mode fs.FileMode, r io.Reader, link string) error {
+	target, err := x.target(name)
+	if err != nil || target == "" {
+		return err
+	}
+	perm := mode.Perm()
+	switch {
+	case mode.IsDir():
+		if err := os.Mkdir(target, perm); err != nil && !os.IsExist(err) {
+			return err
+		}
+		if info, err := os.Lstat(target); err != nil || !info.IsDir() {
+			return fmt.Errorf("%s would replace a file or a link", name)
+		}
+		return os.Chmod(target, perm)
+	case mode&fs.ModeSymlink != 0:
+		_ = os.Remove(target)
+		return os.Symlink(link, target)
+	case mode.IsRegular():
+		if info, err := os.Lstat(target); err == nil && !info.Mode().IsRegular() {
+			return fmt.Errorf("%s would replace a directory or a link", name)
+		}
+		f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+		if err != nil {
+			return err
+		}
+		n, err := io.Copy(f, io.LimitReader(r, x.left+1))
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		if x.left -= n; x.left < 0 {
+			return fmt.Errorf("the archive is larger than the volume once extracted")
+		}
+		return os.Chmod(target, perm)
+	}
+	// Devices, pipes and hard links are skipped.
+	return nil
+}
+
+func (x *extractor) tar(r io.Reader, gzipped bool) error {
+	if gzipped {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := x.entry(hdr.Name, hdr.FileInfo().Mode(), tr, hdr.Linkname); err != nil {
+			return fmt.Errorf("%s: %v", hdr.Name, err)
+		}
+	}
+}
+
+func (x *extractor) zip(r io.ReaderAt, size int64) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+	for _, f := range zr.File {
+		err := func() error {
+			rc, err := f.Open()
+			if err != nil {
+				return err
+			}
+			defer rc.Close()
+			var link string
+			if f.Mode()&fs.ModeSymlink != 0 {
+				data, err := io.ReadAll(io.LimitReader(rc, 4096))
+				if err != nil {
+					return err
+				}
+				link = string(data)
+			}
+			return x.entry(f.Name, f.Mode(), rc, link)
+		}()
+		if err != nil {
+			return fmt.Errorf("%s: %v", f.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// tarEntry is an entry of a test archive.
+type tarEntry struct {
+	hdr     tar.Header
+	content string
+}
+
+func TestExtractorTar(t *testing.T) {
+	// outside stands for a directory next to the volume, where symbolic
+	// links of the archive may point, and which nothing may be written to.
+	const outside = "$OUTSIDE"
+	dir := func(name string) tarEntry {
+		return tarEntry{hdr: tar.Header{Name: name, Typeflag: tar.TypeDir, Mode: 0o755}}
+	}
+	file := func(name, content string) tarEntry {
+		return tarEntry{hdr: tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(content))}, content: content}
+	}
+	link := func(name, target string) tarEntry {
+		return tarEntry{hdr: tar.Header{Name: name, Typeflag: tar.TypeSymlink, Linkname: target}}
+	}
+	special := func(name string, typeflag byte) tarEntry {
+		return tarEntry{hdr: tar.Header{Name: name, Typeflag: typeflag, Mode: 0o644}}
+	}
+	tests := []struct {
+		name    string
+		entries []tarEntry
+		gzipped bool
+		// left is the room in the volume, plenty when zero.
+		left int64
+		// want maps the files expected below the root to their content.
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "files and directories",
+			entries: []tarEntry{dir("data/"), file("data/file", "hello"), file("other/nested/file", "world")},
+			want:    map[string]string{"data/file": "hello", "other/nested/file": "world"},
+		},
+		{
+			name:    "gzipped",
+			entries: []tarEntry{file("file", "hello")},
+			gzipped: true,
+			want:    map[string]string{"file": "hello"},
+		},
+		{
+			name:    "parent references kept below the root",
+			entries: []tarEntry{file("../../escape", "x"), file("data/../../other", "y")},
+			want:    map[string]string{"escape": "x", "other": "y"},
+		},
+		{
+			name:    "absolute names kept below the root",
+			entries: []tarEntry{file("/etc/passwd", "x")},
+			want:    map[string]string{"etc/passwd": "x"},
+		},
+		{
+			name:    "root entry skipped",
+			entries: []tarEntry{dir("./"), file("./file", "x")},
+			want:    map[string]string{"file": "x"},
+		},
+		{
+			name:    "file written through a link",
+			entries: []tarEntry{link("evil", outside), file("evil/file", "x")},
+			wantErr: true,
+		},
+		{
+			name:    "directory created through a link",
+			entries: []tarEntry{link("evil", outside), dir("evil/sub/")},
+			wantErr: true,
+		},
+		{
+			name:    "file replacing a link",
+			entries: []tarEntry{link("evil", outside+"/file"), file("evil", "x")},
+			wantErr: true,
+		},
+		{
+			name:    "directory replacing a file",
+			entries: []tarEntry{file("data", "x"), dir("data/")},
+			wantErr: true,
+		},
+		{
+			name:    "file replacing a directory",
+			entries: []tarEntry{dir("data/"), file("data", "x")},
+			wantErr: true,
+		},
+		{
+			name:    "file overwritten",
+			entries: []tarEntry{file("file", "old"), file("file", "new")},
+			want:    map[string]string{"file": "new"},
+		},
+		{
+			name:    "larger than the volume",
+			entries: []tarEntry{file("a", "hello"), file("b", "world")},
+			left:    8,
+			wantErr: true,
+		},
+		{
+			name:    "exactly the size of the volume",
+			entries: []tarEntry{file("a", "hello"), file("b", "world")},
+			left:    10,
+			want:    map[string]string{"a": "hello", "b": "world"},
+		},
+		{
+			name:    "metadata file",
+			entries: []tarEntry{link(".nfs-provisioner.json", outside+"/file")},
+			wantErr: true,
+		},
+		{
+			name:    "lock file",
+			entries: []tarEntry{file(".rwop.lock", "")},
+			wantErr: true,
+		},
+		{
+			name:    "metadata file name below the root",
+			entries: []tarEntry{file("data/.nfs-provisioner.json", "{}")},
+			want:    map[string]string{"data/.nfs-provisioner.json": "{}"},
+		},
+		{
+			name:    "devices and pipes skipped",
+			entries: []tarEntry{special("null", tar.TypeChar), special("fifo", tar.TypeFifo)},
+			want:    map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmp := t.TempDir()
+			root, escape := filepath.Join(tmp, "volume"), filepath.Join(tmp, "outside")
+			for _, d := range []string{root, escape} {
+				if err := os.Mkdir(d, 0o755); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var buf bytes.Buffer
+			gz := gzip.NewWriter(&buf)
+			tw := tar.NewWriter(&buf)
+			if tt.gzipped {
+				tw = tar.NewWriter(gz)
+			}
+			for _, e := range tt.entries {
+				e.hdr.Linkname = strings.Replace(e.hdr.Linkname, outside, escape, 1)
+				if err := tw.WriteHeader(&e.hdr); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := tw.Write([]byte(e.content)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := tw.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if tt.gzipped {
+				if err := gz.Close(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			left := tt.left
+			if left == 0 {
+				left = 1 << 20
+			}
+			x := &extractor{root: root, left: left, reserved: map[string]bool{".nfs-provisioner.json": true, lockFile: true}}
+			err := x.tar(&buf, tt.gzipped)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("tar() error = %v, want error %v", err, tt.wantErr)
+			}
+			if entries, _ := os.ReadDir(escape); len(entries) > 0 {
+				t.Errorf("wrote %s outside of the volume", entries[0].Name())
+			}
+			if tt.wantErr {
+				return
+			}
+			got := map[string]string{}
+			filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+				if err == nil && d.Type().IsRegular() {
+					rel, _ := filepath.Rel(root, path)
+					data, _ := os.ReadFile(path)
+					got[filepath.ToSlash(rel)] = string(data)
+				}
+				return err
+			})
+			if len(got) != len(tt.want) {
+				t.Errorf("extracted %v, want %v", got, tt.want)
+			}
+			for name, content := range tt.want {
+				if got[name] != content {
+					t.Errorf("%s = %q, want %q", name, got[name], content)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+// mountWatchdog probes the mount of the export by writing, stating and
+// removing a file in it, and pauses provisioning after failures consecutive
+// failed probes, until a probe succeeds again.
+type mountWatchdog struct {
+	p        *nfsProvisioner
+	file     string
+	failures int
+	pod      *v1.ObjectReference
+
+	mu      sync.Mutex
+	failed  int
+	lastErr error
+}
+
+// startMountWatchdog probes the mount every interval until ctx is done.
+func (p *nfsProvisioner) startMountWatchdog(ctx context.Context, interval time.Duration, failures int) *mountWatchdog {
+	hostname, _ := os.Hostname()
+	w := &mountWatchdog{
+		p:        p,
+		file:     filepath.Join(p.mountPath, ".nfs-provisioner-watchdog-"+hostname),
+		failures: failures,
+	}
+	// Events about the watchdog are recorded on the pod, when it knows its
+	// namespace.
+	if namespace := os.Getenv("POD_NAMESPACE"); namespace != "" {
+		name := os.Getenv("POD_NAME")
+		if name == "" {
+			name = hostname
+		}
+		w.pod = &v1.ObjectReference{Kind: "Pod", APIVersion: "v1", Namespace: namespace, Name: name}
+	}
+	go wait.UntilWithContext(ctx, w.check, interval)
+	return w
+}
+
+// probe writes, stats and removes the probe file. A hung NFS mount blocks
+// forever, so it gives up after backendProbeTimeout.
+func (w *mountWatchdog) probe() error {
+	result := make(chan error, 1)
+	go func() {
+		err := os.WriteFile(w.file, []byte(w.p.clock.Now().UTC().Format(time.RFC3339)), 0600)
+		if err == nil {
+			_, err = os.Stat(w.file)
+		}
+		if err == nil {
+			err = os.Remove(w.file)
+		}
+		result <- err
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(backendProbeTimeout):
+		return fmt.Errorf("probe of %s timed out after %s", w.p.mountPath, backendProbeTimeout)
+	}
+}
+
+func (w *mountWatchdog) check(ctx context.Context) {
+	logger := klog.FromContext(ctx)
+	err := w.probe()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	wasHealthy := w.failed < w.failures
+	if err == nil {
+		w.failed, w.lastErr = 0, nil
+		if !wasHealthy {
+			logger.Info("export mount recovered, resuming provisioning", "path", w.p.mountPath)
+			w.event(v1.EventTypeNormal, "MountRecovered", fmt.Sprintf("The mount of the export at %s works again, provisioning resumed", w.p.mountPath))
+		}
+		return
+	}
+	w.failed++
+	w.lastErr = err
+	logger.Error(err, "export mount probe failed", "path", w.p.mountPath, "failures", w.failed)
+	if wasHealthy && w.failed >= w.failures {
+		w.event(v1.EventTypeWarning, "MountUnhealthy", fmt.Sprintf("The mount of the export at %s failed %d probes, provisioning paused: %v", w.p.mountPath, w.failed, err))
+	}
+}
+
+func (w *mountWatchdog) event(eventType, reason, message string) {
+	if w.pod != nil {
+		w.p.recorder.Event(w.pod, eventType, reason, message)
+	}
+}
+
+// healthy returns nil while the mount works, and why it does not otherwise.
+func (w *mountWatchdog) healthy() error {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.failed < w.failures {
+		return nil
+	}
+	return fmt.Errorf("the mount of the export failed %d probes: %v", w.failed, w.lastErr)
+}
+
+// serveReady reports whether the provisioner accepts new volumes, for the
+// readiness probe of its pod.
+func (p *nfsProvisioner) serveReady(w http.ResponseWriter, r *http.Request) {
+	if p.draining.Load() {
+		http.Error(w, errShuttingDown.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err := p.watchdog.healthy(); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	_, _ = w.Write([]byte("ok\n"))
+}
+
+// serveHealth serves the probes of the pod on address until the process
+// exits.
+func (p *nfsProvisioner) serveHealth(ctx context.Context, address string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("ok\n")) })
+	mux.HandleFunc("/readyz", p.serveReady)
+
+	logger := klog.FromContext(ctx)
+	logger.Info("starting health server", "address", address)
+	go func() {
+		err := http.ListenAndServe(address, mux)
+		logger.Error(err, "health server failed", "address", address)
+	}()
+}
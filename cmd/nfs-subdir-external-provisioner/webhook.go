@@ -0,0 +1,87 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	admission "k8s.io/api/admission/v1"
+	storage "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// maxAdmissionReviewSize bounds the AdmissionReview bodies read by the
+// webhook.
+const maxAdmissionReviewSize = 1 << 20
+
+// serveWebhook serves the validating admission webhook of StorageClasses on
+// address, over TLS, until the process exits.
+func (p *nfsProvisioner) serveWebhook(ctx context.Context, address string, tlsConfig *tls.Config) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/validate-storageclass", p.serveValidateClass)
+
+	logger := klog.FromContext(ctx)
+	logger.Info("starting webhook server", "address", address)
+	go func() {
+		listener, err := net.Listen("tcp", address)
+		if err == nil {
+			err = http.Serve(tls.NewListener(listener, tlsConfig), mux)
+		}
+		logger.Error(err, "webhook server failed", "address", address)
+	}()
+}
+
+// serveValidateClass refuses StorageClasses of this provisioner whose
+// parameters checkClass finds problems with. Those of other provisioners are
+// allowed.
+func (p *nfsProvisioner) serveValidateClass(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	review := &admission.AdmissionReview{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAdmissionReviewSize)).Decode(review); err != nil || review.Request == nil {
+		http.Error(w, fmt.Sprintf("invalid AdmissionReview: %v", err), http.StatusBadRequest)
+		return
+	}
+	response := &admission.AdmissionResponse{UID: review.Request.UID, Allowed: true}
+	class := &storage.StorageClass{}
+	if err := json.Unmarshal(review.Request.Object.Raw, class); err != nil {
+		response.Allowed = false
+		response.Result = &metav1.Status{Code: http.StatusBadRequest, Message: fmt.Sprintf("invalid StorageClass: %v", err)}
+	} else if p.owns(class.Provisioner) {
+		if problems := checkClass(class); len(problems) > 0 {
+			klog.FromContext(r.Context()).Info("refusing StorageClass", "storageClass", class.Name, "problems", problems)
+			response.Allowed = false
+			response.Result = &metav1.Status{
+				Code:    http.StatusUnprocessableEntity,
+				Reason:  metav1.StatusReasonInvalid,
+				Message: fmt.Sprintf("StorageClass %s is invalid for provisioner %s: %s", class.Name, class.Provisioner, strings.Join(problems, "; ")),
+			}
+		}
+	}
+	review.Request = nil
+	review.Response = response
+	writeJSON(w, r, review)
+}
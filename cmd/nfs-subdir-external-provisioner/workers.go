@@ -0,0 +1,123 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/sig-storage-lib-external-provisioner/v10/controller"
+)
+
+// workerLimiter bounds how many calls of one kind run at the same time. The
+// controller starts a fixed number of workers; the limit can be changed at
+// runtime anywhere between one and that number.
+type workerLimiter struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	limit   int
+	active  int
+	waiting int
+}
+
+func newWorkerLimiter(limit int) *workerLimiter {
+	l := &workerLimiter{limit: limit}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// acquire blocks until a worker slot is free.
+func (l *workerLimiter) acquire() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.active >= l.limit {
+		l.cond.Wait()
+	}
+	l.active++
+}
+
+// acquireWithin is acquire, unless maxWaiting calls wait for a slot already,
+// in which case it returns false at once.
+func (l *workerLimiter) acquireWithin(maxWaiting int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.active >= l.limit && l.waiting >= maxWaiting {
+		return false
+	}
+	l.waiting++
+	for l.active >= l.limit {
+		l.cond.Wait()
+	}
+	l.waiting--
+	l.active++
+	return true
+}
+
+func (l *workerLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active--
+	l.cond.Broadcast()
+}
+
+// setLimit changes the number of slots. Calls already running are not
+// interrupted when the limit shrinks.
+func (l *workerLimiter) setLimit(limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+	l.cond.Broadcast()
+}
+
+// usage returns the current limit and the number of busy slots.
+func (l *workerLimiter) usage() (int, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit, l.active
+}
+
+// acquireArchiveWorker waits for an archiving worker for volume. Waiting
+// archivings hold a controller worker each, so only as many of them wait as
+// leaves the controller enough workers for the deletions; beyond that, the
+// volume is left for the next resync.
+func (p *nfsProvisioner) acquireArchiveWorker(volume *v1.PersistentVolume) error {
+	deletes, _ := p.deleteWorkers.usage()
+	archives, _ := p.archiveWorkers.usage()
+	maxWaiting := p.maxWorkers - deletes - archives
+	if maxWaiting < 0 {
+		maxWaiting = 0
+	}
+	if !p.archiveWorkers.acquireWithin(maxWaiting) {
+		return &controller.IgnoredError{Reason: fmt.Sprintf("%d archivings are running and %d waiting already, volume %s is archived after the next resync", archives, maxWaiting, volume.Name)}
+	}
+	return nil
+}
+
+// retryRateLimiter returns the rate limiter of the claim and volume queues of
+// the controller: failed items are retried after base, doubled on every
+// further failure up to maxDelay, and the queues overall are limited as the
+// controller does by default.
+func retryRateLimiter(base, maxDelay time.Duration) workqueue.RateLimiter {
+	return workqueue.NewMaxOfRateLimiter(
+		workqueue.NewItemExponentialFailureRateLimiter(base, maxDelay),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
+	)
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// tlsKernelRelease is the first Linux release whose NFS client supports RPC
+// over TLS, handing the handshake to the tlshd daemon of ktls-utils.
+var tlsKernelRelease = [2]int{6, 5}
+
+// tlsSupport reports why this kernel cannot mount exports with xprtsec=tls
+// or xprtsec=mtls, or nil if it can. It is checked once. The TLS module is
+// loaded on demand, and whether tlshd runs can only be seen when mounting.
+var tlsSupport = sync.OnceValue(func() error {
+	data, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	if err != nil {
+		return fmt.Errorf("unable to determine the kernel release: %v", err)
+	}
+	release := strings.TrimSpace(string(data))
+	var major, minor int
+	if _, err := fmt.Sscanf(release, "%d.%d", &major, &minor); err != nil {
+		return fmt.Errorf("unable to parse kernel release %q", release)
+	}
+	if major < tlsKernelRelease[0] || major == tlsKernelRelease[0] && minor < tlsKernelRelease[1] {
+		return fmt.Errorf("kernel %s predates NFS over TLS, which needs %d.%d or later", release, tlsKernelRelease[0], tlsKernelRelease[1])
+	}
+	return nil
+})
+
+// xprtsecPolicy returns the transport security selected by options: "",
+// none, tls or mtls. Other values are refused.
+func xprtsecPolicy(options []string) (string, error) {
+	var policy string
+	for _, o := range splitMountOptions(options) {
+		value, ok := strings.CutPrefix(o, "xprtsec=")
+		if !ok {
+			continue
+		}
+		switch value {
+		case "none", "tls", "mtls":
+			policy = value
+		default:
+			return "", fmt.Errorf("invalid mount option %q, xprtsec must be none, tls or mtls", o)
+		}
+	}
+	return policy, nil
+}
+
+// checkXprtsec refuses options that request NFS over TLS when this kernel
+// cannot provide it.
+func checkXprtsec(options []string) error {
+	policy, err := xprtsecPolicy(options)
+	if err != nil || policy == "" || policy == "none" {
+		return err
+	}
+	if err := tlsSupport(); err != nil {
+		return fmt.Errorf("xprtsec=%s is requested, but NFS over TLS is not supported: %v", policy, err)
+	}
+	return nil
+}
+
+// xprtsecMountOptions returns the mount options of the PVs of an export
+// mounted with backendOptions: unless options choose a transport security
+// themselves, consumers use the one of the export, which may not accept
+// anything else.
+func xprtsecMountOptions(options, backendOptions []string) []string {
+	if policy, _ := xprtsecPolicy(options); policy != "" {
+		return options
+	}
+	policy, _ := xprtsecPolicy(backendOptions)
+	if policy == "" || policy == "none" {
+		return options
+	}
+	return append(append([]string{}, options...), "xprtsec="+policy)
+}